@@ -0,0 +1,202 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Returns a command declaring flags and calling action with their parsed
+// values, for commands written as a single function like the actions of
+// other command line packages:
+//
+//	p.Add("deploy", "deploys the app", command.FromAction([]command.FlagSpec{
+//		{Name: "env", Usage: "target environment", Required: true},
+//		{Name: "replicas", Type: "int", Default: "1"},
+//	}, func(args command.Args) error {
+//		return deploy(args.String("env"), args.Int("replicas"))
+//	}))
+//
+// The Type of a flag is one of "string", which is the default if
+// empty, "bool", "int", "int64", "uint", "uint64", "float64",
+// "time.Duration" and "[]string". The Default is parsed like a value
+// given on the command line, the default of a "[]string" flag is split
+// on commas. Flags marked as Required are checked
+// before the action is called. Persistent and Aliases are ignored.
+// Declaring the flags panics for unsupported types and invalid
+// defaults.
+func FromAction(flags []FlagSpec, action func(Args) error) Cmd {
+	return &actionCmd{flags: flags, action: action}
+}
+
+type actionCmd struct {
+	flags  []FlagSpec
+	action func(Args) error
+	fs     *flag.FlagSet
+}
+
+func (c *actionCmd) Flags(fs *flag.FlagSet) {
+	c.fs = fs
+	for _, spec := range c.flags {
+		if err := defineFlag(fs, spec); err != nil {
+			panic(fmt.Sprintf("Flag -%s of command %q: %v", spec.Name, fs.Name(), err))
+		}
+	}
+}
+
+func (c *actionCmd) Run(args ...string) error {
+	set := setFlags(c.fs)
+	var missing []string
+	for _, spec := range c.flags {
+		if spec.Required && !set[spec.Name] {
+			missing = append(missing, spec.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingFlagsError{Command: c.fs.Name(), Flags: missing}
+	}
+	return c.action(Args{fs: c.fs, args: args})
+}
+
+func defineFlag(fs *flag.FlagSet, spec FlagSpec) error {
+	var err error
+	switch spec.Type {
+	case "", "string":
+		fs.String(spec.Name, spec.Default, spec.Usage)
+	case "[]string":
+		var v []string
+		if spec.Default != "" {
+			v = strings.Split(spec.Default, ",")
+		}
+		StringSliceVar(fs, &v, spec.Name, spec.Usage)
+	case "bool":
+		var v bool
+		if spec.Default != "" {
+			v, err = strconv.ParseBool(spec.Default)
+		}
+		fs.Bool(spec.Name, v, spec.Usage)
+	case "int":
+		v, e := parseDefault(spec.Default, func(s string) (int64, error) { return strconv.ParseInt(s, 0, strconv.IntSize) })
+		fs.Int(spec.Name, int(v), spec.Usage)
+		err = e
+	case "int64":
+		v, e := parseDefault(spec.Default, func(s string) (int64, error) { return strconv.ParseInt(s, 0, 64) })
+		fs.Int64(spec.Name, v, spec.Usage)
+		err = e
+	case "uint":
+		v, e := parseDefault(spec.Default, func(s string) (uint64, error) { return strconv.ParseUint(s, 0, strconv.IntSize) })
+		fs.Uint(spec.Name, uint(v), spec.Usage)
+		err = e
+	case "uint64":
+		v, e := parseDefault(spec.Default, func(s string) (uint64, error) { return strconv.ParseUint(s, 0, 64) })
+		fs.Uint64(spec.Name, v, spec.Usage)
+		err = e
+	case "float64":
+		v, e := parseDefault(spec.Default, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+		fs.Float64(spec.Name, v, spec.Usage)
+		err = e
+	case "time.Duration":
+		v, e := parseDefault(spec.Default, time.ParseDuration)
+		fs.Duration(spec.Name, v, spec.Usage)
+		err = e
+	default:
+		return fmt.Errorf("unsupported type %q", spec.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default %q", spec.Default)
+	}
+	return nil
+}
+
+// Parses def with parse unless it is empty.
+func parseDefault[T any](def string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	if def == "" {
+		return zero, nil
+	}
+	return parse(def)
+}
+
+// The flags and arguments passed to the action of FromAction. The
+// getters panic if the flag isn't declared or is of another type.
+type Args struct {
+	fs   *flag.FlagSet
+	args []string
+}
+
+// Returns the value of the named flag of type string.
+func (a Args) String(name string) string {
+	return a.get(name).(string)
+}
+
+// Returns the value of the named flag of type bool.
+func (a Args) Bool(name string) bool {
+	return a.get(name).(bool)
+}
+
+// Returns the value of the named flag of type int, int64, uint or
+// uint64.
+func (a Args) Int(name string) int {
+	switch v := a.get(name).(type) {
+	case int64:
+		return int(v)
+	case uint:
+		return int(v)
+	case uint64:
+		return int(v)
+	default:
+		return v.(int)
+	}
+}
+
+// Returns the value of the named flag of type float64.
+func (a Args) Float64(name string) float64 {
+	return a.get(name).(float64)
+}
+
+// Returns the value of the named flag of type time.Duration.
+func (a Args) Duration(name string) time.Duration {
+	return a.get(name).(time.Duration)
+}
+
+// Returns the values of the named flag of type []string.
+func (a Args) Strings(name string) []string {
+	return a.get(name).([]string)
+}
+
+// Reports whether the named flag was set by any source, see Source.
+func (a Args) IsSet(name string) bool {
+	a.get(name)
+	return setFlags(a.fs)[name]
+}
+
+// Returns the positional arguments.
+func (a Args) Args() []string {
+	return a.args
+}
+
+func (a Args) get(name string) interface{} {
+	f := a.fs.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("No such flag -%s for command %q", name, a.fs.Name()))
+	}
+	return f.Value.(flag.Getter).Get()
+}
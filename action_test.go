@@ -0,0 +1,85 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFromAction(t *testing.T) {
+	var got Args
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", FromAction([]FlagSpec{
+		{Name: "env", Usage: "target environment", Required: true},
+		{Name: "replicas", Type: "int", Default: "2"},
+		{Name: "size", Type: "uint64"},
+		{Name: "force", Type: "bool"},
+		{Name: "timeout", Type: "time.Duration", Default: "30s"},
+		{Name: "ratio", Type: "float64", Default: "0.5"},
+		{Name: "tags", Type: "[]string", Default: "a,b"},
+	}, func(args Args) error {
+		got = args
+		return nil
+	}))
+	if f := c.FlagSet().Lookup("timeout"); f == nil || f.DefValue != "30s" || f.Usage != "" {
+		t.Errorf("Flag -timeout should be declared with default 30s but was %+v.", f)
+	}
+	if _, err := p.Run("deploy", "-env", "prod", "-force", "-size", "7", "api"); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"env", got.String("env"), "prod"},
+		{"replicas", got.Int("replicas"), 2},
+		{"size", got.Int("size"), 7},
+		{"force", got.Bool("force"), true},
+		{"timeout", got.Duration("timeout"), 30 * time.Second},
+		{"ratio", got.Float64("ratio"), 0.5},
+		{"tags", got.Strings("tags"), []string{"a", "b"}},
+		{"args", got.Args(), []string{"api"}},
+		{"set env", got.IsSet("env"), true},
+		{"set replicas", got.IsSet("replicas"), false},
+	} {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("Value of %s should be %v but was %v.", test.name, test.want, test.got)
+		}
+	}
+	p.ResetFlags()
+	var missing *MissingFlagsError
+	if _, err := p.Run("deploy"); !errors.As(err, &missing) || missing.Flags[0] != "env" {
+		t.Errorf("Run without -env should fail with a MissingFlagsError but was %v.", err)
+	}
+}
+
+func TestFromActionInvalid(t *testing.T) {
+	for _, spec := range []FlagSpec{
+		{Name: "n", Type: "complex128"},
+		{Name: "n", Type: "int", Default: "x"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Declaring %+v should panic.", spec)
+				}
+			}()
+			NewPath().Add("deploy", "", FromAction([]FlagSpec{spec}, nil)).FlagSet()
+		}()
+	}
+}
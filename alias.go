@@ -0,0 +1,75 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Registers alias as an alternative name for the flag name, typically
+// a single character short form such as -v for --verbose. Setting the
+// alias sets the flag, and either counts as set for RequiredFlags. The
+// help output lists both names together. Registering an alias that is
+// already in use by another flag is an error.
+func (c *CmdCont) FlagAlias(name, alias string) error {
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if v, ok := f.Value.(*aliasValue); ok {
+		return fmt.Errorf("Flag -%s of command %q is an alias of -%s", name, c.Name, v.target.Name)
+	}
+	if existing := c.FlagSet().Lookup(alias); existing != nil {
+		if v, ok := existing.Value.(*aliasValue); ok && v.target == f {
+			return nil
+		}
+		return fmt.Errorf("Flag -%s of command %q is already defined", alias, c.Name)
+	}
+	c.FlagSet().Var(&aliasValue{target: f}, alias, f.Usage)
+	return nil
+}
+
+// Proxies all calls to the flag.Value of the target flag.
+type aliasValue struct {
+	target *flag.Flag
+}
+
+func (v *aliasValue) Set(s string) error {
+	return v.target.Value.Set(s)
+}
+
+func (v *aliasValue) String() string {
+	if v.target == nil {
+		return ""
+	}
+	return v.target.Value.String()
+}
+
+func (v *aliasValue) Get() interface{} {
+	if g, ok := v.target.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.String()
+}
+
+func (v *aliasValue) IsBoolFlag() bool {
+	b, ok := v.target.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (v *aliasValue) partner() string {
+	return v.target.Name
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+type outputOpts struct {
+	verbose *bool
+	output  *string
+}
+
+func newOutputPath(opts *outputOpts, requiredFlags ...string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("build", "builds the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			opts.verbose = fs.Bool("verbose", false, "verbose output")
+			opts.output = fs.String("output", "", "output `FILE`")
+		},
+	}, requiredFlags...)
+	return p, c
+}
+
+func TestFlagAlias(t *testing.T) {
+	tests := [][]string{
+		{"build", "-v", "-o", "app.bin"},
+		{"build", "--verbose", "--output", "app.bin"},
+		{"build", "-v", "--output=app.bin"},
+	}
+	for _, args := range tests {
+		var opts outputOpts
+		p, c := newOutputPath(&opts)
+		if err := c.FlagAlias("verbose", "v"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.FlagAlias("output", "o"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Run(args...); err != nil {
+			t.Fatal(err)
+		}
+		if !*opts.verbose || *opts.output != "app.bin" {
+			t.Errorf("%q should set verbose and output but was %v, %q.", args, *opts.verbose, *opts.output)
+		}
+	}
+}
+
+func TestFlagAliasRequired(t *testing.T) {
+	var opts outputOpts
+	p, c := newOutputPath(&opts, "output")
+	if err := c.FlagAlias("output", "o"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("build", "-o", "app.bin"); err != nil {
+		t.Fatalf("Setting the alias should satisfy the required flag but was %v.", err)
+	}
+}
+
+func TestFlagAliasConflicts(t *testing.T) {
+	var opts outputOpts
+	_, c := newOutputPath(&opts)
+	if err := c.FlagAlias("verbose", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FlagAlias("verbose", "v"); err != nil {
+		t.Errorf("Registering the same alias twice should be fine but was %v.", err)
+	}
+	if err := c.FlagAlias("output", "v"); err == nil {
+		t.Error("Reusing an alias for another flag should fail.")
+	}
+	if err := c.FlagAlias("output", "verbose"); err == nil {
+		t.Error("An alias named like an existing flag should fail.")
+	}
+	if err := c.FlagAlias("missing", "m"); err == nil {
+		t.Error("An alias of an unknown flag should fail.")
+	}
+}
+
+func TestFlagAliasHelp(t *testing.T) {
+	var opts outputOpts
+	p, c := newOutputPath(&opts)
+	c.FlagAlias("verbose", "v")
+	c.FlagAlias("output", "o")
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	want := `Usage: build [flags]
+
+builds the app
+
+Flags:
+  -o, --output FILE
+    	output FILE
+  -v, --verbose
+    	verbose output
+`
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Bundles the commands of a program with its name, version and
+// description, so that its main function reduces to
+//
+//	func main() {
+//		app := &command.App{Name: "app", Version: "1.2.0", Commands: p}
+//		os.Exit(app.Main())
+//	}
+//
+// All options of the Path apply to Main as they apply to Execute, and
+// the Path remains usable on its own.
+type App struct {
+	// The program name, the base name of os.Args[0] if empty.
+	Name    string
+	Version string
+	// Printed below the name and version at the top of the command
+	// list.
+	Description string
+	// The commands of the program, the path of the package level
+	// functions if nil.
+	Commands *Path
+	// The arguments dispatched by Main, os.Args[1:] if nil.
+	Args []string
+
+	// set by the first call of Main
+	ready       bool
+	showVersion *bool
+}
+
+// Runs the command given by Args and returns the exit code for its
+// error like Execute, writing the pending entries of the audit log. The
+// first call names the global flags after the program unless they are
+// named already, shows the name, version and description above the
+// command list, and registers the commands help and version and the
+// global flag -version unless commands or a flag of these names are
+// registered. Without a command Main lists the commands to Output, and
+// with -version alone it prints the version.
+func (a *App) Main() int {
+	p := a.path()
+	a.init(p)
+	args := a.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	defer p.closeAudit()
+	c, err := p.Run(args...)
+	var usage *UsageError
+	if errors.As(err, &usage) && usage.Command == p.Flags.Name() && usage.Reason == NoArguments {
+		if a.showVersion != nil && *a.showVersion {
+			p.writeVersion(a.Version)
+			return 0
+		}
+		p.writeAvailableCommands(p.Output())
+		return p.ExitCode(err)
+	}
+	p.writeError(p.Output(), c, err)
+	if c == nil && errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(p.Flags.Output())
+		p.writeAvailableCommands(p.Flags.Output())
+	}
+	return p.ExitCode(err)
+}
+
+func (a *App) path() *Path {
+	if a.Commands == nil {
+		return globalPath
+	}
+	return a.Commands
+}
+
+func (a *App) init(p *Path) {
+	if a.ready {
+		return
+	}
+	a.ready = true
+	name := a.Name
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+	if p.Flags.Name() == "" {
+		p.Flags.Init(name, p.Flags.ErrorHandling())
+	}
+	header := name
+	if a.Version != "" {
+		header += " " + a.Version
+	}
+	if a.Description != "" {
+		header += "\n" + a.Description
+	}
+	p.SetHelpHeader(header)
+	if p.Lookup("help") == nil {
+		p.AddHelpCommand()
+	}
+	if a.Version == "" {
+		return
+	}
+	if p.Lookup("version") == nil {
+		p.AddVersionCommand(a.Version)
+	}
+	if p.Flags.Lookup("version") == nil {
+		a.showVersion = p.Flags.Bool("version", false, "print the version and exit")
+	}
+}
+
+// Shows header above the list of available commands, such as the name
+// and version of the program.
+func (p *Path) SetHelpHeader(header string) {
+	p.helpHeader = header
+}
+
+// Registers the command version, which prints the program name given
+// by the name of the global flags and version.
+func (p *Path) AddVersionCommand(version string) *CmdCont {
+	return p.Add("version", "prints the version", CmdFunc(func(args []string) error {
+		p.writeVersion(version)
+		return nil
+	}))
+}
+
+func (p *Path) writeVersion(version string) {
+	w := p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	if name := p.Flags.Name(); name != "" {
+		fmt.Fprintf(w, "%s %s\n", name, version)
+	} else {
+		fmt.Fprintln(w, version)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command_test
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Drachenfels-GmbH/command"
+)
+
+type greetCmd struct {
+	name *string
+}
+
+func (c *greetCmd) Flags(fs *flag.FlagSet) {
+	c.name = fs.String("name", "World", "who to greet")
+}
+
+func (c *greetCmd) Run(args ...string) error {
+	fmt.Printf("Hello, %s!\n", *c.name)
+	return nil
+}
+
+func ExampleApp() {
+	p := command.NewPath()
+	p.Add("greet", "prints a greeting", &greetCmd{})
+	app := &command.App{
+		Name:        "hello",
+		Version:     "1.0.0",
+		Description: "Greets people.",
+		Commands:    p,
+	}
+	for _, args := range [][]string{{"greet", "-name", "Gopher"}, {"version"}} {
+		app.Args = args
+		if code := app.Main(); code != 0 {
+			fmt.Println("exit code", code)
+		}
+	}
+	// A real program ends with os.Exit(app.Main()).
+	// Output:
+	// Hello, Gopher!
+	// hello 1.0.0
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestApp(out *strings.Builder) *App {
+	p := NewPath()
+	p.SetOutput(out)
+	p.stdout = out
+	p.Flags.SetOutput(out)
+	p.Add("deploy", "deploys the app", CmdFunc(func(args []string) error {
+		if len(args) > 0 {
+			return errors.New("deploy failed")
+		}
+		out.WriteString("deployed\n")
+		return nil
+	}))
+	return &App{Name: "app", Version: "1.2.0", Description: "Deploys apps.", Commands: p}
+}
+
+func TestAppMain(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"deploy"}, 0, "deployed\n"},
+		{[]string{"deploy", "web"}, 1, "deploy failed\n"},
+		{[]string{"version"}, 0, "app 1.2.0\n"},
+		{[]string{"-version"}, 0, "app 1.2.0\n"},
+		{[]string{"destroy"}, ExitUsage, `No such command "destroy"`},
+		{[]string{}, ExitUsage, "app 1.2.0\nDeploys apps.\n\nAvailable commands:\n"},
+		{[]string{"help"}, 0, "app 1.2.0\nDeploys apps.\n\nAvailable commands:\n"},
+		{[]string{"-h"}, 0, "-version\n"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		app := newTestApp(&out)
+		app.Args = test.args
+		if code := app.Main(); code != test.code {
+			t.Errorf("%q should exit with %d but exited with %d.", test.args, test.code, code)
+		}
+		if !strings.Contains(out.String(), test.out) {
+			t.Errorf("The output of %q should contain %q but was %q.", test.args, test.out, out.String())
+		}
+	}
+}
+
+func TestAppMainGlobalHelp(t *testing.T) {
+	var out strings.Builder
+	app := newTestApp(&out)
+	app.Args = []string{"-h"}
+	app.Main()
+	for _, want := range []string{"Usage of app:", "print the version", "\tdeploy\tdeploys the app\n", "\tversion\tprints the version\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("The global help should contain %q but was %q.", want, out.String())
+		}
+	}
+}
+
+func TestAppMainKeepsRegistrations(t *testing.T) {
+	var out strings.Builder
+	app := newTestApp(&out)
+	p := app.Commands
+	p.Flags.Init("tool", p.Flags.ErrorHandling())
+	p.Add("version", "prints the build", CmdFunc(func(args []string) error {
+		out.WriteString("build 42\n")
+		return nil
+	}))
+	app.Args = []string{"version"}
+	app.Main()
+	app.Main()
+	if out.String() != "build 42\nbuild 42\n" {
+		t.Errorf("The own version command should run but the output was %q.", out.String())
+	}
+	if p.Flags.Name() != "tool" {
+		t.Errorf("The program name should stay %q but was %q.", "tool", p.Flags.Name())
+	}
+	if p.Flags.Lookup("version") == nil {
+		t.Error("The -version flag should be registered.")
+	}
+}
+
+func TestAppMainWithoutVersion(t *testing.T) {
+	var out strings.Builder
+	app := newTestApp(&out)
+	app.Version = ""
+	app.Args = []string{"version"}
+	if code := app.Main(); code != ExitUsage {
+		t.Errorf("version should be unknown without a version but exited with %d.", code)
+	}
+	if app.Commands.Lookup("help") == nil {
+		t.Error("The help command should be registered.")
+	}
+}
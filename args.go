@@ -0,0 +1,226 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A named positional argument of a command, see RequiredArg,
+// OptionalArg and VariadicArg.
+type positionalArg struct {
+	name string
+	// the value of an optional argument if omitted
+	def      string
+	optional bool
+	variadic bool
+	// the minimum number of values of a variadic argument
+	min int
+}
+
+func (a positionalArg) usage() string {
+	switch {
+	case a.variadic && a.min == 0:
+		return "[<" + a.name + ">...]"
+	case a.variadic:
+		return "<" + a.name + ">..."
+	case a.optional:
+		return "[" + a.name + "]"
+	}
+	return "<" + a.name + ">"
+}
+
+// Declares the next positional argument of c, which must be given. Run
+// fails with a PositionalArgError if fewer arguments are given than c
+// requires, or more than it declares. The value is returned by
+// ArgValue, the help shows the argument as <name>. Required arguments
+// must be declared before the optional ones.
+func (c *CmdCont) RequiredArg(name string) error {
+	if err := c.checkArgName(name); err != nil {
+		return err
+	}
+	if n := len(c.positionals); n > 0 && c.positionals[n-1].optional && !c.positionals[n-1].variadic {
+		return fmt.Errorf("Required argument <%s> of command %q must precede the optional argument %s",
+			name, c.Name, c.positionals[n-1].usage())
+	}
+	c.positionals = append(c.positionals, positionalArg{name: name})
+	c.changed()
+	return nil
+}
+
+// Declares the next positional argument of c, which may be omitted,
+// in which case ArgValue returns defaultValue. The help shows the
+// argument as [name] and notes the default. Optional arguments must be
+// declared after the required ones and before a variadic one.
+func (c *CmdCont) OptionalArg(name, defaultValue string) error {
+	if err := c.checkArgName(name); err != nil {
+		return err
+	}
+	c.positionals = append(c.positionals, positionalArg{name: name, def: defaultValue, optional: true})
+	c.changed()
+	return nil
+}
+
+// Declares the last positional argument of c, which takes all remaining
+// arguments, including those after "--", and requires at least min of
+// them. The values are returned by ArgSlice. Optional arguments before
+// it are only given values left over by min. The help shows the
+// argument as <name>..., or [<name>...] if min is 0.
+func (c *CmdCont) VariadicArg(name string, min int) error {
+	if err := c.checkArgName(name); err != nil {
+		return err
+	}
+	if min < 0 {
+		return fmt.Errorf("Invalid minimum %d of argument <%s> of command %q", min, name, c.Name)
+	}
+	c.positionals = append(c.positionals, positionalArg{name: name, optional: min == 0, variadic: true, min: min})
+	c.changed()
+	return nil
+}
+
+func (c *CmdCont) checkArgName(name string) error {
+	if name == "" || strings.ContainsAny(name, " <>[]") {
+		return fmt.Errorf("Invalid argument name %q for command %q", name, c.Name)
+	}
+	if c.positional(name) != nil {
+		return fmt.Errorf("Argument <%s> of command %q is already declared", name, c.Name)
+	}
+	if n := len(c.positionals); n > 0 && c.positionals[n-1].variadic {
+		return fmt.Errorf("Argument <%s> of command %q must precede the variadic argument %s",
+			name, c.Name, c.positionals[n-1].usage())
+	}
+	return nil
+}
+
+func (c *CmdCont) positional(name string) *positionalArg {
+	for i := range c.positionals {
+		if c.positionals[i].name == name {
+			return &c.positionals[i]
+		}
+	}
+	return nil
+}
+
+// Returns the value of the named positional argument given to the last
+// Run of c, or the default of an omitted optional argument. ArgValue
+// panics if the argument isn't declared or is variadic.
+func (c *CmdCont) ArgValue(name string) string {
+	i := c.argIndex(name)
+	a := c.positionals[i]
+	if a.variadic {
+		panic(fmt.Sprintf("Argument <%s> of command %q is variadic, use ArgSlice", name, c.Name))
+	}
+	if i < len(c.argValues) && len(c.argValues[i]) > 0 {
+		return c.argValues[i][0]
+	}
+	return a.def
+}
+
+// Returns the values of the named variadic argument given to the last
+// Run of c, which are empty if none were given. ArgSlice panics if the
+// argument isn't declared or isn't variadic.
+func (c *CmdCont) ArgSlice(name string) []string {
+	i := c.argIndex(name)
+	if !c.positionals[i].variadic {
+		panic(fmt.Sprintf("Argument <%s> of command %q isn't variadic, use ArgValue", name, c.Name))
+	}
+	if i < len(c.argValues) {
+		return c.argValues[i]
+	}
+	return nil
+}
+
+func (c *CmdCont) argIndex(name string) int {
+	for i, a := range c.positionals {
+		if a.name == name {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("No such argument <%s> for command %q", name, c.Name))
+}
+
+// Assigns the positional arguments args to the declared arguments for
+// ArgValue and ArgSlice after checking their number: first to the
+// required ones, then to the optional ones as far as the minimum of
+// the variadic one allows, and the rest to the variadic one. Commands
+// taking no arguments, see NoArgs, fail for any.
+func (c *CmdCont) setArgValues(args []string) error {
+	if len(c.positionals) == 0 {
+		if len(args) > 0 && c.takesNoArgs() {
+			return &UnexpectedArgsError{Command: c.CommandPath(), Args: args}
+		}
+		return nil
+	}
+	required, variadic := 0, false
+	for _, a := range c.positionals {
+		switch {
+		case a.variadic:
+			required += a.min
+			variadic = true
+		case !a.optional:
+			required++
+		}
+	}
+	if len(args) < required || !variadic && len(args) > len(c.positionals) {
+		return &PositionalArgError{Command: c.CommandPath(), Usage: c.argsUsage(), Args: len(args)}
+	}
+	spare := len(args) - required
+	values := make([][]string, len(c.positionals))
+	for i, a := range c.positionals {
+		switch {
+		case a.variadic:
+			values[i] = args
+			args = nil
+			continue
+		case a.optional && spare == 0:
+			continue
+		case a.optional:
+			spare--
+		}
+		values[i] = args[:1:1]
+		args = args[1:]
+	}
+	c.argValues = values
+	return nil
+}
+
+// Writes the defaults of the optional arguments of c that have one.
+func writeArgDefaults(w io.Writer, c *CmdCont) {
+	title := "\nArguments:\n"
+	for _, a := range c.positionals {
+		if a.optional && !a.variadic && a.def != "" {
+			fmt.Fprintf(w, "%s  %s\n    \t(default %q)\n", title, a.usage(), a.def)
+			title = ""
+		}
+	}
+}
+
+// Returns the positional arguments of c for the usage line of the help,
+// the declared ones or those described by the command.
+func (c *CmdCont) argsUsage() string {
+	if len(c.positionals) > 0 {
+		args := make([]string, len(c.positionals))
+		for i, a := range c.positionals {
+			args[i] = a.usage()
+		}
+		return strings.Join(args, " ")
+	}
+	if u, ok := c.Cmd.(argsUsager); ok {
+		return u.argsUsage()
+	}
+	return ""
+}
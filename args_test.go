@@ -0,0 +1,160 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newCopyPath(t *testing.T) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("copy", "copies a snapshot", &testCmd{})
+	if err := c.RequiredArg("source"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.OptionalArg("target", "local"); err != nil {
+		t.Fatal(err)
+	}
+	return p, c
+}
+
+func TestOptionalArg(t *testing.T) {
+	tests := []struct {
+		args   []string
+		target string
+		err    string
+	}{
+		{[]string{"copy", "snap1", "eu"}, "eu", ""},
+		{[]string{"copy", "snap1"}, "local", ""},
+		{[]string{"copy"}, "", "copy: expected arguments <source> [target], got 0"},
+		{[]string{"copy", "snap1", "eu", "us"}, "", "copy: expected arguments <source> [target], got 3"},
+	}
+	for _, test := range tests {
+		p, c := newCopyPath(t)
+		_, err := p.Run(test.args...)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err || !errors.Is(err, ErrCmdUsage) {
+				t.Errorf("%q should fail with %q but was %v.", test.args, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := c.ArgValue("source"); s != "snap1" {
+			t.Errorf("The source of %q should be %q but was %q.", test.args, "snap1", s)
+		}
+		if s := c.ArgValue("target"); s != test.target {
+			t.Errorf("The target of %q should be %q but was %q.", test.args, test.target, s)
+		}
+	}
+}
+
+func TestOptionalArgHelp(t *testing.T) {
+	p, c := newCopyPath(t)
+	var help strings.Builder
+	p.WriteHelp(&help, c)
+	for _, want := range []string{
+		"Usage: copy [flags] <source> [target]\n",
+		"\nArguments:\n  [target]\n    \t(default \"local\")\n",
+	} {
+		if !strings.Contains(help.String(), want) {
+			t.Errorf("The help should contain %q but was %q.", want, help.String())
+		}
+	}
+}
+
+func TestOptionalArgOrder(t *testing.T) {
+	_, c := newCopyPath(t)
+	err := c.RequiredArg("region")
+	if want := `Required argument <region> of command "copy" must precede the optional argument [target]`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if err := c.OptionalArg("target", "eu"); err == nil {
+		t.Error("Declaring an argument twice should fail.")
+	}
+	if err := c.OptionalArg("[region]", ""); err == nil {
+		t.Error("Declaring an argument with brackets in its name should fail.")
+	}
+	if err := c.OptionalArg("region", ""); err != nil {
+		t.Errorf("Optional arguments should follow each other but failed with %v.", err)
+	}
+}
+
+func TestVariadicArg(t *testing.T) {
+	tests := []struct {
+		min   int
+		args  []string
+		dest  string
+		mode  string
+		files []string
+		err   string
+	}{
+		{1, []string{"add", "backup", "a.txt"}, "backup", "copy", []string{"a.txt"}, ""},
+		{1, []string{"add", "backup", "move", "a.txt", "b.txt"}, "backup", "move", []string{"a.txt", "b.txt"}, ""},
+		{1, []string{"add", "--", "backup", "-v.txt", "--"}, "backup", "-v.txt", []string{"--"}, ""},
+		{1, []string{"add", "backup"}, "", "", nil, "add: expected arguments <dest> [mode] <file>..., got 1"},
+		{1, []string{"add"}, "", "", nil, "add: expected arguments <dest> [mode] <file>..., got 0"},
+		{0, []string{"add", "backup"}, "backup", "copy", nil, ""},
+		{0, []string{"add", "backup", "move"}, "backup", "move", nil, ""},
+		{0, []string{"add", "backup", "move", "a.txt"}, "backup", "move", []string{"a.txt"}, ""},
+	}
+	for _, test := range tests {
+		p := NewPath()
+		c := p.Add("add", "adds files to a snapshot", &testCmd{})
+		c.RequiredArg("dest")
+		c.OptionalArg("mode", "copy")
+		if err := c.VariadicArg("file", test.min); err != nil {
+			t.Fatal(err)
+		}
+		_, err := p.Run(test.args...)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q should fail with %q but was %v.", test.args, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		dest, mode, files := c.ArgValue("dest"), c.ArgValue("mode"), c.ArgSlice("file")
+		if dest != test.dest || mode != test.mode || strings.Join(files, " ") != strings.Join(test.files, " ") {
+			t.Errorf("%q should set %q, %q and %q but set %q, %q and %q.", test.args, test.dest, test.mode, test.files, dest, mode, files)
+		}
+	}
+}
+
+func TestVariadicArgDeclaration(t *testing.T) {
+	p := NewPath()
+	c := p.Add("add", "adds files to a snapshot", &testCmd{})
+	c.VariadicArg("file", 1)
+	var help strings.Builder
+	p.WriteHelp(&help, c)
+	if want := "Usage: add [flags] <file>...\n"; !strings.HasPrefix(help.String(), want) {
+		t.Errorf("The help should start with %q but was %q.", want, help.String())
+	}
+	err := c.OptionalArg("mode", "copy")
+	if want := `Argument <mode> of command "add" must precede the variadic argument <file>...`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if err := c.VariadicArg("more", 0); err == nil {
+		t.Error("Declaring a second variadic argument should fail.")
+	}
+	if err := p.Add("rm", "removes files", &testCmd{}).VariadicArg("file", -1); err == nil {
+		t.Error("A negative minimum should fail.")
+	}
+}
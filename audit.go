@@ -0,0 +1,187 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// The number of audit log entries buffered while the writer is busy.
+const auditBuffer = 64
+
+// An entry of the audit log, see SetAuditLog.
+type auditEntry struct {
+	// the start of the invocation
+	Time time.Time `json:"time"`
+	// the full name such as "remote add", empty if no command matched
+	Command string `json:"command,omitempty"`
+	// the flags set on the command line, sorted
+	Flags []string `json:"flags,omitempty"`
+	// the values of Flags if enabled with SetAuditValues
+	Values     map[string]string `json:"values,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	DurationMS float64           `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	entries chan []byte
+	done    chan struct{}
+	// the number of entries dropped since the last one was written
+	dropped atomic.Int64
+}
+
+// Written to the audit log in place of the entries that were dropped.
+type auditDropped struct {
+	Time    time.Time `json:"time"`
+	Dropped int64     `json:"dropped"`
+}
+
+// Appends a line of JSON to w for every Run of p, such as
+//
+//	{"time":"2024-05-01T12:00:00Z","command":"deploy","flags":["env"],"args":["api"],"duration_ms":12.5}
+//
+// with the command, the names of the flags set on the command line,
+// the positional arguments, the duration of the command and the error,
+// if any. The lines are written in the background, so that a slow w
+// doesn't delay Run; if too many are pending, entries are dropped.
+// The number of dropped entries is written to the log before the next
+// entry, as in
+//
+//	{"time":"2024-05-01T12:00:01Z","dropped":3}
+//
+// and each drop is reported to the Logger along with write errors, see
+// SetLogger. SetAuditLog(nil) writes the pending entries and disables
+// the log, which Main does before exiting. Mounted paths send their
+// entries to the log of the enclosing path unless set themselves.
+func (p *Path) SetAuditLog(w io.Writer) {
+	p.closeAudit()
+	if w == nil {
+		return
+	}
+	a := &auditLog{entries: make(chan []byte, auditBuffer), done: make(chan struct{})}
+	write := func(b []byte) {
+		if _, err := w.Write(b); err != nil {
+			p.logError("Writing audit log: %v", err)
+		}
+	}
+	go func() {
+		defer close(a.done)
+		for b := range a.entries {
+			if b := a.droppedEntry(); b != nil {
+				write(b)
+			}
+			write(b)
+		}
+		if b := a.droppedEntry(); b != nil {
+			write(b)
+		}
+	}()
+	p.audit = a
+}
+
+// Includes the values of the flags in the audit log. Values of secret
+// flags are redacted, see MarkSecret.
+func (p *Path) SetAuditValues(include bool) {
+	p.auditValues = include
+}
+
+// Writes the pending entries of the audit log and stops it.
+func (p *Path) closeAudit() {
+	if p.audit != nil {
+		close(p.audit.entries)
+		<-p.audit.done
+		p.audit = nil
+	}
+}
+
+// Returns the line reporting the entries dropped since the last call,
+// or nil if there are none.
+func (a *auditLog) droppedEntry() []byte {
+	n := a.dropped.Swap(0)
+	if n == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(auditDropped{Time: now(), Dropped: n})
+	return append(b, '\n')
+}
+
+// Queues the entry of the audit log for the Run of c by p, or counts it
+// as dropped if the log is full.
+func (p *Path) writeAudit(c *CmdCont, d time.Duration, err error) {
+	q := p
+	for q != nil && q.audit == nil {
+		q = q.parent
+	}
+	if q == nil {
+		return
+	}
+	e := auditEntry{Time: now().Add(-d), DurationMS: float64(d) / float64(time.Millisecond)}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if c != nil {
+		if d, ok := q.docCommand(c); ok {
+			e.Command = strings.TrimPrefix(d.name, " ")
+		}
+		values := make(map[string]string)
+		var secrets []secretValue
+		add := func(fs *flag.FlagSet, sources map[string]Source, secret func(*flag.Flag) bool) {
+			for name, s := range sources {
+				f := fs.Lookup(name)
+				if s != SourceCLI || f == nil {
+					continue
+				}
+				if _, ok := values[name]; !ok {
+					e.Flags = append(e.Flags, name)
+				}
+				values[name] = f.Value.String()
+				if secret(f) {
+					secrets = append(secrets, secretValue{flag: name, value: values[name]})
+					values[name] = redacted
+				}
+			}
+		}
+		for r := p; r != nil; r = r.parent {
+			add(r.Flags, r.sources, r.isSecret)
+		}
+		add(c.FlagSet(), c.sources, c.isSecret)
+		sort.Strings(e.Flags)
+		if q.auditValues && len(values) > 0 {
+			e.Values = values
+		}
+		for _, arg := range c.FlagSet().Args() {
+			e.Args = append(e.Args, redactValue(arg, secrets))
+		}
+	}
+	b, jsonErr := json.Marshal(e)
+	if jsonErr != nil {
+		p.logError("Writing audit log: %v", jsonErr)
+		return
+	}
+	b = append(b, '\n')
+	select {
+	case q.audit.entries <- b:
+	default:
+		q.audit.dropped.Add(1)
+		p.logError("Audit log is full, dropping the entry of %q", e.Command)
+	}
+}
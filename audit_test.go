@@ -0,0 +1,225 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readAudit(t *testing.T, s string) []auditEntry {
+	var entries []auditEntry
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("Audit line %q should be JSON: %v", sc.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditLog(t *testing.T) {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	remote := NewPath()
+	c := remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("name", "", "remote name")
+			fs.String("token", "", "API token")
+		},
+	})
+	c.MarkSecret("token")
+	p.Mount("remote", "manages remotes", remote)
+	p.Add("deploy", "deploys the app", &testCmd{
+		run: func(args ...string) error { return errors.New("deploy failed") },
+	})
+	var out strings.Builder
+	p.SetAuditLog(&out)
+	if _, err := p.Run("-verbose", "remote", "add", "-name", "origin", "-token", "s3cr3t", "url"); err != nil {
+		t.Fatal(err)
+	}
+	p.Run("deploy", "api")
+	p.SetAuditLog(nil)
+
+	entries := readAudit(t, out.String())
+	if len(entries) != 2 {
+		t.Fatalf("Audit log should have 2 entries but was %q.", out.String())
+	}
+	e := entries[0]
+	if e.Command != "remote add" || e.Error != "" {
+		t.Errorf("Entry should be the successful remote add but was %+v.", e)
+	}
+	if want := []string{"name", "token", "verbose"}; !reflect.DeepEqual(e.Flags, want) {
+		t.Errorf("Flags should be %q but were %q.", want, e.Flags)
+	}
+	if e.Values != nil {
+		t.Errorf("Values should be left out by default but were %q.", e.Values)
+	}
+	if want := []string{"url"}; !reflect.DeepEqual(e.Args, want) {
+		t.Errorf("Args should be %q but were %q.", want, e.Args)
+	}
+	if e.Time.IsZero() || e.DurationMS < 0 {
+		t.Errorf("Time and duration should be set but were %v and %v.", e.Time, e.DurationMS)
+	}
+	e = entries[1]
+	if e.Command != "deploy" || e.Error != "deploy failed" {
+		t.Errorf("Entry should be the failed deploy but was %+v.", e)
+	}
+	if want := []string{"api"}; !reflect.DeepEqual(e.Args, want) {
+		t.Errorf("Args should be %q but were %q.", want, e.Args)
+	}
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Errorf("Audit log should not contain the secret but was %q.", out.String())
+	}
+}
+
+func TestAuditLogValues(t *testing.T) {
+	p := NewPath()
+	c := p.Add("login", "logs in", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("user", "", "user name")
+			fs.String("password", "", "password")
+		},
+	})
+	c.MarkSecret("password")
+	var out strings.Builder
+	p.SetAuditLog(&out)
+	p.SetAuditValues(true)
+	if _, err := p.Run("login", "-user", "alice", "-password", "s3cr3t", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	p.SetAuditLog(nil)
+	entries := readAudit(t, out.String())
+	if len(entries) != 1 {
+		t.Fatalf("Audit log should have 1 entry but was %q.", out.String())
+	}
+	want := map[string]string{"user": "alice", "password": redacted}
+	if !reflect.DeepEqual(entries[0].Values, want) {
+		t.Errorf("Values should be %q but were %q.", want, entries[0].Values)
+	}
+	if want := []string{redacted}; !reflect.DeepEqual(entries[0].Args, want) {
+		t.Errorf("Args should be %q but were %q.", want, entries[0].Args)
+	}
+}
+
+func TestAuditLogMountedSecret(t *testing.T) {
+	remote := NewPath()
+	remote.Flags.String("pw", "", "password of the remote")
+	remote.Add("add", "adds a remote", &testCmd{})
+	p := NewPath()
+	p.Mount("remote", "manages remotes", remote).MarkSecret("pw")
+	var out strings.Builder
+	p.SetAuditLog(&out)
+	p.SetAuditValues(true)
+	if _, err := p.Run("remote", "-pw", "hunter2", "add", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	p.SetAuditLog(nil)
+	if strings.Contains(out.String(), "hunter2") {
+		t.Errorf("Audit log should redact the secret flag of the mounted path but was %q.", out.String())
+	}
+	entries := readAudit(t, out.String())
+	if want := map[string]string{"pw": redacted}; len(entries) != 1 || !reflect.DeepEqual(entries[0].Values, want) {
+		t.Errorf("Values should be %q but the log was %q.", want, out.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAuditLogWriteError(t *testing.T) {
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	var log strings.Builder
+	p.SetOutput(&log)
+	p.SetAuditLog(failingWriter{})
+	if _, err := p.Run("status"); err != nil {
+		t.Errorf("Run should not fail because of the audit log but was %v.", err)
+	}
+	p.SetAuditLog(nil)
+	if want := "Writing audit log: disk full\n"; log.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, log.String())
+	}
+}
+
+// Blocks all writes until release is closed.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     strings.Builder
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(b)
+}
+
+func TestAuditLogFull(t *testing.T) {
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	var log strings.Builder
+	p.SetOutput(&log)
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	p.SetAuditLog(w)
+	p.Run("status")
+	<-w.started
+	for i := 0; i < auditBuffer; i++ {
+		p.Run("status")
+	}
+
+	// a full log must not block the command, even without a deadline
+	done := make(chan struct{})
+	go func() {
+		p.RunContext(context.Background(), "status")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should drop the entry instead of waiting for the audit log.")
+	}
+	if want := "Audit log is full, dropping the entry of \"status\"\n"; log.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, log.String())
+	}
+	close(w.release)
+	p.SetAuditLog(nil)
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\n"), "\n")
+	if want := auditBuffer + 2; len(lines) != want {
+		t.Fatalf("The log should have %d lines but had %d.", want, len(lines))
+	}
+	var dropped auditDropped
+	if err := json.Unmarshal([]byte(lines[1]), &dropped); err != nil || dropped.Dropped != 1 {
+		t.Errorf("The second line should report the dropped entry but was %q.", lines[1])
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// The annotation listing the roles required to run a command, by
+// convention separated by commas, such as "admin,operator". It is only
+// read by authorizers, see SetAuthorizer.
+const AnnotationRoles = "roles"
+
+// Sets the function Run and RunContext ask whether the caller, which
+// the authorizer typically reads from the context, may run a command.
+// It is called after the command, or the mounted path, is resolved and
+// before its flags are parsed, so a denied caller can't run
+// it or ask for its help. The command may declare what it requires
+// with annotations, such as AnnotationRoles:
+//
+//	p.SetAuthorizer(func(ctx context.Context, c *command.CmdCont) error {
+//		if roles := c.Annotation(command.AnnotationRoles); roles != "" && !hasRole(ctx, roles) {
+//			return errors.New("requires role " + roles)
+//		}
+//		return nil
+//	})
+//
+// If it returns an error, Run fails with a PermissionError wrapping
+// it. Hidden commands and the commands of hidden paths fail with an
+// UnknownCommandError instead, so their existence isn't revealed, and
+// hidden commands are no longer suggested for unknown commands. The
+// commands of mounted paths use the authorizer of the closest enclosing
+// path. Validate doesn't call the authorizer.
+func (p *Path) SetAuthorizer(authorize func(ctx context.Context, c *CmdCont) error) {
+	p.authorizer = authorize
+}
+
+// Sets the annotation key of the command to value. Annotations carry
+// metadata for authorizers and other extensions, see AnnotationRoles.
+func (c *CmdCont) SetAnnotation(key, value string) {
+	if c.annotations == nil {
+		c.annotations = make(map[string]string)
+	}
+	c.annotations[key] = value
+}
+
+// Returns the annotation key of the command, or "" if it isn't set.
+func (c *CmdCont) Annotation(key string) string {
+	return c.annotations[key]
+}
+
+// Returned by Run if the authorizer denies running a command, see
+// SetAuthorizer.
+type PermissionError struct {
+	Command string
+	// the error returned by the authorizer
+	Err error
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("%s: permission denied: %v", e.Command, e.Err)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// Makes a PermissionError exit with ExitNoPerm.
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrNoPerm
+}
+
+// Returns the authorizer of p or of the closest enclosing path.
+func (p *Path) findAuthorizer() func(context.Context, *CmdCont) error {
+	for q := p; q != nil; q = q.parent {
+		if q.authorizer != nil {
+			return q.authorizer
+		}
+	}
+	return nil
+}
+
+// Asks the authorizer whether c may be run with ctx. Returns nil if it
+// may or no authorizer is set.
+func (p *Path) authorize(ctx context.Context, c *CmdCont) error {
+	authorize := p.findAuthorizer()
+	if authorize == nil {
+		return nil
+	}
+	err := authorize(ctx, c)
+	if err == nil {
+		return nil
+	}
+	if c.concealed() {
+		return p.unknownCommand(c.Name)
+	}
+	var perr *PermissionError
+	if errors.As(err, &perr) {
+		return err
+	}
+	return &PermissionError{Command: c.CommandPath(), Err: err}
+}
+
+// Reports whether c or one of the paths it is mounted to is hidden.
+func (c *CmdCont) concealed() bool {
+	for ; c != nil; c = c.Parent() {
+		if c.hiddenCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the error for the unknown command name with the names of
+// similar commands. Hidden commands aren't suggested if an authorizer
+// is set, as the caller may not be allowed to know about them.
+func (p *Path) unknownCommand(name string) error {
+	suggestions := p.suggestCommands(name)
+	if p.findAuthorizer() != nil {
+		listed := suggestions[:0:0]
+		for _, s := range suggestions {
+			if c := p.lookupPath(s); c != nil && !c.hiddenCmd {
+				listed = append(listed, s)
+			}
+		}
+		suggestions = listed
+		if len(suggestions) == 0 {
+			suggestions = nil
+		}
+	}
+	return &UnknownCommandError{Path: p.Flags.Name(), Name: name, Suggestions: suggestions}
+}
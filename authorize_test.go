@@ -0,0 +1,148 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type roleKey struct{}
+
+func newAuthorizedPath(ran *[]string) *Path {
+	record := func(name string) *testCmd {
+		return &testCmd{run: func(args ...string) error {
+			*ran = append(*ran, name)
+			return nil
+		}}
+	}
+	p := NewPath()
+	p.SetAuthorizer(func(ctx context.Context, c *CmdCont) error {
+		required := c.Annotation(AnnotationRoles)
+		if required == "" {
+			return nil
+		}
+		role, _ := ctx.Value(roleKey{}).(string)
+		for _, r := range strings.Split(required, ",") {
+			if r == role {
+				return nil
+			}
+		}
+		return errors.New("requires role " + required)
+	})
+	p.Add("status", "prints the status", record("status"))
+	p.Add("deploy", "deploys the app", record("deploy")).SetAnnotation(AnnotationRoles, "admin,operator")
+	debug := p.Add("debug", "dumps the state", record("debug"))
+	debug.Hide()
+	debug.SetAnnotation(AnnotationRoles, "admin")
+	users := NewPath()
+	users.Add("add", "adds a user", record("users add")).SetAnnotation(AnnotationRoles, "admin")
+	p.Mount("users", "manages users", users)
+	return p
+}
+
+func TestAuthorizer(t *testing.T) {
+	var ran []string
+	p := newAuthorizedPath(&ran)
+	admin := context.WithValue(context.Background(), roleKey{}, "admin")
+	guest := context.WithValue(context.Background(), roleKey{}, "guest")
+	for _, args := range [][]string{{"status"}, {"deploy"}, {"debug"}, {"users", "add"}} {
+		if _, err := p.RunContext(admin, args...); err != nil {
+			t.Errorf("%q should be allowed but failed with %v.", args, err)
+		}
+	}
+	if want := []string{"status", "deploy", "debug", "users add"}; strings.Join(ran, ",") != strings.Join(want, ",") {
+		t.Errorf("The commands %q should have run but %q did.", want, ran)
+	}
+
+	ran = nil
+	if _, err := p.RunContext(guest, "status"); err != nil {
+		t.Errorf("A command without roles should be allowed but failed with %v.", err)
+	}
+	for _, test := range []struct {
+		args         []string
+		command, msg string
+	}{
+		{[]string{"deploy"}, "deploy", "deploy: permission denied: requires role admin,operator"},
+		{[]string{"deploy", "-h"}, "deploy", "deploy: permission denied: requires role admin,operator"},
+		{[]string{"users", "add", "--help"}, "users add", "users add: permission denied: requires role admin"},
+	} {
+		_, err := p.RunContext(guest, test.args...)
+		var e *PermissionError
+		if !errors.As(err, &e) || e.Command != test.command {
+			t.Errorf("%q should fail with a PermissionError but failed with %v.", test.args, err)
+			continue
+		}
+		if err.Error() != test.msg {
+			t.Errorf("The error should be %q but was %q.", test.msg, err.Error())
+		}
+	}
+	if len(ran) != 1 {
+		t.Errorf("Only the allowed command should have run but %q did.", ran)
+	}
+}
+
+func TestAuthorizerHiddenProbe(t *testing.T) {
+	var ran []string
+	p := newAuthorizedPath(&ran)
+	guest := context.WithValue(context.Background(), roleKey{}, "guest")
+	_, hidden := p.RunContext(guest, "debug", "-h")
+	_, unknown := p.RunContext(guest, "debugger")
+	var e *UnknownCommandError
+	if !errors.As(hidden, &e) || hidden.Error() != `No such command "debug".` {
+		t.Errorf("A denied hidden command should fail like an unknown command but failed with %v.", hidden)
+	}
+	if want := `No such command "debugger".`; unknown == nil || unknown.Error() != want {
+		t.Errorf("An unknown command should not suggest hidden commands but failed with %v.", unknown)
+	}
+	if len(ran) != 0 {
+		t.Errorf("No command should have run but %q did.", ran)
+	}
+}
+
+func TestAuthorizerHelpCommand(t *testing.T) {
+	var ran []string
+	p := newAuthorizedPath(&ran)
+	p.AddHelpCommand()
+	var out strings.Builder
+	p.stdout = &out
+	guest := context.WithValue(context.Background(), roleKey{}, "guest")
+	admin := context.WithValue(context.Background(), roleKey{}, "admin")
+	for _, test := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"help", "debug"}, `No such command "debug".`},
+		{[]string{"help", "debugg"}, `No such command "debugg".`},
+		{[]string{"help", "users", "add"}, "users add: permission denied: requires role admin"},
+	} {
+		_, err := p.RunContext(guest, test.args...)
+		if err == nil || err.Error() != test.msg {
+			t.Errorf("%q should fail with %q but failed with %v.", test.args, test.msg, err)
+		}
+		var e *UnknownCommandError
+		if errors.As(err, &e) && len(e.Suggestions) > 0 {
+			t.Errorf("%q should not suggest hidden commands but suggested %q.", test.args, e.Suggestions)
+		}
+	}
+	if out.Len() > 0 {
+		t.Errorf("Denied commands should not print their help but printed %q.", out.String())
+	}
+	if _, err := p.RunContext(admin, "help", "debug"); err != nil || !strings.Contains(out.String(), "dumps the state") {
+		t.Errorf("An allowed caller should see the help but got %v, %q.", err, out.String())
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Binds every flag of the commands of p and of the paths mounted below
+// it to an environment variable named after prefix, the command and
+// the flag, such as MYAPP_DEPLOY_ENV for the flag -env of the command
+// deploy and MYAPP_REMOTE_ADD_NAME for a mounted command. Global flags
+// are bound to variables without a command, such as MYAPP_VERBOSE.
+// Names are upper-cased with dashes replaced by underscores. The
+// variables are resolved like those of BindEnv and shown in the help
+// output. Flags already bound with BindEnv, aliases and flags excluded
+// with NoAutoEnv aren't bound. Commands must be registered before.
+// If two flags would be bound to the same variable, nothing is bound
+// and an error is returned.
+func (p *Path) AutoEnv(prefix string) error {
+	// the flag sets of p and the paths and commands below it
+	type scope struct {
+		env     *map[string]string
+		fs      *flag.FlagSet
+		exclude map[string]bool
+		name    string
+		desc    string
+		// the command owning env, whose help shows the bindings
+		cont *CmdCont
+	}
+	scopes := []scope{{&p.env, p.Flags, p.noAutoEnv, "", "the global flags", nil}}
+	for _, d := range p.docCommands("") {
+		name := strings.TrimPrefix(d.name, " ")
+		if c := d.cont; c.sub != nil {
+			scopes = append(scopes, scope{&c.sub.env, c.sub.Flags, c.sub.noAutoEnv, name, fmt.Sprintf("the global flags of %q", name), nil})
+		} else {
+			scopes = append(scopes, scope{&c.env, c.FlagSet(), c.noAutoEnv, name, fmt.Sprintf("command %q", name), c})
+		}
+	}
+	// the flags bound to each variable for error messages
+	vars := make(map[string]string)
+	for _, s := range scopes {
+		for name, v := range *s.env {
+			vars[v] = fmt.Sprintf("flag -%s of %s", name, s.desc)
+		}
+	}
+	// the new variables of each scope by flag name
+	bindings := make(map[*map[string]string]map[string]string)
+	for _, s := range scopes {
+		var err error
+		s.fs.VisitAll(func(f *flag.Flag) {
+			if _, ok := (*s.env)[f.Name]; ok || s.exclude[f.Name] || err != nil {
+				return
+			}
+			switch v := f.Value.(type) {
+			case *aliasValue:
+				return
+			case *boolPairValue:
+				if v.negate {
+					return
+				}
+			}
+			v := envVarName(prefix, s.name, f.Name)
+			desc := fmt.Sprintf("flag -%s of %s", f.Name, s.desc)
+			if other, ok := vars[v]; ok {
+				err = fmt.Errorf("Environment variable $%s of %s is already bound to %s", v, desc, other)
+				return
+			}
+			vars[v] = desc
+			if bindings[s.env] == nil {
+				bindings[s.env] = make(map[string]string)
+			}
+			bindings[s.env][f.Name] = v
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range scopes {
+		vars, ok := bindings[s.env]
+		if !ok {
+			continue
+		}
+		if *s.env == nil {
+			*s.env = make(map[string]string, len(vars))
+		}
+		for name, v := range vars {
+			(*s.env)[name] = v
+		}
+		if s.cont != nil {
+			s.cont.changed()
+		}
+	}
+	return nil
+}
+
+// Excludes the named flags of the command from AutoEnv.
+func (c *CmdCont) NoAutoEnv(names ...string) error {
+	for _, name := range names {
+		if c.FlagSet().Lookup(name) == nil {
+			return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+		}
+	}
+	if c.noAutoEnv == nil {
+		c.noAutoEnv = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.noAutoEnv[name] = true
+	}
+	return nil
+}
+
+// Same as CmdCont.NoAutoEnv but for the global flags of p.
+func (p *Path) NoAutoEnv(names ...string) error {
+	for _, name := range names {
+		if p.Flags.Lookup(name) == nil {
+			return fmt.Errorf("No such global flag -%s", name)
+		}
+	}
+	if p.noAutoEnv == nil {
+		p.noAutoEnv = make(map[string]bool)
+	}
+	for _, name := range names {
+		p.noAutoEnv[name] = true
+	}
+	return nil
+}
+
+// Joins the non-empty parts with underscores, upper-cased and with all
+// characters but letters and digits replaced by underscores.
+func envVarName(parts ...string) string {
+	var names []string
+	for _, part := range parts {
+		if part != "" {
+			names = append(names, part)
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.Join(names, "_"))
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newAutoEnvPath() *Path {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	for _, name := range []string{"deploy", "destroy"} {
+		p.Add(name, name+"s the app", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.String("env", "", "target environment")
+				fs.Bool("dry-run", false, "only print the changes")
+			},
+		})
+	}
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("name", "", "remote name")
+		},
+	})
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func TestAutoEnv(t *testing.T) {
+	p := newAutoEnvPath()
+	if err := p.registry().entries["destroy"].NoAutoEnv("dry-run"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AutoEnv("MYAPP"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MYAPP_VERBOSE", "true")
+	t.Setenv("MYAPP_DEPLOY_ENV", "prod")
+	t.Setenv("MYAPP_DEPLOY_DRY_RUN", "true")
+	t.Setenv("MYAPP_DESTROY_ENV", "dev")
+	t.Setenv("MYAPP_DESTROY_DRY_RUN", "true")
+	t.Setenv("MYAPP_REMOTE_ADD_NAME", "origin")
+	for _, test := range []struct {
+		args []string
+		want map[string]string
+	}{
+		{[]string{"deploy"}, map[string]string{"env": "prod", "dry-run": "true"}},
+		{[]string{"deploy", "-env", "staging"}, map[string]string{"env": "staging"}},
+		{[]string{"destroy"}, map[string]string{"env": "dev", "dry-run": "false"}},
+		{[]string{"remote", "add"}, map[string]string{"name": "origin"}},
+	} {
+		c, err := p.Run(test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range test.want {
+			if v := c.Flags.Lookup(name).Value.String(); v != want {
+				t.Errorf("Flag -%s of %q should be %q but was %q.", name, test.args, want, v)
+			}
+		}
+		if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+			t.Errorf("Global flag -verbose should be set from $MYAPP_VERBOSE but was %q.", v)
+		}
+		p.ResetFlags()
+	}
+	if s := p.registry().entries["deploy"].ValueSource("env"); s != SourceDefault {
+		t.Errorf("Source should be reset but was %v.", s)
+	}
+	var help strings.Builder
+	p.WriteHelp(&help, p.registry().entries["deploy"])
+	if want := "target environment ($MYAPP_DEPLOY_ENV)\n"; !strings.Contains(help.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, help.String())
+	}
+}
+
+func TestAutoEnvCollision(t *testing.T) {
+	p := newAutoEnvPath()
+	c := p.Add("deploy", "", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("dry-run", "", "")
+			fs.String("dry_run", "", "")
+		},
+	})
+	err := p.AutoEnv("APP")
+	want := `Environment variable $APP_DEPLOY_DRY_RUN of flag -dry_run of command "deploy" is already bound to flag -dry-run of command "deploy"`
+	if err == nil || err.Error() != want {
+		t.Errorf("AutoEnv should fail with %q but was %v.", want, err)
+	}
+	if len(p.env) != 0 || len(c.env) != 0 {
+		t.Errorf("Failed AutoEnv should not bind any flag but bound %q and %q.", p.env, c.env)
+	}
+
+	p = newAutoEnvPath()
+	p.registry().entries["destroy"].BindEnv("env", "APP_DEPLOY_ENV")
+	if err := p.AutoEnv("APP"); err == nil || !strings.Contains(err.Error(), `already bound to flag -env of command "destroy"`) {
+		t.Errorf("AutoEnv should fail for variables bound with BindEnv but was %v.", err)
+	}
+	if err := p.registry().entries["deploy"].NoAutoEnv("env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AutoEnv("APP"); err != nil {
+		t.Errorf("Excluded flags should not collide but AutoEnv failed with %v.", err)
+	}
+	if err := p.NoAutoEnv("colour"); err == nil {
+		t.Error("Excluding an unknown flag should fail.")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+)
+
+func newBenchPath() *Path {
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+			fs.String("region", "", "target region")
+			fs.Bool("force", false, "skip checks")
+		},
+	}, "env", "region")
+	return p
+}
+
+func BenchmarkRunNoFlags(b *testing.B) {
+	p := newBenchPath()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Run("status"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRunRequired(b *testing.B) {
+	p := newBenchPath()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Run("deploy", "-env", "prod", "-region", "eu"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Guards against regressions of the allocations per dispatch, which
+// matter to programs dispatching many small commands. If flags were
+// set by the previous run, resetting them registers the flags of the
+// command anew.
+func TestRunAllocs(t *testing.T) {
+	for _, test := range []struct {
+		args  []string
+		reset bool
+		max   float64
+	}{
+		{[]string{"status"}, false, 3},
+		{[]string{"deploy", "-env", "prod", "-region", "eu"}, false, 7},
+		{[]string{"status"}, true, 3},
+		{[]string{"deploy", "-env", "prod", "-region", "eu"}, true, 24},
+	} {
+		p := newBenchPath()
+		p.SetResetFlags(test.reset)
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := p.Run(test.args...); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > test.max {
+			t.Errorf("Run(%q) with reset %v should allocate at most %v times but allocated %v times.", test.args, test.reset, test.max, allocs)
+		}
+	}
+}
+
+// Measures the startup of a program with many commands, which only
+// registers the flags of the command it runs. The eager variant
+// registers the flags of all commands, like Add did before.
+func BenchmarkStartup(b *testing.B) {
+	newPath := func() *Path {
+		p := NewPath()
+		for i := 0; i < 100; i++ {
+			p.Add(fmt.Sprintf("cmd%d", i), "a generated command", &testCmd{
+				flags: func(fs *flag.FlagSet) {
+					for j := 0; j < 10; j++ {
+						fs.String(fmt.Sprintf("flag%d", j), "", "a generated flag")
+					}
+				},
+			})
+		}
+		return p
+	}
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := newPath().Run("cmd42", "-flag1", "x"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p := newPath()
+			for _, c := range p.registry().entries {
+				c.FlagSet()
+			}
+			if _, err := p.Run("cmd42", "-flag1", "x"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
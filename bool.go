@@ -0,0 +1,101 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// Defines a bool flag with specified name, default value, and usage
+// string, together with its inverse flag named "no-" + name. The
+// argument p points to a bool variable in which to store the value of
+// the flag. Setting -name sets it to true, -no-name sets it to false,
+// and passing both in one invocation is an error. The help output
+// lists the pair as one flag.
+func BoolWithInverseVar(fs *flag.FlagSet, p *bool, name string, value bool, usage string) {
+	*p = value
+	pair := &boolPair{p: p, def: value, name: name, inverse: "no-" + name}
+	fs.Var(&boolPairValue{pair: pair}, name, usage)
+	fs.Var(&boolPairValue{pair: pair, negate: true}, pair.inverse, usage)
+}
+
+// The state shared by a flag and its inverse.
+type boolPair struct {
+	p             *bool
+	def           bool
+	name, inverse string
+	set, negSet   bool
+}
+
+type boolPairValue struct {
+	pair   *boolPair
+	negate bool
+}
+
+func (v *boolPairValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return strconv.ErrSyntax
+	}
+	if v.negate {
+		if v.pair.set {
+			return fmt.Errorf("cannot be combined with -%s", v.pair.name)
+		}
+		v.pair.negSet = true
+		b = !b
+	} else {
+		if v.pair.negSet {
+			return fmt.Errorf("cannot be combined with -%s", v.pair.inverse)
+		}
+		v.pair.set = true
+	}
+	*v.pair.p = b
+	return nil
+}
+
+func (v *boolPairValue) String() string {
+	if v.pair == nil {
+		return "false"
+	}
+	b := *v.pair.p
+	if v.negate {
+		b = !b
+	}
+	return strconv.FormatBool(b)
+}
+
+func (v *boolPairValue) Get() interface{} {
+	return *v.pair.p
+}
+
+func (v *boolPairValue) IsBoolFlag() bool {
+	return true
+}
+
+func (v *boolPairValue) Reset() {
+	*v.pair.p = v.pair.def
+	v.pair.set, v.pair.negSet = false, false
+}
+
+// The name of the other flag of the pair, which is considered set when
+// this one is.
+func (v *boolPairValue) partner() string {
+	if v.negate {
+		return v.pair.name
+	}
+	return v.pair.inverse
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestBoolWithInverse(t *testing.T) {
+	tests := []struct {
+		def  bool
+		args []string
+		want bool
+	}{
+		{true, nil, true},
+		{false, nil, false},
+		{false, []string{"-color"}, true},
+		{true, []string{"--no-color"}, false},
+		{true, []string{"-color=false"}, false},
+		{true, []string{"-color", "-color"}, true},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var color bool
+		BoolWithInverseVar(fs, &color, "color", test.def, "colored output")
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if color != test.want {
+			t.Errorf("%q with default %v should be %v but was %v.", test.args, test.def, test.want, color)
+		}
+	}
+}
+
+func TestBoolWithInverseConflict(t *testing.T) {
+	for _, args := range [][]string{{"-color", "-no-color"}, {"-no-color", "-color"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(new(strings.Builder))
+		var color bool
+		BoolWithInverseVar(fs, &color, "color", true, "colored output")
+		if err := fs.Parse(args); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+			t.Errorf("%q should fail but was %v.", args, err)
+		}
+	}
+}
+
+func TestBoolWithInverseConfig(t *testing.T) {
+	var color bool
+	p := NewPath()
+	p.Add("show", "shows things", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			BoolWithInverseVar(fs, &color, "color", false, "colored output")
+		},
+	})
+	if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"show": {"color": true}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("show", "-no-color"); err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Error("-no-color on the command line should win over the config.")
+	}
+}
+
+func TestBoolWithInverseHelp(t *testing.T) {
+	p := NewPath()
+	c := p.Add("show", "shows things", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			var color bool
+			BoolWithInverseVar(fs, &color, "color", true, "colored output")
+		},
+	})
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	want := "Usage: show [flags]\n\nshows things\n\nFlags:\n  --color, --no-color\n    \tcolored output (default true)\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Units of byte sizes, largest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TiB", 1 << 40},
+	{"TB", 1e12},
+	{"GiB", 1 << 30},
+	{"GB", 1e9},
+	{"MiB", 1 << 20},
+	{"MB", 1e6},
+	{"KiB", 1 << 10},
+	{"KB", 1e3},
+}
+
+// Defines a byte size flag with specified name, default value, and
+// usage string. The argument p points to an int64 variable in which to
+// store the size in bytes. Values are integers with an optional,
+// case-insensitive unit: B, the SI units KB, MB, GB and TB, or the
+// binary units KiB, MiB, GiB and TiB, which may also be written as K,
+// M, G and T. The help output shows the default in the largest unit
+// that represents it exactly.
+func BytesVar(fs *flag.FlagSet, p *int64, name string, value int64, usage string) {
+	*p = value
+	fs.Var((*bytesValue)(p), name, usage)
+}
+
+type bytesValue int64
+
+func (v *bytesValue) Set(s string) error {
+	n, err := parseBytes(s)
+	if err != nil {
+		return err
+	}
+	*v = bytesValue(n)
+	return nil
+}
+
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+	if strings.HasPrefix(num, "-") {
+		return 0, errors.New("size must not be negative")
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	size, err := byteUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64/size {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * size, nil
+}
+
+func byteUnit(unit string) (int64, error) {
+	switch u := strings.ToUpper(unit); u {
+	case "", "B":
+		return 1, nil
+	case "K", "M", "G", "T":
+		unit = u + "iB"
+	case "KI", "MI", "GI", "TI":
+		unit = u + "B"
+	}
+	for _, bu := range byteUnits {
+		if strings.EqualFold(unit, bu.suffix) {
+			return bu.size, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %q, use B, KB, KiB, MB, MiB, GB, GiB, TB or TiB", unit)
+}
+
+func (v *bytesValue) String() string {
+	if v == nil {
+		return "0"
+	}
+	return formatBytes(int64(*v))
+}
+
+func formatBytes(n int64) string {
+	if n != 0 {
+		for _, u := range byteUnits {
+			if n%u.size == 0 {
+				return strconv.FormatInt(n/u.size, 10) + u.suffix
+			}
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func (v *bytesValue) Get() interface{} {
+	return int64(*v)
+}
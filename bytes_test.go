@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestBytesVar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"10KB", 10000},
+		{"10kb", 10000},
+		{"10KiB", 10240},
+		{"10k", 10240},
+		{"10Ki", 10240},
+		{"3MB", 3000000},
+		{"3MiB", 3 << 20},
+		{"2 GB", 2000000000},
+		{"2g", 2 << 30},
+		{"1TB", 1000000000000},
+		{"1tib", 1 << 40},
+	}
+	for _, test := range tests {
+		var n int64
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		BytesVar(fs, &n, "limit", 0, "size limit")
+		if err := fs.Set("limit", test.value); err != nil {
+			t.Errorf("%q: %v", test.value, err)
+			continue
+		}
+		if n != test.want {
+			t.Errorf("%q should be %d but was %d.", test.value, test.want, n)
+		}
+	}
+}
+
+func TestBytesVarString(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:           "0",
+		999:         "999",
+		1000:        "1KB",
+		1024:        "1KiB",
+		1536:        "1536",
+		10 << 20:    "10MiB",
+		2500000:     "2500KB",
+		5000000000:  "5GB",
+		3 << 40:     "3TiB",
+		1<<20 + 1:   "1048577",
+		12000000000: "12GB",
+	} {
+		v := bytesValue(n)
+		if got := v.String(); got != want {
+			t.Errorf("%d should render as %q but was %q.", n, want, got)
+		}
+		back, err := parseBytes(want)
+		if err != nil || back != n {
+			t.Errorf("%q should parse back to %d but was %d (%v).", want, n, back, err)
+		}
+	}
+}
+
+func TestBytesVarErrors(t *testing.T) {
+	for value, want := range map[string]string{
+		"-1KB":       "must not be negative",
+		"10XB":       "unknown unit",
+		"KB":         "invalid size",
+		"1.5GB":      "invalid size",
+		"":           "invalid size",
+		"20000PB":    "unknown unit",
+		"9000000TiB": "too large",
+	} {
+		var n int64
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		BytesVar(fs, &n, "limit", 0, "size limit")
+		if err := fs.Set("limit", value); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q should fail with %q but was %v.", value, want, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// Records the call site of Add, AddE and Mount for every command
+// registered to p, see RegisteredAt. Recording is enabled by default
+// and costs a single stack frame lookup per registration.
+func (p *Path) SetRecordCallers(record bool) {
+	p.noCallers = !record
+}
+
+// Returns the file and line of the call that registered the command,
+// such as "deploy/commands.go:42", with the directory of the file
+// naming its package. Returns "" if the call site wasn't recorded, see
+// SetRecordCallers.
+func (c *CmdCont) RegisteredAt() string {
+	if c.caller == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{c.caller}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File)) + ":" + strconv.Itoa(frame.Line)
+}
+
+// Returns " (registered at file:line)" for messages about c, or "" if
+// the call site isn't known.
+func (c *CmdCont) registeredAtSuffix() string {
+	if at := c.RegisteredAt(); at != "" {
+		return " (registered at " + at + ")"
+	}
+	return ""
+}
+
+// Records the caller of the function calling recordCaller as the call
+// site of c, skipping skip more frames. The location is only resolved
+// by RegisteredAt.
+func (p *Path) recordCaller(c *CmdCont, skip int) {
+	if p.noCallers {
+		return
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(skip+3, pcs[:]) == 1 {
+		c.caller = pcs[0]
+	}
+}
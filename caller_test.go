@@ -0,0 +1,76 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Returns the line following the call of line.
+func nextLine() string {
+	_, _, line, _ := runtime.Caller(1)
+	return "caller_test.go:" + strconv.Itoa(line+1)
+}
+
+func TestRegisteredAt(t *testing.T) {
+	p := NewPath()
+	want := map[string]string{}
+	want["sync"] = nextLine()
+	p.Add("sync", "syncs the files", &testCmd{})
+	want["status"] = nextLine()
+	if _, err := p.AddE("status", "prints the status", &testCmd{}); err != nil {
+		t.Fatal(err)
+	}
+	want["remote"] = nextLine()
+	p.Mount("remote", "manages remotes", NewPath())
+	want["tool"] = nextLine()
+	if _, err := p.AddStruct("tool", &remoteTool{}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range want {
+		if at := p.Lookup(name).RegisteredAt(); !strings.HasSuffix(at, "/"+want) {
+			t.Errorf("%s should be registered at %q but was registered at %q.", name, want, at)
+		}
+	}
+
+	p.SetRecordCallers(false)
+	if at := p.Add("debug", "dumps the state", &testCmd{}).RegisteredAt(); at != "" {
+		t.Errorf("Call sites should not be recorded but was %q.", at)
+	}
+}
+
+func TestRegisteredAtErrors(t *testing.T) {
+	p := NewPath()
+	p.Add("sync", "syncs the files", &testCmd{})
+	at := p.Lookup("sync").RegisteredAt()
+	_, err := p.AddStruct("sync", &remoteTool{})
+	if want := `Command "sync" is already registered (registered at ` + at + ")"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if err := p.AddTopic("sync", "Syncing", "text"); err == nil || !strings.Contains(err.Error(), "caller_test.go:") {
+		t.Errorf("The collision with a command should name its call site but was %v.", err)
+	}
+
+	var tree strings.Builder
+	if err := p.WriteTree(&tree, TreeOptions{Callers: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "sync (registered at " + at + ")\n"; tree.String() != want {
+		t.Errorf("Tree should be %q but was %q.", want, tree.String())
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The severity of a Problem found by Check.
+type Severity int
+
+const (
+	// The command works but is incomplete, such as without description.
+	SeverityWarning Severity = iota
+	// The command fails or can't be used as intended.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// A problem of the registered command tree found by Check.
+type Problem struct {
+	// the command, including the names of the paths it is mounted to
+	Command  string
+	Severity Severity
+	Message  string
+	// the call site registering the command, see RegisteredAt
+	RegisteredAt string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Command, p.Severity, p.Message)
+}
+
+// Audits the commands of p and of the paths mounted to it: commands
+// without a description or with a reserved name, required flags that
+// aren't defined, flags shadowing persistent flags of an enclosing
+// path, deprecated flags without a message, long descriptions that
+// can't be read, see LongFromFS, mounted paths without commands, macro
+// steps invoking unknown commands or the macro itself,
+// see AddMacro, and invalid examples, see CheckExamples. Returns the
+// problems found sorted by command, along with where the command was
+// registered.
+func (p *Path) Check() []Problem {
+	var problems []Problem
+	var at string
+	add := func(cmd string, s Severity, format string, args ...interface{}) {
+		problems = append(problems, Problem{Command: cmd, Severity: s, Message: fmt.Sprintf(format, args...), RegisteredAt: at})
+	}
+	for _, d := range p.docCommands("") {
+		name, c := strings.TrimPrefix(d.name, " "), d.cont
+		at = c.RegisteredAt()
+		if c.Desc == "" {
+			add(name, SeverityWarning, "missing description")
+		}
+		if err := d.path.checkReserved(c.Name); err != nil {
+			add(name, SeverityError, "%v", err)
+		}
+		if c.sub != nil {
+			if len(c.sub.registry().entries) == 0 {
+				add(name, SeverityError, "mounted path has no commands")
+			}
+			continue
+		}
+		for _, r := range c.RequiredFlags {
+			if c.FlagSet().Lookup(r) == nil {
+				add(name, SeverityError, "required flag -%s is not defined", r)
+			}
+		}
+		c.FlagSet().VisitAll(func(f *flag.Flag) {
+			for q := d.path; q != nil; q = q.parent {
+				if q.persistent != nil && q.persistent.Lookup(f.Name) != nil {
+					add(name, SeverityWarning, "flag -%s shadows a persistent flag", f.Name)
+					return
+				}
+			}
+		})
+		if m, ok := c.Cmd.(*macroCmd); ok {
+			for _, msg := range m.check() {
+				add(name, SeverityError, "%s", msg)
+			}
+		}
+		if _, err := c.longText(); err != nil {
+			add(name, SeverityError, "long description: %v", err)
+		}
+		for _, f := range sortedKeys(c.deprecated) {
+			if c.deprecated[f] == "" {
+				add(name, SeverityWarning, "deprecated flag -%s has no message", f)
+			}
+		}
+	}
+	problems = append(problems, p.CheckExamples()...)
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Command < problems[j].Command
+	})
+	return problems
+}
+
+// Same as Check but panics if a problem of SeverityError is found,
+// listing all of them. Warnings are ignored.
+func (p *Path) MustCheck() {
+	var errs []string
+	for _, problem := range p.Check() {
+		if problem.Severity == SeverityError {
+			errs = append(errs, problem.String())
+		}
+	}
+	if len(errs) > 0 {
+		panic("command: invalid command tree:\n" + strings.Join(errs, "\n"))
+	}
+}
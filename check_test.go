@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newBrokenPath() *Path {
+	p := NewPath()
+	p.PersistentFlags().Bool("debug", false, "debug output")
+	p.Add("deploy", "", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("force", false, "skip checks")
+			fs.Bool("debug", false, "debug output")
+		},
+	}, "froce")
+	c := p.Add("status", "prints the status", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("short", false, "short output")
+		},
+	})
+	c.DeprecateFlag("short", "")
+	p.Mount("plugins", "manages plugins", NewPath())
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{}, "name")
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func TestCheck(t *testing.T) {
+	want := []Problem{
+		{"deploy", SeverityWarning, "missing description", ""},
+		{"deploy", SeverityError, "required flag -froce is not defined", ""},
+		{"deploy", SeverityWarning, "flag -debug shadows a persistent flag", ""},
+		{"plugins", SeverityError, "mounted path has no commands", ""},
+		{"remote add", SeverityError, "required flag -name is not defined", ""},
+		{"status", SeverityWarning, "deprecated flag -short has no message", ""},
+	}
+	if problems := withoutCallers(t, newBrokenPath().Check()); !reflect.DeepEqual(problems, want) {
+		t.Errorf("Problems should be %v but were %v.", want, problems)
+	}
+
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	if problems := p.Check(); len(problems) != 0 {
+		t.Errorf("A valid tree should have no problems but had %v.", problems)
+	}
+	p.MustCheck()
+}
+
+// Verifies that the problems name the test file registering the
+// command and clears their call sites for comparison.
+func withoutCallers(t *testing.T, problems []Problem) []Problem {
+	for i, problem := range problems {
+		if !strings.Contains(problem.RegisteredAt, "_test.go:") {
+			t.Errorf("Problem %v should be registered in a test file but was registered at %q.", problem, problem.RegisteredAt)
+		}
+		problems[i].RegisteredAt = ""
+	}
+	return problems
+}
+
+func TestMustCheck(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		want := "command: invalid command tree:\n" +
+			"deploy: error: required flag -froce is not defined\n" +
+			"plugins: error: mounted path has no commands\n" +
+			"remote add: error: required flag -name is not defined"
+		if msg != want {
+			t.Errorf("MustCheck should panic with %q but was %q.", want, msg)
+		}
+		if strings.Contains(msg, "warning") {
+			t.Error("MustCheck should ignore warnings.")
+		}
+	}()
+	newBrokenPath().MustCheck()
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// The default time cleanups may take, see SetCleanupTimeout.
+const defaultCleanupTimeout = 10 * time.Second
+
+// Commands implementing CleanupCmd are cleaned up after they ran,
+// whether they succeeded, failed, timed out, were canceled by their
+// context or panicked with SetRecoverPanics. ctx is not canceled with
+// the context of the command, but once the cleanup timeout elapsed,
+// see SetCleanupTimeout.
+type CleanupCmd interface {
+	Cmd
+	Cleanup(ctx context.Context) error
+}
+
+// Registers fn to run once the current run of the command returns, for
+// any reason, such as to remove a temporary file it created:
+//
+//	c, _ := command.FromContext(ctx)
+//	c.OnCleanup(func() error { return os.Remove(tmp) })
+//
+// The functions run in reverse registration order, before the Cleanup
+// method of a CleanupCmd, and are discarded afterwards. Their errors,
+// and their panics with SetRecoverPanics, are joined to the error of
+// the command; the other cleanups still run.
+func (c *CmdCont) OnCleanup(fn func() error) {
+	c.cleanups = append(c.cleanups, fn)
+}
+
+// Limits the time the cleanups of a command may take after it
+// returned, 10 seconds by default. Run doesn't wait for cleanups that
+// take longer and fails with a CleanupTimeoutError instead.
+func (p *Path) SetCleanupTimeout(d time.Duration) {
+	p.cleanupTimeout = d
+}
+
+// Returns the cleanup timeout of p or the closest enclosing path
+// setting one.
+func (p *Path) findCleanupTimeout() time.Duration {
+	for q := p; q != nil; q = q.parent {
+		if q.cleanupTimeout > 0 {
+			return q.cleanupTimeout
+		}
+	}
+	return defaultCleanupTimeout
+}
+
+// Returned by Run, joined to the error of the command, if its cleanups
+// didn't finish within the cleanup timeout, see SetCleanupTimeout.
+type CleanupTimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e *CleanupTimeoutError) Error() string {
+	return fmt.Sprintf("%scleanup did not finish within %v", commandPrefix(e.Command), e.Timeout)
+}
+
+// Runs the cleanups of cont run by p with ctx and returns their joined
+// errors. Nothing is allocated for commands without cleanups.
+func (p *Path) cleanup(ctx context.Context, cont *CmdCont) error {
+	fns := cont.cleanups
+	cont.cleanups = nil
+	cmd, ok := cont.Cmd.(CleanupCmd)
+	if len(fns) == 0 && !ok {
+		return nil
+	}
+	timeout := p.findCleanupTimeout()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(withCmdCont(ctx, p, cont)), timeout)
+	defer cancel()
+	recovers := p.recovers()
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for i := len(fns) - 1; i >= 0; i-- {
+			if err := callCleanup(cont, recovers, fns[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if ok {
+			err := callCleanup(cont, recovers, func() error {
+				return cmd.Cleanup(ctx)
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		done <- errors.Join(errs...)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return &CleanupTimeoutError{Command: cont.CommandPath(), Timeout: timeout}
+	}
+}
+
+// Calls the cleanup fn of cont. If recovers is set, a panic of fn is
+// returned as a PanicError, so that the other cleanups still run.
+func callCleanup(cont *CmdCont, recovers bool, fn func() error) (err error) {
+	if recovers {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Command: cont.Name, Value: v, stack: panicStack(debug.Stack())}
+			}
+		}()
+	}
+	return fn()
+}
@@ -0,0 +1,191 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type cleanupCmd struct {
+	testContextCmd
+	cleanup func(ctx context.Context) error
+}
+
+func (c *cleanupCmd) Cleanup(ctx context.Context) error {
+	return c.cleanup(ctx)
+}
+
+// Returns a path with the command job, which registers two cleanups
+// recording their order in calls and then runs run.
+func newCleanupPath(calls *[]string, run func(ctx context.Context) error) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("job", "runs a job", &cleanupCmd{
+		testContextCmd: testContextCmd{runContext: func(ctx context.Context, args ...string) error {
+			c, _ := FromContext(ctx)
+			c.OnCleanup(func() error {
+				*calls = append(*calls, "lock")
+				return nil
+			})
+			c.OnCleanup(func() error {
+				*calls = append(*calls, "tmp")
+				return nil
+			})
+			return run(ctx)
+		}},
+		cleanup: func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			*calls = append(*calls, "cmd")
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestCleanup(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name string
+		run  func(ctx context.Context) error
+		err  error
+	}{
+		{"success", func(ctx context.Context) error { return nil }, nil},
+		{"error", func(ctx context.Context) error { return errFailed }, errFailed},
+		{"timeout", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, context.DeadlineExceeded},
+		{"panic", func(ctx context.Context) error { panic("boom") }, nil},
+	}
+	for _, test := range tests {
+		var calls []string
+		p, c := newCleanupPath(&calls, test.run)
+		p.SetRecoverPanics(true)
+		c.SetTimeout(10 * time.Millisecond)
+		_, err := p.Run("job")
+		if test.err != nil && !errors.Is(err, test.err) || test.err == nil && test.name != "panic" && err != nil {
+			t.Errorf("%s: Run should fail with %v but was %v.", test.name, test.err, err)
+		}
+		if want := []string{"tmp", "lock", "cmd"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("%s: The cleanups should run as %q but ran as %q.", test.name, want, calls)
+		}
+		if len(c.cleanups) != 0 {
+			t.Errorf("%s: The cleanups should be discarded after the run.", test.name)
+		}
+	}
+}
+
+func TestCleanupCanceled(t *testing.T) {
+	var calls []string
+	ctx, cancel := context.WithCancel(context.Background())
+	p, _ := newCleanupPath(&calls, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	if _, err := p.RunContext(ctx, "job"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run should fail with %v but was %v.", context.Canceled, err)
+	}
+	if want := []string{"tmp", "lock", "cmd"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("The cleanups should run with an uncanceled context as %q but ran as %q.", want, calls)
+	}
+}
+
+func TestCleanupErrors(t *testing.T) {
+	errFailed := errors.New("failed")
+	errRemove := errors.New("cannot remove tmp")
+	var calls []string
+	p, _ := newCleanupPath(&calls, func(ctx context.Context) error {
+		c, _ := FromContext(ctx)
+		c.OnCleanup(func() error { return errRemove })
+		return errFailed
+	})
+	p.SetExitCode(errFailed, 3)
+	_, err := p.Run("job")
+	if !errors.Is(err, errFailed) || !errors.Is(err, errRemove) {
+		t.Errorf("The error should match the command and cleanup errors but was %v.", err)
+	}
+	if want := "failed\ncannot remove tmp"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if code := p.ExitCode(err); code != 3 {
+		t.Errorf("The exit code should follow the command error but was %d.", code)
+	}
+	calls = nil
+	p, _ = newCleanupPath(&calls, func(ctx context.Context) error {
+		c, _ := FromContext(ctx)
+		c.OnCleanup(func() error { return errRemove })
+		return nil
+	})
+	if _, err := p.Run("job"); !errors.Is(err, errRemove) || err.Error() != errRemove.Error() {
+		t.Errorf("Run should fail with the cleanup error but was %v.", err)
+	}
+	if want := []string{"tmp", "lock", "cmd"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("A failing cleanup should not stop the others but they ran as %q.", calls)
+	}
+}
+
+func TestCleanupPanic(t *testing.T) {
+	var calls []string
+	p, _ := newCleanupPath(&calls, func(ctx context.Context) error {
+		c, _ := FromContext(ctx)
+		c.OnCleanup(func() error {
+			calls = append(calls, "last")
+			return nil
+		})
+		c.OnCleanup(func() error { panic("boom") })
+		c.OnCleanup(func() error {
+			calls = append(calls, "first")
+			return nil
+		})
+		return nil
+	})
+	p.SetRecoverPanics(true)
+	_, err := p.Run("job")
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("Run should fail with the panic of the cleanup but was %v.", err)
+	}
+	if want := []string{"first", "last", "tmp", "lock", "cmd"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("A panicking cleanup should not stop the others but they ran as %q.", calls)
+	}
+}
+
+func TestCleanupTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	p := NewPath()
+	p.SetCleanupTimeout(10 * time.Millisecond)
+	p.Add("job", "runs a job", &testContextCmd{runContext: func(ctx context.Context, args ...string) error {
+		c, _ := FromContext(ctx)
+		c.OnCleanup(func() error {
+			<-release
+			return nil
+		})
+		return nil
+	}})
+	_, err := p.Run("job")
+	var timeout *CleanupTimeoutError
+	if !errors.As(err, &timeout) || timeout.Timeout != 10*time.Millisecond {
+		t.Fatalf("Run should fail with a CleanupTimeoutError but was %v.", err)
+	}
+	if want := "job: cleanup did not finish within 10ms"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Loads command aliases like those of git, mapping alias names to
+// command lines such as "deploy -env prod". Typing an alias in place
+// of a command name runs the command line split with SplitArgs
+// followed by the remaining arguments. A command line may start with
+// another alias. Aliases are listed by PrintAvailableCommands and
+// completed like commands. Commands must be registered before the
+// aliases are loaded; aliases shadowing a command, invoking an unknown
+// command or expanding to themselves are rejected, in which case none
+// of m is loaded. Later loads override the aliases of earlier ones.
+func (p *Path) LoadAliases(m map[string]string) error {
+	aliases := make(map[string][]string, len(p.aliases)+len(m))
+	for name, exp := range p.aliases {
+		aliases[name] = exp
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := p.registry().entries[name]; ok {
+			return fmt.Errorf("Alias %q shadows the command of the same name", name)
+		}
+		args, err := SplitArgs(m[name])
+		if err != nil {
+			return fmt.Errorf("Alias %q: %w", name, err)
+		}
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("Alias %q must start with a command name", name)
+		}
+		aliases[name] = args
+	}
+	for _, name := range names {
+		seen := map[string]bool{name: true}
+		for next := aliases[name][0]; ; next = aliases[next][0] {
+			if seen[next] {
+				return fmt.Errorf("Alias %q is recursive", name)
+			}
+			if _, ok := aliases[next]; !ok {
+				if _, ok := p.registry().entries[next]; !ok {
+					return fmt.Errorf("Alias %q invokes unknown command %q", name, next)
+				}
+				break
+			}
+			seen[next] = true
+		}
+	}
+	p.aliases = aliases
+	return nil
+}
+
+// Returns the sorted names of the aliases of p.
+func (p *Path) aliasNames() []string {
+	names := make([]string, 0, len(p.aliases))
+	for name := range p.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Replaces a leading alias in args by its command line.
+func (p *Path) expandAlias(args []string) []string {
+	for len(args) > 0 {
+		exp, ok := p.aliases[args[0]]
+		if !ok {
+			break
+		}
+		args = append(append([]string(nil), exp...), args[1:]...)
+	}
+	return args
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newAliasPath(got *[]string) *Path {
+	p := NewPath()
+	p.Add("checkout", "switches branches", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("b", false, "create a branch")
+		},
+		run: func(args ...string) error {
+			*got = append([]string{"checkout"}, args...)
+			return nil
+		},
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	return p
+}
+
+func TestLoadAliases(t *testing.T) {
+	var got []string
+	p := newAliasPath(&got)
+	if err := p.LoadAliases(map[string]string{
+		"co":  "checkout",
+		"cob": "co -b",
+		"new": `cob "my branch"`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var trace strings.Builder
+	p.SetTrace(&trace)
+	c, err := p.Run("new", "main")
+	if err != nil || c.Name != "checkout" {
+		t.Fatalf("Alias should run checkout but was %v, %v.", c, err)
+	}
+	if want := []string{"checkout", "my branch", "main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args should be %q but were %q.", want, got)
+	}
+	if v := c.Flags.Lookup("b").Value.String(); v != "true" {
+		t.Errorf("Flag -b should be set by the alias but was %q.", v)
+	}
+	if want := `trace: expanded alias "new" to ["checkout" "-b" "my branch"]` + "\n"; !strings.Contains(trace.String(), want) {
+		t.Errorf("Trace should contain %q but was %q.", want, trace.String())
+	}
+	if names := p.Complete("c"); !reflect.DeepEqual(names, []string{"checkout", "co", "cob"}) {
+		t.Errorf("Completion should offer the aliases but was %q.", names)
+	}
+	if flags := p.Complete("co", "-"); !reflect.DeepEqual(flags, []string{"-b"}) {
+		t.Errorf("Completion should offer the flags of the aliased command but was %q.", flags)
+	}
+}
+
+func TestAliasesListedInOrder(t *testing.T) {
+	var got []string
+	p := newAliasPath(&got)
+	if err := p.LoadAliases(map[string]string{
+		"st":  "status",
+		"co":  "checkout",
+		"cob": "co -b",
+		"ci":  "status",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	p.writeAvailableCommands(&out)
+	want := "\tci\talias for status\n\tco\talias for checkout\n\tcob\talias for co -b\n\tst\talias for status\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("The aliases should be listed as %q but were %q.", want, out.String())
+	}
+}
+
+func TestLoadAliasesInvalid(t *testing.T) {
+	var got []string
+	for _, test := range []struct {
+		aliases map[string]string
+		err     string
+	}{
+		{map[string]string{"status": "checkout"}, `Alias "status" shadows the command of the same name`},
+		{map[string]string{"a": "b", "b": "c x", "c": "a"}, `Alias "a" is recursive`},
+		{map[string]string{"a": "a"}, `Alias "a" is recursive`},
+		{map[string]string{"a": "deploy"}, `Alias "a" invokes unknown command "deploy"`},
+		{map[string]string{"a": "-b checkout"}, `Alias "a" must start with a command name`},
+		{map[string]string{"a": "checkout 'x"}, `Alias "a": unterminated ' quote at offset 9`},
+	} {
+		p := newAliasPath(&got)
+		if err := p.LoadAliases(test.aliases); err == nil || err.Error() != test.err {
+			t.Errorf("Loading %q should fail with %q but was %v.", test.aliases, test.err, err)
+		}
+		if len(p.aliases) != 0 {
+			t.Errorf("Failed load of %q should not load any alias but loaded %q.", test.aliases, p.aliases)
+		}
+	}
+	p := newAliasPath(&got)
+	if err := p.LoadAliases(map[string]string{"co": "checkout"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadAliases(map[string]string{"x": "co", "co": "x"}); err == nil {
+		t.Error("Cycles through an earlier alias should be rejected.")
+	}
+}
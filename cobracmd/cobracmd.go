@@ -0,0 +1,172 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cobra
+
+package cobracmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Returns a command running c together with the name and description
+// to register it with, taken from the Use and Short fields of c:
+//
+//	cmd, name, desc := cobracmd.FromCobra(deployCmd)
+//	p.Add(name, desc, cmd)
+//
+// The local, persistent and inherited flags of c are registered with
+// their shorthands as flags of the command. On Run the arguments are
+// checked with the Args of c and the hooks of c are called like by
+// cobra: the closest PersistentPreRun, PreRun, Run, PostRun and the
+// closest PersistentPostRun, preferring their E variants. Sub-commands
+// of c are not run, mount a path for them instead.
+func FromCobra(c *cobra.Command) (command.Cmd, string, string) {
+	return &cobraCmd{c: c}, c.Name(), c.Short
+}
+
+type cobraCmd struct {
+	c *cobra.Command
+}
+
+func (a *cobraCmd) Flags(fs *flag.FlagSet) {
+	register := func(f *pflag.Flag) {
+		if fs.Lookup(f.Name) != nil {
+			return
+		}
+		v := flagValue(f)
+		fs.Var(v, f.Name, f.Usage)
+		if f.Shorthand != "" && fs.Lookup(f.Shorthand) == nil {
+			fs.Var(v, f.Shorthand, f.Usage)
+		}
+	}
+	a.c.LocalFlags().VisitAll(register)
+	a.c.InheritedFlags().VisitAll(register)
+}
+
+func (a *cobraCmd) Run(args ...string) error {
+	return a.RunContext(context.Background(), args...)
+}
+
+func (a *cobraCmd) RunContext(ctx context.Context, args ...string) error {
+	c := a.c
+	c.SetContext(ctx)
+	if err := c.ValidateArgs(args); err != nil {
+		return err
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPreRunE != nil || p.PersistentPreRun != nil {
+			if err := run(c, args, p.PersistentPreRunE, p.PersistentPreRun); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if err := run(c, args, c.PreRunE, c.PreRun); err != nil {
+		return err
+	}
+	if c.RunE == nil && c.Run == nil {
+		return fmt.Errorf("Command %q is not runnable", c.Name())
+	}
+	if err := run(c, args, c.RunE, c.Run); err != nil {
+		return err
+	}
+	if err := run(c, args, c.PostRunE, c.PostRun); err != nil {
+		return err
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPostRunE != nil || p.PersistentPostRun != nil {
+			return run(c, args, p.PersistentPostRunE, p.PersistentPostRun)
+		}
+	}
+	return nil
+}
+
+// Calls runE if set and run otherwise.
+func run(c *cobra.Command, args []string, runE func(*cobra.Command, []string) error, run func(*cobra.Command, []string)) error {
+	if runE != nil {
+		return runE(c, args)
+	}
+	if run != nil {
+		run(c, args)
+	}
+	return nil
+}
+
+// Returns a flag.Value setting the value of f, which marks f as changed
+// for cobra's Changed.
+func flagValue(f *pflag.Flag) flag.Value {
+	if f.Value.Type() == "bool" {
+		return &boolValue{pflagValue{f: f}}
+	}
+	return &pflagValue{f: f}
+}
+
+type pflagValue struct {
+	f *pflag.Flag
+}
+
+func (v *pflagValue) String() string {
+	if v.f == nil {
+		return ""
+	}
+	return v.f.Value.String()
+}
+
+func (v *pflagValue) Set(s string) error {
+	if sv, ok := v.f.Value.(pflag.SliceValue); ok && !v.f.Changed {
+		// pflag only replaces the default with the first value, which
+		// it doesn't know about after a Reset
+		sv.Replace([]string{})
+	}
+	if err := v.f.Value.Set(s); err != nil {
+		return err
+	}
+	v.f.Changed = true
+	return nil
+}
+
+// Restores the default of the flag and clears Changed, so the flag
+// isn't reported as changed after the flags of the command were reset.
+// Slice values append on Set and are replaced with their default
+// instead.
+func (v *pflagValue) Reset() {
+	if sv, ok := v.f.Value.(pflag.SliceValue); ok {
+		def := strings.TrimSuffix(strings.TrimPrefix(v.f.DefValue, "["), "]")
+		values := []string{}
+		if def != "" {
+			values = strings.Split(def, ",")
+		}
+		sv.Replace(values)
+	} else {
+		v.f.Value.Set(v.f.DefValue)
+	}
+	v.f.Changed = false
+}
+
+// Lets bool flags be given without a value.
+type boolValue struct {
+	pflagValue
+}
+
+func (v *boolValue) IsBoolFlag() bool {
+	return true
+}
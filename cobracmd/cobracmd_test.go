@@ -0,0 +1,121 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cobra
+
+package cobracmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/spf13/cobra"
+)
+
+func TestFromCobra(t *testing.T) {
+	var calls []string
+	var env string
+	var force bool
+	var gotArgs []string
+	root := &cobra.Command{
+		Use: "app",
+		PersistentPreRun: func(c *cobra.Command, args []string) {
+			calls = append(calls, "persistent pre-run")
+		},
+	}
+	var region string
+	root.PersistentFlags().StringVar(&region, "region", "eu", "cloud region")
+	deploy := &cobra.Command{
+		Use:   "deploy [flags] service",
+		Short: "deploys the app",
+		Args:  cobra.MinimumNArgs(1),
+		PreRunE: func(c *cobra.Command, args []string) error {
+			calls = append(calls, "pre-run")
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			calls = append(calls, "run")
+			gotArgs = args
+			if !c.Flags().Changed("env") {
+				t.Error("Flag --env should be changed.")
+			}
+			return nil
+		},
+	}
+	deploy.Flags().StringVarP(&env, "env", "e", "dev", "target environment")
+	deploy.Flags().BoolVarP(&force, "force", "f", false, "skip the checks")
+	root.AddCommand(deploy)
+
+	p := command.NewPath()
+	cmd, name, desc := FromCobra(deploy)
+	c := p.Add(name, desc, cmd)
+	if c.Name != "deploy" || c.Desc != "deploys the app" {
+		t.Errorf("Command should be deploy, %q but was %q, %q.", "deploys the app", c.Name, c.Desc)
+	}
+	if _, err := p.Run("deploy", "-e", "prod", "-f", "-region", "us", "api", "web"); err != nil {
+		t.Fatal(err)
+	}
+	if env != "prod" || !force || region != "us" {
+		t.Errorf("Flags should be prod, true, us but were %q, %v, %q.", env, force, region)
+	}
+	if want := []string{"api", "web"}; !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("Args should be %q but were %q.", want, gotArgs)
+	}
+	if want := []string{"persistent pre-run", "pre-run", "run"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls should be %q but were %q.", want, calls)
+	}
+	p.ResetFlags()
+	if _, err := p.Run("deploy"); err == nil {
+		t.Error("Run without the arguments required by Args should fail.")
+	}
+}
+
+func TestFromCobraResetFlags(t *testing.T) {
+	var tags []string
+	var changed bool
+	deploy := &cobra.Command{
+		Use: "deploy",
+		Run: func(c *cobra.Command, args []string) {
+			changed = c.Flags().Changed("env")
+		},
+	}
+	deploy.Flags().StringSliceVar(&tags, "tag", []string{"stable"}, "tags of the release")
+	deploy.Flags().String("env", "dev", "target environment")
+
+	p := command.NewPath()
+	cmd, name, desc := FromCobra(deploy)
+	p.Add(name, desc, cmd)
+	if _, err := p.Run("deploy", "-tag", "v2", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v2"}; !reflect.DeepEqual(tags, want) || !changed {
+		t.Errorf("Tags should be %q and --env changed but were %q, %v.", want, tags, changed)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"stable"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags should be reset to %q but were %q.", want, tags)
+	}
+	if changed {
+		t.Error("Flag --env should not be changed after the reset.")
+	}
+	if _, err := p.Run("deploy", "-tag", "v3", "-tag", "v4"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v3", "v4"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags should be %q but were %q.", want, tags)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cobracmd runs commands defined with github.com/spf13/cobra as
+// commands of the command package, see FromCobra. It is only built with
+// the build tag cobra, so that the command package doesn't depend on
+// cobra:
+//
+//	go build -tags cobra
+package cobracmd
@@ -19,20 +19,161 @@
 package command
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 )
 
 // A map of all of the registered sub-commands.
+//
+// A Path serves one dispatch at a time: Run and the other methods
+// dispatching a command parse into the flag sets of the path and its
+// commands and record the state of the dispatch on them, so they must
+// not be called concurrently. The registry is safe for concurrent use,
+// Add, Remove, Lookup and Complete may be called while a command is
+// dispatched. Programs dispatching concurrently serialize the
+// dispatches, as HTTPHandler does, or use a Path per goroutine, see
+// ForEachOptions.
 type Path struct {
-	entries map[string]*CmdCont
+	// the registered commands, see registry
+	snapshot atomic.Pointer[registry]
+	// guards modifications of the registry
+	mu sync.Mutex
+	// the registry modified in place until it is first read
+	building *registry
+	// resolve unique prefixes of command names, see SetPrefixMatching
+	prefixMatching bool
+	// Global flags, parsed by Run before the sub-command name.
+	Flags *flag.FlagSet
+
+	config              config
+	ignoreUnknownConfig bool
+	output              io.Writer
+	showDeprecated      bool
+	persistent          *flag.FlagSet
+	// environment variables bound to global flags
+	env map[string]string
+	// sources of the global flags, set by Run
+	sources map[string]Source
+	// destination of the __complete command, os.Stdout if nil
+	stdout io.Writer
+	term   Terminal
+	// wrap the errors of commands in a CommandError
+	wrapErrors bool
+	exitCodes  []exitCode
+	// destination of the dispatch trace, see SetTrace
+	trace io.Writer
+	// the path a path is mounted to and the command it is mounted as
+	parent *Path
+	mount  *CmdCont
+	logger Logger
+	// callbacks registered with OnComplete
+	completed     []func(*CmdCont, time.Duration, error)
+	recoverPanics bool
+	// warnings not yet returned by Warnings
+	warnings      []Warning
+	quietWarnings bool
+	// built-in commands disabled by OverrideBuiltin
+	overridden map[string]bool
+	// stripped from the program name by RunMultiCall
+	multiCallPrefix string
+	// command aliases loaded by LoadAliases
+	aliases map[string][]string
+	// global flags left out by AutoEnv
+	noAutoEnv map[string]bool
+	// the file loaded by LoadUserConfig
+	configFile  string
+	audit       *auditLog
+	auditValues bool
+	// set by ForceInteractive
+	interactive *bool
+	// the pager command set by SetPager, $PAGER if empty
+	pager       string
+	noPager     bool
+	noPagerFlag *bool
+	// keep the flag values between dispatches, see SetResetFlags
+	noReset bool
+	// set by a dispatch, cleared by ResetFlags
+	dispatched bool
+	// the command matched by the last dispatch
+	last *CmdCont
+	// run if no command is given, see SetRoot
+	rootCmd *CmdCont
+	// render the help output anew every time, see DisableHelpCache
+	noHelpCache bool
+	// help topics by name, see AddTopic
+	topics map[string]topic
+	// the number of dispatches in progress
+	running int
+	// expand @file arguments, see SetResponseFiles
+	responseFiles bool
+	// hidden global flags, see Path.HideFlag
+	hidden map[string]bool
+	// see EnableProfiling
+	profiling bool
+	// see SetRecordCallers
+	noCallers bool
+	// see SetFeatureGate
+	featureGate func(feature string) bool
+	// see SetAuthorizer
+	authorizer func(ctx context.Context, c *CmdCont) error
+	// see SetPlainExitCodes
+	plainExitCodes bool
+	// see SetQuiet and QuietFlag
+	quiet     bool
+	quietFlag *bool
+	// see EnableVerboseFlag
+	verbosity *verbosity
+	// see SetHelpHeader
+	helpHeader string
+	// see EnableTimeoutFlag
+	timeoutFlag *time.Duration
+	// see SetReportAllErrors
+	reportAllErrors bool
+	// see SetStrictArgs
+	strictArgs bool
+	// see SetCleanupTimeout
+	cleanupTimeout time.Duration
+	// see SetAllowReentry
+	allowReentry bool
+	// the commands matched by the dispatches enclosing the one of
+	// last, see setLast
+	earlier []*CmdCont
+	// ask which command to run if none is given, see
+	// EnableInteractivePicker
+	picker bool
+	// the argument separating the stages of a pipeline, see
+	// SetPipeSeparator
+	pipeSep string
 }
 
 func NewPath() *Path {
 	return &Path{
-		entries: make(map[string]*CmdCont),
+		Flags: flag.NewFlagSet("", flag.ContinueOnError),
+	}
+}
+
+// Sets the destination for warnings printed by Run.
+// If w is nil, os.Stderr is used.
+func (p *Path) SetOutput(w io.Writer) {
+	p.output = w
+}
+
+// Returns the destination for warnings printed by Run.
+func (p *Path) Output() io.Writer {
+	if p.output == nil {
+		return os.Stderr
 	}
+	return p.output
 }
 
 var (
@@ -60,12 +201,174 @@ func (s CmdFunc) Run(args ...string) error {
 	return s(args)
 }
 
+// Commands implementing CmdWithCont are run with their container
+// instead of their Run method, so that they can look up their flags,
+// see CmdCont.FlagSet and CmdCont.FlagWasSet.
+type CmdWithCont interface {
+	Cmd
+	RunCont(c *CmdCont, args ...string) error
+}
+
+// A func that implements the CmdWithCont interface.
+// For registering simple commands defining their flags on their
+// container after Add.
+type CmdContFunc func(c *CmdCont, args []string) error
+
+func (s CmdContFunc) Flags(fs *flag.FlagSet) {
+}
+
+// Calls s without a container. Run calls RunCont instead.
+func (s CmdContFunc) Run(args ...string) error {
+	return s(nil, args)
+}
+
+func (s CmdContFunc) RunCont(c *CmdCont, args ...string) error {
+	return s(c, args)
+}
+
 type CmdCont struct {
 	Cmd
 	Name          string
 	Desc          string
 	RequiredFlags []string
-	Flags         *flag.FlagSet
+	// The flags of the command. They are registered by the Flags
+	// method of Cmd when the command is first used, such as by Run or
+	// WriteHelp, so use FlagSet to access them before.
+	Flags *flag.FlagSet
+	// guards the lazy registration of Flags, see FlagSet
+	mu         sync.Mutex
+	registered bool
+
+	// deprecation messages by flag name
+	deprecated map[string]string
+	warned     map[string]bool
+	hidden     map[string]bool
+	validators []flagValidator
+	// required flags that must not be empty
+	nonEmpty    map[string]bool
+	allNonEmpty bool
+	// the nested path of a command registered with Mount
+	sub *Path
+	// the path the command is registered to
+	path *Path
+	// left out of the list of commands, see Hide
+	hiddenCmd bool
+	// see Requires
+	requirements []Requirement
+	// see EnabledIf
+	enabled func() (bool, string)
+	// see OnFlagSet
+	onFlagSet []flagCallback
+	// see Retry
+	retry *retryPolicy
+	// see ConfirmFunc
+	confirm func(c *CmdCont) string
+	// the maximum number of arguments read from stdin, 0 if disabled,
+	// see StdinArgs
+	stdinArgs int
+	// global and persistent flags visible to the command, the
+	// closest first, set by Run
+	globals []*flag.FlagSet
+	// environment variables bound to flags
+	env map[string]string
+	// sources of the flag values, set by Run
+	sources map[string]Source
+	// completion values by flag name
+	choices map[string][]string
+	groups  []flagGroup
+	// normalizes flag names given on the command line
+	normalize func(string) string
+	secret    map[string]bool
+	// required flags to prompt for if missing
+	prompt map[string]bool
+	// split clusters of single character flags
+	combineShorts bool
+	// example invocations added with AddExample
+	examples []string
+	// flags left out by AutoEnv
+	noAutoEnv map[string]bool
+	// renders the text output of a ResultRenderer
+	outputTemplate *template.Template
+	// the rendered help output and the generation of the metadata
+	// shown in it, see WriteHelp
+	help    *helpCache
+	helpGen uint64
+	// the call site of the registration, see RegisteredAt
+	caller uintptr
+	// the feature the command is gated by, see Feature
+	feature string
+	// see SetAnnotation
+	annotations map[string]string
+	// see ExclusiveLock
+	lock *exclusiveLock
+	// see LongFromFS
+	long *longHelp
+	// see RequiredArg, OptionalArg and VariadicArg
+	positionals []positionalArg
+	// the values of the positionals given to the last run, see
+	// ArgValue
+	argValues [][]string
+	// the arguments the flags were last parsed from, see
+	// PassthroughArgs
+	parsedArgs []string
+	// see SetDefaultValue
+	defaultValues map[string]string
+	// see SetTimeout
+	timeout time.Duration
+	// see NoArgs and ArbitraryArgs
+	noArgs  bool
+	anyArgs bool
+	// registered with OnCleanup during the current run
+	cleanups []func() error
+	// the number of dispatches in progress, see SetAllowReentry
+	dispatching atomic.Int32
+	// the flags whose values the shell completes as paths, see
+	// FlagFilename
+	pathFlags map[string]pathCompletion
+	// descriptions of environment variables, see EnvDoc
+	envDocs map[string]string
+}
+
+// Returns the container of the path c is registered to if the path is
+// mounted, such as remote for the add command of "remote add", or nil
+// otherwise.
+func (c *CmdCont) Parent() *CmdCont {
+	if c.path == nil {
+		return nil
+	}
+	return c.path.mount
+}
+
+// Returns the names of the command and the paths it is mounted to,
+// separated by spaces, such as "remote add".
+func (c *CmdCont) CommandPath() string {
+	if c.isRoot() {
+		// named after its path, see SetRoot
+		if q := c.Parent(); q != nil {
+			return q.CommandPath()
+		}
+		return c.path.Flags.Name()
+	}
+	name := c.Name
+	for q := c.Parent(); q != nil; q = q.Parent() {
+		name = q.Name + " " + name
+	}
+	return name
+}
+
+// Returns the flags of the command after registering them with the
+// Flags method of Cmd if that didn't happen yet. Add defers the
+// registration, so that a program with many commands only registers
+// the flags of the one it runs. FlagSet is safe for concurrent use.
+func (c *CmdCont) FlagSet() *flag.FlagSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.registered {
+		c.registered = true
+		c.Cmd.Flags(c.Flags)
+		c.showDefaultValues()
+	}
+	return c.Flags
 }
 
 // Registers a Cmd for the provided sub-command Name.
@@ -77,14 +380,83 @@ func (p *Path) Add(name, description string, command Cmd, requiredFlags ...strin
 		Desc:          description,
 		RequiredFlags: requiredFlags,
 		Flags:         flag.NewFlagSet(name, flag.ContinueOnError),
+		normalize:     NormalizeUnderscores,
+		path:          p,
 	}
-	// register subcommand flags
-	c.Cmd.Flags(c.Flags)
+	c.Flags.Usage = func() {
+		p.page(c.Flags.Output(), func(w io.Writer) { p.WriteHelp(w, c) })
+	}
+	p.recordCaller(c, 0)
+	// the subcommand flags are registered by FlagSet
 	// TODO warn before overwriting an existing command ?
-	p.entries[name] = c
+	p.setEntry(name, c)
 	return c
 }
 
+// Same as Add but fails if a required flag isn't defined by the
+// command, so that a misspelled name doesn't make every invocation
+// fail, or if the name is reserved for a built-in command, see
+// OverrideBuiltin. The command isn't registered in that case.
+func (p *Path) AddE(name, description string, command Cmd, requiredFlags ...string) (*CmdCont, error) {
+	if err := p.checkReserved(name); err != nil {
+		return nil, err
+	}
+	if _, ok := p.topics[name]; ok {
+		return nil, fmt.Errorf("Command %q collides with the help topic of the same name", name)
+	}
+	prev := p.Lookup(name)
+	replaced := prev != nil
+	c := p.Add(name, description, command, requiredFlags...)
+	for _, r := range requiredFlags {
+		if c.FlagSet().Lookup(r) == nil {
+			if replaced {
+				p.setEntry(name, prev)
+			} else {
+				p.Remove(name)
+			}
+			return nil, fmt.Errorf("No such flag -%s for command %q", r, name)
+		}
+	}
+	p.recordCaller(c, 0)
+	return c, nil
+}
+
+// Registers the sub-commands of sub below the provided Name.
+// E.g. Name is the `remote` in `git remote add`. The global flags of
+// sub are parsed between Name and the name of its sub-command. Mounting
+// sub again, to p or another path, moves it there, see Parent.
+func (p *Path) Mount(name, description string, sub *Path) *CmdCont {
+	sub.Flags.Init(name, flag.ContinueOnError)
+	c := &CmdCont{
+		Cmd:   pathCmd{sub},
+		Name:  name,
+		Desc:  description,
+		Flags: sub.Flags,
+		sub:   sub,
+		path:  p,
+		// the global flags of sub are registered already
+		registered: true,
+	}
+	sub.parent = p
+	sub.mount = c
+	p.recordCaller(c, 0)
+	p.setEntry(name, c)
+	return c
+}
+
+// Runs a mounted Path as a Cmd.
+type pathCmd struct {
+	path *Path
+}
+
+func (c pathCmd) Flags(fs *flag.FlagSet) {
+}
+
+func (c pathCmd) Run(args ...string) error {
+	_, err := c.path.Run(args...)
+	return err
+}
+
 // Parses the flags and leftover arguments to match them with a
 // sub-command. Evaluate all of the global flags and register
 // sub-command handlers before calling it. Sub-command handler's
@@ -92,53 +464,535 @@ func (p *Path) Add(name, description string, command Cmd, requiredFlags ...strin
 // A usage with flag defaults will be printed if provided arguments
 // don't match the configuration.
 // Global flags are accessible once Parse executes.
+// Flags not set on the command line take their value from the bound
+// environment variables or the loaded configuration, if any, see
+// Source.
+// For commands registered with Mount the sub-command of the nested
+// path is run and returned.
 func (p *Path) Run(args ...string) (*CmdCont, error) {
+	return p.RunContext(context.Background(), args...)
+}
+
+// Same as Run but doesn't run the matched command: parses the flags
+// and checks the required flags and validators without printing parse
+// errors, prompting for missing flags or warning about deprecated
+// ones. The flag values stay set, see ResetFlags.
+func (p *Path) Validate(args ...string) (*CmdCont, error) {
+	if p.responseFiles {
+		var err error
+		if args, err = expandResponseFiles(args, 0); err != nil {
+			return nil, err
+		}
+	}
+	p.beginDispatch()
+	defer p.endDispatch()
+	return p.run(context.Background(), args, nil, nil, false)
+}
+
+// Same as Run but also accepts the persistent flags of the enclosing
+// paths and exposes their global flags, the closest first. The matched
+// command is only run if exec is set.
+func (p *Path) run(ctx context.Context, args []string, inherited, globals []*flag.FlagSet, exec bool) (cont *CmdCont, err error) {
+	// the duration of the Run of the command and if a mounted
+	// path completes the invocation
+	var d time.Duration
+	var mounted bool
+	defer func() {
+		if !mounted && exec {
+			p.notifyComplete(cont, d, err)
+		}
+	}()
 	// if there are no subcommands registered,
 	// return immediately
-	if len(p.entries) < 1 || len(args) < 1 {
-		return nil, ErrCmdUsage
+	// the commands as of the start of the dispatch
+	r := p.registry()
+	if len(r.entries) < 1 && p.rootCmd == nil {
+		return nil, &UsageError{Command: p.Flags.Name(), Reason: NoCommandsRegistered}
+	}
+	// the global flags of p were parsed once for all the stages of a
+	// pipeline, see RunPipeline
+	shared := sharesGlobals(ctx, p)
+	if !shared {
+		inherited, globals = p.globalSets(inherited, globals)
+	}
+	if !exec {
+		for _, fs := range globals {
+			defer discardOutput(fs)()
+			defer continueOnError(fs)()
+		}
+	}
+	if p.rootCmd != nil && p.invokesRoot(args, inherited) {
+		cont = p.rootCmd
+		if exec {
+			if err := p.authorize(ctx, cont); err != nil {
+				return cont, err
+			}
+		}
+		d, err = p.runRoot(ctx, args, inherited, globals, exec)
+		return cont, err
+	}
+	if !shared {
+		if args, err = p.parseGlobals(ctx, args, inherited, globals); err != nil {
+			return nil, err
+		}
+		if exec && p.profiling {
+			stop, perr := p.startProfiling()
+			if perr != nil {
+				return nil, perr
+			}
+			defer func() {
+				if serr := stop(); err == nil {
+					err = serr
+				}
+			}()
+		}
+		if tr := p.tracer(); tr != nil {
+			fmt.Fprintf(tr, "trace: path %q: global args %q\n", p.Flags.Name(), args[:len(args)-p.Flags.NArg()])
+			traceFlags(tr, "", p.Flags, p.sources, nil)
+		}
+		args = p.Flags.Args()
+	}
+	tr := p.tracer()
+	if len(args) < 1 && exec && p.pickerEnabled() && p.canPick(EnvFromContext(ctx)) {
+		name, err := p.pickCommand(EnvFromContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			args = []string{name}
+			ctx = context.WithValue(ctx, pickedKey{}, r.entries[name])
+		}
+	}
+	if len(args) < 1 {
+		return nil, &UsageError{Command: p.Flags.Name(), Reason: NoArguments, Commands: len(r.entries)}
+	}
+	if _, ok := p.aliases[args[0]]; ok {
+		expanded := p.expandAlias(args)
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: expanded alias %q to %q\n", args[0], expanded[:len(expanded)-len(args)+1])
+		}
+		args = expanded
 	}
 	// first argument is the subcommand
-	if cont, ok := p.entries[args[0]]; ok {
-		if len(args) > 1 {
-			err := cont.Flags.Parse(args[1:])
-			if err != nil {
+	cont, ok := r.entries[args[0]]
+	if !ok {
+		if cont, ok = p.lookupPrefix(args[0]); ok && tr != nil {
+			fmt.Fprintf(tr, "trace: resolved prefix %q to %q\n", args[0], cont.Name)
+		}
+	}
+	if ok {
+		if exec {
+			if err := p.authorize(ctx, cont); err != nil {
+				if tr != nil {
+					fmt.Fprintf(tr, "trace: %s: %v\n", cont.Name, err)
+				}
+				if _, unknown := err.(*UnknownCommandError); unknown {
+					return nil, err
+				}
 				return cont, err
 			}
 		}
+		p.setLast(cont)
+		if enabled, reason := cont.Enabled(); !enabled {
+			if tr != nil {
+				fmt.Fprintf(tr, "trace: %s: disabled: %s\n", cont.Name, reason)
+			}
+			if !cont.featureEnabled() {
+				return cont, &FeatureDisabledError{Command: cont.CommandPath(), Feature: cont.feature}
+			}
+			return cont, &DisabledCommandError{Command: cont.CommandPath(), Reason: reason}
+		}
+		if cont.sub != nil {
+			if tr != nil {
+				fmt.Fprintf(tr, "trace: matched mounted path %q\n", cont.Name)
+			}
+			mounted = true
+			return cont.sub.run(withCmdCont(ctx, p, cont), args[1:], inherited, globals, exec)
+		}
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: matched command %q\n", cont.Name)
+		}
+		outer, ok := p.enter(cont)
+		if !ok {
+			return cont, &ConcurrentInvocationError{Command: cont.CommandPath()}
+		}
+		defer cont.leave(outer)
+		d, err = p.runLeaf(ctx, cont, args[1:], globals, exec, tr)
+		return cont, err
+	}
+	if tr != nil {
+		fmt.Fprintf(tr, "trace: no such command %q\n", args[0])
+	}
+	if _, ok := p.topics[args[0]]; ok {
+		return nil, &UnknownCommandError{Path: p.Flags.Name(), Name: args[0], Topic: true}
+	}
+	return nil, p.unknownCommand(args[0])
+}
+
+// Returns inherited and globals with the persistent and global flags of
+// p added.
+func (p *Path) globalSets(inherited, globals []*flag.FlagSet) ([]*flag.FlagSet, []*flag.FlagSet) {
+	if p.persistent != nil {
+		inherited = append([]*flag.FlagSet{p.persistent}, inherited...)
+		globals = append([]*flag.FlagSet{p.persistent}, globals...)
+	}
+	return inherited, append([]*flag.FlagSet{p.Flags}, globals...)
+}
+
+// Parses the global flags of p and the inherited persistent flags given
+// at the start of args and applies their other sources. Returns args
+// with the inherited flags moved behind the global ones; the remaining
+// arguments are those of p.Flags.
+func (p *Path) parseGlobals(ctx context.Context, args []string, inherited, globals []*flag.FlagSet) ([]string, error) {
+	args, err := parseInherited(args, p.Flags, inherited)
+	if err != nil {
+		return nil, newFlagParseError(p.Flags.Name(), err)
+	}
+	if err := p.parseFlagSet(p.Flags, args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, newFlagParseError(p.Flags.Name(), suggestFlags(err, globals, nil))
+	}
+	if p.sources, err = resolveFlags(EnvFromContext(ctx), p.Flags, p.env, p.config.global); err != nil {
+		return nil, err
+	}
+	applyLazyDefaults(p.Flags, p.sources)
+	p.applyVerbosity()
+	return args, nil
+}
 
-		// check for required / mandatory flags.
-		missingFlags := make(map[string]bool)
-		for _, flagName := range cont.RequiredFlags {
-			missingFlags[flagName] = true
+// Parses the flags of the command cont in args and runs it if exec is
+// set. Returns how long the command ran.
+func (p *Path) runLeaf(ctx context.Context, cont *CmdCont, args []string, globals []*flag.FlagSet, exec bool, tr io.Writer) (time.Duration, error) {
+	cont.globals = globals
+	// global flags given after the command name are forwarded to
+	// their paths, see parseCommand
+	if err := p.parseCommand(ctx, cont, args, globals, exec); err != nil {
+		err = cont.redactError(err, args)
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+		}
+		return 0, err
+	}
+	if !exec {
+		return 0, nil
+	}
+	if p.ReportsAllErrors() {
+		if err := cont.validate(); err != nil {
+			err = cont.redactError(err, args)
+			if tr != nil {
+				fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+			}
+			return 0, err
+		}
+	}
+	if err := cont.notifyFlagsSet(); err != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+		}
+		return 0, err
+	}
+	if err := cont.checkRequirements(); err != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+		}
+		return 0, err
+	}
+	if err := p.confirmRun(cont); err != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+		}
+		return 0, err
+	}
+	rest, err := cont.readStdinArgs(ctx, args)
+	if err == nil {
+		err = cont.setArgValues(rest)
+	}
+	if err != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+		}
+		return 0, err
+	}
+	if cont.lock != nil && cont.lock.path != nil {
+		unlock, err := cont.acquireLock(ctx)
+		if err != nil {
+			if tr != nil {
+				fmt.Fprintf(tr, "trace: %s: failed: %v\n", cont.Name, err)
+			}
+			return 0, err
+		}
+		defer unlock()
+	}
+	if tr != nil {
+		fmt.Fprintf(tr, "trace: %s: running with args %q\n", cont.Name, rest)
+		if before, tail := splitPassthrough(cont.parsedArgs, cont.FlagSet().Args()); tail != nil {
+			fmt.Fprintf(tr, "trace: %s: args before \"--\" %q, passthrough args %q\n", cont.Name, before, tail)
+		}
+	}
+	stage := stageFromContext(ctx, cont)
+	if stage != nil {
+		stage.running()
+	}
+	start := now()
+	err = p.runWithTimeout(ctx, cont, func(ctx context.Context) error {
+		if cont.retry != nil {
+			return p.runRetrying(ctx, cont, rest, tr)
+		}
+		return p.runCommand(ctx, cont, rest)
+	})
+	d := now().Sub(start)
+	if stage != nil {
+		stage.returned()
+	}
+	if cerr := p.cleanup(ctx, cont); cerr != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: cleanup failed: %v\n", cont.Name, cerr)
+		}
+		if err == nil {
+			err = cerr
+		} else {
+			err = errors.Join(err, cerr)
+		}
+	}
+	if err != nil {
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: returned error: %v\n", cont.Name, err)
+			if e, ok := err.(*PanicError); ok {
+				fmt.Fprintf(tr, "trace: %s: panic: %v\n%s", cont.Name, e.Value, e.Stack())
+			}
+		}
+		if p.wrapErrors {
+			err = &CommandError{Name: cont.CommandPath(), Err: err}
 		}
-		cont.Flags.Visit(func(f *flag.Flag) {
-			delete(missingFlags, f.Name)
-		})
+		return d, err
+	}
+	if tr != nil {
+		fmt.Fprintf(tr, "trace: %s: done\n", cont.Name)
+	}
+	return d, nil
+}
 
-		if len(missingFlags) > 0 {
-			keys := make([]string, 0, len(missingFlags))
-			for k := range missingFlags {
-				keys = append(keys, k)
+// Parses the flags of the command cont in args and checks that they
+// satisfy the requirements of the command. Deprecated flags are only
+// warned about and missing flags only prompted for if exec is set.
+// The flags of the inherited sets, the global and persistent flags of
+// the enclosing paths, are accepted along with those of cont.
+func (p *Path) parseCommand(ctx context.Context, cont *CmdCont, args []string, inherited []*flag.FlagSet, exec bool) error {
+	if !exec {
+		defer discardOutput(cont.FlagSet())()
+		defer continueOnError(cont.FlagSet())()
+	}
+	tr := p.tracer()
+	if tr != nil {
+		fmt.Fprintf(tr, "trace: %s: args %q\n", cont.Name, cont.traceArgs(args))
+	}
+	normalized, marked, numbers := args, args, -1
+	values, invoked := takeFlagValues(ctx)
+	if invoked {
+		// the arguments of RunWithFlags are all positional, the
+		// flags are set below
+		marked, numbers = append([]string{"--"}, args...), len(args)
+	} else {
+		normalized = cont.normalizeArgs(args)
+		marked, numbers = cont.markNumbers(normalized, inherited)
+	}
+	split, err := cont.splitShorts(marked, inherited)
+	if err != nil {
+		return newFlagParseError(cont.Name, err)
+	}
+	if tr != nil {
+		for i, arg := range normalized {
+			if arg != args[i] {
+				fmt.Fprintf(tr, "trace: %s: normalized %s to %s\n", cont.Name, args[i], arg)
 			}
-			return cont, fmt.Errorf("Required flags not set: %q\n", keys)
 		}
-		return cont, cont.Run(cont.Flags.Args()...)
+		if numbers >= 0 && !invoked {
+			fmt.Fprintf(tr, "trace: %s: taking %s as an argument\n", cont.Name, marked[len(marked)-numbers])
+		}
+		if len(split) != len(marked) {
+			fmt.Fprintf(tr, "trace: %s: split short flags into %q\n", cont.Name, cont.traceArgs(split))
+		}
+	}
+	if args, err = parseInherited(split, cont.FlagSet(), inherited); err != nil {
+		return newFlagParseError(cont.Name, err)
+	}
+	if len(args) < len(split) {
+		p.markForwardedFlags()
+	}
+	cont.parsedArgs = unmarkNumbers(args, numbers)
+	if err := cont.parseFlags(p, args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		err = suggestFlags(err, append([]*flag.FlagSet{cont.FlagSet()}, inherited...), cont.hideFlag)
+		return newFlagParseError(cont.Name, err)
+	}
+	if invoked {
+		if err := p.setFlagValues(cont, values, inherited); err != nil {
+			return err
+		}
 	}
-	return nil, ErrNoSuchCmd
+	if exec {
+		cont.warnDeprecated(p)
+	}
+	if cont.sources, err = resolveFlags(EnvFromContext(ctx), cont.FlagSet(), cont.env, p.config.commands[cont.Name]); err != nil {
+		return err
+	}
+	if exec {
+		if err := p.promptMissing(cont); err != nil {
+			return err
+		}
+		if err := p.promptPicked(ctx, cont); err != nil {
+			return err
+		}
+	}
+	applyLazyDefaults(cont.FlagSet(), cont.sources)
+	if err := cont.setDefaultValues(cont.sources); err != nil {
+		return err
+	}
+	if tr != nil {
+		traceFlags(tr, cont.Name+": ", cont.FlagSet(), cont.sources, cont.isSecret)
+		if len(cont.RequiredFlags) > 0 {
+			fmt.Fprintf(tr, "trace: %s: checking required flags %q\n", cont.Name, cont.RequiredFlags)
+		}
+		if len(cont.validators) > 0 {
+			fmt.Fprintf(tr, "trace: %s: running %d validators\n", cont.Name, len(cont.validators))
+		}
+	}
+
+	if p.ReportsAllErrors() {
+		if exec {
+			// checked by runLeaf along with the requirements and
+			// the arguments
+			return nil
+		}
+		return validationError(cont, cont.flagErrors())
+	}
+	// check for required / mandatory flags.
+	if missing := cont.missingFlags(); len(missing) > 0 {
+		return &MissingFlagsError{Command: cont.CommandPath(), Flags: missing}
+	}
+	if empty := cont.emptyRequiredFlags(); len(empty) > 0 {
+		sort.Strings(empty)
+		return &MissingFlagsError{Command: cont.CommandPath(), Flags: empty, Empty: true}
+	}
+	return cont.validateFlags()
+}
+
+// Discards the output of fs, such as parse errors and the help, until
+// the returned function restores it.
+func discardOutput(fs *flag.FlagSet) func() {
+	out := fs.Output()
+	if out == os.Stderr {
+		// keep following os.Stderr if it is replaced
+		out = nil
+	}
+	fs.SetOutput(io.Discard)
+	return func() {
+		fs.SetOutput(out)
+	}
+}
+
+// Returns the sorted names of the required flags not set by any
+// source. Nothing is allocated if all of them are set.
+func (c *CmdCont) missingFlags() []string {
+	var missing []string
+	for _, name := range c.RequiredFlags {
+		if _, ok := c.sources[name]; !ok && !containsString(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the names of all flags of fs set on the command line. Flags
+// related to a set flag, such as the target of an alias, count as set.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if v, ok := f.Value.(interface{ partner() string }); ok {
+			set[v.partner()] = true
+		}
+	})
+	return set
 }
 
+// Prints the registered commands and aliases to standard output,
+// through the pager if they don't fit onto the terminal, see SetPager.
 func (p *Path) PrintAvailableCommands() {
-	fmt.Println("Available commands:")
-	for _, c := range p.entries {
-		fmt.Printf("\t%s\t%s\n", c.Name, c.Desc)
+	p.page(os.Stdout, p.writeAvailableCommands)
+}
+
+func (p *Path) writeAvailableCommands(w io.Writer) {
+	if p.helpHeader != "" {
+		fmt.Fprintf(w, "%s\n\n", p.helpHeader)
+	}
+	p.writeRootUsage(w)
+	fmt.Fprintln(w, "Available commands:")
+	r := p.registry()
+	for _, name := range r.names {
+		if c := r.entries[name]; c.listed() {
+			fmt.Fprintf(w, "\t%s\t%s\n", c.Name, c.Desc)
+		}
+	}
+	for _, name := range p.aliasNames() {
+		fmt.Fprintf(w, "\t%s\talias for %s\n", name, strings.Join(p.aliases[name], " "))
 	}
+	p.writeTopicList(w)
 }
 
 var globalPath = NewPath()
 
+// Returns the path used by the package level functions such as Add and
+// Run.
+func Default() *Path {
+	return globalPath
+}
+
+// Installs p as the path used by the package level functions and
+// returns the previous one, so that a test can restore it:
+//
+//	defer command.SetDefault(command.SetDefault(command.NewPath()))
+//
+// Tests replacing the path must not run in parallel; parallel tests
+// should run their own paths instead.
+func SetDefault(p *Path) *Path {
+	prev := globalPath
+	globalPath = p
+	return prev
+}
+
+// Replaces the path used by the package level functions by an empty
+// one. Only intended for tests, see SetDefault.
+func Reset() {
+	globalPath = NewPath()
+}
+
 func Add(name, description string, command Cmd, requiredFlags ...string) *CmdCont {
-	return globalPath.Add(name, description, command, requiredFlags...)
+	c := globalPath.Add(name, description, command, requiredFlags...)
+	globalPath.recordCaller(c, 0)
+	return c
+}
+
+func AddE(name, description string, command Cmd, requiredFlags ...string) (*CmdCont, error) {
+	c, err := globalPath.AddE(name, description, command, requiredFlags...)
+	if err == nil {
+		globalPath.recordCaller(c, 0)
+	}
+	return c, err
 }
 
 func PrintAvailableCommands() {
@@ -15,9 +15,34 @@
 package command
 
 import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
+// A Cmd for tests that registers flags via flags and runs run.
+type testCmd struct {
+	flags func(fs *flag.FlagSet)
+	run   func(args ...string) error
+}
+
+func (c *testCmd) Flags(fs *flag.FlagSet) {
+	if c.flags != nil {
+		c.flags(fs)
+	}
+}
+
+func (c *testCmd) Run(args ...string) error {
+	if c.run != nil {
+		return c.run(args...)
+	}
+	return nil
+}
+
 func TestMissingCommand(t *testing.T) {
 	t.Skip("Not implemented.")
 }
@@ -31,6 +56,7 @@ func TestInvalidParams(t *testing.T) {
 }
 
 func TestCommandFunc(t *testing.T) {
+	defer SetDefault(SetDefault(NewPath()))
 	var val string
 	Add(
 		"hello",
@@ -44,9 +70,211 @@ func TestCommandFunc(t *testing.T) {
 
 	_, err := Run("hello", "world")
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 	if val != "world" {
 		t.Fatalf("Command should set val to %q but was %q.", "world", val)
 	}
 }
+
+func TestSetDefault(t *testing.T) {
+	p := NewPath()
+	prev := SetDefault(p)
+	if Default() != p {
+		t.Error("SetDefault should install the path.")
+	}
+	Reset()
+	if Default() == p || Default() == prev {
+		t.Error("Reset should install a new path.")
+	}
+	if SetDefault(prev); Default() != prev {
+		t.Error("SetDefault should restore the previous path.")
+	}
+}
+
+func TestDefaultIsolationRegister(t *testing.T) {
+	defer SetDefault(SetDefault(NewPath()))
+	Add("scoped", "only registered for this test", CmdFunc(func(args []string) error { return nil }))
+	if _, err := Run("scoped"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultIsolationLookup(t *testing.T) {
+	if _, err := Run("scoped"); err == nil {
+		t.Errorf("Commands of another test should not be registered but Run returned %v.", err)
+	}
+}
+
+func TestAddE(t *testing.T) {
+	p := NewPath()
+	flags := func(fs *flag.FlagSet) {
+		fs.Bool("force", false, "skip checks")
+	}
+	c, err := p.AddE("deploy", "deploys the app", &testCmd{flags: flags}, "force")
+	if err != nil || c == nil || p.registry().entries["deploy"] != c {
+		t.Fatalf("Registering with a defined required flag should succeed but was %v.", err)
+	}
+	_, err = p.AddE("deploy", "deploys the app", &testCmd{flags: flags}, "froce")
+	if want := `No such flag -froce for command "deploy"`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if p.registry().entries["deploy"] != c {
+		t.Error("A failed registration should keep the registered command.")
+	}
+	if _, err := p.AddE("destroy", "destroys the app", &testCmd{}, "force"); err == nil || p.registry().entries["destroy"] != nil {
+		t.Errorf("A failed registration should not register the command but was %v.", err)
+	}
+}
+
+func TestLazyFlags(t *testing.T) {
+	registered := make(map[string]int)
+	p := NewPath()
+	for _, name := range []string{"deploy", "status"} {
+		name := name
+		p.Add(name, "", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				registered[name]++
+				fs.String("env", "", "target environment")
+			},
+		}, "env")
+	}
+	if len(registered) != 0 {
+		t.Errorf("Add should not register flags but registered %v.", registered)
+	}
+	var missing *MissingFlagsError
+	if _, err := p.Run("deploy"); !errors.As(err, &missing) || missing.Flags[0] != "env" {
+		t.Errorf("Run without -env should fail with a MissingFlagsError but was %v.", err)
+	}
+	if registered["deploy"] != 1 || registered["status"] != 0 {
+		t.Errorf("Run should only register the flags of deploy but registered %v.", registered)
+	}
+	p.ResetFlags()
+	if registered["status"] != 0 {
+		t.Errorf("ResetFlags should not register the flags of status but registered %v.", registered)
+	}
+	var help strings.Builder
+	p.WriteHelp(&help, p.registry().entries["status"])
+	if !strings.Contains(help.String(), "--env string") {
+		t.Errorf("Help should list the flags but was %q.", help.String())
+	}
+	if got := p.Complete("status", "--e"); len(got) != 1 || got[0] != "--env" {
+		t.Errorf("Completions should be %q but were %q.", []string{"--env"}, got)
+	}
+}
+
+func TestLazyFlagsConcurrent(t *testing.T) {
+	var registered int32
+	p := NewPath()
+	c := p.Add("deploy", "", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			atomic.AddInt32(&registered, 1)
+			fs.String("env", "", "target environment")
+		},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.FlagSet().Lookup("env") == nil {
+				t.Error("FlagSet should return the registered flags.")
+			}
+		}()
+	}
+	wg.Wait()
+	if registered != 1 {
+		t.Errorf("Flags should be registered once but were registered %d times.", registered)
+	}
+}
+
+type testContCmd struct {
+	testCmd
+	runCont func(c *CmdCont, args ...string) error
+}
+
+func (c *testContCmd) RunCont(cont *CmdCont, args ...string) error {
+	return c.runCont(cont, args...)
+}
+
+func TestCmdContFunc(t *testing.T) {
+	p := NewPath()
+	var got *CmdCont
+	var env string
+	var set bool
+	var args []string
+	c := p.Add("deploy", "deploys the app", CmdContFunc(func(c *CmdCont, a []string) error {
+		got, env, set, args = c, c.FlagSet().Lookup("env").Value.String(), c.FlagWasSet("env"), a
+		return nil
+	}))
+	c.FlagSet().String("env", "dev", "target environment")
+	if _, err := p.Run("deploy", "-env", "prod", "api"); err != nil {
+		t.Fatal(err)
+	}
+	if got != c || env != "prod" || !set || !reflect.DeepEqual(args, []string{"api"}) {
+		t.Errorf("The command should be run with its container, -env prod and [api] but was %v, %q, %v, %q.", got, env, set, args)
+	}
+}
+
+func TestCmdWithContPreferred(t *testing.T) {
+	p := NewPath()
+	var ran string
+	p.Add("deploy", "deploys the app", &testContCmd{
+		testCmd: testCmd{run: func(args ...string) error { ran = "Run"; return nil }},
+		runCont: func(c *CmdCont, args ...string) error { ran = "RunCont"; return nil },
+	})
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if ran != "RunCont" {
+		t.Errorf("RunCont should be preferred over Run but %s was called.", ran)
+	}
+}
+
+func TestCommandPath(t *testing.T) {
+	origin := NewPath()
+	push := origin.Add("push", "pushes to the remote", &testCmd{run: func(args ...string) error {
+		return errors.New("connection refused")
+	}})
+	remote := NewPath()
+	originCont := remote.Mount("origin", "manages the origin", origin)
+	p := NewPath()
+	remoteCont := p.Mount("remote", "manages remotes", remote)
+	status := p.Add("status", "prints the status", &testCmd{})
+	for _, test := range []struct {
+		c      *CmdCont
+		parent *CmdCont
+		path   string
+	}{
+		{status, nil, "status"},
+		{remoteCont, nil, "remote"},
+		{originCont, remoteCont, "remote origin"},
+		{push, originCont, "remote origin push"},
+	} {
+		if got := test.c.Parent(); got != test.parent {
+			t.Errorf("The parent of %s should be %v but was %v.", test.c.Name, test.parent, got)
+		}
+		if got := test.c.CommandPath(); got != test.path {
+			t.Errorf("The command path of %s should be %q but was %q.", test.c.Name, test.path, got)
+		}
+	}
+	var b strings.Builder
+	origin.WriteHelp(&b, push)
+	if !strings.HasPrefix(b.String(), "Usage: remote origin push [flags]\n") {
+		t.Errorf("The help should name the full path but was %q.", b.String())
+	}
+	origin.SetWrapErrors(true)
+	if _, err := p.Run("remote", "origin", "push"); err == nil || err.Error() != "remote origin push: connection refused" {
+		t.Errorf("The error should name the full path but was %v.", err)
+	}
+
+	mirrors := NewPath()
+	mirrorsCont := p.Mount("mirrors", "manages mirrors", mirrors)
+	mirrors.Mount("origin", "manages the origin", origin)
+	if got := push.CommandPath(); got != "mirrors origin push" {
+		t.Errorf("The command path should follow a re-mount but was %q.", got)
+	}
+	if got := push.Parent().Parent(); got != mirrorsCont {
+		t.Errorf("The grandparent should be the new mount but was %v.", got)
+	}
+}
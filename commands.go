@@ -0,0 +1,78 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Registers the hidden command commands, which lists the commands of p
+// and of the paths mounted to it for scripts and support engineers.
+// It prints the commands one per line, the names of nested commands
+// separated by spaces, such as "remote add", in lexicographical order:
+//
+//	app commands | while read -r cmd; do app $cmd --help; done
+//
+// Mounted paths themselves aren't listed, only their commands. Hidden
+// and disabled commands are left out unless --all is set. With
+// --format json it prints the Spec of the listed commands instead, and
+// with --tree the tree of WriteTree, including hidden commands,
+// aliases and the numbers of flags.
+func (p *Path) AddCommandsCommand() *CmdCont {
+	c := p.Add("commands", "lists the commands", &commandsCmd{p: p})
+	c.Hide()
+	return c
+}
+
+type commandsCmd struct {
+	p      *Path
+	tree   bool
+	all    bool
+	format string
+}
+
+func (c *commandsCmd) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.tree, "tree", false, "print the commands as a tree")
+	fs.BoolVar(&c.all, "all", false, "include hidden and disabled commands")
+	EnumVar(fs, &c.format, "format", "text", []string{"text", "json"}, "output format")
+}
+
+func (c *commandsCmd) Run(args ...string) error {
+	w := c.p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	if c.tree {
+		return c.p.WriteTree(w, TreeOptions{Aliases: true, Hidden: true, FlagCounts: true})
+	}
+	if c.format == "json" {
+		return c.p.spec(c.all).WriteJSON(w)
+	}
+	var names []string
+	return c.p.Walk(func(cont *CmdCont, depth int) error {
+		if !cont.listed() && !c.all {
+			return SkipPath
+		}
+		names = append(names[:depth], cont.Name)
+		if cont.sub != nil {
+			return nil
+		}
+		_, err := fmt.Fprintln(w, strings.Join(names, " "))
+		return err
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func runCommands(t *testing.T, p *Path, args ...string) string {
+	var out strings.Builder
+	p.stdout = &out
+	if _, err := p.Run(append([]string{"commands"}, args...)...); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestCommandsCommand(t *testing.T) {
+	p := newWalkPath()
+	p.AddCommandsCommand()
+	if got, want := runCommands(t, p), "remote add\nremote origin push\nstatus\n"; got != want {
+		t.Errorf("The commands should be %q but were %q.", want, got)
+	}
+	if got, want := runCommands(t, p, "--all"), "commands\ndebug\nremote add\nremote origin push\nstatus\n"; got != want {
+		t.Errorf("The commands with --all should be %q but were %q.", want, got)
+	}
+	if got := runCommands(t, p, "--tree"); !strings.Contains(got, "debug (0 flags) [hidden]\n") || !strings.Contains(got, "    push (0 flags)\n") {
+		t.Errorf("The tree should list all commands but was %q.", got)
+	}
+}
+
+func TestCommandsCommandJSON(t *testing.T) {
+	p := newWalkPath()
+	p.AddCommandsCommand()
+	for _, test := range []struct {
+		args  []string
+		names []string
+	}{
+		{[]string{"--format", "json"}, []string{"remote", "remote add", "remote origin", "remote origin push", "status"}},
+		{[]string{"--format", "json", "--all"}, []string{"commands", "debug", "remote", "remote add", "remote origin", "remote origin push", "status"}},
+	} {
+		s, err := ReadSpecJSON(strings.NewReader(runCommands(t, p, test.args...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, c := range s.Commands {
+			names = append(names, c.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.names, ",") {
+			t.Errorf("The spec of %q should list %q but listed %q.", test.args, test.names, names)
+		}
+	}
+}
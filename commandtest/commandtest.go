@@ -0,0 +1,86 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commandtest provides helpers for testing command line tools
+// built with the command package.
+package commandtest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+)
+
+// The outcome of Execute.
+type Result struct {
+	// everything written to os.Stdout and os.Stderr during the run
+	Stdout string
+	Stderr string
+	// the command matched by Run, nil if none matched
+	Cmd *command.CmdCont
+	Err error
+}
+
+// Runs args on p like p.Run and captures what the commands write to
+// os.Stdout and os.Stderr. If p is nil, the path of the package level
+// functions, see command.Default, is used. The flags of p are reset
+// before the run, so that repeated executions in one test don't see
+// the values of earlier ones. As the standard streams are replaced
+// during the run, tests calling Execute must not run in parallel.
+func Execute(t testing.TB, p *command.Path, args ...string) Result {
+	t.Helper()
+	if p == nil {
+		p = command.Default()
+	}
+	p.ResetFlags()
+	var res Result
+	outW, outDone := capture(t, &res.Stdout)
+	errW, errDone := capture(t, &res.Stderr)
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	func() {
+		defer func() {
+			os.Stdout, os.Stderr = stdout, stderr
+			outDone()
+			errDone()
+		}()
+		res.Cmd, res.Err = p.Run(args...)
+	}()
+	return res
+}
+
+// Returns a pipe whose contents are stored in s once the returned
+// function is called.
+func capture(t testing.TB, s *string) (*os.File, func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	var buf bytes.Buffer
+	go func() {
+		io.Copy(&buf, r)
+		r.Close()
+		close(done)
+	}()
+	return w, func() {
+		w.Close()
+		<-done
+		*s = buf.String()
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/Drachenfels-GmbH/command/commandtest"
+)
+
+type deployCmd struct {
+	env string
+}
+
+func (c *deployCmd) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.env, "env", "staging", "target environment")
+}
+
+func (c *deployCmd) Run(args ...string) error {
+	if c.env == "prod" && len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "refusing to deploy nothing to prod")
+		return errors.New("nothing to deploy")
+	}
+	fmt.Printf("deploying %s to %s\n", strings.Join(args, ", "), c.env)
+	return nil
+}
+
+func newPath() *command.Path {
+	p := command.NewPath()
+	p.Add("deploy", "deploys the app", &deployCmd{})
+	return p
+}
+
+func TestExecuteOutput(t *testing.T) {
+	res := commandtest.Execute(t, newPath(), "deploy", "-env", "prod", "web")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if want := "deploying web to prod\n"; res.Stdout != want {
+		t.Errorf("Stdout should be %q but was %q.", want, res.Stdout)
+	}
+	if res.Cmd == nil || res.Cmd.Name != "deploy" {
+		t.Errorf("The deploy command should be matched but was %v.", res.Cmd)
+	}
+}
+
+func TestExecuteError(t *testing.T) {
+	res := commandtest.Execute(t, newPath(), "deploy", "-env", "prod")
+	if res.Err == nil || res.Err.Error() != "nothing to deploy" {
+		t.Errorf("Execute should fail with %q but was %v.", "nothing to deploy", res.Err)
+	}
+	if want := "refusing to deploy nothing to prod\n"; res.Stderr != want {
+		t.Errorf("Stderr should be %q but was %q.", want, res.Stderr)
+	}
+
+	res = commandtest.Execute(t, newPath(), "destroy")
+	if !errors.Is(res.Err, command.ErrNoSuchCmd) {
+		t.Errorf("Execute should fail with %v but was %v.", command.ErrNoSuchCmd, res.Err)
+	}
+	if res.Cmd != nil {
+		t.Errorf("No command should be matched but was %q.", res.Cmd.Name)
+	}
+}
+
+func TestExecuteRepeated(t *testing.T) {
+	p := newPath()
+	commandtest.Execute(t, p, "deploy", "-env", "prod", "web")
+	res := commandtest.Execute(t, p, "deploy", "web")
+	if want := "deploying web to staging\n"; res.Stdout != want {
+		t.Errorf("Stdout should be %q but was %q.", want, res.Stdout)
+	}
+}
+
+func TestExecuteDefault(t *testing.T) {
+	defer command.SetDefault(command.SetDefault(command.NewPath()))
+	command.Add("greet", "greets the user", command.CmdFunc(func(args []string) error {
+		fmt.Println("hello", args[0])
+		return nil
+	}))
+	res := commandtest.Execute(t, nil, "greet", "world")
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if want := "hello world\n"; res.Stdout != want {
+		t.Errorf("Stdout should be %q but was %q.", want, res.Stdout)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Records the failures reported to it.
+type failTB struct {
+	testing.TB
+	failures []string
+}
+
+func (t *failTB) Helper() {}
+
+func (t *failTB) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *failTB) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+}
+
+func writeString(s string) func(w io.Writer) {
+	return func(w io.Writer) {
+		io.WriteString(w, s)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "help.golden")
+	if err := os.WriteFile(name, []byte("Usage: deploy\r\n  -env string\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var tb failTB
+	Golden(&tb, name, writeString("Usage: deploy\n  -env string\n"))
+	if len(tb.failures) > 0 {
+		t.Errorf("Output should match the golden file but failed with %q.", tb.failures)
+	}
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "help.golden")
+	want := "Usage: deploy [flags]\n\n1\n2\n3\n4\n5\n6\n7\n8\n9\n  -env string\n"
+	if err := os.WriteFile(name, []byte(want), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var tb failTB
+	Golden(&tb, name, writeString("Usage: deploy [flags] [args]\n\n1\n2\n3\n4\n5\n6\n7\n8\n9\n  -env value"))
+	diff := `--- ` + name + `
++++ output
+@@ -1,4 +1,4 @@
+-Usage: deploy [flags]
++Usage: deploy [flags] [args]
+ 
+ 1
+ 2
+@@ -9,4 +9,4 @@
+ 7
+ 8
+ 9
+-  -env string
++  -env value
+\ No newline at end of file
+`
+	if len(tb.failures) != 1 || !strings.HasSuffix(tb.failures[0], "\n"+diff) {
+		t.Errorf("Golden should fail with the diff %q but was %q.", diff, tb.failures)
+	}
+}
+
+func TestGoldenMissing(t *testing.T) {
+	var tb failTB
+	Golden(&tb, filepath.Join(t.TempDir(), "missing.golden"), writeString(""))
+	if len(tb.failures) != 1 || !strings.Contains(tb.failures[0], "-update") {
+		t.Errorf("Golden should suggest -update but was %q.", tb.failures)
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	defer func(u bool) { *update = u }(*update)
+	*update = true
+	name := filepath.Join(t.TempDir(), "testdata", "help.golden")
+	Golden(t, name, writeString("Usage: deploy\r\n"))
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "Usage: deploy\n" {
+		t.Errorf("The golden file should be %q but was %q.", "Usage: deploy\n", b)
+	}
+}
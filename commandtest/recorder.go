@@ -0,0 +1,182 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A command.Cmd recording its invocations, for tests of the wiring of
+// commands. The zero value records all calls and returns nil.
+type Recorder struct {
+	// Register the probe flags -probe and -probe-bool, whose values
+	// are recorded with each call.
+	Probe bool
+	// Registers additional flags, if not nil.
+	FlagsFunc func(fs *flag.FlagSet)
+
+	mu        sync.Mutex
+	calls     []Call
+	err       error
+	results   map[int]result
+	probe     string
+	probeBool bool
+}
+
+// A recorded invocation of a Recorder.
+type Call struct {
+	Args []string
+	Time time.Time
+	// the error returned by the call
+	Err error
+	// the values of the probe flags
+	Probe     string
+	ProbeBool bool
+}
+
+// The outcome of a programmed call.
+type result struct {
+	err   error
+	panic interface{}
+}
+
+// Records the arguments and returns the programmed error or panics
+// with the programmed value.
+func (r *Recorder) Run(args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.calls)
+	res, ok := r.results[n]
+	if !ok {
+		res.err = r.err
+	}
+	r.calls = append(r.calls, Call{
+		Args:      append([]string{}, args...),
+		Time:      time.Now(),
+		Err:       res.err,
+		Probe:     r.probe,
+		ProbeBool: r.probeBool,
+	})
+	if res.panic != nil {
+		panic(res.panic)
+	}
+	return res.err
+}
+
+func (r *Recorder) Flags(fs *flag.FlagSet) {
+	if r.Probe {
+		fs.StringVar(&r.probe, "probe", "", "string flag recorded by the recorder")
+		fs.BoolVar(&r.probeBool, "probe-bool", false, "bool flag recorded by the recorder")
+	}
+	if r.FlagsFunc != nil {
+		r.FlagsFunc(fs)
+	}
+}
+
+// Makes all calls without a programmed outcome return err.
+func (r *Recorder) Return(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Makes the n-th call, starting at 1, return err.
+func (r *Recorder) ReturnOnCall(n int, err error) {
+	r.program(n, result{err: err})
+}
+
+// Makes the n-th call, starting at 1, panic with v.
+func (r *Recorder) PanicOnCall(n int, v interface{}) {
+	r.program(n, result{panic: v})
+}
+
+func (r *Recorder) program(n int, res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = make(map[int]result)
+	}
+	r.results[n-1] = res
+}
+
+// Returns the arguments of all calls in order.
+func (r *Recorder) Calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([][]string, len(r.calls))
+	for i, c := range r.calls {
+		calls[i] = c.Args
+	}
+	return calls
+}
+
+// Returns all recorded calls in order.
+func (r *Recorder) Invocations() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Forgets the recorded calls and the programmed outcomes.
+func (r *Recorder) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls, r.err, r.results = nil, nil, nil
+}
+
+// Fails t unless the last call received args.
+func (r *Recorder) AssertCalledWith(t testing.TB, args ...string) {
+	t.Helper()
+	calls := r.Calls()
+	if len(calls) == 0 {
+		t.Errorf("Command should be called with %q but was not called.", args)
+		return
+	}
+	if last := calls[len(calls)-1]; !equalArgs(last, args) {
+		t.Errorf("Command should be called with %q but was called with %q.", args, last)
+	}
+}
+
+// Fails t unless the recorder was called exactly n times.
+func (r *Recorder) AssertCalls(t testing.TB, n int) {
+	t.Helper()
+	if calls := r.Calls(); len(calls) != n {
+		t.Errorf("Command should be called %s but was called %s.", times(n), times(len(calls)))
+	}
+}
+
+// Fails t if the recorder was called.
+func (r *Recorder) AssertNotCalled(t testing.TB) {
+	t.Helper()
+	if calls := r.Calls(); len(calls) > 0 {
+		t.Errorf("Command should not be called but was called with %q.", calls)
+	}
+}
+
+func equalArgs(a, b []string) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
+
+func times(n int) string {
+	if n == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", n)
+}
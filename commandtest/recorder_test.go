@@ -0,0 +1,143 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/Drachenfels-GmbH/command/commandtest"
+)
+
+func TestRecorder(t *testing.T) {
+	r := &commandtest.Recorder{}
+	errDenied := errors.New("denied")
+	r.ReturnOnCall(2, errDenied)
+	r.PanicOnCall(3, "boom")
+	p := command.NewPath()
+	p.Add("deploy", "deploys the app", r)
+
+	if _, err := p.Run("deploy", "web"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy", "db"); err != errDenied {
+		t.Errorf("The second call should fail with %v but was %v.", errDenied, err)
+	}
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("The third call should panic with %q but was %v.", "boom", v)
+			}
+		}()
+		p.Run("deploy")
+	}()
+
+	r.AssertCalls(t, 3)
+	r.AssertCalledWith(t)
+	if calls := r.Calls(); fmt.Sprint(calls) != "[[web] [db] []]" {
+		t.Errorf("Calls should be %q but was %q.", "[[web] [db] []]", calls)
+	}
+	inv := r.Invocations()
+	if inv[1].Err != errDenied || inv[0].Time.IsZero() || inv[1].Time.Before(inv[0].Time) {
+		t.Errorf("Invocations should record the errors and times but was %+v.", inv)
+	}
+
+	r.Clear()
+	r.Return(errDenied)
+	if _, err := p.Run("deploy"); err != errDenied {
+		t.Errorf("Return should apply to all calls but Run returned %v.", err)
+	}
+}
+
+func TestRecorderProbe(t *testing.T) {
+	r := &commandtest.Recorder{Probe: true}
+	p := command.NewPath()
+	p.Add("deploy", "deploys the app", r, "probe")
+	if _, err := p.Run("deploy", "-probe", "prod", "-probe-bool", "web"); err != nil {
+		t.Fatal(err)
+	}
+	r.AssertCalledWith(t, "web")
+	if c := r.Invocations()[0]; c.Probe != "prod" || !c.ProbeBool {
+		t.Errorf("The probe flags should be recorded but were %q, %v.", c.Probe, c.ProbeBool)
+	}
+	p.ResetFlags()
+	if _, err := p.Run("deploy", "web"); err == nil {
+		t.Error("The required probe flag should be missing.")
+	}
+	r.AssertCalls(t, 1)
+}
+
+// Records the failures reported by the assertions.
+type fakeTB struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorderAssertions(t *testing.T) {
+	r := &commandtest.Recorder{}
+	tests := []struct {
+		assert func(t testing.TB)
+		want   string
+	}{
+		{func(t testing.TB) { r.AssertCalledWith(t, "web") }, `Command should be called with ["web"] but was not called.`},
+		{func(t testing.TB) { r.AssertCalls(t, 1) }, `Command should be called once but was called 0 times.`},
+	}
+	for _, test := range tests {
+		var tb fakeTB
+		test.assert(&tb)
+		if len(tb.failures) != 1 || tb.failures[0] != test.want {
+			t.Errorf("Assertion should fail with %q but was %q.", test.want, tb.failures)
+		}
+	}
+
+	r.Run("db")
+	tests = []struct {
+		assert func(t testing.TB)
+		want   string
+	}{
+		{func(t testing.TB) { r.AssertCalledWith(t, "web") }, `Command should be called with ["web"] but was called with ["db"].`},
+		{func(t testing.TB) { r.AssertNotCalled(t) }, `Command should not be called but was called with [["db"]].`},
+	}
+	for _, test := range tests {
+		var tb fakeTB
+		test.assert(&tb)
+		if len(tb.failures) != 1 || tb.failures[0] != test.want {
+			t.Errorf("Assertion should fail with %q but was %q.", test.want, tb.failures)
+		}
+	}
+}
+
+func ExampleRecorder() {
+	deploy := &commandtest.Recorder{}
+	p := command.NewPath()
+	p.Add("deploy", "deploys the app", deploy)
+	p.Run("deploy", "web", "db")
+	p.Run("deploy", "--", "-web")
+	for _, args := range deploy.Calls() {
+		fmt.Println(strings.Join(args, " "))
+	}
+	// Output:
+	// web db
+	// -web
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+)
+
+// Runs the commands of script on p and checks their results. Each line
+// of the script is one of
+//
+//	app deploy -env prod   run the arguments after the program name
+//	! app deploy           same, but the command must fail
+//	exit 2                 the exit code of the last command, see ExitCode
+//	stdout: text           the output of the last command contains text
+//	stderr: /regexp/       the error output matches the regular expression
+//	! stdout: text         the output doesn't contain text
+//	env NAME=value         set an environment variable for the test
+//
+// Arguments are separated by spaces and may be quoted with single or
+// double quotes. A stdout: or stderr: line without a pattern starts a
+// block of indented lines, which must appear in the output verbatim.
+// Empty lines and lines starting with # are ignored. Each command is
+// run by Execute, so the flags are reset in between. Failures are
+// reported with the line number in the script.
+func RunScript(t testing.TB, p *command.Path, script string) {
+	t.Helper()
+	if p == nil {
+		p = command.Default()
+	}
+	lines := strings.Split(normalizeNewlines(script), "\n")
+	var last *Result
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		if negate {
+			line = strings.TrimSpace(line[1:])
+		}
+		word, rest := line, ""
+		if j := strings.IndexAny(line, " \t"); j >= 0 {
+			word, rest = line[:j], strings.TrimSpace(line[j+1:])
+		}
+		switch word {
+		case "env":
+			name, value, ok := strings.Cut(rest, "=")
+			if negate || !ok || name == "" {
+				t.Fatalf("script:%d: env expects NAME=value", num)
+			}
+			t.Setenv(name, value)
+		case "exit":
+			code, err := strconv.Atoi(rest)
+			if negate || err != nil {
+				t.Fatalf("script:%d: exit expects a number", num)
+			}
+			if last == nil {
+				t.Fatalf("script:%d: exit before any command", num)
+			}
+			if got := p.ExitCode(last.Err); got != code {
+				t.Errorf("script:%d: Exit code should be %d but was %d.", num, code, got)
+			}
+		case "stdout:", "stderr:":
+			if last == nil {
+				t.Fatalf("script:%d: %s before any command", num, word)
+			}
+			if rest == "" {
+				var block []string
+				block, i = scriptBlock(lines, i+1)
+				rest = strings.Join(block, "")
+			}
+			out := last.Stdout
+			if word == "stderr:" {
+				out = last.Stderr
+			}
+			if err := matchOutput(strings.TrimSuffix(word, ":"), rest, out, negate); err != nil {
+				t.Errorf("script:%d: %v", num, err)
+			}
+		default:
+			args, err := command.SplitArgs(line)
+			if err != nil {
+				t.Fatalf("script:%d: %v", num, err)
+			}
+			res := Execute(t, p, args[1:]...)
+			last = &res
+			if negate && res.Err == nil {
+				t.Errorf("script:%d: %s should fail but succeeded.", num, line)
+			} else if !negate && res.Err != nil {
+				t.Errorf("script:%d: %s failed: %v", num, line, res.Err)
+			}
+		}
+	}
+}
+
+// Returns the indented lines starting at lines[i], without their
+// common indentation and each terminated by a newline, and the index
+// of the last one.
+func scriptBlock(lines []string, i int) ([]string, int) {
+	var block []string
+	indent := ""
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == l || trimmed == "" {
+			break
+		}
+		if indent == "" {
+			indent = l[:len(l)-len(trimmed)]
+		}
+		block = append(block, strings.TrimPrefix(l, indent)+"\n")
+	}
+	return block, i - 1
+}
+
+// Checks whether out contains pattern, or matches it if it is a
+// regular expression enclosed in slashes.
+func matchOutput(name, pattern, out string, negate bool) error {
+	var match bool
+	verb := "contain"
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return err
+		}
+		match = re.MatchString(out)
+		verb = "match"
+	} else {
+		match = strings.Contains(out, pattern)
+	}
+	switch {
+	case negate && match:
+		return fmt.Errorf("The %s should not %s %q but was %q.", name, verb, pattern, out)
+	case !negate && !match:
+		return fmt.Errorf("The %s should %s %q but was %q.", name, verb, pattern, out)
+	}
+	return nil
+}
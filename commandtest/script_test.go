@@ -0,0 +1,62 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtest_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/Drachenfels-GmbH/command/commandtest"
+)
+
+func TestRunScript(t *testing.T) {
+	script, err := os.ReadFile("testdata/deploy.script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := command.NewPath()
+	c := p.Add("deploy", "deploys the app", &deployCmd{})
+	if err := c.BindEnv("env", "APP_ENV"); err != nil {
+		t.Fatal(err)
+	}
+	commandtest.RunScript(t, p, string(script))
+}
+
+func TestRunScriptFailures(t *testing.T) {
+	script := `
+app deploy -env prod
+! app deploy web
+stdout: to staging
+stdout: /^deploying db/
+! stdout:
+  deploying web to staging
+exit 2
+`
+	var tb fakeTB
+	commandtest.RunScript(&tb, newPath(), script)
+	want := []string{
+		`script:2: app deploy -env prod failed: nothing to deploy`,
+		`script:3: app deploy web should fail but succeeded.`,
+		`script:5: The stdout should match "/^deploying db/" but was "deploying web to staging\n".`,
+		`script:6: The stdout should not contain "deploying web to staging\n" but was "deploying web to staging\n".`,
+		`script:8: Exit code should be 2 but was 0.`,
+	}
+	if !reflect.DeepEqual(tb.failures, want) {
+		t.Errorf("RunScript should fail with\n%s\nbut was\n%s", strings.Join(want, "\n"), strings.Join(tb.failures, "\n"))
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Declares the values offered when completing the named flag, such as
+// the regions of a --region flag. Other values are still accepted.
+func (c *CmdCont) FlagChoices(name string, values ...string) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.choices == nil {
+		c.choices = make(map[string][]string)
+	}
+	c.choices[name] = values
+	return nil
+}
+
+// Same as FlagChoices but also rejects values that aren't one of
+// values once the flag is set.
+func (c *CmdCont) FlagChoicesStrict(name string, values ...string) error {
+	if err := c.FlagChoices(name, values...); err != nil {
+		return err
+	}
+	return c.ValidateFlagIfSet(name, OneOf(values...))
+}
+
+// Returns the completion candidates for the last of args, which are
+// the command line arguments typed so far without the program name.
+// The last argument is the possibly empty word being completed. Shell
+// completion scripts call it to complete command names, flag names and
+// flag values. Hidden and deprecated flags are never offered, neither
+// are the values of flags marked with FlagFilename or FlagDirname, which
+// the shell completes itself.
+func (p *Path) Complete(args ...string) []string {
+	candidates, _ := p.completion(args)
+	return candidates
+}
+
+// Same as Complete but also returns the directive for the shell if it
+// should complete a path instead, see pathCompletion.
+func (p *Path) completion(args []string) ([]string, string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	word := args[len(args)-1]
+	prev := args[:len(args)-1]
+
+	// skip the global flags preceding the command name
+	i := skipFlags(p.Flags, prev)
+	if i >= len(prev) {
+		if len(prev) > 0 {
+			if f := flagWithValue(p.Flags, prev[len(prev)-1]); f != nil {
+				return matchPrefix(valueCandidates(f), word), ""
+			}
+		}
+		if strings.HasPrefix(word, "-") {
+			return matchPrefix(flagCandidates(p.Flags, p.hideGlobalFlag), word), ""
+		}
+		var names []string
+		r := p.registry()
+		for _, name := range p.namesWithPrefix(word) {
+			if r.entries[name].listed() {
+				names = append(names, name)
+			}
+		}
+		if len(p.aliases) == 0 {
+			return names, ""
+		}
+		for name := range p.aliases {
+			names = append(names, name)
+		}
+		return matchPrefix(names, word), ""
+	}
+	prev = append(prev[:i:i], p.expandAlias(prev[i:])...)
+	c, ok := p.registry().entries[prev[i]]
+	if !ok {
+		return nil, ""
+	}
+	if enabled, _ := c.Enabled(); !enabled {
+		return nil, ""
+	}
+	if c.sub != nil {
+		return c.sub.completion(append(prev[i+1:], word))
+	}
+	return c.complete(prev[i+1:], word)
+}
+
+// Completes word following the arguments prev of the command.
+func (c *CmdCont) complete(prev []string, word string) ([]string, string) {
+	for _, arg := range prev {
+		if arg == "--" {
+			return nil, ""
+		}
+	}
+	if len(prev) > 0 {
+		if f := flagWithValue(c.FlagSet(), prev[len(prev)-1]); f != nil {
+			if pc, ok := c.pathFlags[canonicalFlag(c.FlagSet(), f).Name]; ok {
+				return nil, pc.directive()
+			}
+			return matchPrefix(c.valueCandidates(f), word), ""
+		}
+	}
+	if !strings.HasPrefix(word, "-") {
+		return nil, ""
+	}
+	if i := strings.IndexByte(word, '='); i > 0 {
+		f := c.FlagSet().Lookup(strings.TrimLeft(word[:i], "-"))
+		if f == nil || c.hideFlag(f) {
+			return nil, ""
+		}
+		values := c.valueCandidates(f)
+		for j, v := range values {
+			values[j] = word[:i+1] + v
+		}
+		return matchPrefix(values, word), ""
+	}
+	return matchPrefix(flagCandidates(c.FlagSet(), c.hideFlag), word), ""
+}
+
+// Returns the index of the first argument that isn't a flag of fs or
+// the value of one.
+func skipFlags(fs *flag.FlagSet, args []string) int {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "-" {
+		if args[i] == "--" {
+			return i + 1
+		}
+		if flagWithValue(fs, args[i]) != nil {
+			i++
+		}
+		i++
+	}
+	if i > len(args) {
+		return len(args)
+	}
+	return i
+}
+
+// Returns the flag named by arg if it expects its value in the
+// following argument.
+func flagWithValue(fs *flag.FlagSet, arg string) *flag.Flag {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+		return nil
+	}
+	f := fs.Lookup(strings.TrimLeft(arg, "-"))
+	if f == nil {
+		return nil
+	}
+	if isBoolFlag(f) {
+		return nil
+	}
+	return f
+}
+
+// Returns the names of all flags of fs, formatted as on the command
+// line, except for those hide returns true for.
+func flagCandidates(fs *flag.FlagSet, hide func(*flag.Flag) bool) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if hide == nil || !hide(f) {
+			names = append(names, flagName(f.Name))
+		}
+	})
+	return names
+}
+
+// Same as valueCandidates but prefers the values declared with
+// FlagChoices.
+func (c *CmdCont) valueCandidates(f *flag.Flag) []string {
+	name := f.Name
+	if a, ok := f.Value.(*aliasValue); ok {
+		name = a.target.Name
+	}
+	if values, ok := c.choices[name]; ok {
+		return append([]string(nil), values...)
+	}
+	return valueCandidates(f)
+}
+
+// Returns the values offered for a flag, such as the choices of an
+// enum flag.
+func valueCandidates(f *flag.Flag) []string {
+	v := f.Value
+	if a, ok := v.(*aliasValue); ok {
+		v = a.target.Value
+	}
+	return append([]string(nil), valueCompletions(v)...)
+}
+
+// Returns the values offered by v if it implements completions.
+func valueCompletions(v flag.Value) []string {
+	if c, ok := v.(interface{ completions() []string }); ok {
+		return c.completions()
+	}
+	return nil
+}
+
+// Returns the sorted candidates starting with prefix.
+func matchPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Marks the named flag as taking a file name, which the completion
+// scripts let the shell complete with its own file completion. If
+// extensions are given, such as "yaml" and "yml", only files with one
+// of them and directories are offered.
+func (c *CmdCont) FlagFilename(name string, extensions ...string) error {
+	exts := make([]string, len(extensions))
+	for i, ext := range extensions {
+		exts[i] = strings.TrimPrefix(ext, ".")
+	}
+	return c.setPathFlag(name, pathCompletion{extensions: exts})
+}
+
+// Same as FlagFilename but for a flag taking a directory, only
+// directories are offered.
+func (c *CmdCont) FlagDirname(name string) error {
+	return c.setPathFlag(name, pathCompletion{dirs: true})
+}
+
+func (c *CmdCont) setPathFlag(name string, pc pathCompletion) error {
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if isBoolFlag(f) {
+		return fmt.Errorf("Flag -%s of command %q takes no value", name, c.Name)
+	}
+	if c.pathFlags == nil {
+		c.pathFlags = make(map[string]pathCompletion)
+	}
+	c.pathFlags[canonicalFlag(c.FlagSet(), f).Name] = pc
+	return nil
+}
+
+// How the shell completes the value of a flag marked with FlagFilename
+// or FlagDirname. The completion command prints the directive as its
+// only line instead of candidates: ":dirs" for directories, ":files"
+// for all files and ":files yaml yml" for files with one of the
+// extensions. The completion scripts pass it on to the file completion
+// of the shell.
+type pathCompletion struct {
+	dirs       bool
+	extensions []string
+}
+
+func (pc pathCompletion) directive() string {
+	if pc.dirs {
+		return ":dirs"
+	}
+	return strings.Join(append([]string{":files"}, pc.extensions...), " ")
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompletePathFlags(t *testing.T) {
+	p := newCompletionPath()
+	c := p.Lookup("deploy")
+	c.Flags.String("config", "", "config file")
+	c.Flags.String("output", "", "output directory")
+	c.Flags.String("region", "", "region")
+	c.FlagAlias("config", "c")
+	if err := c.FlagFilename("config", ".yaml", "yml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FlagDirname("output"); err != nil {
+		t.Fatal(err)
+	}
+	c.FlagChoices("region", "eu-west-1")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"deploy", "--config", ""}, ":files yaml yml\n"},
+		{[]string{"deploy", "-c", "conf"}, ":files yaml yml\n"},
+		{[]string{"deploy", "--output", "bu"}, ":dirs\n"},
+		{[]string{"deploy", "--region", ""}, "eu-west-1\n"},
+		{[]string{"deploy", "--config=", ""}, ""},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		p.stdout = &buf
+		if _, err := p.Run(append([]string{"__complete"}, test.args...)...); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("__complete %q should print %q but printed %q.", test.args, test.want, buf.String())
+		}
+	}
+	if got := p.Complete("deploy", "--config", ""); got != nil {
+		t.Errorf("Complete should offer no values for a file flag but offered %q.", got)
+	}
+	c.FlagFilename("region")
+	if got := p.Complete("deploy", "--region", ""); got != nil {
+		t.Errorf("FlagFilename should replace the choices but Complete offered %q.", got)
+	}
+}
+
+func TestCompletePathFlagsMounted(t *testing.T) {
+	remote := NewPath()
+	c := remote.Add("add", "adds a remote", &testCmd{})
+	c.Flags.String("key", "", "key file")
+	c.FlagFilename("key")
+	p := NewPath()
+	p.Mount("remote", "manages remotes", remote)
+	var buf bytes.Buffer
+	p.stdout = &buf
+	if _, err := p.Run("__complete", "remote", "add", "-key", ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := ":files\n"; buf.String() != want {
+		t.Errorf("__complete should print %q but printed %q.", want, buf.String())
+	}
+}
+
+func TestPathFlagErrors(t *testing.T) {
+	p := newCompletionPath()
+	c := p.Lookup("deploy")
+	if err := c.FlagFilename("nope"); err == nil || err.Error() != `No such flag -nope for command "deploy"` {
+		t.Errorf("FlagFilename should fail for an unknown flag but returned %v.", err)
+	}
+	if err := c.FlagDirname("nope"); err == nil {
+		t.Error("FlagDirname should fail for an unknown flag.")
+	}
+	if err := c.FlagFilename("force"); err == nil {
+		t.Error("FlagFilename should fail for a bool flag.")
+	}
+}
+
+func TestCompletionScriptsPathFlags(t *testing.T) {
+	p := newCompletionPath()
+	for name, test := range map[string]struct {
+		write func(io.Writer, string) error
+		want  []string
+	}{
+		"bash":       {p.WriteBashCompletion, []string{`:dirs)`, `compgen -d -- "$cur"`, `compgen -f -X "!*.$ext" -- "$cur"`, `compopt -o filenames`}},
+		"zsh":        {p.WriteZshCompletion, []string{`:dirs) _files -/ ;;`, `_files -g "*.(${(j:|:)${=candidates[1]#:files }})"`}},
+		"fish":       {p.WriteFishCompletion, []string{`__fish_complete_directories`, `__fish_complete_path`, `__fish_complete_suffix .$ext`}},
+		"powershell": {p.WritePowerShellCompletion, []string{`'^:(dirs|files( .*)?)$'`, `Get-ChildItem -Path "$wordToComplete*"`, `'ProviderContainer'`}},
+	} {
+		var buf bytes.Buffer
+		if err := test.write(&buf, "my-app"); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s script should contain %q:\n%s", name, want, buf.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A shell supported by AddCompletionCommand.
+type completionShell struct {
+	name  string
+	write func(p *Path, w io.Writer, prog string) error
+	// returns the per-user file the script is installed to and a hint
+	// for enabling it, if needed
+	install func(home, prog string) (string, string)
+}
+
+var completionShells = []completionShell{
+	{"bash", (*Path).WriteBashCompletion, func(home, prog string) (string, string) {
+		return filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local", "share"), "bash-completion", "completions", prog), ""
+	}},
+	{"zsh", (*Path).WriteZshCompletion, func(home, prog string) (string, string) {
+		dir := filepath.Join(home, ".zfunc")
+		if zdotdir := os.Getenv("ZDOTDIR"); filepath.IsAbs(zdotdir) {
+			dir = filepath.Join(zdotdir, ".zfunc")
+		}
+		return filepath.Join(dir, "_"+prog), fmt.Sprintf("Add fpath+=(%s) before compinit to your .zshrc if it isn't already there.", dir)
+	}},
+	{"fish", (*Path).WriteFishCompletion, func(home, prog string) (string, string) {
+		return filepath.Join(xdgDir("XDG_CONFIG_HOME", home, ".config"), "fish", "completions", prog+".fish"), ""
+	}},
+	{"powershell", (*Path).WritePowerShellCompletion, nil},
+}
+
+// Returns the directory in the environment variable name if it is an
+// absolute path, as the XDG Base Directory Specification requires, or
+// the default below home otherwise.
+func xdgDir(name, home string, def ...string) string {
+	if dir := os.Getenv(name); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(append([]string{home}, def...)...)
+}
+
+// Registers the command completion with the sub-commands bash, zsh,
+// fish and powershell printing the completion script for the program
+// prog. With --install the script is written to the per-user
+// completion directory of the shell instead, such as
+// ~/.local/share/bash-completion/completions/prog; an existing file is
+// only replaced with --force. PowerShell has no such directory, its
+// script is loaded from the profile.
+func (p *Path) AddCompletionCommand(prog string) *CmdCont {
+	sub := NewPath()
+	for _, sh := range completionShells {
+		sub.Add(sh.name, "prints the "+sh.name+" completion script", &completionCmd{p: p, shell: sh, prog: prog})
+	}
+	return p.Mount("completion", "prints or installs shell completion scripts", sub)
+}
+
+type completionCmd struct {
+	p       *Path
+	shell   completionShell
+	prog    string
+	install bool
+	force   bool
+}
+
+func (c *completionCmd) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.install, "install", false, "install the script for the current user")
+	fs.BoolVar(&c.force, "force", false, "replace an installed script")
+}
+
+func (c *completionCmd) Run(args ...string) error {
+	w := c.p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	var script bytes.Buffer
+	if err := c.shell.write(c.p, &script, c.prog); err != nil {
+		return err
+	}
+	if !c.install {
+		_, err := w.Write(script.Bytes())
+		return err
+	}
+	if c.shell.install == nil {
+		return fmt.Errorf("Installing the %s completion isn't supported; add '%s completion %s | Out-String | Invoke-Expression' to your profile", c.shell.name, c.prog, c.shell.name)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	name, hint := c.shell.install(home, c.prog)
+	if _, err := os.Stat(name); err == nil && !c.force {
+		return fmt.Errorf("%s already exists; use --force to replace it", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, script.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Installed the %s completion for %s to %s\n", c.shell.name, c.prog, name)
+	if hint != "" {
+		fmt.Fprintln(w, hint)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newInstallPath(t *testing.T) (*Path, *strings.Builder, string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, name := range []string{"XDG_DATA_HOME", "XDG_CONFIG_HOME", "ZDOTDIR"} {
+		t.Setenv(name, "")
+	}
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{})
+	p.AddCompletionCommand("app")
+	var out strings.Builder
+	p.stdout = &out
+	return p, &out, home
+}
+
+func TestCompletionCommand(t *testing.T) {
+	p, out, _ := newInstallPath(t)
+	if _, err := p.Run("completion", "bash"); err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	p.WriteBashCompletion(&want, "app")
+	if out.String() != want.String() {
+		t.Errorf("Output should be %q but was %q.", want.String(), out.String())
+	}
+	out.Reset()
+	if _, err := p.Run("completion", "powershell"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Register-ArgumentCompleter -Native -CommandName 'app'") {
+		t.Errorf("Output should be the PowerShell script but was %q.", out.String())
+	}
+}
+
+func TestCompletionInstall(t *testing.T) {
+	for _, test := range []struct {
+		shell, file, hint string
+	}{
+		{"bash", ".local/share/bash-completion/completions/app", ""},
+		{"zsh", ".zfunc/_app", "Add fpath+=("},
+		{"fish", ".config/fish/completions/app.fish", ""},
+	} {
+		p, out, home := newInstallPath(t)
+		name := filepath.Join(home, filepath.FromSlash(test.file))
+		if _, err := p.Run("completion", test.shell, "--install"); err != nil {
+			t.Fatal(err)
+		}
+		p.ResetFlags()
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("The %s completion should be installed to %s: %v", test.shell, name, err)
+		}
+		if !strings.Contains(string(b), "# "+test.shell+" completion for app\n") {
+			t.Errorf("Installed %s script should be the completion script but was %q.", test.shell, b)
+		}
+		if want := "Installed the " + test.shell + " completion for app to " + name + "\n" + test.hint; !strings.HasPrefix(out.String(), want) {
+			t.Errorf("Output should start with %q but was %q.", want, out.String())
+		}
+
+		if _, err := p.Run("completion", test.shell, "--install"); err == nil || !strings.Contains(err.Error(), "use --force") {
+			t.Errorf("Installing the %s completion again should fail but error was %v.", test.shell, err)
+		}
+		p.ResetFlags()
+		os.WriteFile(name, []byte("old"), 0o644)
+		if _, err := p.Run("completion", test.shell, "--install", "--force"); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(name); string(b) == "old" {
+			t.Errorf("The %s completion should be replaced with --force.", test.shell)
+		}
+	}
+}
+
+func TestCompletionInstallXDG(t *testing.T) {
+	p, _, home := newInstallPath(t)
+	data := filepath.Join(home, "data")
+	t.Setenv("XDG_DATA_HOME", data)
+	if _, err := p.Run("completion", "bash", "--install"); err != nil {
+		t.Fatal(err)
+	}
+	p.ResetFlags()
+	if _, err := os.Stat(filepath.Join(data, "bash-completion", "completions", "app")); err != nil {
+		t.Errorf("The bash completion should be installed below $XDG_DATA_HOME: %v", err)
+	}
+
+	// relative paths must be ignored
+	t.Setenv("XDG_CONFIG_HOME", "config")
+	if _, err := p.Run("completion", "fish", "--install"); err != nil {
+		t.Fatal(err)
+	}
+	p.ResetFlags()
+	if _, err := os.Stat(filepath.Join(home, ".config", "fish", "completions", "app.fish")); err != nil {
+		t.Errorf("The fish completion should be installed below ~/.config: %v", err)
+	}
+
+	if _, err := p.Run("completion", "powershell", "--install"); err == nil || !strings.Contains(err.Error(), "Invoke-Expression") {
+		t.Errorf("Installing the PowerShell completion should fail but error was %v.", err)
+	}
+}
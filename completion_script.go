@@ -0,0 +1,177 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The name of the hidden command the completion scripts run to obtain
+// the candidates, one per line, for the arguments following it. It is
+// handled by Run unless a command of the same name is registered.
+const completeCmd = "__complete"
+
+// Writes a bash completion script for the program prog, typically
+// sourced from ~/.bashrc or installed into the bash-completion
+// directory. The script obtains the candidates from prog itself, so it
+// doesn't need to be regenerated when commands or flags change. The
+// values of flags marked with FlagFilename or FlagDirname are completed
+// by the file completion of the shell.
+func (p *Path) WriteBashCompletion(w io.Writer, prog string) error {
+	fn := "_" + shellIdent(prog) + "_complete"
+	_, err := fmt.Fprintf(w, `# bash completion for %[1]s
+%[2]s() {
+	local IFS=$'\n' cur=${COMP_WORDS[COMP_CWORD]} ext
+	local -a reply
+	reply=($(%[1]s %[3]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	case ${reply[0]} in
+	:dirs)
+		compopt -o filenames
+		COMPREPLY=($(compgen -d -- "$cur"))
+		;;
+	:files)
+		compopt -o filenames
+		COMPREPLY=($(compgen -f -- "$cur"))
+		;;
+	":files "*)
+		compopt -o filenames
+		COMPREPLY=($(compgen -d -- "$cur"))
+		IFS=' '
+		for ext in ${reply[0]#:files }; do
+			IFS=$'\n'
+			COMPREPLY+=($(compgen -f -X "!*.$ext" -- "$cur"))
+		done
+		;;
+	*)
+		COMPREPLY=("${reply[@]}")
+		;;
+	esac
+}
+complete -o default -F %[2]s %[1]s
+`, prog, fn, completeCmd)
+	return err
+}
+
+// Same as WriteBashCompletion but for zsh. The script belongs into a
+// file named _prog in a directory of $fpath.
+func (p *Path) WriteZshCompletion(w io.Writer, prog string) error {
+	fn := "_" + shellIdent(prog)
+	_, err := fmt.Fprintf(w, `#compdef %[1]s
+# zsh completion for %[1]s
+%[2]s() {
+	local -a candidates
+	candidates=(${(f)"$(%[1]s %[3]s "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	case $candidates[1] in
+	:dirs) _files -/ ;;
+	:files) _files ;;
+	":files "*) _files -g "*.(${(j:|:)${=candidates[1]#:files }})" ;;
+	*) compadd -a candidates ;;
+	esac
+}
+compdef %[2]s %[1]s
+`, prog, fn, completeCmd)
+	return err
+}
+
+// Same as WriteBashCompletion but for fish. The script belongs into
+// ~/.config/fish/completions/prog.fish.
+func (p *Path) WriteFishCompletion(w io.Writer, prog string) error {
+	fn := "__" + shellIdent(prog) + "_complete"
+	_, err := fmt.Fprintf(w, `# fish completion for %[1]s
+function %[2]s
+	set -l args (commandline -opc) (commandline -ct)
+	set -l reply (%[1]s %[3]s $args[2..-1] 2>/dev/null)
+	switch "$reply[1]"
+	case :dirs
+		__fish_complete_directories (commandline -ct)
+	case :files
+		__fish_complete_path (commandline -ct)
+	case ':files *'
+		for ext in (string split ' ' -- $reply[1])[2..-1]
+			__fish_complete_suffix .$ext
+		end
+	case '*'
+		string join \n -- $reply
+	end
+end
+complete -c %[1]s -f -a '(%[2]s)'
+`, prog, fn, completeCmd)
+	return err
+}
+
+// Same as WriteBashCompletion but for PowerShell. The script is
+// typically loaded from the profile with Invoke-Expression.
+func (p *Path) WritePowerShellCompletion(w io.Writer, prog string) error {
+	_, err := fmt.Fprintf(w, `# powershell completion for %[1]s
+Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+	if ($wordToComplete -eq '') {
+		$words += ''
+	}
+	$reply = @(& '%[1]s' %[2]s @words 2>$null)
+	if ($reply.Count -eq 1 -and $reply[0] -match '^:(dirs|files( .*)?)$') {
+		$exts = @($reply[0].Split(' ') | Select-Object -Skip 1)
+		$dirsOnly = $reply[0] -eq ':dirs'
+		$dir = $wordToComplete.Substring(0, $wordToComplete.LastIndexOfAny([char[]]'/\') + 1)
+		Get-ChildItem -Path "$wordToComplete*" -ErrorAction SilentlyContinue | Where-Object {
+			$_.PSIsContainer -or (-not $dirsOnly -and ($exts.Count -eq 0 -or $exts -contains $_.Extension.TrimStart('.')))
+		} | ForEach-Object {
+			$kind = if ($_.PSIsContainer) { 'ProviderContainer' } else { 'ProviderItem' }
+			[System.Management.Automation.CompletionResult]::new($dir + $_.Name, $_.Name, $kind, $_.Name)
+		}
+		return
+	}
+	$reply | ForEach-Object {
+		[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+	}
+}
+`, prog, completeCmd)
+	return err
+}
+
+// Prints the completion candidates for args, one per line, or the
+// directive to complete a path if the shell should do that instead.
+func (p *Path) runComplete(args []string) error {
+	w := p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	candidates, directive := p.completion(args)
+	if directive != "" {
+		_, err := fmt.Fprintln(w, directive)
+		return err
+	}
+	for _, c := range candidates {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns prog with all characters not allowed in shell function names
+// replaced by underscores.
+func shellIdent(prog string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, prog)
+}
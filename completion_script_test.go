@@ -0,0 +1,61 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	p := newCompletionPath()
+	for name, write := range map[string]func(io.Writer, string) error{
+		"bash": p.WriteBashCompletion,
+		"zsh":  p.WriteZshCompletion,
+		"fish": p.WriteFishCompletion,
+	} {
+		var buf bytes.Buffer
+		if err := write(&buf, "my-app"); err != nil {
+			t.Fatal(err)
+		}
+		script := buf.String()
+		if !strings.Contains(script, "my-app __complete") {
+			t.Errorf("%s script should call %q:\n%s", name, "my-app __complete", script)
+		}
+		if !strings.Contains(script, "my_app_complete") && !strings.Contains(script, "_my_app()") {
+			t.Errorf("%s script should define a function for my-app:\n%s", name, script)
+		}
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	p := newCompletionPath()
+	p.registry().entries["deploy"].Flags.String("region", "", "region")
+	p.registry().entries["deploy"].FlagChoices("region", "eu-west-1", "us-east-1")
+	var buf bytes.Buffer
+	p.stdout = &buf
+	cont, err := p.Run("__complete", "deploy", "--region", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cont != nil {
+		t.Errorf("__complete should not run a command but ran %q.", cont.Name)
+	}
+	if want := "eu-west-1\nus-east-1\n"; buf.String() != want {
+		t.Errorf("__complete should print %q but printed %q.", want, buf.String())
+	}
+}
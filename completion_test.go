@@ -0,0 +1,127 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func newCompletionPath() *Path {
+	p := NewPath()
+	p.Flags.String("config", "", "config file")
+	p.Flags.Bool("verbose", false, "verbose output")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			var format string
+			fs.String("env", "dev", "target environment")
+			fs.Bool("force", false, "skip checks")
+			EnumVar(fs, &format, "format", "text", []string{"text", "json", "yaml"}, "output format")
+		},
+	})
+	c.FlagAlias("force", "f")
+	c.DeprecateFlag("env", "use a config file")
+	p.Add("destroy", "destroys the app", CmdFunc(func(args []string) error { return nil }))
+	p.Add("status", "prints the status", CmdFunc(func(args []string) error { return nil }))
+	return p
+}
+
+func TestComplete(t *testing.T) {
+	p := newCompletionPath()
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"deploy", "destroy", "status"}},
+		{[]string{"de"}, []string{"deploy", "destroy"}},
+		{[]string{"-"}, []string{"--config", "--verbose"}},
+		{[]string{"--verbose", "--config", "x.json", "st"}, []string{"status"}},
+		{[]string{"--config", ""}, nil},
+		{[]string{"deploy", "-"}, []string{"--force", "--format", "-f"}},
+		{[]string{"deploy", "--fo"}, []string{"--force", "--format"}},
+		{[]string{"deploy", "--format", ""}, []string{"json", "text", "yaml"}},
+		{[]string{"deploy", "-f", "--format", "j"}, []string{"json"}},
+		{[]string{"deploy", "--format=y"}, []string{"--format=yaml"}},
+		{[]string{"deploy", "--", "-"}, nil},
+		{[]string{"unknown", "-"}, nil},
+	}
+	for _, test := range tests {
+		got := p.Complete(test.args...)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q should complete to %q but was %q.", test.args, test.want, got)
+		}
+	}
+}
+
+func TestCompleteMounted(t *testing.T) {
+	p := newCompletionPath()
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("name", "", "remote name")
+		},
+	})
+	p.Mount("remote", "manages remotes", remote)
+	for _, test := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"remote", ""}, []string{"add"}},
+		{[]string{"--verbose", "remote", "add", "-"}, []string{"--name"}},
+	} {
+		got := p.Complete(test.args...)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q should complete to %q but was %q.", test.args, test.want, got)
+		}
+	}
+}
+
+func TestFlagChoices(t *testing.T) {
+	p := newCompletionPath()
+	c := p.registry().entries["deploy"]
+	if err := c.FlagChoices("region", "eu"); err == nil {
+		t.Error("Choices for an unknown flag should fail.")
+	}
+	c.Flags.String("region", "", "region")
+	if err := c.FlagChoices("region", "eu-west-1", "us-east-1", "eu-central-1"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"eu-central-1", "eu-west-1"}
+	if got := p.Complete("deploy", "--region", "eu"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Region should complete to %q but was %q.", want, got)
+	}
+	if _, err := p.Run("deploy", "--region", "ap-south-1"); err != nil {
+		t.Errorf("Choices should not restrict the value: %v", err)
+	}
+}
+
+func TestFlagChoicesStrict(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("region", "", "region")
+		},
+	})
+	if err := c.FlagChoicesStrict("region", "eu-west-1", "us-east-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy", "--region", "us-east-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy", "--region", "ap-south-1"); err == nil {
+		t.Error("Strict choices should reject other values.")
+	}
+}
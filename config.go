@@ -0,0 +1,241 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flag values loaded from configuration files, keyed by flag name.
+// The values are applied by Run for all flags the user did not pass
+// explicitly, so the command line always wins over the configuration
+// and the configuration wins over the flag defaults.
+type config struct {
+	global   map[string]configValue
+	commands map[string]map[string]configValue
+}
+
+// The value of a single flag in string form as accepted by Set. Values
+// read from an array are passed to separate calls of Set, which is
+// only allowed for repeatable flags.
+type configValue struct {
+	values []string
+	list   bool
+}
+
+// Flag values accepting repeated occurrences, such as slice flags,
+// implement RepeatableValue with IsRepeatable returning true. They can
+// be configured with arrays, each element is passed to a separate call
+// of Set. Values of other flags must be scalars.
+type RepeatableValue interface {
+	flag.Value
+	IsRepeatable() bool
+}
+
+// Reports if v accepts arrays in the configuration.
+func isRepeatable(v flag.Value) bool {
+	r, ok := v.(RepeatableValue)
+	return ok && r.IsRepeatable()
+}
+
+// Ignore configuration keys that don't match a registered command or
+// flag instead of failing to load the configuration.
+func (p *Path) SetIgnoreUnknownConfig(ignore bool) {
+	p.ignoreUnknownConfig = ignore
+}
+
+// Reads flag values from a JSON document of the form
+//
+//	{"global": {"verbose": true}, "commands": {"deploy": {"env": "prod"}}}
+//
+// Commands and flags must be registered before the configuration is
+// loaded. Later loads override values of earlier ones.
+func (p *Path) LoadConfigJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var tree map[string]interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return fmt.Errorf("Invalid JSON config: %w", err)
+	}
+	return p.loadConfigTree(tree)
+}
+
+// Same as LoadConfigJSON but reads the named file.
+func (p *Path) LoadConfigJSONFile(name string) error {
+	return loadConfigFile(name, p.LoadConfigJSON)
+}
+
+func loadConfigFile(name string, load func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := load(f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Converts a decoded document with "global" and "commands" sections
+// into flag values and merges them into the configuration of p.
+func (p *Path) loadConfigTree(tree map[string]interface{}) error {
+	c := config{commands: make(map[string]map[string]configValue)}
+	var unknown []string
+	for key, section := range tree {
+		switch key {
+		case "global":
+			m, ok := section.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Config section %q must be a table of flags", key)
+			}
+			values, err := configFlagValues(key, m)
+			if err != nil {
+				return err
+			}
+			c.global = values
+		case "commands":
+			m, ok := section.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Config section %q must be a table of commands", key)
+			}
+			for name, flags := range m {
+				fm, ok := flags.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("Config for command %q must be a table of flags", name)
+				}
+				values, err := configFlagValues(key+"."+name, fm)
+				if err != nil {
+					return err
+				}
+				c.commands[name] = values
+			}
+		default:
+			unknown = append(unknown, key)
+		}
+	}
+	return p.mergeConfig(c, unknown)
+}
+
+// Converts the values of a decoded flag table into their string form
+// as accepted by flag.Value.Set.
+func configFlagValues(section string, m map[string]interface{}) (map[string]configValue, error) {
+	values := make(map[string]configValue, len(m))
+	for name, v := range m {
+		var cv configValue
+		if list, ok := v.([]interface{}); ok {
+			cv.list = true
+			for _, elem := range list {
+				s, err := configString(elem)
+				if err != nil {
+					return nil, fmt.Errorf("Config key %s.%s: %w", section, name, err)
+				}
+				cv.values = append(cv.values, s)
+			}
+		} else {
+			s, err := configString(v)
+			if err != nil {
+				return nil, fmt.Errorf("Config key %s.%s: %w", section, name, err)
+			}
+			cv.values = []string{s}
+		}
+		values[name] = cv
+	}
+	return values, nil
+}
+
+func configString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("unsupported value %v (%T)", v, v)
+}
+
+// Verifies that all configured commands and flags exist and merges the
+// values of c into the configuration of p. Unknown keys found by the
+// format specific loaders are passed in unknown.
+func (p *Path) mergeConfig(c config, unknown []string) error {
+	for name, v := range c.global {
+		f := p.Flags.Lookup(name)
+		if f == nil {
+			unknown = append(unknown, "global."+name)
+			delete(c.global, name)
+		} else if err := checkConfigList(f, v); err != nil {
+			return fmt.Errorf("Config key global.%s: %w", name, err)
+		}
+	}
+	for cmd, values := range c.commands {
+		cont, ok := p.registry().entries[cmd]
+		if !ok {
+			unknown = append(unknown, "commands."+cmd)
+			delete(c.commands, cmd)
+			continue
+		}
+		for name, v := range values {
+			f := cont.FlagSet().Lookup(name)
+			if f == nil {
+				unknown = append(unknown, "commands."+cmd+"."+name)
+				delete(values, name)
+			} else if err := checkConfigList(f, v); err != nil {
+				return fmt.Errorf("Config key commands.%s.%s: %w", cmd, name, err)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		if !p.ignoreUnknownConfig {
+			return fmt.Errorf("Unknown config keys: %s", strings.Join(unknown, ", "))
+		}
+		p.warn("", WarnIgnoredConfig, "Ignoring unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+
+	p.config.global = mergeConfigValues(p.config.global, c.global)
+	if p.config.commands == nil {
+		p.config.commands = make(map[string]map[string]configValue)
+	}
+	for cmd, values := range c.commands {
+		p.config.commands[cmd] = mergeConfigValues(p.config.commands[cmd], values)
+	}
+	return nil
+}
+
+func checkConfigList(f *flag.Flag, v configValue) error {
+	if v.list && !isRepeatable(f.Value) {
+		return fmt.Errorf("flag -%s does not accept a list of values", f.Name)
+	}
+	return nil
+}
+
+func mergeConfigValues(dst, src map[string]configValue) map[string]configValue {
+	if dst == nil {
+		dst = make(map[string]configValue, len(src))
+	}
+	for name, v := range src {
+		dst[name] = v
+	}
+	return dst
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reads flag values from an INI document. Each section names a command,
+// the [global] section holds the global flags:
+//
+//	; legacy deployment settings
+//	[global]
+//	verbose = true
+//
+//	[deploy]
+//	env = "prod"
+//
+// Lines starting with ';' or '#' are comments, as is anything after a
+// ';' or '#' preceded by whitespace in unquoted values. Values may be
+// enclosed in single or double quotes. A key repeated within a section
+// is treated like an array: all values are set in order, which is only
+// allowed for repeatable flags and is an error otherwise.
+func (p *Path) LoadConfigINI(r io.Reader) error {
+	tree, err := parseINI(r)
+	if err != nil {
+		return fmt.Errorf("Invalid INI config: %w", err)
+	}
+	return p.loadConfigTree(tree)
+}
+
+// Same as LoadConfigINI but reads the named file.
+func (p *Path) LoadConfigINIFile(name string) error {
+	return loadConfigFile(name, p.LoadConfigINI)
+}
+
+// Parses an INI document into the tree shape consumed by loadConfigTree.
+func parseINI(r io.Reader) (map[string]interface{}, error) {
+	commands := make(map[string]interface{})
+	tree := map[string]interface{}{"commands": commands}
+	var section map[string]interface{}
+	s := bufio.NewScanner(r)
+	for num := 1; s.Scan(); num++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", num)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", num)
+			}
+			parent := commands
+			if name == "global" {
+				parent = tree
+			}
+			if m, ok := parent[name].(map[string]interface{}); ok {
+				section = m
+			} else {
+				section = make(map[string]interface{})
+				parent[name] = section
+			}
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected 'key = value'", num)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: key outside of a section", num)
+		}
+		key := strings.TrimSpace(line[:i])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", num)
+		}
+		value, err := parseINIValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		switch prev := section[key].(type) {
+		case nil:
+			section[key] = value
+		case []interface{}:
+			section[key] = append(prev, value)
+		default:
+			section[key] = []interface{}{prev, value}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func parseINIValue(v string) (string, error) {
+	if v != "" && (v[0] == '"' || v[0] == '\'') {
+		end := strings.IndexByte(v[1:], v[0])
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		rest := strings.TrimSpace(v[end+2:])
+		if rest != "" && rest[0] != ';' && rest[0] != '#' {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		return v[1 : end+1], nil
+	}
+	for i := 1; i < len(v); i++ {
+		if (v[i] == ';' || v[i] == '#') && (v[i-1] == ' ' || v[i-1] == '\t') {
+			return strings.TrimSpace(v[:i]), nil
+		}
+	}
+	return v, nil
+}
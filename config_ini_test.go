@@ -0,0 +1,96 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const iniFixture = `; deployment settings, maintained by ops
+# both comment styles are in use
+
+[global]
+verbose = true
+
+[deploy]
+env    = "prod ; not a comment"
+target = 'eu-west'   ; primary region
+port   = 8080 # https offloaded
+
+[tag]
+tag = stable
+tag = latest
+`
+
+func TestConfigINI(t *testing.T) {
+	var opts deployOpts
+	var tags listValue
+	p := newDeployPath(&opts)
+	p.Add("tag", "tags a release", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Var(&tags, "tag", "tag to add, repeatable")
+		},
+	})
+	if err := p.LoadConfigINI(strings.NewReader(iniFixture)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod ; not a comment" {
+		t.Errorf("env should be %q but was %q.", "prod ; not a comment", *opts.env)
+	}
+	if *opts.target != "eu-west" || *opts.port != 8080 {
+		t.Errorf("deploy should be configured with eu-west:8080 but was %s:%d.", *opts.target, *opts.port)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose should be %q but was %q.", "true", v)
+	}
+	if _, err := p.Run("tag"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (listValue{"stable", "latest"}); !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags should be %q but were %q.", want, tags)
+	}
+}
+
+func TestConfigINIRepeatedScalarKey(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigINI(strings.NewReader("[deploy]\nenv = prod\nenv = dev\n"))
+	if err == nil || !strings.Contains(err.Error(), "commands.deploy.env") {
+		t.Fatalf("A repeated key of a scalar flag should fail but was %v.", err)
+	}
+}
+
+func TestConfigINIMalformed(t *testing.T) {
+	tests := map[string]string{
+		"[deploy]\nenv prod\n":    "line 2",
+		"env = prod\n":            "line 1",
+		"[deploy\n":               "line 1",
+		"[deploy]\nenv = 'prod\n": "line 2",
+	}
+	for doc, line := range tests {
+		var opts deployOpts
+		p := newDeployPath(&opts)
+		err := p.LoadConfigINI(strings.NewReader(doc))
+		if err == nil || !strings.Contains(err.Error(), line) {
+			t.Errorf("%q should fail on %s but was %v.", doc, line, err)
+		}
+	}
+}
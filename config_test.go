@@ -0,0 +1,162 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type deployOpts struct {
+	env    *string
+	target *string
+	port   *int
+}
+
+func newDeployPath(opts *deployOpts, requiredFlags ...string) *Path {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			opts.env = fs.String("env", "dev", "target environment")
+			opts.target = fs.String("target", "", "deployment target")
+			opts.port = fs.Int("port", 80, "port to listen on")
+		},
+	}, requiredFlags...)
+	return p
+}
+
+func TestConfigJSONOverridesDefaults(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigJSON(strings.NewReader(`{
+		"global": {"verbose": true},
+		"commands": {"deploy": {"env": "prod", "port": 8080}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" {
+		t.Errorf("env should be %q but was %q.", "prod", *opts.env)
+	}
+	if *opts.port != 8080 {
+		t.Errorf("port should be %d but was %d.", 8080, *opts.port)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose should be %q but was %q.", "true", v)
+	}
+}
+
+func TestConfigJSONLosesToExplicitFlags(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"env": "prod"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy", "-env", "staging"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "staging" {
+		t.Errorf("env should be %q but was %q.", "staging", *opts.env)
+	}
+}
+
+func TestConfigJSONSatisfiesRequiredFlags(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts, "target")
+	if _, err := p.Run("deploy"); err == nil {
+		t.Fatal("Run should fail without the required flag.")
+	}
+	err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"target": "eu"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.target != "eu" {
+		t.Errorf("target should be %q but was %q.", "eu", *opts.target)
+	}
+}
+
+func TestConfigJSONUnknownKeys(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	doc := `{
+		"global": {"colour": true},
+		"commands": {"deploy": {"envv": "prod"}, "destroy": {}},
+		"extra": 1
+	}`
+	err := p.LoadConfigJSON(strings.NewReader(doc))
+	if err == nil {
+		t.Fatal("Unknown keys should fail to load.")
+	}
+	want := "Unknown config keys: commands.deploy.envv, commands.destroy, extra, global.colour"
+	if err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+
+	p.SetIgnoreUnknownConfig(true)
+	if err := p.LoadConfigJSON(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigJSONInvalidValue(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"port": "http"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err == nil {
+		t.Fatal("Run should fail for an invalid config value.")
+	}
+	err = p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"port": [1, 2]}}}`))
+	if err == nil {
+		t.Fatal("Unsupported values should fail to load.")
+	}
+}
+
+func TestConfigJSONFile(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte(`{"commands": {"deploy": {"env": "prod"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadConfigJSONFile(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" {
+		t.Errorf("env should be %q but was %q.", "prod", *opts.env)
+	}
+	if err := p.LoadConfigJSONFile(name + ".missing"); err == nil {
+		t.Fatal("Missing files should fail to load.")
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reads flag values from a TOML document of the form
+//
+//	[global]
+//	verbose = true
+//
+//	[commands.deploy]
+//	env = "prod"
+//	tags = ["a", "b"]
+//
+// Values are applied exactly like those of LoadConfigJSON. Arrays are
+// only accepted for repeatable flags, each element is set separately.
+// Arrays of tables and inline tables are not supported.
+func (p *Path) LoadConfigTOML(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	tree, err := parseTOML(string(b))
+	if err != nil {
+		return fmt.Errorf("Invalid TOML config: %w", err)
+	}
+	return p.loadConfigTree(tree)
+}
+
+// Same as LoadConfigTOML but reads the named file.
+func (p *Path) LoadConfigTOMLFile(name string) error {
+	return loadConfigFile(name, p.LoadConfigTOML)
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// Parses a TOML document into nested map[string]interface{},
+// []interface{}, bool and string values. Numbers and dates are kept in
+// their string form.
+func parseTOML(src string) (map[string]interface{}, error) {
+	t := &tomlParser{src: src, line: 1}
+	root := make(map[string]interface{})
+	table := root
+	// tables defined by a header, which must not be defined twice
+	defined := make(map[string]bool)
+	for {
+		t.skipSpace(true)
+		if t.pos >= len(t.src) {
+			return root, nil
+		}
+		if t.src[t.pos] == '[' {
+			if strings.HasPrefix(t.src[t.pos:], "[[") {
+				return nil, t.errorf("arrays of tables are not supported")
+			}
+			t.pos++
+			t.skipSpace(false)
+			keys, err := t.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			t.skipSpace(false)
+			if !t.consume(']') {
+				return nil, t.errorf("expected ']' after table name")
+			}
+			name := strings.Join(keys, ".")
+			if defined[name] {
+				return nil, t.errorf("table %q defined twice", name)
+			}
+			defined[name] = true
+			if table, err = t.subTable(root, keys); err != nil {
+				return nil, err
+			}
+		} else {
+			keys, err := t.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			t.skipSpace(false)
+			if !t.consume('=') {
+				return nil, t.errorf("expected '=' after key")
+			}
+			t.skipSpace(false)
+			v, err := t.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			parent, err := t.subTable(table, keys[:len(keys)-1])
+			if err != nil {
+				return nil, err
+			}
+			key := keys[len(keys)-1]
+			if _, dup := parent[key]; dup {
+				return nil, t.errorf("duplicate key %q", key)
+			}
+			parent[key] = v
+		}
+		if err := t.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+// Returns the table at keys below m, creating missing tables.
+func (t *tomlParser) subTable(m map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, k := range keys {
+		v, ok := m[k]
+		if !ok {
+			sub := make(map[string]interface{})
+			m[k] = sub
+			m = sub
+			continue
+		}
+		if m, ok = v.(map[string]interface{}); !ok {
+			return nil, t.errorf("key %q is not a table", k)
+		}
+	}
+	return m, nil
+}
+
+// Skips whitespace and comments, and newlines if multiline is set.
+func (t *tomlParser) skipSpace(multiline bool) {
+	for t.pos < len(t.src) {
+		switch c := t.src[t.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			t.pos++
+		case c == '\n' && multiline:
+			t.pos++
+			t.line++
+		case c == '#':
+			for t.pos < len(t.src) && t.src[t.pos] != '\n' {
+				t.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (t *tomlParser) endOfLine() error {
+	t.skipSpace(false)
+	if t.pos < len(t.src) && t.src[t.pos] != '\n' {
+		return t.errorf("unexpected %q after value", t.src[t.pos])
+	}
+	return nil
+}
+
+func (t *tomlParser) consume(c byte) bool {
+	if t.pos < len(t.src) && t.src[t.pos] == c {
+		t.pos++
+		return true
+	}
+	return false
+}
+
+// Parses a possibly dotted and quoted key.
+func (t *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		t.skipSpace(false)
+		if t.pos >= len(t.src) {
+			return nil, t.errorf("expected a key")
+		}
+		var key string
+		switch c := t.src[t.pos]; {
+		case c == '"' || c == '\'':
+			s, err := t.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := t.pos
+			for t.pos < len(t.src) && isTOMLBareKeyChar(t.src[t.pos]) {
+				t.pos++
+			}
+			if start == t.pos {
+				return nil, t.errorf("invalid key character %q", t.src[t.pos])
+			}
+			key = t.src[start:t.pos]
+		}
+		keys = append(keys, key)
+		t.skipSpace(false)
+		if !t.consume('.') {
+			return keys, nil
+		}
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (t *tomlParser) parseValue() (interface{}, error) {
+	if t.pos >= len(t.src) {
+		return nil, t.errorf("expected a value")
+	}
+	switch c := t.src[t.pos]; c {
+	case '"', '\'':
+		return t.parseString()
+	case '[':
+		return t.parseArray()
+	case '{':
+		return nil, t.errorf("inline tables are not supported")
+	}
+	start := t.pos
+	for t.pos < len(t.src) && !strings.ContainsRune(" \t\r\n#,]", rune(t.src[t.pos])) {
+		t.pos++
+	}
+	// local date-times may be separated from the time by a space
+	if t.pos+1 < len(t.src) && t.src[t.pos] == ' ' && isTOMLDate(t.src[start:t.pos]) &&
+		t.src[t.pos+1] >= '0' && t.src[t.pos+1] <= '9' {
+		t.pos++
+		for t.pos < len(t.src) && !strings.ContainsRune(" \t\r\n#,]", rune(t.src[t.pos])) {
+			t.pos++
+		}
+	}
+	raw := t.src[start:t.pos]
+	switch {
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case raw == "":
+		return nil, t.errorf("expected a value")
+	case isTOMLDate(raw):
+		return raw, nil
+	}
+	num := strings.Replace(raw, "_", "", -1)
+	switch {
+	case strings.HasPrefix(num, "0x"), strings.HasPrefix(num, "0o"), strings.HasPrefix(num, "0b"):
+		n, err := strconv.ParseInt(num, 0, 64)
+		if err != nil {
+			return nil, t.errorf("invalid integer %q", raw)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case num == "inf" || num == "+inf" || num == "-inf" || num == "nan" || num == "+nan" || num == "-nan":
+		return num, nil
+	}
+	if _, err := strconv.ParseFloat(num, 64); err != nil {
+		return nil, t.errorf("invalid value %q", raw)
+	}
+	return num, nil
+}
+
+func isTOMLDate(s string) bool {
+	return len(s) >= 10 && s[4] == '-' && s[7] == '-' || len(s) >= 8 && s[2] == ':' && s[5] == ':'
+}
+
+func (t *tomlParser) parseArray() (interface{}, error) {
+	t.pos++ // '['
+	arr := []interface{}{}
+	for {
+		t.skipSpace(true)
+		if t.consume(']') {
+			return arr, nil
+		}
+		v, err := t.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		t.skipSpace(true)
+		if t.consume(']') {
+			return arr, nil
+		}
+		if !t.consume(',') {
+			return nil, t.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (t *tomlParser) parseString() (string, error) {
+	quote := t.src[t.pos]
+	if strings.HasPrefix(t.src[t.pos:], strings.Repeat(string(quote), 3)) {
+		return "", t.errorf("multi-line strings are not supported")
+	}
+	t.pos++
+	var b strings.Builder
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == quote:
+			t.pos++
+			return b.String(), nil
+		case c == '\n':
+			return "", t.errorf("unterminated string")
+		case c == '\\' && quote == '"':
+			if err := t.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		default:
+			b.WriteByte(c)
+		}
+		t.pos++
+	}
+	return "", t.errorf("unterminated string")
+}
+
+func (t *tomlParser) parseEscape(b *strings.Builder) error {
+	t.pos++ // '\\'
+	if t.pos >= len(t.src) {
+		return t.errorf("unterminated string")
+	}
+	c := t.src[t.pos]
+	t.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if t.pos+n > len(t.src) {
+			return t.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(t.src[t.pos:t.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return t.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		t.pos += n
+	default:
+		return t.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A repeatable flag value collecting all values it was set to.
+type listValue []string
+
+func (l *listValue) String() string     { return strings.Join(*l, ",") }
+func (l *listValue) Set(s string) error { *l = append(*l, s); return nil }
+func (l *listValue) IsRepeatable() bool { return true }
+
+func TestConfigTOMLTables(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigTOML(strings.NewReader(`
+# ~/.myapp.toml
+[global]
+verbose = true
+
+[commands.deploy]
+env = "prod" # inline comment
+port = 8_443
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" || *opts.port != 8443 {
+		t.Errorf("deploy should be configured with prod:8443 but was %s:%d.", *opts.env, *opts.port)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose should be %q but was %q.", "true", v)
+	}
+}
+
+func TestConfigTOMLMissingTable(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	if err := p.LoadConfigTOML(strings.NewReader("[global]\nverbose = false\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "dev" || *opts.port != 80 {
+		t.Errorf("deploy should keep its defaults dev:80 but was %s:%d.", *opts.env, *opts.port)
+	}
+}
+
+func TestConfigTOMLArrays(t *testing.T) {
+	var opts deployOpts
+	var tags listValue
+	p := newDeployPath(&opts)
+	p.Add("tag", "tags a release", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Var(&tags, "tag", "tag to add, repeatable")
+		},
+	})
+	err := p.LoadConfigTOML(strings.NewReader("[commands.tag]\ntag = [\n  \"stable\",\n  \"latest\",\n]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("tag"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (listValue{"stable", "latest"}); !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags should be %q but were %q.", want, tags)
+	}
+
+	err = p.LoadConfigTOML(strings.NewReader("[commands.deploy]\nenv = [\"prod\", \"dev\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "commands.deploy.env") {
+		t.Fatalf("An array for a scalar flag should fail naming the key but was %v.", err)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	got, err := parseTOML(`
+title = 'literal \n'
+"quoted key" = "tab\tand é"
+hex = 0xff
+float = 1e3
+when = 1979-05-27 07:32:00
+a.b = false
+[x.y]
+list = [1, [2], "three"]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"title":      `literal \n`,
+		"quoted key": "tab\tand é",
+		"hex":        "255",
+		"float":      "1e3",
+		"when":       "1979-05-27 07:32:00",
+		"a":          map[string]interface{}{"b": false},
+		"x": map[string]interface{}{
+			"y": map[string]interface{}{
+				"list": []interface{}{"1", []interface{}{"2"}, "three"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Document should parse to %#v but was %#v.", want, got)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []string{
+		"a = 1\na = 2\n",
+		"[t]\n[t]\n",
+		"a = \"open\n",
+		"a = {b = 1}\n",
+		"[[t]]\n",
+		"a = 1 2\n",
+		"a = [1 2]\n",
+		"a = nope\n",
+		"a = 1\n[a]\n",
+	}
+	for _, doc := range tests {
+		if _, err := parseTOML(doc); err == nil {
+			t.Errorf("%q should fail to parse.", doc)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reads flag values from a YAML document of the form
+//
+//	global:
+//	  verbose: true
+//	commands:
+//	  deploy:
+//	    env: prod
+//	    timeout: 30s
+//
+// Values are applied exactly like those of LoadConfigJSON. Only the
+// block subset of YAML needed for configuration files is supported:
+// nested mappings, sequences, plain and quoted scalars and comments.
+func (p *Path) LoadConfigYAML(r io.Reader) error {
+	v, err := parseYAML(r)
+	if err != nil {
+		return fmt.Errorf("Invalid YAML config: %w", err)
+	}
+	if v == nil {
+		return nil
+	}
+	tree, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Invalid YAML config: document is not a mapping")
+	}
+	return p.loadConfigTree(tree)
+}
+
+// Same as LoadConfigYAML but reads the named file.
+func (p *Path) LoadConfigYAMLFile(name string) error {
+	return loadConfigFile(name, p.LoadConfigYAML)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// Parses a YAML document into nested map[string]interface{},
+// []interface{} and string values.
+func parseYAML(r io.Reader) (interface{}, error) {
+	var lines []yamlLine
+	s := bufio.NewScanner(r)
+	for num := 1; s.Scan(); num++ {
+		raw := s.Text()
+		text := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(text)
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", num)
+		}
+		text = strings.TrimRight(stripYAMLComment(text), " \t")
+		if text == "" || (indent == 0 && strings.HasPrefix(text, "%")) {
+			continue
+		}
+		if indent == 0 && text == "---" {
+			if len(lines) > 0 {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", num)
+			}
+			continue
+		}
+		if indent == 0 && text == "..." {
+			break
+		}
+		lines = append(lines, yamlLine{num: num, indent: indent, text: text})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	y := &yamlParser{lines: lines}
+	v, err := y.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if y.pos < len(y.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", y.lines[y.pos].num)
+	}
+	return v, nil
+}
+
+// Removes a trailing comment, which starts with a '#' at the
+// beginning of the line or after whitespace outside of quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t:[,-", rune(s[i-1]))):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// Parses the mapping or sequence starting at the current line, which
+// must be indented by exactly indent.
+func (y *yamlParser) parseBlock(indent int) (interface{}, error) {
+	l := y.lines[y.pos]
+	if l.text == "-" || strings.HasPrefix(l.text, "- ") {
+		return y.parseSequence(indent)
+	}
+	return y.parseMapping(indent)
+}
+
+func (y *yamlParser) parseMapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for y.pos < len(y.lines) {
+		l := y.lines[y.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+		key, rest, err := splitYAMLKey(l)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.num, key)
+		}
+		y.pos++
+		v, err := y.parseValue(l, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+func (y *yamlParser) parseSequence(indent int) (interface{}, error) {
+	var seq []interface{}
+	for y.pos < len(y.lines) {
+		l := y.lines[y.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+		if l.text != "-" && !strings.HasPrefix(l.text, "- ") {
+			return nil, fmt.Errorf("line %d: expected a sequence item", l.num)
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest != "" && !isYAMLQuoted(rest) && (strings.Contains(rest, ": ") || strings.HasSuffix(rest, ":")) {
+			// A mapping nested into the item, e.g. "- name: x".
+			// Reparse the remainder as if it started on its own line.
+			y.lines[y.pos] = yamlLine{num: l.num, indent: l.indent + len(l.text) - len(rest), text: rest}
+			v, err := y.parseMapping(y.lines[y.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+		y.pos++
+		v, err := y.parseValue(l, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+	}
+	return seq, nil
+}
+
+// Parses the value following a key or a sequence dash on line l. An
+// empty value either starts a nested block or is null.
+func (y *yamlParser) parseValue(l yamlLine, rest string, indent int) (interface{}, error) {
+	if rest != "" {
+		return parseYAMLScalar(l.num, rest)
+	}
+	if y.pos < len(y.lines) && y.lines[y.pos].indent > indent {
+		return y.parseBlock(y.lines[y.pos].indent)
+	}
+	// a sequence may be indented at the same level as its parent key
+	if y.pos < len(y.lines) && y.lines[y.pos].indent == indent && strings.HasPrefix(y.lines[y.pos].text, "-") &&
+		!strings.HasPrefix(l.text, "-") {
+		return y.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+func splitYAMLKey(l yamlLine) (string, string, error) {
+	text := l.text
+	var key string
+	if isYAMLQuoted(text) {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", fmt.Errorf("line %d: unterminated quoted key", l.num)
+		}
+		k, err := parseYAMLScalar(l.num, text[:end+2])
+		if err != nil {
+			return "", "", err
+		}
+		key = k.(string)
+		text = text[end+2:]
+		if !strings.HasPrefix(text, ":") {
+			return "", "", fmt.Errorf("line %d: expected ':' after key", l.num)
+		}
+		return key, strings.TrimSpace(text[1:]), nil
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", fmt.Errorf("line %d: expected 'key: value'", l.num)
+		}
+		i = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:i])
+	if key == "" {
+		return "", "", fmt.Errorf("line %d: empty key", l.num)
+	}
+	return key, strings.TrimSpace(text[i+1:]), nil
+}
+
+func isYAMLQuoted(s string) bool {
+	return strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'")
+}
+
+func parseYAMLScalar(num int, s string) (interface{}, error) {
+	switch {
+	case s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		return parseYAMLFlowSequence(num, s[1:len(s)-1])
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("line %d: flow mappings are not supported", num)
+	case s == "|" || s == ">" || strings.HasPrefix(s, "|-") || strings.HasPrefix(s, ">-"):
+		return nil, fmt.Errorf("line %d: block scalars are not supported", num)
+	case strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*") || strings.HasPrefix(s, "!"):
+		return nil, fmt.Errorf("line %d: anchors, aliases and tags are not supported", num)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case strings.HasPrefix(s, `"`):
+		if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+		}
+		return unescapeYAML(num, s[1:len(s)-1])
+	}
+	return s, nil
+}
+
+func unescapeYAML(num int, s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("line %d: invalid escape at end of string", num)
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '"', '\\', '/', ' ':
+			b.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("line %d: unsupported escape \\%c", num, s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func parseYAMLFlowSequence(num int, s string) (interface{}, error) {
+	seq := []interface{}{}
+	if strings.TrimSpace(s) == "" {
+		return seq, nil
+	}
+	var quote byte
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			c := s[i]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				} else if c == '\\' && quote == '"' {
+					i++
+				}
+				continue
+			}
+			if c == '"' || c == '\'' {
+				quote = c
+				continue
+			}
+			if c == '[' || c == '{' {
+				return nil, fmt.Errorf("line %d: nested flow collections are not supported", num)
+			}
+			if c != ',' {
+				continue
+			}
+		}
+		item := strings.TrimSpace(s[start:i])
+		if item == "" {
+			return nil, fmt.Errorf("line %d: empty item in flow sequence", num)
+		}
+		v, err := parseYAMLScalar(num, item)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+		start = i + 1
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+	}
+	return seq, nil
+}
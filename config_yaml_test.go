@@ -0,0 +1,158 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigYAMLMultipleCommands(t *testing.T) {
+	var (
+		opts    deployOpts
+		timeout *time.Duration
+		force   *bool
+		name    *string
+	)
+	p := newDeployPath(&opts)
+	p.Add("rollback", "rolls back a deployment", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			timeout = fs.Duration("timeout", time.Minute, "rollback timeout")
+			force = fs.Bool("force", false, "skip checks")
+			name = fs.String("name", "", "release name")
+		},
+	})
+	err := p.LoadConfigYAML(strings.NewReader(`---
+# written by ops
+global:
+  verbose: true # applies to all commands
+commands:
+  deploy:
+    env: prod
+    port: 8443
+  rollback:
+    timeout: 90s
+    force: true
+    name: "release #12"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" || *opts.port != 8443 {
+		t.Errorf("deploy should be configured with prod:8443 but was %s:%d.", *opts.env, *opts.port)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose should be %q but was %q.", "true", v)
+	}
+	if _, err := p.Run("rollback"); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 90*time.Second {
+		t.Errorf("timeout should be %v but was %v.", 90*time.Second, *timeout)
+	}
+	if !*force {
+		t.Error("force should be set.")
+	}
+	if *name != "release #12" {
+		t.Errorf("name should be %q but was %q.", "release #12", *name)
+	}
+}
+
+func TestConfigYAMLTypeMismatch(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigYAML(strings.NewReader(`
+commands:
+  deploy:
+    env:
+      name: prod
+`))
+	if err == nil || !strings.Contains(err.Error(), "commands.deploy.env") {
+		t.Fatalf("A mapping as flag value should fail naming the key but was %v.", err)
+	}
+
+	err = p.LoadConfigYAML(strings.NewReader("commands:\n  deploy:\n    port: eighty\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err == nil || !strings.Contains(err.Error(), "-port") {
+		t.Fatalf("A non-numeric port should fail naming the flag but was %v.", err)
+	}
+}
+
+func TestConfigYAMLSharesUnknownKeyHandling(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	err := p.LoadConfigYAML(strings.NewReader("commands:\n  deploy:\n    region: eu\n"))
+	if err == nil || err.Error() != "Unknown config keys: commands.deploy.region" {
+		t.Fatalf("Unknown keys should be reported but was %v.", err)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want interface{}
+	}{
+		{"", nil},
+		{"a: 1\nb: 'it''s'\nc: \"x\\ty\"\n", map[string]interface{}{"a": "1", "b": "it's", "c": "x\ty"}},
+		{"a:\n  - x\n  - y # comment\nb: [1, 'two', \"3\"]\n", map[string]interface{}{
+			"a": []interface{}{"x", "y"},
+			"b": []interface{}{"1", "two", "3"},
+		}},
+		{"a:\n- x\n- y\n", map[string]interface{}{"a": []interface{}{"x", "y"}}},
+		{"- name: x\n  port: 1\n- y\n", []interface{}{
+			map[string]interface{}{"name": "x", "port": "1"},
+			"y",
+		}},
+		{"url: http://example.com:8080/#top\nempty:\n", map[string]interface{}{
+			"url":   "http://example.com:8080/#top",
+			"empty": nil,
+		}},
+	}
+	for _, test := range tests {
+		got, err := parseYAML(strings.NewReader(test.doc))
+		if err != nil {
+			t.Errorf("%q: %v", test.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q should parse to %#v but was %#v.", test.doc, test.want, got)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []string{
+		"a: 1\n  b: 2\n",
+		"a: 1\na: 2\n",
+		"a: 'open\n",
+		"a: {b: 1}\n",
+		"a: |\n  text\n",
+		"\ta: 1\n",
+		"just text\n",
+	}
+	for _, doc := range tests {
+		if _, err := parseYAML(strings.NewReader(doc)); err == nil {
+			t.Errorf("%q should fail to parse.", doc)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Asks the user to confirm running the command, such as one deleting
+// data, with message followed by [y/N]. See ConfirmFunc.
+func (c *CmdCont) Confirm(message string) error {
+	return c.ConfirmFunc(func(*CmdCont) string { return message })
+}
+
+// Same as Confirm but calls message with the container once the flags
+// are parsed, so that the message can include their values. Defines
+// the flag --yes, with the alias -y unless that is taken, which skips
+// the confirmation. Only y and yes confirm. Without a terminal, see
+// SetTerminal and ForceInteractive, the command fails with a
+// ConfirmationRequiredError unless --yes is given.
+func (c *CmdCont) ConfirmFunc(message func(c *CmdCont) string) error {
+	fs := c.FlagSet()
+	if c.confirm == nil {
+		if fs.Lookup("yes") != nil {
+			return fmt.Errorf("Flag -yes of command %q is already defined", c.Name)
+		}
+		fs.Bool("yes", false, "run without asking for confirmation")
+		if fs.Lookup("y") == nil {
+			c.FlagAlias("yes", "y")
+		}
+	}
+	c.confirm = message
+	return nil
+}
+
+// Asks for the confirmation of c, if set up, unless --yes is given.
+func (p *Path) confirmRun(c *CmdCont) error {
+	if c.confirm == nil || c.FlagSet().Lookup("yes").Value.String() == "true" {
+		return nil
+	}
+	msg := c.confirm(c)
+	t := p.terminal()
+	if forced, ok := p.forcedInteractive(); ok && !forced || !ok && !t.IsTerminal() {
+		return &ConfirmationRequiredError{Command: c.Name, Message: msg}
+	}
+	answer, err := t.ReadLine(msg+" [y/N] ", true)
+	if err != nil {
+		return fmt.Errorf("Reading confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return &ConfirmationRequiredError{Command: c.Name, Message: msg, Declined: true}
+}
+
+// Returned by Run for commands set up with Confirm that weren't
+// confirmed, either because there is no terminal to ask and --yes
+// isn't given, or because the user declined. The message has one of
+// the forms
+//
+//	purge: confirmation required, pass --yes to run without a terminal
+//	purge: not confirmed
+type ConfirmationRequiredError struct {
+	Command string
+	// the question that was or would have been asked
+	Message string
+	// the user answered other than yes
+	Declined bool
+}
+
+func (e *ConfirmationRequiredError) Error() string {
+	if e.Declined {
+		return commandPrefix(e.Command) + "not confirmed"
+	}
+	return commandPrefix(e.Command) + "confirmation required, pass --yes to run without a terminal"
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+)
+
+func newPurgePath(ran *bool) (*Path, *CmdCont) {
+	p := NewPath()
+	var dir string
+	c := p.Add("purge", "deletes the cache", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&dir, "dir", "/var/cache", "cache directory")
+		},
+		run: func(args ...string) error {
+			*ran = true
+			return nil
+		},
+	})
+	c.ConfirmFunc(func(c *CmdCont) string {
+		return fmt.Sprintf("This will delete %s. Continue?", dir)
+	})
+	return p, c
+}
+
+func TestConfirm(t *testing.T) {
+	for _, test := range []struct {
+		answer string
+		ran    bool
+	}{
+		{"y", true},
+		{" YES ", true},
+		{"n", false},
+		{"", false},
+	} {
+		var ran bool
+		p, _ := newPurgePath(&ran)
+		term := &fakeTerminal{tty: true, lines: []string{test.answer}}
+		p.SetTerminal(term)
+		_, err := p.Run("purge", "-dir", "/tmp/cache")
+		if ran != test.ran {
+			t.Errorf("Answer %q should run the command: %v but was %v.", test.answer, test.ran, ran)
+		}
+		var confirmErr *ConfirmationRequiredError
+		if !test.ran && (!errors.As(err, &confirmErr) || !confirmErr.Declined) {
+			t.Errorf("Answer %q should decline but was %v.", test.answer, err)
+		}
+		if want := "This will delete /tmp/cache. Continue? [y/N] "; len(term.prompts) != 1 || term.prompts[0] != want {
+			t.Errorf("Prompt should be %q but was %q.", want, term.prompts)
+		}
+	}
+}
+
+func TestConfirmYes(t *testing.T) {
+	for _, arg := range []string{"--yes", "-y"} {
+		var ran bool
+		p, _ := newPurgePath(&ran)
+		term := &fakeTerminal{tty: true}
+		p.SetTerminal(term)
+		if _, err := p.Run("purge", arg); err != nil || !ran {
+			t.Errorf("%s should run without asking but was %v.", arg, err)
+		}
+		if len(term.prompts) > 0 {
+			t.Errorf("%s should not prompt but was %q.", arg, term.prompts)
+		}
+	}
+}
+
+func TestConfirmNonInteractive(t *testing.T) {
+	var ran bool
+	p, c := newPurgePath(&ran)
+	p.SetTerminal(&fakeTerminal{tty: false})
+	_, err := p.Run("purge")
+	var confirmErr *ConfirmationRequiredError
+	if !errors.As(err, &confirmErr) || confirmErr.Declined || ran {
+		t.Fatalf("Run should fail with a ConfirmationRequiredError but was %v.", err)
+	}
+	if want := "purge: confirmation required, pass --yes to run without a terminal"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+	if confirmErr.Message != "This will delete /var/cache. Continue?" {
+		t.Errorf("Message should be the question but was %q.", confirmErr.Message)
+	}
+	if _, err := p.Run("purge", "-yes"); err != nil || !ran {
+		t.Errorf("--yes should run without a terminal but was %v.", err)
+	}
+	if err := c.Confirm("Sure?"); err != nil {
+		t.Errorf("Confirm should replace the message but was %v.", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Commands implementing ContextCmd are run with the context passed to
+// RunContext instead of their Run method. The context carries the
+// container of the command, see FromContext.
+type ContextCmd interface {
+	Cmd
+	RunContext(ctx context.Context, args ...string) error
+}
+
+// Same as Run but passes ctx to commands implementing ContextCmd. The
+// command isn't run if ctx is done before, the error of ctx is
+// returned instead.
+func (p *Path) RunContext(ctx context.Context, args ...string) (*CmdCont, error) {
+	if len(args) > 0 && args[0] == completeCmd && p.registry().entries[completeCmd] == nil {
+		return nil, p.runComplete(args[1:])
+	}
+	if p.responseFiles {
+		var err error
+		if args, err = expandResponseFiles(args, 0); err != nil {
+			return nil, err
+		}
+	}
+	if stages := p.splitPipeline(args); stages != nil {
+		conts, err := p.RunPipeline(ctx, stages...)
+		if len(conts) == 0 {
+			return nil, err
+		}
+		return conts[len(conts)-1], err
+	}
+	p.beginDispatch()
+	defer p.endDispatch()
+	return p.run(ctx, args, nil, nil, true)
+}
+
+// The context a command is run with, carrying its container and, if
+// it was dispatched through mounted paths, their containers.
+type cmdContext struct {
+	context.Context
+	cont   *CmdCont
+	parent *cmdContext
+}
+
+type cmdContKey struct{}
+
+func (c *cmdContext) Value(key interface{}) interface{} {
+	if key == (cmdContKey{}) {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// Returns a copy of ctx carrying cont, matched by p. Containers added
+// for the mounted path of p become its parents; those of an earlier
+// dispatch, such as one running the dispatch of p, don't.
+func withCmdCont(ctx context.Context, p *Path, cont *CmdCont) context.Context {
+	c := &cmdContext{Context: ctx, cont: cont}
+	if parent, ok := ctx.Value(cmdContKey{}).(*cmdContext); ok && parent.cont.sub == p {
+		c.parent = parent
+	}
+	return c
+}
+
+// Returns the container of the command run with ctx by RunContext.
+// For commands of mounted paths it is the container of the command,
+// not of the paths, see PathFromContext.
+func FromContext(ctx context.Context) (*CmdCont, bool) {
+	c, ok := ctx.Value(cmdContKey{}).(*cmdContext)
+	if !ok {
+		return nil, false
+	}
+	return c.cont, true
+}
+
+// Returns the containers matched by the dispatch of the command run
+// with ctx, starting with those of the mounted paths, such as remote
+// for "remote add", and ending with the container of the command.
+func PathFromContext(ctx context.Context) []*CmdCont {
+	var chain []*CmdCont
+	c, _ := ctx.Value(cmdContKey{}).(*cmdContext)
+	for ; c != nil; c = c.parent {
+		chain = append([]*CmdCont{c.cont}, chain...)
+	}
+	return chain
+}
+
+// The standard streams, environment variables and working directory
+// of a command. Commands implementing ContextCmd should use the Env of
+// EnvFromContext rather than os.Stdin, os.Stdout, os.Stderr, os.Getenv
+// and the working directory of the process, so that their callers can
+// redirect and replace them.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// The environment variables of the command, looked up by
+	// EnvLookup and by the flags bound with BindEnv and AutoEnv. If
+	// nil, those of the process are used.
+	Vars map[string]string
+	// The directory relative paths are resolved against by EnvPath.
+	// If empty, the working directory of the process is used.
+	Dir string
+}
+
+type envKey struct{}
+
+// Returns a copy of ctx carrying env, see EnvFromContext.
+func WithEnv(ctx context.Context, env Env) context.Context {
+	return context.WithValue(ctx, envKey{}, env)
+}
+
+// Returns the Env added to ctx by WithEnv. Streams that weren't set
+// default to os.Stdin, os.Stdout and os.Stderr.
+func EnvFromContext(ctx context.Context) Env {
+	env, _ := ctx.Value(envKey{}).(Env)
+	if env.Stdin == nil {
+		env.Stdin = os.Stdin
+	}
+	if env.Stdout == nil {
+		env.Stdout = os.Stdout
+	}
+	if env.Stderr == nil {
+		env.Stderr = os.Stderr
+	}
+	return env
+}
+
+// Returns the value of the environment variable key in env.Vars, or in
+// the environment of the process if env.Vars is nil.
+func EnvLookup(env Env, key string) (string, bool) {
+	if env.Vars == nil {
+		return os.LookupEnv(key)
+	}
+	v, ok := env.Vars[key]
+	return v, ok
+}
+
+// Returns path resolved against env.Dir if it is relative. The path
+// is returned unchanged if env.Dir is empty, it is then relative to the
+// working directory of the process.
+func EnvPath(env Env, path string) string {
+	if env.Dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(env.Dir, path)
+}
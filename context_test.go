@@ -0,0 +1,124 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	var got []*CmdCont
+	var chains [][]*CmdCont
+	record := &testContextCmd{runContext: func(ctx context.Context, args ...string) error {
+		c, ok := FromContext(ctx)
+		if !ok {
+			t.Error("The context should carry the container of the command.")
+		}
+		got = append(got, c)
+		chains = append(chains, PathFromContext(ctx))
+		return nil
+	}}
+	db := NewPath()
+	migrate := db.Add("migrate", "migrates the database", record)
+	cloud := NewPath()
+	dbCont := cloud.Mount("db", "manages databases", db)
+	p := NewPath()
+	status := p.Add("status", "prints the status", record)
+	cloudCont := p.Mount("cloud", "manages the cloud", cloud)
+	var nested *CmdCont
+	p.Add("deploy", "deploys the app", &testContextCmd{runContext: func(ctx context.Context, args ...string) error {
+		if _, err := p.RunContext(ctx, "status"); err != nil {
+			return err
+		}
+		nested, _ = FromContext(ctx)
+		return nil
+	}})
+
+	ctx := context.Background()
+	for _, args := range [][]string{{"status"}, {"cloud", "db", "migrate"}, {"deploy"}} {
+		if _, err := p.RunContext(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []*CmdCont{status, migrate, status}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FromContext should return the commands %v but returned %v.", want, got)
+	}
+	want := [][]*CmdCont{{status}, {cloudCont, dbCont, migrate}, {status}}
+	if !reflect.DeepEqual(chains, want) {
+		t.Errorf("PathFromContext should return %v but returned %v.", want, chains)
+	}
+	if nested != p.Lookup("deploy") {
+		t.Errorf("A nested dispatch should not change the context of the running command but it was %v.", nested)
+	}
+	if _, ok := FromContext(ctx); ok {
+		t.Error("A context not passed to a command should not carry a container.")
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	p := newAutoEnvPath()
+	if err := p.AutoEnv("MYAPP"); err != nil {
+		t.Fatal(err)
+	}
+	var home, config string
+	p.Add("status", "prints the status", &testContextCmd{
+		testCmd: testCmd{flags: func(fs *flag.FlagSet) {
+			fs.String("config", "app.conf", "config file")
+		}},
+		runContext: func(ctx context.Context, args ...string) error {
+			env := EnvFromContext(ctx)
+			home, _ = EnvLookup(env, "HOME")
+			c, _ := FromContext(ctx)
+			config = EnvPath(env, c.Flags.Lookup("config").Value.String())
+			return nil
+		},
+	})
+	t.Setenv("MYAPP_DEPLOY_ENV", "process")
+	t.Setenv("MYAPP_VERBOSE", "false")
+	t.Setenv("HOME", "/home/process")
+	for _, test := range []struct {
+		env                Env
+		deployEnv, verbose string
+		home, conf         string
+	}{
+		{Env{Vars: map[string]string{"MYAPP_DEPLOY_ENV": "prod", "MYAPP_VERBOSE": "true", "HOME": "/home/a"}, Dir: "/srv/a"}, "prod", "true", "/home/a", "/srv/a/app.conf"},
+		{Env{Vars: map[string]string{"MYAPP_DEPLOY_ENV": "dev"}, Dir: "/srv/b"}, "dev", "false", "", "/srv/b/app.conf"},
+		{Env{}, "process", "false", "/home/process", "app.conf"},
+	} {
+		ctx := WithEnv(context.Background(), test.env)
+		c, err := p.RunContext(ctx, "deploy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := c.Flags.Lookup("env").Value.String(); v != test.deployEnv {
+			t.Errorf("Flag -env should be %q with %v but was %q.", test.deployEnv, test.env.Vars, v)
+		}
+		if v := p.Flags.Lookup("verbose").Value.String(); v != test.verbose {
+			t.Errorf("Global flag -verbose should be %q with %v but was %q.", test.verbose, test.env.Vars, v)
+		}
+		p.ResetFlags()
+		if _, err := p.RunContext(ctx, "status"); err != nil {
+			t.Fatal(err)
+		}
+		if home != test.home || config != filepath.FromSlash(test.conf) {
+			t.Errorf("The command should see $HOME %q and the config %q but saw %q and %q.", test.home, test.conf, home, config)
+		}
+		p.ResetFlags()
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strconv"
+)
+
+// Defines a counter flag with specified name and usage string. The
+// argument p points to an int variable in which to store the count.
+// Like a bool flag it takes no value: each occurrence, as in -v -v -v,
+// increments the count. An explicit value, as in -v=3, sets it.
+func CountVar(fs *flag.FlagSet, p *int, name, usage string) {
+	fs.Var((*countValue)(p), name, usage)
+}
+
+// Defines a counter flag like CountVar and returns the address of the
+// count.
+func Count(fs *flag.FlagSet, name, usage string) *int {
+	p := new(int)
+	CountVar(fs, p, name, usage)
+	return p
+}
+
+type countValue int
+
+func (v *countValue) Set(s string) error {
+	switch s {
+	case "true":
+		*v++
+		return nil
+	case "false":
+		*v = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return strconv.ErrSyntax
+	}
+	if n < 0 {
+		return strconv.ErrRange
+	}
+	*v = countValue(n)
+	return nil
+}
+
+func (v *countValue) String() string {
+	if v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*v))
+}
+
+func (v *countValue) Get() interface{} {
+	return int(*v)
+}
+
+func (v *countValue) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-v", "-v", "-v"}, 3},
+		{[]string{"-v=3"}, 3},
+		{[]string{"-v", "-v=5", "-v"}, 6},
+		{[]string{"-v", "-v=false"}, 0},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		v := Count(fs, "v", "verbosity")
+		if err := fs.Parse(append(test.args, "arg")); err != nil {
+			t.Fatal(err)
+		}
+		if *v != test.want {
+			t.Errorf("%q should count %d but was %d.", test.args, test.want, *v)
+		}
+		if len(fs.Args()) != 1 {
+			t.Errorf("%q should leave one argument but left %q.", test.args, fs.Args())
+		}
+	}
+}
+
+func TestCountInvalid(t *testing.T) {
+	for _, arg := range []string{"-v=many", "-v=-1"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(new(strings.Builder))
+		Count(fs, "v", "verbosity")
+		if err := fs.Parse([]string{arg}); err == nil {
+			t.Errorf("%q should fail.", arg)
+		}
+	}
+}
+
+func TestCountHelp(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	level := 2
+	CountVar(fs, &level, "v", "verbosity, repeat for more")
+	Count(fs, "q", "quietness")
+	fs.PrintDefaults()
+	want := "  -q\tquietness\n  -v\tverbosity, repeat for more (default 2)\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
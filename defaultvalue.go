@@ -0,0 +1,89 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Overrides the default of the named flag of command, such as "deploy"
+// or "remote add" for a command of a mounted path, for products
+// embedding a shared set of commands. The help output shows value as
+// the default, and Run sets the flag to it unless the flag is set
+// otherwise, so the command line, environment variables and the
+// configuration take precedence. value is parsed like a value given
+// on the command line when the command runs.
+func (p *Path) SetDefaultValue(command, name, value string) error {
+	c := p.lookupPath(command)
+	if c == nil {
+		return fmt.Errorf("No such command %q", command)
+	}
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, command)
+	}
+	f = canonicalFlag(c.FlagSet(), f)
+	if c.defaultValues == nil {
+		c.defaultValues = make(map[string]string)
+	}
+	c.defaultValues[f.Name] = value
+	f.DefValue = value
+	c.changed()
+	return nil
+}
+
+// Returns the command named by the space separated names of the
+// mounted paths and the command, or nil.
+func (p *Path) lookupPath(command string) *CmdCont {
+	var c *CmdCont
+	for _, name := range strings.Fields(command) {
+		if c != nil {
+			if c.sub == nil {
+				return nil
+			}
+			p = c.sub
+		}
+		if c = p.Lookup(name); c == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+// Shows the defaults set by SetDefaultValue in the help output of c,
+// once its flags are registered.
+func (c *CmdCont) showDefaultValues() {
+	for name, value := range c.defaultValues {
+		if f := c.Flags.Lookup(name); f != nil {
+			f.DefValue = value
+		}
+	}
+}
+
+// Sets the flags of c without a source in sources to their defaults set
+// by SetDefaultValue. They don't count as set, so they don't satisfy
+// RequiredFlags.
+func (c *CmdCont) setDefaultValues(sources map[string]Source) error {
+	for name, value := range c.defaultValues {
+		if _, set := sources[name]; set {
+			continue
+		}
+		if err := c.FlagSet().Lookup(name).Value.Set(value); err != nil {
+			return fmt.Errorf("Invalid default %q for flag -%s of command %q: %w", value, name, c.CommandPath(), err)
+		}
+	}
+	return nil
+}
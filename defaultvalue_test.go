@@ -0,0 +1,127 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetDefaultValue(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	var help strings.Builder
+	p.WriteHelp(&help, p.Lookup("deploy"))
+	if err := p.SetDefaultValue("deploy", "env", "staging"); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	p.WriteHelp(&out, p.Lookup("deploy"))
+	if want := `(default "staging")`; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+	if out.String() == help.String() {
+		t.Error("The help output should change with the default.")
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "staging" {
+		t.Errorf("-env should be %q but was %q.", "staging", *opts.env)
+	}
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" {
+		t.Errorf("-env should be %q but was %q.", "prod", *opts.env)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "staging" {
+		t.Errorf("-env should be reset to %q but was %q.", "staging", *opts.env)
+	}
+	out.Reset()
+	p.WriteHelp(&out, p.Lookup("deploy"))
+	if want := `(default "staging")`; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should still contain %q after a run but was %q.", want, out.String())
+	}
+}
+
+func TestSetDefaultValuePrecedence(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	if err := p.SetDefaultValue("deploy", "port", "8080"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"port": 9090}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.port != 9090 {
+		t.Errorf("-port should be %d but was %d.", 9090, *opts.port)
+	}
+}
+
+func TestSetDefaultValueRequired(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts, "target")
+	if err := p.SetDefaultValue("deploy", "target", "eu"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := p.Run("deploy")
+	if want := "deploy: required flags not set: --target"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+}
+
+func TestSetDefaultValueErrors(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	for _, test := range []struct {
+		command, flag, err string
+	}{
+		{"destroy", "env", `No such command "destroy"`},
+		{"deploy", "region", `No such flag -region for command "deploy"`},
+	} {
+		err := p.SetDefaultValue(test.command, test.flag, "x")
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Error should be %q but was %v.", test.err, err)
+		}
+	}
+	if err := p.SetDefaultValue("deploy", "port", "eighty"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err == nil || !strings.HasPrefix(err.Error(), `Invalid default "eighty" for flag -port of command "deploy"`) {
+		t.Errorf("An invalid default should fail the command but was %v.", err)
+	}
+}
+
+func TestSetDefaultValueMounted(t *testing.T) {
+	var opts deployOpts
+	p := NewPath()
+	p.Mount("app", "manages the app", newDeployPath(&opts))
+	if err := p.SetDefaultValue("app deploy", "env", "qa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("app", "deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "qa" {
+		t.Errorf("-env should be %q but was %q.", "qa", *opts.env)
+	}
+}
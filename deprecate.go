@@ -0,0 +1,115 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Marks the named flag as deprecated. The flag keeps working, but
+// setting it on the command line logs a warning including message, once
+// per command, see SetLogger. Deprecated flags are omitted
+// from the help output unless SetShowDeprecated is enabled.
+func (c *CmdCont) DeprecateFlag(name, message string) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.deprecated == nil {
+		c.deprecated = make(map[string]string)
+	}
+	c.deprecated[name] = message
+	c.changed()
+	return nil
+}
+
+// Deprecates the flag old in favor of the flag new. Setting old also
+// sets new. If old isn't defined by the command, it is registered as an
+// alias of new.
+func (c *CmdCont) RenameFlag(old, new string) error {
+	nf := c.FlagSet().Lookup(new)
+	if nf == nil {
+		return fmt.Errorf("No such flag -%s for command %q", new, c.Name)
+	}
+	if of := c.FlagSet().Lookup(old); of == nil {
+		if err := c.FlagAlias(new, old); err != nil {
+			return err
+		}
+	} else if _, ok := of.Value.(*mirrorValue); !ok {
+		of.Value = &mirrorValue{Value: of.Value, target: nf}
+	}
+	return c.DeprecateFlag(old, fmt.Sprintf("use %s instead", flagName(new)))
+}
+
+// Include deprecated flags in the help output.
+func (p *Path) SetShowDeprecated(show bool) {
+	p.showDeprecated = show
+}
+
+// Logs a warning to p for each deprecated flag set on the command line,
+// at most once per flag.
+func (c *CmdCont) warnDeprecated(p *Path) {
+	if len(c.deprecated) == 0 {
+		return
+	}
+	c.FlagSet().Visit(func(f *flag.Flag) {
+		message, ok := c.deprecated[f.Name]
+		if !ok || c.warned[f.Name] {
+			return
+		}
+		if c.warned == nil {
+			c.warned = make(map[string]bool)
+		}
+		c.warned[f.Name] = true
+		p.warn(c.Name, WarnDeprecatedFlag, "Flag %s is deprecated, %s", flagName(f.Name), message)
+	})
+}
+
+// Sets the target flag along with the wrapped value.
+type mirrorValue struct {
+	flag.Value
+	target *flag.Flag
+}
+
+func (v *mirrorValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	return v.target.Value.Set(s)
+}
+
+func (v *mirrorValue) String() string {
+	if v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func (v *mirrorValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (v *mirrorValue) IsRepeatable() bool {
+	return isRepeatable(v.Value)
+}
+
+func (v *mirrorValue) completions() []string {
+	return valueCompletions(v.Value)
+}
+
+func (v *mirrorValue) partner() string {
+	return v.target.Name
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newColorPath(colour, color *string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("show", "shows things", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			if colour != nil {
+				fs.StringVar(colour, "colour", "auto", "when to use colors")
+			}
+			fs.StringVar(color, "color", "auto", "when to use colors")
+		},
+	})
+	return p, c
+}
+
+func TestDeprecateFlagWarning(t *testing.T) {
+	var colour, color string
+	p, c := newColorPath(&colour, &color)
+	var out strings.Builder
+	p.SetOutput(&out)
+	if err := c.DeprecateFlag("colour", "use --color instead"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("show", "-color", "never"); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("Only deprecated flags should warn but got %q.", out.String())
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Run("show", "-colour", "never"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "Flag --colour is deprecated, use --color instead\n"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+	if colour != "never" {
+		t.Errorf("The deprecated flag should still work, but colour was %q.", colour)
+	}
+	if err := c.DeprecateFlag("missing", "gone"); err == nil {
+		t.Error("Deprecating an unknown flag should fail.")
+	}
+}
+
+func TestRenameFlag(t *testing.T) {
+	var colour, color string
+	// old flag still defined by the command
+	p, c := newColorPath(&colour, &color)
+	p.SetOutput(new(strings.Builder))
+	if err := c.RenameFlag("colour", "color"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("show", "-colour", "always"); err != nil {
+		t.Fatal(err)
+	}
+	if colour != "always" || color != "always" {
+		t.Errorf("Both flags should be set but were %q and %q.", colour, color)
+	}
+
+	// old flag no longer defined
+	color = ""
+	p, c = newColorPath(nil, &color)
+	var out strings.Builder
+	p.SetOutput(&out)
+	if err := c.RenameFlag("colour", "color"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("show", "--colour=always"); err != nil {
+		t.Fatal(err)
+	}
+	if color != "always" {
+		t.Errorf("color should be mirrored but was %q.", color)
+	}
+	if want := "Flag --colour is deprecated, use --color instead\n"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestDeprecatedFlagHelp(t *testing.T) {
+	var colour, color string
+	p, c := newColorPath(&colour, &color)
+	c.DeprecateFlag("colour", "use --color instead")
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if strings.Contains(out.String(), "colour") {
+		t.Errorf("Help should omit deprecated flags but was %q.", out.String())
+	}
+
+	p.SetShowDeprecated(true)
+	out.Reset()
+	p.WriteHelp(&out, c)
+	if !strings.Contains(out.String(), "--colour") {
+		t.Errorf("Help should include deprecated flags but was %q.", out.String())
+	}
+
+	var renamed string
+	p, c = newColorPath(nil, &renamed)
+	c.RenameFlag("colour", "color")
+	out.Reset()
+	p.WriteHelp(&out, c)
+	if strings.Contains(out.String(), "colour") {
+		t.Errorf("Help should omit renamed flags but was %q.", out.String())
+	}
+}
+
+func TestRenameFlagWrapsValue(t *testing.T) {
+	var tags, labels []string
+	p := NewPath()
+	c := p.Add("send", "sends a payload", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			StringSliceVar(fs, &tags, "tag", "tag to add")
+			StringSliceVar(fs, &labels, "label", "label to add")
+			fs.Duration("wait", 0, "time to wait")
+			fs.Duration("delay", 0, "time to wait")
+		},
+	})
+	if err := c.RenameFlag("tag", "label"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameFlag("wait", "delay"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"send": {"tag": ["a", "b"]}}}`)); err != nil {
+		t.Fatalf("Repeatable flags should accept arrays but failed: %v", err)
+	}
+	p.SetShowDeprecated(true)
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if !strings.Contains(out.String(), "-wait duration\n") {
+		t.Errorf("Help should name the type of the flag:\n%s", out.String())
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// A command as listed in generated documentation.
+type docCommand struct {
+	path *Path
+	cont *CmdCont
+	// the full command line name, e.g. "prog remote add"
+	name string
+}
+
+// Returns the commands of p and of the paths mounted below it, sorted
+// by name. Mounted paths are listed before their commands.
+func (p *Path) docCommands(prefix string) []docCommand {
+	var cmds []docCommand
+	r := p.registry()
+	for _, name := range r.names {
+		c := r.entries[name]
+		cmds = append(cmds, docCommand{path: p, cont: c, name: prefix + " " + name})
+		if c.sub != nil {
+			cmds = append(cmds, c.sub.docCommands(prefix+" "+name)...)
+		}
+	}
+	return cmds
+}
+
+// Returns the entry of c in the docCommands of p.
+func (p *Path) docCommand(c *CmdCont) (docCommand, bool) {
+	for _, d := range p.docCommands("") {
+		if d.cont == c {
+			return d, true
+		}
+	}
+	return docCommand{}, false
+}
+
+func (d docCommand) usage() string {
+	if d.cont.sub != nil {
+		return d.name + " [flags] command"
+	}
+	if args := d.cont.argsUsage(); args != "" {
+		return d.name + " [flags] " + args
+	}
+	return d.name + " [flags]"
+}
+
+// Returns the global and persistent flags of p.
+func (p *Path) globalFlags() []helpFlag {
+	flags := collectFlags(p.Flags, p.hideGlobalFlag)
+	if p.persistent != nil {
+		flags = append(flags, collectFlags(p.persistent, nil)...)
+	}
+	return flags
+}
+
+// Writes the documentation of all commands of the program prog in
+// Markdown, one section per command with the same flag sections as
+// the help output.
+func (p *Path) WriteMarkdown(w io.Writer, prog string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", prog)
+	if flags := p.globalFlags(); len(flags) > 0 {
+		b.WriteString("\n## Global flags\n\n")
+		writeMarkdownFlags(&b, flags)
+	}
+	for _, d := range p.docCommands(prog) {
+		fmt.Fprintf(&b, "\n## %s\n", d.name)
+		if d.cont.Desc != "" {
+			fmt.Fprintf(&b, "\n%s\n", d.cont.Desc)
+		}
+		if long, _ := d.cont.longText(); long != "" {
+			fmt.Fprintf(&b, "\n%s\n", long)
+		}
+		fmt.Fprintf(&b, "\n    %s\n", d.usage())
+		for _, s := range d.path.flagSections(d.cont) {
+			fmt.Fprintf(&b, "\n### %s\n\n", s.title)
+			writeMarkdownFlags(&b, s.flags)
+		}
+		if reqs := d.cont.namedRequirements(); len(reqs) > 0 {
+			b.WriteString("\n### Requirements\n\n")
+			for _, r := range reqs {
+				fmt.Fprintf(&b, "- %s", r.Name)
+				if r.Hint != "" {
+					fmt.Fprintf(&b, ": %s", r.Hint)
+				}
+				b.WriteString("\n")
+			}
+		}
+		if env := d.cont.envEntries(); len(env) > 0 {
+			b.WriteString("\n### Environment\n\n")
+			for _, e := range env {
+				fmt.Fprintf(&b, "- `%s`", e.name)
+				if e.flag != "" {
+					fmt.Fprintf(&b, " (`%s`)", e.flag)
+				}
+				if desc := e.description(); desc != "" {
+					fmt.Fprintf(&b, ": %s", desc)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownFlags(b *strings.Builder, flags []helpFlag) {
+	for _, f := range flags {
+		name := strings.Join(f.names, ", ")
+		if f.typ != "" {
+			name += " " + f.typ
+		}
+		fmt.Fprintf(b, "- `%s`: %s", name, strings.Replace(f.usage, "\n", " ", -1))
+		if def := f.defValue(); def != "" {
+			fmt.Fprintf(b, " (default %s)", def)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Writes the documentation of all commands of the program prog in
+// reStructuredText, like WriteMarkdown.
+func (p *Path) WriteReST(w io.Writer, prog string) error {
+	var b strings.Builder
+	writeReSTTitle(&b, prog, '=')
+	if flags := p.globalFlags(); len(flags) > 0 {
+		b.WriteString("\n")
+		writeReSTTitle(&b, "Global flags", '-')
+		writeReSTFlags(&b, flags)
+	}
+	for _, d := range p.docCommands(prog) {
+		b.WriteString("\n")
+		writeReSTTitle(&b, d.name, '-')
+		if d.cont.Desc != "" {
+			fmt.Fprintf(&b, "\n%s\n", reSTEscape(d.cont.Desc))
+		}
+		fmt.Fprintf(&b, "\n::\n\n    %s\n", d.usage())
+		for _, s := range d.path.flagSections(d.cont) {
+			b.WriteString("\n")
+			writeReSTTitle(&b, s.title, '~')
+			writeReSTFlags(&b, s.flags)
+		}
+		if reqs := d.cont.namedRequirements(); len(reqs) > 0 {
+			b.WriteString("\n")
+			writeReSTTitle(&b, "Requirements", '~')
+			b.WriteString("\n")
+			for _, r := range reqs {
+				fmt.Fprintf(&b, "- %s", reSTEscape(r.Name))
+				if r.Hint != "" {
+					fmt.Fprintf(&b, ": %s", reSTEscape(r.Hint))
+				}
+				b.WriteString("\n")
+			}
+		}
+		if env := d.cont.envEntries(); len(env) > 0 {
+			b.WriteString("\n")
+			writeReSTTitle(&b, "Environment", '~')
+			b.WriteString("\n")
+			for _, e := range env {
+				fmt.Fprintf(&b, "- ``%s``", e.name)
+				if e.flag != "" {
+					fmt.Fprintf(&b, " (``%s``)", e.flag)
+				}
+				if desc := e.description(); desc != "" {
+					fmt.Fprintf(&b, ": %s", reSTEscape(desc))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeReSTTitle(b *strings.Builder, title string, underline rune) {
+	fmt.Fprintf(b, "%s\n%s\n", title, strings.Repeat(string(underline), utf8.RuneCountInString(title)))
+}
+
+func writeReSTFlags(b *strings.Builder, flags []helpFlag) {
+	for _, f := range flags {
+		name := strings.Join(f.names, ", ")
+		if f.typ != "" {
+			name += " " + f.typ
+		}
+		fmt.Fprintf(b, "\n``%s``\n    %s", name, reSTEscape(strings.Replace(f.usage, "\n", " ", -1)))
+		if def := f.defValue(); def != "" {
+			fmt.Fprintf(b, " (default %s)", reSTEscape(def))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Escapes the characters of s starting inline markup.
+func reSTEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\*`|_", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Writes a man page in section 1 documenting all commands of the
+// program prog, with the same flag sections as the help output.
+func (p *Path) WriteMan(w io.Writer, prog string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %q 1\n", strings.ToUpper(prog))
+	fmt.Fprintf(&b, ".SH NAME\n%s\n", roffEscape(prog))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n\\fB%s\\fR [\\fIflags\\fR] \\fIcommand\\fR [\\fIflags\\fR] [\\fIargs\\fR]\n", roffEscape(prog))
+	if flags := p.globalFlags(); len(flags) > 0 {
+		b.WriteString(".SH GLOBAL FLAGS\n")
+		writeManFlags(&b, flags)
+	}
+	if cmds := p.docCommands(prog); len(cmds) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, d := range cmds {
+			fmt.Fprintf(&b, ".SS %q\n", d.name)
+			if d.cont.Desc != "" {
+				fmt.Fprintf(&b, "%s\n", roffEscape(d.cont.Desc))
+			}
+			fmt.Fprintf(&b, ".PP\n\\fB%s\\fR\n", roffEscape(d.usage()))
+			for _, s := range d.path.flagSections(d.cont) {
+				fmt.Fprintf(&b, ".PP\n\\fB%s:\\fR\n", roffEscape(s.title))
+				writeManFlags(&b, s.flags)
+			}
+			if reqs := d.cont.namedRequirements(); len(reqs) > 0 {
+				b.WriteString(".PP\n\\fBRequirements:\\fR\n")
+				for _, r := range reqs {
+					fmt.Fprintf(&b, ".TP\n%s\n%s\n", roffEscape(r.Name), roffEscape(r.Hint))
+				}
+			}
+			if env := d.cont.envEntries(); len(env) > 0 {
+				b.WriteString(".PP\n\\fBEnvironment:\\fR\n")
+				for _, e := range env {
+					fmt.Fprintf(&b, ".TP\n\\fB%s\\fR", roffEscape(e.name))
+					if e.flag != "" {
+						fmt.Fprintf(&b, " (\\fB%s\\fR)", roffEscape(e.flag))
+					}
+					fmt.Fprintf(&b, "\n%s\n", roffEscape(e.description()))
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeManFlags(b *strings.Builder, flags []helpFlag) {
+	for _, f := range flags {
+		names := make([]string, len(f.names))
+		for i, name := range f.names {
+			names[i] = "\\fB" + roffEscape(name) + "\\fR"
+		}
+		fmt.Fprintf(b, ".TP\n%s", strings.Join(names, ", "))
+		if f.typ != "" {
+			fmt.Fprintf(b, " \\fI%s\\fR", roffEscape(f.typ))
+		}
+		b.WriteString("\n" + roffEscape(f.usage))
+		if def := f.defValue(); def != "" {
+			fmt.Fprintf(b, " (default %s)", roffEscape(def))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Escapes s for use as roff text: backslashes and dashes are escaped
+// and lines starting with a control character are protected.
+func roffEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = "\\&" + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	p, _ := newGroupPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	var out strings.Builder
+	if err := p.WriteMarkdown(&out, "app"); err != nil {
+		t.Fatal(err)
+	}
+	want := `# app
+
+## Global flags
+
+- '--verbose': verbose output
+
+## app deploy
+
+deploys the app
+
+    app deploy [flags]
+
+### Connection options
+
+- '--host string': server host (default "localhost")
+- '--port int': server port (default 80)
+
+### Output options
+
+- '--format string': output format (default "text")
+- '-q, --quiet': suppress output
+
+### Other flags
+
+- '--env string': target environment
+- '--force': skip checks
+`
+	// raw strings can't contain backquotes
+	want = strings.Replace(want, "'", "`", -1)
+	if out.String() != want {
+		t.Errorf("Markdown should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	p, _ := newGroupPath()
+	remote := NewPath()
+	remote.Add("add", "adds a remote", CmdFunc(func(args []string) error { return nil }))
+	p.Mount("remote", "manages remotes", remote)
+	var out strings.Builder
+	if err := p.WriteMan(&out, "app"); err != nil {
+		t.Fatal(err)
+	}
+	man := out.String()
+	for _, want := range []string{
+		".TH \"APP\" 1\n",
+		".SS \"app deploy\"\ndeploys the app\n",
+		".PP\n\\fBConnection options:\\fR\n.TP\n\\fB\\-\\-host\\fR \\fIstring\\fR\nserver host (default \"localhost\")\n",
+		".PP\n\\fBOther flags:\\fR\n",
+		".SS \"app remote add\"\n",
+	} {
+		if !strings.Contains(man, want) {
+			t.Errorf("Man page should contain %q:\n%s", want, man)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Registers the command doctor, which checks the requirements of the
+// commands of p and of the paths mounted to it without running them,
+// see Requires. It prints a report per command that declares
+// requirements, such as
+//
+//	deploy
+//	  ok    docker on $PATH
+//	  FAIL  $KUBECONFIG set: not set
+//	        export KUBECONFIG=~/.kube/config
+//
+// and fails if a requirement of a command that isn't hidden isn't met.
+// Unmet requirements of hidden commands are reported but tolerated,
+// disabled commands aren't checked at all.
+// With --command NAME only the command of that name is checked, such
+// as "remote add", or the commands of the mounted path of that name.
+func (p *Path) AddDoctorCommand() *CmdCont {
+	return p.Add("doctor", "checks the requirements of the commands", &doctorCmd{p: p})
+}
+
+type doctorCmd struct {
+	p       *Path
+	command string
+}
+
+func (c *doctorCmd) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.command, "command", "", "only check the command or path of this name")
+}
+
+func (c *doctorCmd) Run(args ...string) error {
+	w := c.p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	var names []string
+	var hidden []bool
+	matched := c.command == ""
+	checked, failed := 0, 0
+	err := c.p.Walk(func(cont *CmdCont, depth int) error {
+		names = append(names[:depth], cont.Name)
+		hidden = append(hidden[:depth], cont.hiddenCmd || depth > 0 && hidden[depth-1])
+		name := strings.Join(names, " ")
+		if c.command != "" && name != c.command && !strings.HasPrefix(name, c.command+" ") {
+			if !strings.HasPrefix(c.command, name+" ") {
+				return SkipPath
+			}
+			return nil
+		}
+		matched = true
+		if enabled, _ := cont.Enabled(); !enabled {
+			// disabled commands can't be run anyway
+			return SkipPath
+		}
+		if len(cont.requirements) == 0 {
+			return nil
+		}
+		n, err := writeDoctorReport(w, name, cont, hidden[depth])
+		checked += len(cont.requirements)
+		if !hidden[depth] {
+			failed += n
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("No such command %q", c.command)
+	}
+	if checked == 0 {
+		_, err := fmt.Fprintln(w, "No requirements declared.")
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d requirements not met", failed, checked)
+	}
+	return nil
+}
+
+// Checks the requirements of cont and writes the results to w. Returns
+// the number of requirements that aren't met.
+func writeDoctorReport(w io.Writer, name string, cont *CmdCont, hidden bool) (int, error) {
+	if hidden {
+		name += " (hidden)"
+	}
+	if _, err := fmt.Fprintln(w, name); err != nil {
+		return 0, err
+	}
+	failed := 0
+	for _, r := range cont.requirements {
+		line := strings.TrimRight("  ok    "+r.Name, " ")
+		if err := r.Check(); err != nil {
+			failed++
+			line = "  FAIL  " + requirementMessage(r, err)
+			if r.Hint != "" {
+				line += "\n        " + r.Hint
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
+}
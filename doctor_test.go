@@ -0,0 +1,105 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var (
+	passing = Requirement{Name: "git on $PATH", Check: func() error { return nil }}
+	failing = Requirement{
+		Name:  "$TOKEN set",
+		Hint:  "export TOKEN=<your API token>",
+		Check: func() error { return errors.New("not set") },
+	}
+)
+
+func newDoctorPath() *Path {
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{}).Requires(passing)
+	p.Add("debug", "dumps the state", &testCmd{}).Hide()
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{}).Requires(passing, failing)
+	remote.Add("list", "lists the remotes", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	p.AddDoctorCommand()
+	return p
+}
+
+func runDoctor(p *Path, args ...string) (string, error) {
+	var out strings.Builder
+	p.stdout = &out
+	_, err := p.Run(append([]string{"doctor"}, args...)...)
+	return out.String(), err
+}
+
+func TestDoctor(t *testing.T) {
+	p := newDoctorPath()
+	out, err := runDoctor(p)
+	want := "remote add\n" +
+		"  ok    git on $PATH\n" +
+		"  FAIL  $TOKEN set: not set\n" +
+		"        export TOKEN=<your API token>\n" +
+		"status\n" +
+		"  ok    git on $PATH\n"
+	if out != want {
+		t.Errorf("The report should be %q but was %q.", want, out)
+	}
+	if err == nil || err.Error() != "1 of 3 requirements not met" {
+		t.Errorf("The doctor should fail with \"1 of 3 requirements not met\" but failed with %v.", err)
+	}
+}
+
+func TestDoctorFilter(t *testing.T) {
+	p := newDoctorPath()
+	for _, test := range []struct {
+		command, out string
+		fail         bool
+	}{
+		{"status", "status\n  ok    git on $PATH\n", false},
+		{"remote", "remote add\n", true},
+		{"remote add", "remote add\n", true},
+		{"remote list", "No requirements declared.\n", false},
+	} {
+		out, err := runDoctor(p, "--command", test.command)
+		if !strings.HasPrefix(out, test.out) {
+			t.Errorf("The report for %q should start with %q but was %q.", test.command, test.out, out)
+		}
+		if (err != nil) != test.fail {
+			t.Errorf("The doctor for %q should fail: %v, but failed with %v.", test.command, test.fail, err)
+		}
+	}
+	if _, err := runDoctor(p, "--command", "remote push"); err == nil || err.Error() != `No such command "remote push"` {
+		t.Errorf("An unknown command should fail but failed with %v.", err)
+	}
+}
+
+func TestDoctorHidden(t *testing.T) {
+	p := NewPath()
+	c := p.Add("debug", "dumps the state", &testCmd{})
+	c.Requires(failing)
+	c.Hide()
+	p.AddDoctorCommand()
+	out, err := runDoctor(p)
+	if err != nil {
+		t.Errorf("Unmet requirements of hidden commands should be tolerated but failed with %v.", err)
+	}
+	if !strings.HasPrefix(out, "debug (hidden)\n  FAIL  $TOKEN set: not set\n") {
+		t.Errorf("The report should include the hidden command but was %q.", out)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Registers the hidden command __dump, which prints where the flags
+// of a command would take their values from, for troubleshooting a
+// flag that doesn't take effect:
+//
+//	app __dump remote add
+//
+// It names the call site registering the command, see RegisteredAt.
+// For the global flags of p, of the paths the command is mounted to
+// and for the flags of the command it lists the default, the bound
+// environment variable and its value, the configured value and the
+// resulting value and Source as Run resolves them when the flag isn't
+// given on the command line. Secret values are redacted. The report is
+// written as text or, with --output json or yaml, as a document, see
+// OutputFlag. Without a command name only the global flags are listed.
+func (p *Path) AddDumpCommand() *CmdCont {
+	c := p.Add(dumpCmdName, "prints the sources of the flag values of a command", &dumpCmd{p: p})
+	c.Hide()
+	c.OutputFlag()
+	return c
+}
+
+const dumpCmdName = "__dump"
+
+type dumpCmd struct {
+	p *Path
+}
+
+func (c *dumpCmd) Flags(fs *flag.FlagSet) {
+}
+
+// Writes the report as text. Run renders it through RunResulting in
+// the format selected by --output instead.
+func (c *dumpCmd) Run(args ...string) error {
+	d, err := c.RunResulting(args...)
+	if err != nil {
+		return err
+	}
+	w := c.p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err = fmt.Fprintln(w, d)
+	return err
+}
+
+func (c *dumpCmd) RunResulting(args ...string) (interface{}, error) {
+	d := &flagDump{Command: strings.Join(args, " ")}
+	q := c.p
+	d.addPath(q, "")
+	for i, name := range args {
+		cont := q.Lookup(name)
+		if cont == nil {
+			return nil, fmt.Errorf("No such command %q", strings.Join(args[:i+1], " "))
+		}
+		d.RegisteredAt = cont.RegisteredAt()
+		if cont.sub != nil {
+			q = cont.sub
+			d.addPath(q, " of "+strings.Join(args[:i+1], " "))
+			continue
+		}
+		if i < len(args)-1 {
+			return nil, fmt.Errorf("Command %q has no subcommands", strings.Join(args[:i+1], " "))
+		}
+		d.add("flags of "+d.Command, cont.FlagSet(), cont.env, q.config.commands[cont.Name], cont.isSecret)
+	}
+	return d, nil
+}
+
+// Returns where f takes its value from without a value on the command
+// line, following the precedence of Source.
+func dumpFlag(f *flag.Flag, envVar string, config map[string]configValue, secret bool) dumpedFlag {
+	d := dumpedFlag{Name: f.Name, Default: f.DefValue, Value: f.DefValue, Source: SourceDefault.String(), Env: envVar}
+	if v, ok := config[f.Name]; ok {
+		s := strings.Join(v.values, ",")
+		d.Config = &s
+		d.Value, d.Source = s, SourceConfig.String()
+	}
+	if envVar != "" {
+		if v, ok := os.LookupEnv(envVar); ok {
+			d.EnvValue = &v
+			d.Value, d.Source = v, SourceEnv.String()
+		}
+	}
+	if secret {
+		for _, s := range []*string{&d.Default, &d.Value, d.EnvValue, d.Config} {
+			if s != nil && *s != "" {
+				*s = redacted
+			}
+		}
+	}
+	return d
+}
+
+// The report of the __dump command.
+type flagDump struct {
+	Command      string            `json:"command"`
+	RegisteredAt string            `json:"registeredAt,omitempty"`
+	Sections     []flagDumpSection `json:"sections"`
+}
+
+type flagDumpSection struct {
+	Title string       `json:"title"`
+	Flags []dumpedFlag `json:"flags"`
+}
+
+type dumpedFlag struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Source   string  `json:"source"`
+	Default  string  `json:"default"`
+	Env      string  `json:"env,omitempty"`
+	EnvValue *string `json:"envValue,omitempty"`
+	Config   *string `json:"config,omitempty"`
+}
+
+// Adds the global and persistent flags of q.
+func (d *flagDump) addPath(q *Path, of string) {
+	d.add("global flags"+of, q.Flags, q.env, q.config.global, nil)
+	if q.persistent != nil {
+		d.add("persistent flags"+of, q.persistent, nil, nil, nil)
+	}
+}
+
+// Adds a section listing the flags of fs unless it has none.
+func (d *flagDump) add(title string, fs *flag.FlagSet, env map[string]string, config map[string]configValue, secret func(*flag.Flag) bool) {
+	s := flagDumpSection{Title: title}
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*aliasValue); !ok {
+			s.Flags = append(s.Flags, dumpFlag(f, env[f.Name], config, secret != nil && secret(f)))
+		}
+	})
+	if len(s.Flags) > 0 {
+		d.Sections = append(d.Sections, s)
+	}
+}
+
+func (d *flagDump) String() string {
+	var b strings.Builder
+	if d.RegisteredAt != "" {
+		fmt.Fprintf(&b, "%s: registered at %s\n\n", d.Command, d.RegisteredAt)
+	}
+	for i, s := range d.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:\n", s.Title)
+		for _, f := range s.Flags {
+			fmt.Fprintf(&b, "  -%s = %q (%s)\n", f.Name, f.Value, f.Source)
+			details := []string{"default " + strconv.Quote(f.Default)}
+			if f.Env != "" && f.EnvValue != nil {
+				details = append(details, fmt.Sprintf("$%s = %q", f.Env, *f.EnvValue))
+			} else if f.Env != "" {
+				details = append(details, fmt.Sprintf("$%s unset", f.Env))
+			}
+			if f.Config != nil {
+				details = append(details, "config "+strconv.Quote(*f.Config))
+			}
+			fmt.Fprintf(&b, "      %s\n", strings.Join(details, ", "))
+		}
+	}
+	if len(d.Sections) == 0 {
+		b.WriteString("No flags defined.\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
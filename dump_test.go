@@ -0,0 +1,116 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newDumpPath(t *testing.T) *Path {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	p.BindEnv("verbose", "TEST_DUMP_VERBOSE")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "dev", "target environment")
+			fs.Int("port", 80, "port to listen on")
+			fs.String("token", "", "API token")
+			fs.Bool("dry-run", false, "only print the changes")
+		},
+	})
+	c.BindEnv("env", "TEST_DUMP_ENV")
+	c.BindEnv("token", "TEST_DUMP_TOKEN")
+	c.MarkSecret("token")
+	p.AddDumpCommand()
+	err := p.LoadConfigJSON(strings.NewReader(`{
+		"global": {"verbose": true},
+		"commands": {"deploy": {"env": "prod", "port": 8080, "token": "c0nf1g"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_DUMP_ENV", "staging")
+	t.Setenv("TEST_DUMP_TOKEN", "s3cr3t")
+	return p
+}
+
+func TestDump(t *testing.T) {
+	p := newDumpPath(t)
+	out := runRendered(t, p, "__dump", "deploy")
+	want := "deploy: registered at " + p.Lookup("deploy").RegisteredAt() + `
+
+global flags:
+  -verbose = "true" (config)
+      default "false", $TEST_DUMP_VERBOSE unset, config "true"
+
+flags of deploy:
+  -dry-run = "false" (default)
+      default "false"
+  -env = "staging" (env)
+      default "dev", $TEST_DUMP_ENV = "staging", config "prod"
+  -port = "8080" (config)
+      default "80", config "8080"
+  -token = "****" (env)
+      default "", $TEST_DUMP_TOKEN = "****", config "****"
+`
+	if out != want {
+		t.Errorf("Dump should be %q but was %q.", want, out)
+	}
+	if strings.Contains(out, "s3cr3t") || strings.Contains(out, "c0nf1g") {
+		t.Errorf("Dump should not contain the secret but was %q.", out)
+	}
+	if !p.Lookup("__dump").Hidden() {
+		t.Error("__dump should be a hidden command.")
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	p := newDumpPath(t)
+	var d flagDump
+	if err := json.Unmarshal([]byte(runRendered(t, p, "__dump", "--output", "json", "deploy")), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Sections) != 2 || d.Command != "deploy" || !strings.Contains(d.RegisteredAt, "dump_test.go:") {
+		t.Fatalf("Dump should list the global flags and the flags of deploy but was %+v.", d)
+	}
+	sources := make(map[string]string)
+	for _, f := range d.Sections[1].Flags {
+		sources[f.Name] = f.Source
+	}
+	for name, want := range map[string]string{"dry-run": "default", "env": "env", "port": "config", "token": "env"} {
+		if sources[name] != want {
+			t.Errorf("Source of -%s should be %q but was %q.", name, want, sources[name])
+		}
+	}
+	if env := d.Sections[1].Flags[1]; env.Env != "TEST_DUMP_ENV" || *env.EnvValue != "staging" || *env.Config != "prod" {
+		t.Errorf("Dump of -env should list its environment variable and configured value but was %+v.", env)
+	}
+}
+
+func TestDumpErrors(t *testing.T) {
+	p := newDumpPath(t)
+	for _, args := range [][]string{{"destroy"}, {"deploy", "now"}} {
+		if _, err := p.Run(append([]string{"__dump"}, args...)...); err == nil {
+			t.Errorf("__dump %s should fail.", strings.Join(args, " "))
+		}
+		p.ResetFlags()
+	}
+	if out := runRendered(t, p, "__dump"); !strings.HasPrefix(out, "global flags:\n") || strings.Contains(out, "deploy") {
+		t.Errorf("Dump without a command should only list the global flags but was %q.", out)
+	}
+}
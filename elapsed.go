@@ -0,0 +1,71 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defines the global flag --time, which makes Run report how long the
+// command took to Output once it returns, such as
+//
+//	remote add completed in 1.2s
+//	backup failed after 3m12s
+//
+// Invocations failing before a command runs, such as for invalid
+// flags, aren't reported. The report is written by an OnComplete
+// callback, so mounted paths report for the enclosing path.
+func (p *Path) TimeFlag() {
+	enabled := p.Flags.Bool("time", false, "report the time the command took")
+	p.OnComplete(func(c *CmdCont, d time.Duration, err error) {
+		if !*enabled || c == nil || errors.Is(err, ErrCmdUsage) || errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(p.Output(), "%s failed after %s\n", c.CommandPath(), humanDuration(d))
+		} else {
+			fmt.Fprintf(p.Output(), "%s completed in %s\n", c.CommandPath(), humanDuration(d))
+		}
+	})
+}
+
+// Formats d in milliseconds below a second, such as 450ms, else in
+// hours, minutes and seconds leaving out zero units, such as 1.2s, 45s,
+// 3m12s or 2h5m.
+func humanDuration(d time.Duration) string {
+	if d < time.Second {
+		return strconv.FormatInt(d.Round(time.Millisecond).Milliseconds(), 10) + "ms"
+	}
+	if d < 10*time.Second {
+		return strconv.FormatFloat(d.Round(100*time.Millisecond).Seconds(), 'f', -1, 64) + "s"
+	}
+	d = d.Round(time.Second)
+	var b strings.Builder
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{{time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}} {
+		if n := d / unit.d; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10) + unit.name)
+			d -= n * unit.d
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ms"},
+		{450 * time.Millisecond, "450ms"},
+		{999 * time.Millisecond, "999ms"},
+		{1200 * time.Millisecond, "1.2s"},
+		{time.Second, "1s"},
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 12*time.Second, "3m12s"},
+		{3 * time.Minute, "3m"},
+		{2*time.Hour + 5*time.Minute + 400*time.Millisecond, "2h5m"},
+	}
+	for _, test := range tests {
+		if s := humanDuration(test.d); s != test.want {
+			t.Errorf("%v should be formatted as %q but was %q.", test.d, test.want, s)
+		}
+	}
+}
+
+func TestTimeFlag(t *testing.T) {
+	defer func(fn func() time.Time) { now = fn }(now)
+	clock := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		clock = clock.Add(96 * time.Second)
+		return clock
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"backup"}, ""},
+		{[]string{"-time", "backup"}, "backup completed in 1m36s\n"},
+		{[]string{"-time", "backup", "fail"}, "backup failed after 1m36s\n"},
+		{[]string{"-time", "backup", "-bogus"}, ""},
+		{[]string{"-time", "destroy"}, ""},
+		{[]string{"-time", "remote", "add"}, "remote add completed in 1m36s\n"},
+	}
+	for _, test := range tests {
+		p := NewPath()
+		p.TimeFlag()
+		var out, stdout strings.Builder
+		p.SetOutput(&out)
+		p.Add("backup", "backs up the data", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.SetOutput(io.Discard)
+			},
+			run: func(args ...string) error {
+				if len(args) > 0 {
+					return errors.New("disk full")
+				}
+				return nil
+			},
+		})
+		remote := NewPath()
+		remote.Add("add", "adds a remote", &testCmd{})
+		p.Mount("remote", "manages remotes", remote)
+		p.RunContext(WithEnv(t.Context(), Env{Stdout: &stdout}), test.args...)
+		if out.String() != test.want {
+			t.Errorf("%q should report %q but was %q.", test.args, test.want, out.String())
+		}
+		if stdout.Len() > 0 {
+			t.Errorf("%q should not report to stdout but wrote %q.", test.args, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// Makes the command available only while enabled returns true, such as
+// on certain platforms or with a licensed feature. enabled is called
+// whenever the command is listed, completed or run, not when it is
+// registered, and returns why the command is disabled along with
+// false. A disabled command is left out of the list of commands and of
+// completion, and running it fails with a DisabledCommandError.
+func (c *CmdCont) EnabledIf(enabled func() (bool, string)) {
+	c.enabled = enabled
+}
+
+// Reports whether the command is enabled and why not, see EnabledIf
+// and Feature.
+func (c *CmdCont) Enabled() (bool, string) {
+	if !c.featureEnabled() {
+		return false, fmt.Sprintf("feature %q is disabled", c.feature)
+	}
+	if c.enabled == nil {
+		return true, ""
+	}
+	return c.enabled()
+}
+
+// Reports whether the command is listed and completed, which hidden
+// and disabled commands aren't.
+func (c *CmdCont) listed() bool {
+	if c.hiddenCmd {
+		return false
+	}
+	ok, _ := c.Enabled()
+	return ok
+}
+
+// Returned by Run for commands that are disabled, see EnabledIf.
+type DisabledCommandError struct {
+	Command string
+	// why the command is disabled, may be empty
+	Reason string
+}
+
+func (e *DisabledCommandError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s: command is disabled", e.Command)
+	}
+	return fmt.Sprintf("%s: command is disabled: %s", e.Command, e.Reason)
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEnabledIf(t *testing.T) {
+	licensed, calls := false, 0
+	ran := false
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	c := p.Add("audit", "audits the cluster", &testCmd{run: func(args ...string) error {
+		ran = true
+		return nil
+	}})
+	c.EnabledIf(func() (bool, string) {
+		calls++
+		return licensed, "requires an enterprise license"
+	})
+	if calls != 0 {
+		t.Errorf("The predicate should not be called when it is set but was called %d times.", calls)
+	}
+
+	var out strings.Builder
+	p.writeAvailableCommands(&out)
+	if strings.Contains(out.String(), "audit") {
+		t.Errorf("The list of commands should omit the disabled command but was %q.", out.String())
+	}
+	if got := strings.Join(p.Complete(""), " "); got != "status" {
+		t.Errorf("Completion should omit the disabled command but was %q.", got)
+	}
+	_, err := p.Run("audit")
+	var e *DisabledCommandError
+	if !errors.As(err, &e) || e.Reason != "requires an enterprise license" {
+		t.Fatalf("Run should fail with a DisabledCommandError but failed with %v.", err)
+	}
+	if want := "audit: command is disabled: requires an enterprise license"; err.Error() != want {
+		t.Errorf("The error should be %q but was %q.", want, err.Error())
+	}
+	if ran {
+		t.Error("The disabled command should not run.")
+	}
+
+	licensed = true
+	out.Reset()
+	p.writeAvailableCommands(&out)
+	if !strings.Contains(out.String(), "\taudit\taudits the cluster\n") {
+		t.Errorf("The list of commands should include the enabled command but was %q.", out.String())
+	}
+	if got := strings.Join(p.Complete(""), " "); got != "audit status" {
+		t.Errorf("Completion should include the enabled command but was %q.", got)
+	}
+	if _, err := p.Run("audit"); err != nil || !ran {
+		t.Errorf("The enabled command should run but failed with %v.", err)
+	}
+}
+
+func TestEnabledIfMounted(t *testing.T) {
+	enabled := false
+	p := NewPath()
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote).EnabledIf(func() (bool, string) { return enabled, "" })
+	if got := p.Complete("remote", ""); len(got) != 0 {
+		t.Errorf("The commands of a disabled path should not be completed but were %q.", got)
+	}
+	if _, err := p.Run("remote", "add"); err == nil || err.Error() != "remote: command is disabled" {
+		t.Errorf("The commands of a disabled path should fail but failed with %v.", err)
+	}
+	var out strings.Builder
+	if err := p.WriteTree(&out, TreeOptions{Hidden: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "remote [disabled]\n  add\n"; out.String() != want {
+		t.Errorf("The tree should be %q but was %q.", want, out.String())
+	}
+	enabled = true
+	if _, err := p.Run("remote", "add"); err != nil {
+		t.Errorf("The commands of an enabled path should run but failed with %v.", err)
+	}
+}
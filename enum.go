@@ -0,0 +1,74 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Defines a string flag with specified name, default value, and usage
+// string which only accepts one of the given choices. The argument p
+// points to a string variable in which to store the value of the flag.
+// The choices are appended to the usage string, other values are
+// rejected at parse time.
+func EnumVar(fs *flag.FlagSet, p *string, name, value string, choices []string, usage string) {
+	enumVar(fs, p, name, value, choices, usage, false)
+}
+
+// Same as EnumVar but matches the choices case-insensitively. The
+// choice is stored as spelled in choices.
+func EnumVarFold(fs *flag.FlagSet, p *string, name, value string, choices []string, usage string) {
+	enumVar(fs, p, name, value, choices, usage, true)
+}
+
+func enumVar(fs *flag.FlagSet, p *string, name, value string, choices []string, usage string, fold bool) {
+	*p = value
+	usage = fmt.Sprintf("%s (%s)", usage, strings.Join(choices, "|"))
+	fs.Var(&enumValue{p: p, choices: choices, fold: fold}, name, usage)
+}
+
+type enumValue struct {
+	p       *string
+	choices []string
+	fold    bool
+}
+
+func (v *enumValue) Set(s string) error {
+	for _, c := range v.choices {
+		if s == c || v.fold && strings.EqualFold(s, c) {
+			*v.p = c
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(v.choices, ", "))
+}
+
+func (v *enumValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *enumValue) Get() interface{} {
+	return *v.p
+}
+
+// The values offered for completion.
+func (v *enumValue) completions() []string {
+	return v.choices
+}
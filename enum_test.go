@@ -0,0 +1,78 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestEnum(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var format string
+	EnumVar(fs, &format, "format", "text", []string{"text", "json", "yaml"}, "output format")
+	if format != "text" {
+		t.Errorf("format should default to %q but was %q.", "text", format)
+	}
+	if err := fs.Parse([]string{"-format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if format != "json" {
+		t.Errorf("format should be %q but was %q.", "json", format)
+	}
+}
+
+func TestEnumInvalid(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	var format string
+	EnumVar(fs, &format, "format", "text", []string{"text", "json", "yaml"}, "output format")
+	err := fs.Parse([]string{"-format", "JSON"})
+	if err == nil {
+		t.Fatal("Values other than the choices should fail.")
+	}
+	if !strings.Contains(err.Error(), "must be one of text, json, yaml") {
+		t.Errorf("Error should list the choices but was %q.", err)
+	}
+	if format != "text" {
+		t.Errorf("format should keep its default but was %q.", format)
+	}
+}
+
+func TestEnumFold(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var format string
+	EnumVarFold(fs, &format, "format", "text", []string{"text", "json", "yaml"}, "output format")
+	if err := fs.Parse([]string{"-format", "JSON"}); err != nil {
+		t.Fatal(err)
+	}
+	if format != "json" {
+		t.Errorf("format should be stored as %q but was %q.", "json", format)
+	}
+}
+
+func TestEnumHelp(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	var format string
+	EnumVar(fs, &format, "format", "text", []string{"text", "json", "yaml"}, "output format")
+	fs.PrintDefaults()
+	want := "  -format value\n    \toutput format (text|json|yaml) (default text)\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Documents the environment variable name, which the command reads
+// itself, with desc in the Environment section of its help and of the
+// generated documentation. Variables bound to flags with BindEnv or
+// AutoEnv and those required with EnvSet are listed without it, desc
+// replaces their description.
+func (c *CmdCont) EnvDoc(name, desc string) {
+	if c.envDocs == nil {
+		c.envDocs = make(map[string]string)
+	}
+	c.envDocs[name] = desc
+	c.changed()
+}
+
+// An environment variable as listed in the Environment section.
+type envEntry struct {
+	name string
+	// the flag it is bound to, formatted as on the command line, or ""
+	flag     string
+	desc     string
+	required bool
+}
+
+// Returns the description of e with a note if it is required.
+func (e envEntry) description() string {
+	if !e.required {
+		return e.desc
+	}
+	if e.desc == "" {
+		return "required"
+	}
+	return e.desc + " (required)"
+}
+
+// Returns the environment variables influencing c sorted by name: those
+// bound to flags not hidden from the help, required by a requirement or
+// documented with EnvDoc.
+func (c *CmdCont) envEntries() []envEntry {
+	if len(c.env) == 0 && len(c.envDocs) == 0 && len(c.requirements) == 0 {
+		return nil
+	}
+	entries := make(map[string]*envEntry)
+	entry := func(name string) *envEntry {
+		e, ok := entries[name]
+		if !ok {
+			e = &envEntry{name: name}
+			entries[name] = e
+		}
+		return e
+	}
+	for name, v := range c.env {
+		f := c.FlagSet().Lookup(name)
+		if f == nil || c.hidden[name] {
+			continue
+		}
+		e := entry(v)
+		e.flag = flagName(name)
+		_, usage := flag.UnquoteUsage(f)
+		e.desc, _, _ = strings.Cut(usage, "\n")
+	}
+	for _, r := range c.requirements {
+		if r.env != "" {
+			entry(r.env).required = true
+		}
+	}
+	for name, desc := range c.envDocs {
+		entry(name).desc = desc
+	}
+	list := make([]envEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, *e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// Writes the Environment section of the help of c, if any.
+func writeEnvSection(w io.Writer, c *CmdCont) {
+	entries := c.envEntries()
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nEnvironment:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", e.name, e.flag, e.description())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+)
+
+// Exits the process, replaced by tests.
+var osExit = os.Exit
+
+// Sets how Run handles errors parsing the flags of c: the default
+// flag.ContinueOnError returns them, flag.ExitOnError exits the
+// process with status 2, or 0 for -h, and flag.PanicOnError panics
+// with the error, for example to catch bad wiring during development.
+// The error and usage are printed once before, as by the flag package.
+// Validate, Explain and the completion always continue on errors.
+func (c *CmdCont) SetErrorHandling(h flag.ErrorHandling) {
+	c.FlagSet().Init(c.FlagSet().Name(), h)
+}
+
+// Same as the SetErrorHandling of CmdCont but for the global flags of
+// p.
+func (p *Path) SetErrorHandling(h flag.ErrorHandling) {
+	p.Flags.Init(p.Flags.Name(), h)
+}
+
+// Parses args with fs, printing the error and usage like the flag
+// package unless p is quiet, and handles the error as set by
+// SetErrorHandling.
+func (p *Path) parseFlagSet(fs *flag.FlagSet, args []string) error {
+	h := fs.ErrorHandling()
+	if h != flag.ContinueOnError {
+		// the error is handled below, after printing it once
+		fs.Init(fs.Name(), flag.ContinueOnError)
+		defer fs.Init(fs.Name(), h)
+	}
+	var err error
+	if p.mayBeQuiet() {
+		err = p.parseQuietly(fs, args)
+	} else {
+		err = fs.Parse(args)
+	}
+	if err == nil {
+		return nil
+	}
+	switch h {
+	case flag.ExitOnError:
+		if err == flag.ErrHelp {
+			osExit(0)
+		} else {
+			osExit(2)
+		}
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// Makes fs continue on errors until the returned function restores its
+// error handling, for parsing without running the command.
+func continueOnError(fs *flag.FlagSet) func() {
+	h := fs.ErrorHandling()
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	return func() {
+		fs.Init(fs.Name(), h)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newErrorHandlingPath(out *strings.Builder, h flag.ErrorHandling) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(out)
+			fs.String("env", "", "target environment")
+		},
+	})
+	c.SetErrorHandling(h)
+	return p, c
+}
+
+func TestContinueOnError(t *testing.T) {
+	var out strings.Builder
+	p, _ := newErrorHandlingPath(&out, flag.ContinueOnError)
+	_, err := p.Run("deploy", "-bogus")
+	var perr *FlagParseError
+	if !errors.As(err, &perr) || perr.Flag != "bogus" {
+		t.Errorf("Run should fail with a FlagParseError but was %v.", err)
+	}
+	if n := strings.Count(out.String(), "flag provided but not defined: -bogus"); n != 1 {
+		t.Errorf("The error should be printed once but was %d times in %q.", n, out.String())
+	}
+}
+
+func TestPanicOnError(t *testing.T) {
+	var out strings.Builder
+	p, c := newErrorHandlingPath(&out, flag.PanicOnError)
+	if _, err := p.Validate("deploy", "-bogus"); err == nil {
+		t.Error("Validate should return the error instead of panicking.")
+	}
+	if out.Len() > 0 {
+		t.Errorf("Validate should not print anything but printed %q.", out.String())
+	}
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if err == nil || err.Error() != "flag provided but not defined: -bogus" {
+				t.Errorf("Run should panic with the parse error but panicked with %v.", err)
+			}
+		}()
+		p.Run("deploy", "-bogus")
+		t.Error("Run should panic.")
+	}()
+	if n := strings.Count(out.String(), "flag provided but not defined: -bogus"); n != 1 {
+		t.Errorf("The error should be printed once but was %d times in %q.", n, out.String())
+	}
+	if h := c.FlagSet().ErrorHandling(); h != flag.PanicOnError {
+		t.Errorf("The error handling should be restored after the panic but was %v.", h)
+	}
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Errorf("Valid flags should not panic but Run failed with %v.", err)
+	}
+}
+
+func TestExitOnError(t *testing.T) {
+	defer func(fn func(int)) { osExit = fn }(osExit)
+	var codes []int
+	osExit = func(code int) {
+		codes = append(codes, code)
+	}
+	var out strings.Builder
+	p, _ := newErrorHandlingPath(&out, flag.ExitOnError)
+	p.SetQuiet(true)
+	p.Run("deploy", "-bogus")
+	p.Run("deploy", "-h")
+	if len(codes) != 2 || codes[0] != 2 || codes[1] != 0 {
+		t.Errorf("Run should exit with 2 for invalid flags and 0 for -h but exited with %v.", codes)
+	}
+	if strings.Contains(out.String(), "bogus") || !strings.Contains(out.String(), "target environment") {
+		t.Errorf("Only the requested help should be printed in quiet mode but was %q.", out.String())
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Why Run failed with a UsageError.
+type UsageReason int
+
+const (
+	// The path has no commands.
+	NoCommandsRegistered UsageReason = iota + 1
+	// No command was given.
+	NoArguments
+)
+
+func (r UsageReason) String() string {
+	switch r {
+	case NoCommandsRegistered:
+		return "no commands registered"
+	case NoArguments:
+		return "no command given"
+	}
+	return "invalid usage"
+}
+
+// Returned by Run if no command is given or none is registered.
+type UsageError struct {
+	// the name of the mounted path, empty for the top-level path
+	Command string
+	Reason  UsageReason
+	// the number of commands registered with the path
+	Commands int
+}
+
+func (e *UsageError) Error() string {
+	return ErrCmdUsage.Error()
+}
+
+func (e *UsageError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
+
+// Returned by Run if the command given isn't registered.
+type UnknownCommandError struct {
+	// the name of the mounted path, empty for the top-level path
+	Path string
+	// the command given
+	Name string
+	// similar commands, the most similar first
+	Suggestions []string
+	// the name is a help topic, see AddTopic
+	Topic bool
+}
+
+func (e *UnknownCommandError) Error() string {
+	if e.Topic {
+		return fmt.Sprintf("%q is a help topic, not a command; read it with \"help %s\".", e.Name, e.Name)
+	}
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf("No such command %q, did you mean %s?", e.Name, quoteNames(e.Suggestions))
+	}
+	return fmt.Sprintf("No such command %q.", e.Name)
+}
+
+func (e *UnknownCommandError) Is(target error) bool {
+	return target == ErrNoSuchCmd
+}
+
+// Returned by Run if the flags of a command or the global flags of a
+// path can't be parsed. Err is the error of the flag package.
+type FlagParseError struct {
+	// the name of the command or mounted path
+	Command string
+	// the name of the offending flag as given on the command line,
+	// without dashes, or empty if unknown
+	Flag string
+	Err  error
+}
+
+// Formats of the messages of the flag package naming the offending flag
+// after the prefix.
+var flagErrorPrefixes = []string{
+	"flag provided but not defined: -",
+	"flag needs an argument: -",
+	"bad flag syntax: ",
+	"invalid boolean flag ",
+}
+
+func newFlagParseError(command string, err error) *FlagParseError {
+	msg := err.Error()
+	var s *suggestionError
+	if errors.As(err, &s) {
+		msg = s.err.Error()
+	}
+	return &FlagParseError{Command: command, Flag: errorFlag(msg), Err: err}
+}
+
+// Returns the name of the flag named by the message of a parse error.
+func errorFlag(msg string) string {
+	var rest string
+	for _, prefix := range flagErrorPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			rest = msg[len(prefix):]
+			break
+		}
+	}
+	if rest == "" {
+		// invalid value "x" for flag -name: reason
+		// invalid boolean value "x" for -name: reason
+		for _, prefix := range []string{"invalid value ", "invalid boolean value "} {
+			if !strings.HasPrefix(msg, prefix) {
+				continue
+			}
+			quoted, err := strconv.QuotedPrefix(msg[len(prefix):])
+			if err != nil {
+				return ""
+			}
+			switch rest = msg[len(prefix)+len(quoted):]; {
+			case strings.HasPrefix(rest, " for flag -"):
+				rest = rest[len(" for flag -"):]
+			case strings.HasPrefix(rest, " for -"):
+				rest = rest[len(" for -"):]
+			default:
+				return ""
+			}
+			break
+		}
+	}
+	if i := strings.IndexAny(rest, " :"); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimLeft(rest, "-")
+}
+
+func (e *FlagParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FlagParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FlagParseError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
+
+// Returned by Run if required flags of a command aren't set, or are
+// empty if required by RequireNonEmpty. The message has the form
+//
+//	deploy: required flags not set: --env, --target
+type MissingFlagsError struct {
+	Command string
+	// the names of the flags, sorted
+	Flags []string
+	// the flags are set to empty values
+	Empty bool
+}
+
+func (e *MissingFlagsError) Error() string {
+	names := make([]string, len(e.Flags))
+	for i, name := range e.Flags {
+		names[i] = flagName(name)
+	}
+	if e.Empty {
+		return commandPrefix(e.Command) + "required flags set to empty values: " + strings.Join(names, ", ")
+	}
+	return commandPrefix(e.Command) + "required flags not set: " + strings.Join(names, ", ")
+}
+
+// Returns the prefix of error messages about the named command, which
+// is empty for the unnamed root command, see SetRoot.
+func commandPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + ": "
+}
+
+func (e *MissingFlagsError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
+
+// Returned by Run if the value of a flag is rejected by a validator or
+// by the deferred check of its type. Several of them are joined with
+// errors.Join.
+type ArgError struct {
+	Command string
+	Flag    string
+	Value   string
+	Err     error
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("invalid value %q for flag -%s: %v", e.Value, e.Flag, e.Err)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ArgError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
+
+// Returned by Run for the error of a command if enabled by
+// SetWrapErrors, so the failing command can be told from the message.
+type CommandError struct {
+	// the name of the command, including the paths it is mounted
+	// to, see CmdCont.CommandPath
+	Name string
+	// the error returned by the Run method of the command
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return commandPrefix(e.Name) + e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Wraps the non-nil errors returned by the Run method of the commands
+// of p in a CommandError, prefixing their message with the command
+// name, as in "deploy: connection refused" or "remote add: connection
+// refused". Commands of a mounted path are wrapped according to the
+// setting of that path. The errors are returned unchanged by default.
+func (p *Path) SetWrapErrors(wrap bool) {
+	p.wrapErrors = wrap
+}
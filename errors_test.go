@@ -0,0 +1,220 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func newFailingPath(err error) *Path {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		run: func(args ...string) error {
+			return err
+		},
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	return p
+}
+
+func TestWrapErrors(t *testing.T) {
+	cause := &fs.PathError{Op: "open", Path: "app.yaml", Err: fs.ErrNotExist}
+	p := newFailingPath(cause)
+	p.SetWrapErrors(true)
+	_, err := p.Run("deploy")
+	if want := "deploy: open app.yaml: file does not exist"; err == nil || err.Error() != want {
+		t.Fatalf("Error should be %q but was %v.", want, err)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Name != "deploy" || cmdErr.Err != cause {
+		t.Errorf("Error should be a CommandError of deploy but was %#v.", err)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Error should wrap %v but was %v.", cause, err)
+	}
+	if _, err := p.Run("status"); err != nil {
+		t.Errorf("Succeeding commands should not fail but was %v.", err)
+	}
+}
+
+func TestWrapErrorsDisabled(t *testing.T) {
+	cause := errors.New("connection refused")
+	_, err := newFailingPath(cause).Run("deploy")
+	if err != cause {
+		t.Errorf("Error should be returned unchanged but was %v.", err)
+	}
+}
+
+var errInvalidEnv = errors.New("unknown environment")
+
+func newErrorsPath() *Path {
+	p := NewPath()
+	p.Flags.SetOutput(io.Discard)
+	p.Flags.Int("retries", 3, "number of retries")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(io.Discard)
+			fs.String("env", "", "target environment")
+			fs.String("token", "", "API token")
+		},
+	}, "env", "token")
+	c.RequireNonEmpty("token")
+	c.ValidateFlag("env", func(value string) error {
+		if value != "prod" {
+			return errInvalidEnv
+		}
+		return nil
+	})
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		sentinel error
+		target   interface{}
+		want     interface{}
+	}{
+		{nil, ErrCmdUsage, new(*UsageError), &UsageError{Reason: NoArguments, Commands: 2}},
+		{[]string{"-retries", "2"}, ErrCmdUsage, new(*UsageError), &UsageError{Reason: NoArguments, Commands: 2}},
+		{[]string{"remote"}, ErrCmdUsage, new(*UsageError), &UsageError{Command: "remote", Reason: NoArguments, Commands: 1}},
+		{[]string{"destroy"}, ErrNoSuchCmd, new(*UnknownCommandError), &UnknownCommandError{Name: "destroy"}},
+		{[]string{"remote", "rm"}, ErrNoSuchCmd, new(*UnknownCommandError), &UnknownCommandError{Path: "remote", Name: "rm"}},
+		{[]string{"-retries", "x", "deploy"}, ErrCmdUsage, new(*FlagParseError), ""},
+		{[]string{"deploy", "-force"}, ErrCmdUsage, new(*FlagParseError), "deploy"},
+		{[]string{"deploy", "-env"}, ErrCmdUsage, new(*FlagParseError), "deploy"},
+		{[]string{"deploy", "-env", "prod"}, ErrCmdUsage, new(*MissingFlagsError), &MissingFlagsError{Command: "deploy", Flags: []string{"token"}}},
+		{[]string{"deploy", "-env", "prod", "-token", " "}, ErrCmdUsage, new(*MissingFlagsError), &MissingFlagsError{Command: "deploy", Flags: []string{"token"}, Empty: true}},
+		{[]string{"deploy", "-env", "dev", "-token", "t"}, ErrCmdUsage, new(*ArgError), &ArgError{Command: "deploy", Flag: "env", Value: "dev", Err: errInvalidEnv}},
+	}
+	for _, test := range tests {
+		_, err := newErrorsPath().Run(test.args...)
+		if !errors.Is(err, test.sentinel) {
+			t.Errorf("%q should fail with %v but was %v.", test.args, test.sentinel, err)
+			continue
+		}
+		if !errors.As(err, test.target) {
+			t.Errorf("%q should fail with a %T but was %#v.", test.args, test.target, err)
+			continue
+		}
+		got := reflect.ValueOf(test.target).Elem().Interface()
+		if e, ok := got.(*FlagParseError); ok {
+			if e.Command != test.want || e.Err == nil || errors.Unwrap(e) != e.Err {
+				t.Errorf("%q should fail with a parse error of %q but was %#v.", test.args, test.want, e)
+			}
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q should fail with %#v but was %#v.", test.args, test.want, got)
+		}
+	}
+}
+
+func TestArgErrorUnwrap(t *testing.T) {
+	_, err := newErrorsPath().Run("deploy", "-env", "dev", "-token", "t")
+	if !errors.Is(err, errInvalidEnv) {
+		t.Errorf("Error should wrap the validator error but was %v.", err)
+	}
+	if want := `invalid value "dev" for flag -env: unknown environment`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+}
+
+func TestFlagParseError(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		flag    string
+		msg     string
+	}{
+		{[]string{"deploy", "-force"}, "deploy", "force", "flag provided but not defined: -force"},
+		{[]string{"deploy", "--tokn", "t"}, "deploy", "tokn", "flag provided but not defined: -tokn, did you mean --token?"},
+		{[]string{"-retries", "x", "deploy"}, "", "retries", `invalid value "x" for flag -retries: parse error`},
+		{[]string{"-retries", "x for flag -y", "deploy"}, "", "retries", `invalid value "x for flag -y" for flag -retries: parse error`},
+		{[]string{"deploy", "-env"}, "deploy", "env", "flag needs an argument: -env"},
+		{[]string{"deploy", "---env", "prod"}, "deploy", "env", "bad flag syntax: ---env"},
+		{[]string{"remote", "-x", "add"}, "remote", "x", "flag provided but not defined: -x"},
+	}
+	for _, test := range tests {
+		_, err := newErrorsPath().Run(test.args...)
+		var parseErr *FlagParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q should fail with a FlagParseError but was %#v.", test.args, err)
+			continue
+		}
+		if parseErr.Command != test.command || parseErr.Flag != test.flag {
+			t.Errorf("%q should fail for flag %q of %q but was %q of %q.", test.args, test.flag, test.command, parseErr.Flag, parseErr.Command)
+		}
+		if err.Error() != test.msg || errors.Unwrap(parseErr) != parseErr.Err {
+			t.Errorf("%q should fail with %q but was %q.", test.args, test.msg, err)
+		}
+	}
+}
+
+func TestErrorFlag(t *testing.T) {
+	tests := map[string]string{
+		"flag provided but not defined: -z in -xzf":      "z",
+		"flag needs an argument: -f in -xvf":             "f",
+		`invalid boolean value "y" for -v: parse error`:  "v",
+		"invalid boolean flag verbose: invalid syntax":   "verbose",
+		`invalid value "a" for flag -n: element "a" bad`: "n",
+		"Required flags not set":                         "",
+		`invalid value "unterminated`:                    "",
+	}
+	for msg, want := range tests {
+		if got := errorFlag(msg); got != want {
+			t.Errorf("Flag of %q should be %q but was %q.", msg, want, got)
+		}
+	}
+}
+
+func TestMissingFlagsMessage(t *testing.T) {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("target", "", "target host")
+			fs.String("env", "", "target environment")
+			fs.String("r", "", "region")
+			fs.Bool("force", false, "skip checks")
+		},
+	}, "target", "r", "env")
+	for i := 0; i < 10; i++ {
+		_, err := p.Run("deploy", "-force")
+		if want := "deploy: required flags not set: --env, -r, --target"; err == nil || err.Error() != want {
+			t.Fatalf("Error should be %q but was %v.", want, err)
+		}
+	}
+}
+
+func TestUsageErrorReason(t *testing.T) {
+	_, err := NewPath().Run("deploy")
+	var usage *UsageError
+	if !errors.As(err, &usage) || usage.Reason != NoCommandsRegistered || usage.Commands != 0 {
+		t.Errorf("A path without commands should fail with NoCommandsRegistered but was %#v.", err)
+	}
+	if !errors.Is(err, ErrCmdUsage) || err.Error() != ErrCmdUsage.Error() {
+		t.Errorf("Error should match ErrCmdUsage but was %v.", err)
+	}
+	if s := NoArguments.String(); s != "no command given" {
+		t.Errorf("Reason should be %q but was %q.", "no command given", s)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Adds an example invocation of c, including the program name, such as
+// "app deploy -env prod", to its help output. Placeholders in angle
+// brackets, such as <file>, stand for values chosen by the user. See
+// CheckExamples.
+func (c *CmdCont) AddExample(line string) {
+	c.examples = append(c.examples, line)
+	c.changed()
+}
+
+// Validates the examples of all commands of p and of the paths mounted
+// to it, see AddExample. Each example is split by SplitArgs and passed
+// to Validate without the program name. Examples naming unknown
+// commands or undefined flags, missing required flags or invoking
+// another command than the one they belong to are reported as errors.
+// Placeholders are accepted as positional arguments and as values of
+// any flag. The flags are reset after each example, see ResetFlags.
+func (p *Path) CheckExamples() []Problem {
+	var problems []Problem
+	for _, d := range p.docCommands("") {
+		for _, example := range d.cont.examples {
+			if err := p.checkExample(d.cont, example); err != nil {
+				problems = append(problems, Problem{
+					Command:      strings.TrimPrefix(d.name, " "),
+					Severity:     SeverityError,
+					Message:      fmt.Sprintf("example %q: %v", example, err),
+					RegisteredAt: d.cont.RegisteredAt(),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+func (p *Path) checkExample(want *CmdCont, example string) error {
+	args, err := SplitArgs(example)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("empty example")
+	}
+	defer p.ResetFlags()
+	c, err := p.Validate(args[1:]...)
+	if err != nil && !isPlaceholderError(err) {
+		return err
+	}
+	if c != want {
+		return errors.New("invokes another command")
+	}
+	return nil
+}
+
+// Reports whether err rejects a placeholder as the value of a flag.
+func isPlaceholderError(err error) bool {
+	var argErr *ArgError
+	if errors.As(err, &argErr) {
+		return isPlaceholder(argErr.Value)
+	}
+	var parseErr *FlagParseError
+	if errors.As(err, &parseErr) {
+		// formatted by the flag package as
+		// invalid value "<n>" for flag -port: ...
+		msg := parseErr.Err.Error()
+		if !strings.HasPrefix(msg, "invalid value ") {
+			return false
+		}
+		quoted, err := strconv.QuotedPrefix(strings.TrimPrefix(msg, "invalid value "))
+		if err != nil {
+			return false
+		}
+		value, err := strconv.Unquote(quoted)
+		return err == nil && isPlaceholder(value)
+	}
+	return false
+}
+
+func isPlaceholder(s string) bool {
+	return len(s) > 2 && strings.HasPrefix(s, "<") && strings.HasSuffix(s, ">")
+}
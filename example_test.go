@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	ran := false
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+		run: func(args ...string) error {
+			ran = true
+			return nil
+		},
+	}, "env")
+	if c, err := p.Validate("deploy", "-env", "prod"); err != nil || c.Name != "deploy" {
+		t.Errorf("Validate should match deploy but was %v, %v.", c, err)
+	}
+	var missing *MissingFlagsError
+	p.ResetFlags()
+	if _, err := p.Validate("deploy"); !errors.As(err, &missing) {
+		t.Errorf("Validate should fail with a MissingFlagsError but was %v.", err)
+	}
+	if ran {
+		t.Error("Validate should not run the command.")
+	}
+}
+
+func newExamplePath() (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+			fs.Int("replicas", 1, "number of replicas")
+		},
+	}, "env")
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	return p, c
+}
+
+func TestCheckExamples(t *testing.T) {
+	p, c := newExamplePath()
+	c.AddExample("app deploy -env prod web")
+	c.AddExample("app deploy -env <env> -replicas <n> <file>")
+	c.AddExample("app deploy -environment prod")
+	c.AddExample("app deploy")
+	c.AddExample("app remote add")
+	c.AddExample("app 'deploy")
+	p.registry().entries["remote"].sub.registry().entries["add"].AddExample("app remote add origin")
+	var messages []string
+	for _, problem := range p.CheckExamples() {
+		messages = append(messages, problem.String())
+	}
+	want := []string{
+		`deploy: error: example "app deploy -environment prod": flag provided but not defined: -environment`,
+		`deploy: error: example "app deploy": deploy: required flags not set: --env`,
+		`deploy: error: example "app remote add": invokes another command`,
+		`deploy: error: example "app 'deploy": unterminated ' quote at offset 4`,
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("CheckExamples should report\n%s\nbut was\n%s", strings.Join(want, "\n"), strings.Join(messages, "\n"))
+	}
+	if _, err := p.Run("deploy"); err == nil {
+		t.Error("CheckExamples should reset the flags.")
+	}
+}
+
+func TestExamplesHelp(t *testing.T) {
+	p, c := newExamplePath()
+	c.AddExample("app deploy -env prod")
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if want := "\nExamples:\n  app deploy -env prod\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Help should end with %q but was %q.", want, out.String())
+	}
+}
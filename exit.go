@@ -0,0 +1,117 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Errors implementing ExitCoder determine the exit code of Execute
+// themselves, taking precedence over the codes set by SetExitCode.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// The exit codes of Execute for errors matching err with errors.Is.
+type exitCode struct {
+	err  error
+	code int
+}
+
+// Makes Execute exit with code for errors matching err with errors.Is,
+// such as 69 for an error indicating an unavailable service. Codes set
+// later take precedence. By default Execute exits with 0 for nil and
+// flag.ErrHelp, with ExitUsage for errors matching ErrCmdUsage or
+// ErrNoSuchCmd, such as a MissingFlagsError or a FlagParseError, with
+// the sysexits.h code for errors matching ErrNoInput and the other
+// sentinel errors, and with 1 for all other errors. SetPlainExitCodes
+// uses 2 for usage errors instead.
+func (p *Path) SetExitCode(err error, code int) {
+	p.exitCodes = append(p.exitCodes, exitCode{err: err, code: code})
+}
+
+// Returns the exit code of Execute for the error err returned by Run.
+func (p *Path) ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	for i := len(p.exitCodes) - 1; i >= 0; i-- {
+		if errors.Is(err, p.exitCodes[i].err) {
+			return p.exitCodes[i].code
+		}
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, ErrCmdUsage), errors.Is(err, ErrNoSuchCmd):
+		if p.plainExitCodes {
+			return 2
+		}
+		return ExitUsage
+	}
+	if code := sysexitCode(err); code != 0 && !p.plainExitCodes {
+		return code
+	}
+	return 1
+}
+
+// Runs the command given by args like Run and returns the exit code for
+// its error, see SetExitCode. The error is printed to Output unless
+// the help was requested. Missing required flags are listed with their
+// usage.
+func (p *Path) Execute(args ...string) int {
+	c, err := p.Run(args...)
+	p.writeError(p.Output(), c, err)
+	return p.ExitCode(err)
+}
+
+// Writes the error err returned by Run for the command c to w, see
+// Execute.
+func writeError(w io.Writer, c *CmdCont, err error) {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	fmt.Fprintln(w, err)
+	var missing *MissingFlagsError
+	if c != nil && errors.As(err, &missing) {
+		writeMissingFlags(w, c, missing.Flags)
+	}
+}
+
+// Runs the command given by the command line arguments and exits the
+// process with the code returned by Execute, after writing the pending
+// entries of the audit log.
+func (p *Path) Main() {
+	code := p.Execute(os.Args[1:]...)
+	p.closeAudit()
+	os.Exit(code)
+}
+
+func SetExitCode(err error, code int) {
+	globalPath.SetExitCode(err, code)
+}
+
+func Main() {
+	globalPath.Main()
+}
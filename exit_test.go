@@ -0,0 +1,156 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var errUnavailable = errors.New("service unavailable")
+
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit %d", int(e))
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{flag.ErrHelp, 0},
+		{errors.New("failed"), 1},
+		{ErrCmdUsage, 64},
+		{ErrNoSuchCmd, 2},
+		{&UnknownCommandError{Name: "x"}, 2},
+		{&MissingFlagsError{Command: "deploy", Flags: []string{"env"}}, 64},
+		{&FlagParseError{Err: errors.New("bad")}, 64},
+		{errUnavailable, 69},
+		{fmt.Errorf("deploy: %w", errUnavailable), 69},
+		{&CommandError{Name: "deploy", Err: errUnavailable}, 69},
+		{exitError(3), 3},
+		{&CommandError{Name: "deploy", Err: exitError(3)}, 3},
+		{fmt.Errorf("%w: %w", exitError(5), errUnavailable), 5},
+	}
+	p := NewPath()
+	p.SetExitCode(errUnavailable, 69)
+	p.SetExitCode(ErrNoSuchCmd, 1)
+	p.SetExitCode(ErrCmdUsage, 64)
+	p.SetExitCode(ErrNoSuchCmd, 2)
+	for _, test := range tests {
+		if code := p.ExitCode(test.err); code != test.want {
+			t.Errorf("Exit code of %v should be %d but was %d.", test.err, test.want, code)
+		}
+	}
+}
+
+func TestSysexits(t *testing.T) {
+	tests := []struct {
+		err   error
+		want  int
+		plain int
+	}{
+		{nil, 0, 0},
+		{flag.ErrHelp, 0, 0},
+		{errors.New("failed"), 1, 1},
+		{ErrCmdUsage, ExitUsage, 2},
+		{&UsageError{Reason: NoArguments}, ExitUsage, 2},
+		{ErrNoSuchCmd, ExitUsage, 2},
+		{&UnknownCommandError{Name: "x"}, ExitUsage, 2},
+		{&FlagParseError{Err: errors.New("bad")}, ExitUsage, 2},
+		{&MissingFlagsError{Command: "deploy", Flags: []string{"env"}}, ExitUsage, 2},
+		{ErrNoInput, ExitNoInput, 1},
+		{fmt.Errorf("config.json: %w", ErrNoInput), ExitNoInput, 1},
+		{&CommandError{Name: "deploy", Err: ErrNoInput}, ExitNoInput, 1},
+		{ErrDataErr, ExitDataErr, 1},
+		{ErrUnavailable, ExitUnavailable, 1},
+		{ErrSoftware, ExitSoftware, 1},
+		{ErrCantCreate, ExitCantCreate, 1},
+		{ErrIOErr, ExitIOErr, 1},
+		{fmt.Errorf("fetch: %w", ErrTempFail), ExitTempFail, 1},
+		{ErrProtocol, ExitProtocol, 1},
+		{ErrNoPerm, ExitNoPerm, 1},
+		{&PermissionError{Command: "deploy", Err: errors.New("not an admin")}, ExitNoPerm, 1},
+		{ErrConfig, ExitConfig, 1},
+		{exitError(3), 3, 3},
+		{fmt.Errorf("%w: %w", exitError(5), ErrNoInput), 5, 5},
+	}
+	p := NewPath()
+	plain := NewPath()
+	plain.SetPlainExitCodes(true)
+	for _, test := range tests {
+		if code := p.ExitCode(test.err); code != test.want {
+			t.Errorf("Exit code of %v should be %d but was %d.", test.err, test.want, code)
+		}
+		if code := plain.ExitCode(test.err); code != test.plain {
+			t.Errorf("Plain exit code of %v should be %d but was %d.", test.err, test.plain, code)
+		}
+	}
+	plain.SetExitCode(ErrNoInput, ExitNoInput)
+	if code := plain.ExitCode(ErrNoInput); code != ExitNoInput {
+		t.Errorf("Exit code set with SetExitCode should be %d but was %d.", ExitNoInput, code)
+	}
+}
+
+func newExitPath() *Path {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(io.Discard)
+			fs.String("env", "", "target environment")
+		},
+		run: func(args ...string) error {
+			if len(args) > 0 {
+				return errUnavailable
+			}
+			return nil
+		},
+	}, "env")
+	p.SetExitCode(errUnavailable, 69)
+	return p
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"deploy", "-env", "prod"}, 0, ""},
+		{[]string{"deploy", "-env", "prod", "eu"}, 69, "service unavailable\n"},
+		{[]string{"deploy"}, 64, "deploy: required flags not set: --env\n  --env string  target environment (required)\n"},
+		{[]string{"deploy", "-h"}, 0, ""},
+		{[]string{"destroy"}, 64, "No such command \"destroy\".\n"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		p := newExitPath()
+		p.SetOutput(&out)
+		if code := p.Execute(test.args...); code != test.code || out.String() != test.out {
+			t.Errorf("%q should exit with %d and print %q but was %d and %q.", test.args, test.code, test.out, code, out.String())
+		}
+	}
+}
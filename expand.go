@@ -0,0 +1,85 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Allows the named flag to be given as @path, which sets the flag to
+// the contents of the file at path with one trailing newline removed.
+// A literal value starting with '@' must be escaped as "@@". Files
+// that can't be read fail the parsing of the flag, so the command
+// isn't run.
+func (c *CmdCont) FileExpandable(name string) error {
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if _, ok := f.Value.(*fileExpandValue); !ok {
+		f.Value = &fileExpandValue{f.Value}
+	}
+	return nil
+}
+
+// Wraps a flag.Value to expand @path values to the file's contents.
+type fileExpandValue struct {
+	flag.Value
+}
+
+func (v *fileExpandValue) Set(s string) error {
+	if !strings.HasPrefix(s, "@") {
+		return v.Value.Set(s)
+	}
+	if strings.HasPrefix(s, "@@") {
+		return v.Value.Set(s[1:])
+	}
+	b, err := os.ReadFile(s[1:])
+	if err != nil {
+		return err
+	}
+	s = strings.TrimSuffix(string(b), "\n")
+	return v.Value.Set(strings.TrimSuffix(s, "\r"))
+}
+
+func (v *fileExpandValue) String() string {
+	if v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func (v *fileExpandValue) Get() interface{} {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.String()
+}
+
+func (v *fileExpandValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (v *fileExpandValue) IsRepeatable() bool {
+	return isRepeatable(v.Value)
+}
+
+func (v *fileExpandValue) completions() []string {
+	return valueCompletions(v.Value)
+}
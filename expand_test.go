@@ -0,0 +1,131 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newPayloadPath(payload *string, ran *bool) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("send", "sends a payload", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(payload, "payload", "", "JSON payload")
+		},
+		run: func(args ...string) error {
+			*ran = true
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestFileExpandable(t *testing.T) {
+	var payload string
+	var ran bool
+	p, c := newPayloadPath(&payload, &ran)
+	if err := c.FileExpandable("payload"); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(name, []byte("{\"app\": \"web\"}\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("send", "-payload", "@"+name); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"app\": \"web\"}\n"; payload != want {
+		t.Errorf("payload should be %q but was %q.", want, payload)
+	}
+}
+
+func TestFileExpandableEscape(t *testing.T) {
+	var payload string
+	var ran bool
+	p, c := newPayloadPath(&payload, &ran)
+	if err := c.FileExpandable("payload"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("send", "-payload", "@@mention"); err != nil {
+		t.Fatal(err)
+	}
+	if payload != "@mention" {
+		t.Errorf("payload should be %q but was %q.", "@mention", payload)
+	}
+}
+
+func TestFileExpandableMissingFile(t *testing.T) {
+	var payload string
+	var ran bool
+	p, c := newPayloadPath(&payload, &ran)
+	c.Flags.SetOutput(new(strings.Builder))
+	if err := c.FileExpandable("payload"); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "missing.json")
+	_, err := p.Run("send", "-payload", "@"+name)
+	if err == nil {
+		t.Fatal("A missing file should fail.")
+	}
+	if !strings.Contains(err.Error(), "-payload") || !strings.Contains(err.Error(), name) {
+		t.Errorf("Error should name the flag and the path but was %q.", err)
+	}
+	if ran {
+		t.Error("The command should not run.")
+	}
+}
+
+func TestFileExpandableNotEnabled(t *testing.T) {
+	var payload string
+	var ran bool
+	p, c := newPayloadPath(&payload, &ran)
+	if _, err := p.Run("send", "-payload", "@file"); err != nil {
+		t.Fatal(err)
+	}
+	if payload != "@file" {
+		t.Errorf("payload should be %q but was %q.", "@file", payload)
+	}
+	if err := c.FileExpandable("missing"); err == nil {
+		t.Error("Unknown flags should fail.")
+	}
+}
+
+func TestFileExpandableWrapsValue(t *testing.T) {
+	var tags []string
+	p := NewPath()
+	c := p.Add("send", "sends a payload", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("payload", "", "JSON payload")
+			StringSliceVar(fs, &tags, "tag", "tag to add")
+		},
+	})
+	for _, name := range []string{"payload", "tag"} {
+		if err := c.FileExpandable(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"send": {"tag": ["a", "b"]}}}`)); err != nil {
+		t.Fatalf("Repeatable flags should accept arrays but failed: %v", err)
+	}
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if !strings.Contains(out.String(), "-payload string\n") {
+		t.Errorf("Help should name the type of the flag:\n%s", out.String())
+	}
+}
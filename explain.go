@@ -0,0 +1,102 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writes a report of what Run would do for args to w without running
+// the command: the matched command, the value and source of every
+// flag, the positional arguments and the callbacks that would fire.
+// Secret values are redacted. The arguments are processed like by
+// Validate, whose error is returned, and the flags are reset
+// afterwards, see ResetFlags.
+func (p *Path) Explain(w io.Writer, args ...string) error {
+	defer p.ResetFlags()
+	c, err := p.Validate(args...)
+	if c == nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return err
+	}
+	d, _ := p.docCommand(c)
+	fmt.Fprintf(w, "command: %s\n", strings.TrimPrefix(d.name, " "))
+	// the paths the command is mounted to, the outermost first
+	var paths []*Path
+	for q := d.path; q != nil; q = q.parent {
+		paths = append([]*Path{q}, paths...)
+		if q == p {
+			break
+		}
+	}
+	for _, q := range paths {
+		of := ""
+		if name := q.Flags.Name(); name != "" {
+			of = " of " + name
+		}
+		explainFlags(w, "global flags"+of, q.Flags, q.sources, nil)
+		if q.persistent != nil {
+			explainFlags(w, "persistent flags"+of, q.persistent, nil, nil)
+		}
+	}
+	explainFlags(w, "flags", c.FlagSet(), c.sources, c.isSecret)
+	if err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return err
+	}
+	before, tail := splitPassthrough(c.parsedArgs, c.FlagSet().Args())
+	fmt.Fprintf(w, "args: %q\n", c.traceArgs(before))
+	if tail != nil {
+		fmt.Fprintf(w, "passthrough args: %q\n", c.traceArgs(tail))
+	}
+	var callbacks int
+	recovers := false
+	for _, q := range paths {
+		callbacks += len(q.completed)
+		recovers = recovers || q.recoverPanics
+	}
+	fmt.Fprintf(w, "on complete: %d callbacks\n", callbacks)
+	fmt.Fprintf(w, "recover panics: %v\n", recovers)
+	return nil
+}
+
+// Writes the flags of fs under title. The source of flags without one
+// in sources is taken from the command line.
+func explainFlags(w io.Writer, title string, fs *flag.FlagSet, sources map[string]Source, secret func(*flag.Flag) bool) {
+	if sources == nil {
+		sources = make(map[string]Source)
+		for name := range setFlags(fs) {
+			sources[name] = SourceCLI
+		}
+	}
+	first := true
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*aliasValue); ok {
+			return
+		}
+		if first {
+			fmt.Fprintf(w, "%s:\n", title)
+			first = false
+		}
+		value := f.Value.String()
+		if secret != nil && secret(f) {
+			value = redacted
+		}
+		fmt.Fprintf(w, "  -%s = %q (%s)\n", f.Name, value, sources[f.Name])
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestExplainErrors(t *testing.T) {
+	p := NewPath()
+	ran := false
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+		run: func(...string) error {
+			ran = true
+			return nil
+		},
+	}, "env")
+	var out strings.Builder
+	if err := p.Explain(&out, "destroy"); err == nil {
+		t.Error("Explaining an unknown command should fail.")
+	}
+	if !strings.HasPrefix(out.String(), "error: ") {
+		t.Errorf("Report should start with the error but was %q.", out.String())
+	}
+	out.Reset()
+	if err := p.Explain(&out, "deploy"); err == nil {
+		t.Error("Explaining a command with missing required flags should fail.")
+	}
+	for _, want := range []string{
+		"command: deploy\n",
+		"  -env = \"\" (default)\n",
+		"error: deploy: required flags not set: --env\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report should contain %q but was %q.", want, out.String())
+		}
+	}
+	out.Reset()
+	if err := p.Explain(&out, "deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if ran {
+		t.Error("Explain should not run the command.")
+	}
+	if v := p.registry().entries["deploy"].Flags.Lookup("env").Value.String(); v != "" {
+		t.Errorf("Flag -env should be reset but was %q.", v)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// Decides whether the features named with CmdCont.Feature are on, such
+// as by asking a feature flag service at startup. The gate of the
+// closest enclosing path is used for the commands of mounted paths.
+// gate is called whenever a gated command is listed, completed or run.
+func (p *Path) SetFeatureGate(gate func(feature string) bool) {
+	p.featureGate = gate
+}
+
+// Puts the command behind the named feature: while the feature gate of
+// its path, see SetFeatureGate, reports the feature as off, the command
+// is disabled like with EnabledIf, and running it fails with a
+// FeatureDisabledError. Without a gate all features are off. The
+// feature is listed in the Spec.
+func (c *CmdCont) Feature(name string) {
+	c.feature = name
+}
+
+// Reports whether the feature of c is on, which it is for commands
+// without a feature.
+func (c *CmdCont) featureEnabled() bool {
+	if c.feature == "" {
+		return true
+	}
+	for q := c.path; q != nil; q = q.parent {
+		if q.featureGate != nil {
+			return q.featureGate(c.feature)
+		}
+	}
+	return false
+}
+
+// Returned by Run for commands whose feature is off, see Feature.
+type FeatureDisabledError struct {
+	Command string
+	Feature string
+}
+
+func (e *FeatureDisabledError) Error() string {
+	return fmt.Sprintf("%s: feature %q is disabled", e.Command, e.Feature)
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFeature(t *testing.T) {
+	features := map[string]bool{}
+	var asked []string
+	ran := false
+	p := NewPath()
+	p.SetFeatureGate(func(feature string) bool {
+		asked = append(asked, feature)
+		return features[feature]
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	p.Add("sync", "syncs the files", &testCmd{run: func(args ...string) error {
+		ran = true
+		return nil
+	}}).Feature("fast-sync")
+
+	var out strings.Builder
+	p.writeAvailableCommands(&out)
+	if strings.Contains(out.String(), "sync") {
+		t.Errorf("The list of commands should omit the gated command but was %q.", out.String())
+	}
+	if got := strings.Join(p.Complete(""), " "); got != "status" {
+		t.Errorf("Completion should omit the gated command but was %q.", got)
+	}
+	_, err := p.Run("sync")
+	var e *FeatureDisabledError
+	if !errors.As(err, &e) || e.Feature != "fast-sync" || e.Command != "sync" {
+		t.Fatalf("Run should fail with a FeatureDisabledError but failed with %v.", err)
+	}
+	if want := `sync: feature "fast-sync" is disabled`; err.Error() != want {
+		t.Errorf("The error should be %q but was %q.", want, err.Error())
+	}
+	if ran {
+		t.Error("The gated command should not run.")
+	}
+	if len(asked) == 0 || asked[0] != "fast-sync" {
+		t.Errorf("The gate should be asked for the feature but was asked for %q.", asked)
+	}
+
+	features["fast-sync"] = true
+	out.Reset()
+	p.writeAvailableCommands(&out)
+	if !strings.Contains(out.String(), "\tsync\tsyncs the files\n") {
+		t.Errorf("The list of commands should include the enabled command but was %q.", out.String())
+	}
+	if got := strings.Join(p.Complete(""), " "); got != "status sync" {
+		t.Errorf("Completion should include the enabled command but was %q.", got)
+	}
+	if _, err := p.Run("sync"); err != nil || !ran {
+		t.Errorf("The enabled command should run but failed with %v.", err)
+	}
+}
+
+func TestFeatureMounted(t *testing.T) {
+	on := false
+	p := NewPath()
+	p.SetFeatureGate(func(string) bool { return on })
+	remote := NewPath()
+	remote.Add("prune", "prunes remotes", &testCmd{}).Feature("prune")
+	p.Mount("remote", "manages remotes", remote)
+	if _, err := p.Run("remote", "prune"); err == nil || err.Error() != `remote prune: feature "prune" is disabled` {
+		t.Errorf("A command of a mounted path should use the gate of the enclosing path but failed with %v.", err)
+	}
+	on = true
+	if _, err := p.Run("remote", "prune"); err != nil {
+		t.Errorf("The enabled command should run but failed with %v.", err)
+	}
+
+	unguarded := NewPath()
+	unguarded.Add("sync", "syncs the files", &testCmd{}).Feature("fast-sync")
+	if enabled, reason := unguarded.Lookup("sync").Enabled(); enabled || reason != `feature "fast-sync" is disabled` {
+		t.Errorf("Features should be off without a gate but enabled was %v with %q.", enabled, reason)
+	}
+}
+
+func TestFeatureSpec(t *testing.T) {
+	old := NewPath()
+	old.Add("sync", "syncs the files", &testCmd{})
+	p := NewPath()
+	p.Add("sync", "syncs the files", &testCmd{}).Feature("fast-sync")
+	s := p.Spec()
+	if s.Commands[0].Feature != "fast-sync" {
+		t.Errorf("The spec should list the feature but was %+v.", s.Commands[0])
+	}
+	changes := DiffSpecs(old.Spec(), s)
+	if len(changes) != 1 || changes[0].String() != `breaking: sync: command is now gated by feature "fast-sync"` {
+		t.Errorf("Gating a command should be a breaking change but the changes were %v.", changes)
+	}
+	if changes := DiffSpecs(s, old.Spec()); len(changes) != 1 || changes[0].Kind != Additive {
+		t.Errorf("Removing the gate should be an additive change but the changes were %v.", changes)
+	}
+}
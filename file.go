@@ -0,0 +1,115 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"os"
+)
+
+// Defines a string flag with specified name, default value, and usage
+// string that only accepts paths of existing files. The argument p
+// points to a string variable in which to store the value of the flag.
+// The default value is not checked.
+func ExistingFileVar(fs *flag.FlagSet, p *string, name, value, usage string) {
+	*p = value
+	fs.Var(&existingValue{p: p}, name, usage)
+}
+
+// Same as ExistingFileVar but only accepts paths of existing
+// directories.
+func ExistingDirVar(fs *flag.FlagSet, p *string, name, value, usage string) {
+	*p = value
+	fs.Var(&existingValue{p: p, dir: true}, name, usage)
+}
+
+// Same as ExistingFileVar but checks the path only once Run parsed all
+// flags of the command, so that the help output and completion aren't
+// blocked by an invalid path.
+func ExistingFileVarDeferred(fs *flag.FlagSet, p *string, name, value, usage string) {
+	*p = value
+	fs.Var(&existingValue{p: p, deferred: true}, name, usage)
+}
+
+// Same as ExistingDirVar but checks the path like
+// ExistingFileVarDeferred.
+func ExistingDirVarDeferred(fs *flag.FlagSet, p *string, name, value, usage string) {
+	*p = value
+	fs.Var(&existingValue{p: p, dir: true, deferred: true}, name, usage)
+}
+
+// Flag values checked after parsing implement deferredValue. Run
+// calls check for all flags set by any source.
+type deferredValue interface {
+	flag.Value
+	check() error
+}
+
+type existingValue struct {
+	p        *string
+	dir      bool
+	deferred bool
+}
+
+func (v *existingValue) Set(s string) error {
+	if !v.deferred {
+		if err := checkPath(s, v.dir); err != nil {
+			return err
+		}
+	}
+	*v.p = s
+	return nil
+}
+
+func (v *existingValue) check() error {
+	if !v.deferred {
+		return nil
+	}
+	return checkPath(*v.p, v.dir)
+}
+
+func (v *existingValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *existingValue) Get() interface{} {
+	return *v.p
+}
+
+// Checks that path names an existing directory if dir is set, or an
+// existing file otherwise.
+func checkPath(path string, dir bool) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if dir {
+				return errors.New("no such directory")
+			}
+			return errors.New("no such file")
+		}
+		return err
+	}
+	if dir && !fi.IsDir() {
+		return errors.New("is a file, expected directory")
+	}
+	if !dir && fi.IsDir() {
+		return errors.New("is a directory, expected file")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExistingFileVar(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+	tests := []struct {
+		dir   bool
+		value string
+		err   string
+	}{
+		{false, file, ""},
+		{false, missing, "no such file"},
+		{false, dir, "is a directory, expected file"},
+		{true, dir, ""},
+		{true, missing, "no such directory"},
+		{true, file, "is a file, expected directory"},
+	}
+	for _, test := range tests {
+		var path string
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		if test.dir {
+			ExistingDirVar(fs, &path, "path", "", "path")
+		} else {
+			ExistingFileVar(fs, &path, "path", "", "path")
+		}
+		err := fs.Parse([]string{"-path", test.value})
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%q should be accepted but was %v.", test.value, err)
+			} else if path != test.value {
+				t.Errorf("path should be %q but was %q.", test.value, path)
+			}
+		} else if err == nil || !strings.HasSuffix(err.Error(), test.err) {
+			t.Errorf("%q should fail with %q but was %v.", test.value, test.err, err)
+		}
+	}
+}
+
+func TestExistingFileVarDeferred(t *testing.T) {
+	dir := t.TempDir()
+	var config, out string
+	ran := false
+	newPath := func() *Path {
+		p := NewPath()
+		p.Add("deploy", "deploys the app", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				ExistingFileVarDeferred(fs, &config, "config", "", "config file")
+				ExistingDirVarDeferred(fs, &out, "out", "", "output directory")
+			},
+			run: func(args ...string) error {
+				ran = true
+				return nil
+			},
+		})
+		return p
+	}
+	missing := filepath.Join(dir, "missing.json")
+	_, err := newPath().Run("deploy", "-config", missing, "-out", dir)
+	if err == nil || !strings.Contains(err.Error(), "-config") || !strings.Contains(err.Error(), "no such file") {
+		t.Errorf("Run should fail for the missing config but was %v.", err)
+	}
+	if ran {
+		t.Error("The command should not run with an invalid path.")
+	}
+	if config != missing {
+		t.Errorf("config should be set before the check but was %q.", config)
+	}
+	if _, err := newPath().Run("deploy", "-out", dir); err != nil {
+		t.Errorf("Run should succeed without config but was %v.", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Defines a filesystem path flag with specified name, default value,
+// and usage string. The argument p points to a string variable in which
+// to store the value of the flag. A leading ~ is expanded to the home
+// directory of the user and $VAR or ${VAR} to the value of the
+// environment variable, for the default value as well. The help output
+// shows the default as given.
+func PathVar(fs *flag.FlagSet, p *string, name, value, usage string) {
+	pathVar(fs, p, name, value, usage, pathExpand)
+}
+
+// Same as PathVar but also cleans the path with filepath.Clean.
+func PathVarClean(fs *flag.FlagSet, p *string, name, value, usage string) {
+	pathVar(fs, p, name, value, usage, pathClean)
+}
+
+// Same as PathVar but converts the path to a clean absolute path
+// relative to the working directory.
+func PathVarAbs(fs *flag.FlagSet, p *string, name, value, usage string) {
+	pathVar(fs, p, name, value, usage, pathAbs)
+}
+
+type pathMode int
+
+const (
+	pathExpand pathMode = iota
+	pathClean
+	pathAbs
+)
+
+func pathVar(fs *flag.FlagSet, p *string, name, value, usage string, mode pathMode) {
+	v := &pathValue{p: p, mode: mode}
+	if err := v.Set(value); err != nil {
+		*p = value
+	}
+	fs.Var(v, name, usage)
+	fs.Lookup(name).DefValue = value
+}
+
+type pathValue struct {
+	p    *string
+	mode pathMode
+}
+
+func (v *pathValue) Set(s string) error {
+	path, err := expandPath(s)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		switch v.mode {
+		case pathClean:
+			path = filepath.Clean(path)
+		case pathAbs:
+			if path, err = filepath.Abs(path); err != nil {
+				return err
+			}
+		}
+	}
+	*v.p = path
+	return nil
+}
+
+func (v *pathValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *pathValue) Get() interface{} {
+	return *v.p
+}
+
+// Expands a leading ~ and environment variables in path.
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+	return os.ExpandEnv(path), nil
+}
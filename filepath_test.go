@@ -0,0 +1,87 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathVar(t *testing.T) {
+	t.Setenv("HOME", "/home/gopher")
+	t.Setenv("LOG_DIR", "/var/log")
+	tests := []struct {
+		value, want string
+	}{
+		{"~", "/home/gopher"},
+		{"~/logs", "/home/gopher/logs"},
+		{"$HOME/logs", "/home/gopher/logs"},
+		{"${LOG_DIR}/app", "/var/log/app"},
+		{"logs/../app", "logs/../app"},
+		{"~other/logs", "~other/logs"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		var path string
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		PathVar(fs, &path, "dir", "", "directory")
+		if err := fs.Parse([]string{"-dir", test.value}); err != nil {
+			t.Fatal(err)
+		}
+		if path != test.want {
+			t.Errorf("%q should expand to %q but was %q.", test.value, test.want, path)
+		}
+	}
+}
+
+func TestPathVarDefault(t *testing.T) {
+	t.Setenv("HOME", "/home/gopher")
+	var path string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	PathVar(fs, &path, "dir", "~/logs", "directory")
+	if path != "/home/gopher/logs" {
+		t.Errorf("Default should expand to %q but was %q.", "/home/gopher/logs", path)
+	}
+	fs.PrintDefaults()
+	if want := "(default ~/logs)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+}
+
+func TestPathVarCleanAbs(t *testing.T) {
+	t.Setenv("HOME", "/home/gopher")
+	var clean, abs string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	PathVarClean(fs, &clean, "clean", "", "cleaned path")
+	PathVarAbs(fs, &abs, "abs", "", "absolute path")
+	if err := fs.Parse([]string{"-clean", "~/a/../b/", "-abs", "logs/../app"}); err != nil {
+		t.Fatal(err)
+	}
+	if clean != "/home/gopher/b" {
+		t.Errorf("clean should be %q but was %q.", "/home/gopher/b", clean)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(wd, "app"); abs != want {
+		t.Errorf("abs should be %q but was %q.", want, abs)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The placeholder in the command line template of RunForEach replaced
+// by a line of input.
+const forEachPlaceholder = "{}"
+
+// Options of RunForEach.
+type ForEachOptions struct {
+	// The number of lines run at the same time. Lines are run one
+	// after the other if it is 1 or less. More than one requires
+	// NewPath.
+	Parallel int
+	// Keeps running the remaining lines after a line failed instead of
+	// stopping at the first failure.
+	KeepGoing bool
+	// Returns a new path with the commands of the path RunForEach is
+	// called on, such as the function building the commands of the
+	// program. Each parallel worker runs its lines on a path of its
+	// own, since a command has only one set of flags.
+	NewPath func() *Path
+}
+
+// The outcome of running the command line of a line of input.
+type ForEachResult struct {
+	Line string
+	// the command line the template was expanded to
+	Args []string
+	Err  error
+}
+
+// Runs the command line template once for every non-blank line read
+// from r, like xargs: each "{}" in the arguments of template is
+// replaced by the line, or the line is appended if template has no
+// placeholder. The command lines are dispatched by p like the arguments
+// of Run, and the flags of the commands are reset after each line, so
+// the lines don't see each other's flags. RunForEach stops at the first
+// failing line unless opts.KeepGoing is set, lines already running in
+// parallel are finished. It returns the results of the lines run in
+// the order of the input and a ForEachError if any of them failed.
+func (p *Path) RunForEach(ctx context.Context, r io.Reader, template []string, opts ForEachOptions) ([]ForEachResult, error) {
+	if len(template) == 0 {
+		return nil, errors.New("No command line to run for each line")
+	}
+	workers := opts.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > 1 && opts.NewPath == nil {
+		return nil, errors.New("Running lines in parallel requires ForEachOptions.NewPath")
+	}
+	var (
+		mu      sync.Mutex
+		results []ForEachResult
+		indices []int
+		failed  bool
+	)
+	run := func(q *Path, index int, line string) {
+		args := expandForEach(template, line)
+		c, err := q.RunContext(ctx, args...)
+		if c != nil {
+			c.resetFlags()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, ForEachResult{Line: line, Args: args, Err: err})
+		indices = append(indices, index)
+		failed = failed || err != nil
+	}
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed && !opts.KeepGoing
+	}
+	s := bufio.NewScanner(r)
+	next := func() (string, bool) {
+		for !stopped() && s.Scan() {
+			if line := strings.TrimSuffix(s.Text(), "\r"); strings.TrimSpace(line) != "" {
+				return line, true
+			}
+		}
+		return "", false
+	}
+	if workers == 1 {
+		for index := 0; ; index++ {
+			line, ok := next()
+			if !ok {
+				break
+			}
+			run(p, index, line)
+		}
+	} else {
+		type job struct {
+			index int
+			line  string
+		}
+		jobs := make(chan job)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			q := opts.NewPath()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range jobs {
+					run(q, j.index, j.line)
+				}
+			}()
+		}
+		for index := 0; ; index++ {
+			line, ok := next()
+			if !ok {
+				break
+			}
+			jobs <- job{index, line}
+		}
+		close(jobs)
+		wg.Wait()
+	}
+	sortForEachResults(results, indices)
+	if err := s.Err(); err != nil {
+		return results, fmt.Errorf("Reading the lines to run: %w", err)
+	}
+	var e *ForEachError
+	for _, r := range results {
+		if r.Err != nil {
+			if e == nil {
+				e = &ForEachError{Lines: len(results)}
+			}
+			e.Failed = append(e.Failed, r)
+		}
+	}
+	if e == nil {
+		return results, nil
+	}
+	return results, e
+}
+
+// Returns template with the placeholders replaced by line.
+func expandForEach(template []string, line string) []string {
+	args := make([]string, len(template), len(template)+1)
+	replaced := false
+	for i, arg := range template {
+		if strings.Contains(arg, forEachPlaceholder) {
+			arg = strings.Replace(arg, forEachPlaceholder, line, -1)
+			replaced = true
+		}
+		args[i] = arg
+	}
+	if !replaced {
+		args = append(args, line)
+	}
+	return args
+}
+
+func sortForEachResults(results []ForEachResult, indices []int) {
+	sort.Sort(forEachOrder{results, indices})
+}
+
+type forEachOrder struct {
+	results []ForEachResult
+	indices []int
+}
+
+func (o forEachOrder) Len() int           { return len(o.results) }
+func (o forEachOrder) Less(i, j int) bool { return o.indices[i] < o.indices[j] }
+func (o forEachOrder) Swap(i, j int) {
+	o.results[i], o.results[j] = o.results[j], o.results[i]
+	o.indices[i], o.indices[j] = o.indices[j], o.indices[i]
+}
+
+// Returned by RunForEach if the command line of some lines failed. The
+// message has the form
+//
+//	2 of 5 lines failed
+//
+// and the errors of the lines can be inspected with errors.Is and
+// errors.As.
+type ForEachError struct {
+	// the number of lines run
+	Lines int
+	// the results of the failed lines, in the order of the input
+	Failed []ForEachResult
+}
+
+func (e *ForEachError) Error() string {
+	return fmt.Sprintf("%d of %d lines failed", len(e.Failed), e.Lines)
+}
+
+func (e *ForEachError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// Registers the command foreach, which runs the command line given as
+// its arguments for every line of its standard input like RunForEach,
+// as in
+//
+//	cat hosts | app foreach ping {}
+//
+// and prints a summary line per input line, such as
+//
+//	ok    host1
+//	FAIL  host2: ping: unreachable
+//
+// --keep-going runs the remaining lines after a failure and
+// --parallel N runs N lines at the same time, each worker on a path
+// returned by newPath. --parallel fails if newPath is nil.
+func (p *Path) AddForEachCommand(newPath func() *Path) *CmdCont {
+	return p.Add("foreach", "runs a command for every line of standard input", &forEachCmd{p: p, newPath: newPath})
+}
+
+type forEachCmd struct {
+	p         *Path
+	newPath   func() *Path
+	parallel  int
+	keepGoing bool
+}
+
+func (c *forEachCmd) Flags(fs *flag.FlagSet) {
+	fs.IntVar(&c.parallel, "parallel", 1, "run `N` lines at the same time")
+	fs.BoolVar(&c.keepGoing, "keep-going", false, "run the remaining lines after a line failed")
+}
+
+func (c *forEachCmd) Run(args ...string) error {
+	return c.RunContext(context.Background(), args...)
+}
+
+func (c *forEachCmd) RunContext(ctx context.Context, args ...string) error {
+	env := EnvFromContext(ctx)
+	results, err := c.p.RunForEach(ctx, env.Stdin, args, ForEachOptions{
+		Parallel:  c.parallel,
+		KeepGoing: c.keepGoing,
+		NewPath:   c.newPath,
+	})
+	for _, r := range results {
+		line := "ok    " + r.Line
+		if r.Err != nil {
+			line = "FAIL  " + r.Line + ": " + strings.Replace(r.Err.Error(), "\n", "\n      ", -1)
+		}
+		if _, werr := fmt.Fprintln(env.Stdout, line); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pingLog struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (l *pingLog) add(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hosts = append(l.hosts, host)
+}
+
+// Returns a path with the command ping, which fails for hosts starting
+// with "bad", and the command set, which records its flags.
+func newForEachPath(log *pingLog, wait func()) *Path {
+	p := NewPath()
+	p.Add("ping", "pings a host", &testCmd{
+		run: func(args ...string) error {
+			if wait != nil {
+				wait()
+			}
+			log.add(strings.Join(args, " "))
+			if strings.HasPrefix(args[len(args)-1], "bad") {
+				return fmt.Errorf("%s unreachable", args[len(args)-1])
+			}
+			return nil
+		},
+	})
+	var x, y string
+	p.Add("set", "sets values", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&x, "x", "", "x")
+			fs.StringVar(&y, "y", "", "y")
+		},
+		run: func(args ...string) error {
+			log.add("x=" + x + " y=" + y)
+			return nil
+		},
+	})
+	return p
+}
+
+func TestRunForEach(t *testing.T) {
+	var log pingLog
+	p := newForEachPath(&log, nil)
+	results, err := p.RunForEach(context.Background(), strings.NewReader("a\n\nb\r\nc\n"), []string{"ping", "{}.example.com"}, ForEachOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.example.com", "b.example.com", "c.example.com"}; !reflect.DeepEqual(log.hosts, want) {
+		t.Errorf("ping should run with %q but ran with %q.", want, log.hosts)
+	}
+	if len(results) != 3 || results[1].Line != "b" || !reflect.DeepEqual(results[1].Args, []string{"ping", "b.example.com"}) {
+		t.Errorf("Results should list the lines but were %+v.", results)
+	}
+
+	log.hosts = nil
+	if _, err := p.RunForEach(context.Background(), strings.NewReader("-x=1\n-y=2\n"), []string{"set"}, ForEachOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"x=1 y=", "x= y=2"}; !reflect.DeepEqual(log.hosts, want) {
+		t.Errorf("Each line should run with its own flags %q but ran with %q.", want, log.hosts)
+	}
+}
+
+func TestRunForEachFailure(t *testing.T) {
+	var log pingLog
+	p := newForEachPath(&log, nil)
+	results, err := p.RunForEach(context.Background(), strings.NewReader("a\nbad1\nc\nbad2\n"), []string{"ping"}, ForEachOptions{})
+	if want := []string{"a", "bad1"}; !reflect.DeepEqual(log.hosts, want) {
+		t.Errorf("RunForEach should stop after the failing line and run %q but ran %q.", want, log.hosts)
+	}
+	if len(results) != 2 {
+		t.Errorf("RunForEach should return 2 results but returned %d.", len(results))
+	}
+	var fe *ForEachError
+	if !errors.As(err, &fe) || err.Error() != "1 of 2 lines failed" {
+		t.Fatalf("RunForEach should fail with a ForEachError but returned %v.", err)
+	}
+
+	log.hosts = nil
+	_, err = p.RunForEach(context.Background(), strings.NewReader("a\nbad1\nc\nbad2\n"), []string{"ping"}, ForEachOptions{KeepGoing: true})
+	if want := []string{"a", "bad1", "c", "bad2"}; !reflect.DeepEqual(log.hosts, want) {
+		t.Errorf("RunForEach should run all lines %q with KeepGoing but ran %q.", want, log.hosts)
+	}
+	if !errors.As(err, &fe) || err.Error() != "2 of 4 lines failed" {
+		t.Fatalf("RunForEach should fail with a ForEachError but returned %v.", err)
+	}
+	if fe.Failed[0].Line != "bad1" || fe.Failed[1].Line != "bad2" {
+		t.Errorf("The failed lines should be bad1 and bad2 but were %+v.", fe.Failed)
+	}
+	if errs := fe.Unwrap(); len(errs) != 2 || errs[0].Error() != "bad1 unreachable" {
+		t.Errorf("The error should wrap the errors of the lines but wrapped %q.", errs)
+	}
+}
+
+func TestRunForEachParallel(t *testing.T) {
+	var log pingLog
+	// each ping waits until two of them are running
+	var running sync.WaitGroup
+	running.Add(2)
+	wait := func() {
+		running.Done()
+		done := make(chan struct{})
+		go func() {
+			running.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("The lines should run in parallel.")
+		}
+	}
+	newPath := func() *Path {
+		return newForEachPath(&log, wait)
+	}
+	if _, err := NewPath().RunForEach(context.Background(), strings.NewReader("a\nb\n"), []string{"ping"}, ForEachOptions{Parallel: 2}); err == nil {
+		t.Error("RunForEach should fail to run in parallel without NewPath.")
+	}
+	results, err := NewPath().RunForEach(context.Background(), strings.NewReader("a\nb\n"), []string{"ping"}, ForEachOptions{Parallel: 2, NewPath: newPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Line != "a" || results[1].Line != "b" {
+		t.Errorf("Results should be in input order but were %+v.", results)
+	}
+	if len(log.hosts) != 2 {
+		t.Errorf("ping should run twice but ran %q.", log.hosts)
+	}
+}
+
+func TestForEachCommand(t *testing.T) {
+	var log pingLog
+	p := newForEachPath(&log, nil)
+	p.AddForEachCommand(nil)
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader("a\nbad1\nc\n"), Stdout: &out})
+	_, err := p.RunContext(ctx, "foreach", "-keep-going", "ping", "{}")
+	if err == nil {
+		t.Error("foreach should fail if a line failed.")
+	}
+	if want := "ok    a\nFAIL  bad1: bad1 unreachable\nok    c\n"; out.String() != want {
+		t.Errorf("foreach should print %q but printed %q.", want, out.String())
+	}
+	if _, err := p.RunContext(ctx, "foreach", "-parallel", "2", "ping"); err == nil {
+		t.Error("foreach --parallel should fail without a path constructor.")
+	}
+}
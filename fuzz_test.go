@@ -0,0 +1,111 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns a tree of paths using the argument pre-processors, with
+// commands that do nothing. File expansion isn't used, as arguments
+// such as @/dev/zero would read without bounds.
+func newFuzzPath() *Path {
+	flags := func(fs *flag.FlagSet) {
+		fs.SetOutput(io.Discard)
+		fs.String("env", "", "target environment")
+		fs.Bool("force", false, "skip checks")
+		fs.Bool("v", false, "verbose output")
+		fs.Bool("q", false, "quiet output")
+		Count(fs, "d", "debug level")
+		fs.Int("port", 80, "server port")
+		var tags []string
+		StringSliceVar(fs, &tags, "tag", "release tags")
+		var labels map[string]string
+		StringMapVar(fs, &labels, "label", "labels")
+		var backoff []time.Duration
+		DurationSliceVar(fs, &backoff, "backoff", nil, "retry delays")
+		var mode string
+		EnumVar(fs, &mode, "mode", "safe", []string{"fast", "safe"}, "deploy mode")
+		var color bool
+		BoolWithInverseVar(fs, &color, "color", true, "colored output")
+		var selector map[string]string
+		JSONVar(fs, &selector, "selector", "selector")
+		var size int64
+		BytesVar(fs, &size, "size", 0, "size limit")
+	}
+	newPath := func() *Path {
+		p := NewPath()
+		p.SetOutput(io.Discard)
+		p.stdout = io.Discard
+		p.Flags.SetOutput(io.Discard)
+		p.Flags.Bool("verbose", false, "verbose output")
+		p.PersistentFlags().String("namespace", "default", "namespace")
+		return p
+	}
+	setup := func(c *CmdCont) {
+		c.FlagAlias("env", "e")
+		c.RenameFlag("yes", "force")
+		c.SetCombinedShorts(true)
+		c.ValidateFlagIfSet("env", func(v string) error { return nil })
+	}
+	p := newPath()
+	setup(p.Add("deploy", "deploys the app", &testCmd{flags: flags}, "env"))
+	setup(p.Add("größe", "prints the size", &testCmd{flags: flags}))
+	// nest paths several levels deep
+	leaf := p
+	for _, name := range []string{"remote", "branch", "ref"} {
+		sub := newPath()
+		setup(sub.Add("add", "adds an entry", &testCmd{flags: flags}))
+		leaf.Mount(name, "manages entries", sub)
+		leaf = sub
+	}
+	return p
+}
+
+func FuzzDispatch(f *testing.F) {
+	for _, args := range [][]string{
+		{"deploy", "-env", "prod"},
+		{"deploy", "--env=prod", "--", "-web", "db"},
+		{"deploy", "-e=", "-tag", "a,b", "-tag=c", "-label", "k=v"},
+		{"deploy", "-vqddd", "-env", `"prod"`, "'quoted arg'"},
+		{"deploy", "-env", "prod", "-no-color", "-color"},
+		{"deploy", "-env", "prod", "-selector", `{"app":"web"}`, "-size", "1KiB"},
+		{"deploy", "-env", "prod", "-backoff", "1s,5s", "-mode", "fast", "-yes"},
+		{"deploy", "-envv", "prod"},
+		{"-verbose", "größe", "-port", "0x50"},
+		{"remote", "-namespace", "ns", "branch", "ref", "add", "-env", "x", "--"},
+		{"remote", "branch", "ref"},
+		{"__complete", "remote", "br"},
+		{"-h"},
+		{"deploy", "--help"},
+		{"=", "--", "-", "---env"},
+		{""},
+	} {
+		f.Add(strings.Join(args, "\x00"))
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		// arguments are separated by NUL, which they can't contain
+		args := strings.Split(s, "\x00")
+		c, err := newFuzzPath().Run(args...)
+		// the built-in completion command doesn't match a command
+		if err == nil && c == nil && args[0] != completeCmd {
+			t.Errorf("Run(%q) should return an error or a command.", args)
+		}
+	})
+}
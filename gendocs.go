@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The documentation formats of GenDocsMain by name, with the extension
+// of the generated file.
+var docFormats = map[string]struct {
+	ext   string
+	write func(p *Path, w io.Writer, prog string) error
+}{
+	"man":      {".1", (*Path).WriteMan},
+	"markdown": {".md", (*Path).WriteMarkdown},
+	"rest":     {".rst", (*Path).WriteReST},
+	"json": {".json", func(p *Path, w io.Writer, prog string) error {
+		return p.Spec().WriteJSON(w)
+	}},
+}
+
+// Generates the documentation of p and exits, meant to be the main
+// function of a generator run by go:generate:
+//
+//	//go:generate go run ./cmd/gendocs --format man --out docs --prog app
+//
+// The flags select the format, one of man, markdown, rest or json for
+// the Spec, the output directory and the name of the program, which
+// defaults to the name of the global flags of p. The file written,
+// such as docs/app.1, is printed to standard output. Invalid flags
+// exit with 2 and other errors with 1.
+func GenDocsMain(p *Path) {
+	os.Exit(genDocs(p, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Implements GenDocsMain and returns the exit code.
+func genDocs(p *Path, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gendocs", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var format string
+	EnumVar(fs, &format, "format", "markdown", []string{"man", "markdown", "rest", "json"}, "documentation format")
+	out := fs.String("out", ".", "output directory")
+	prog := fs.String("prog", p.Flags.Name(), "program name")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *prog == "" {
+		fmt.Fprintln(stderr, "gendocs: --prog is required")
+		return 2
+	}
+	name, err := writeDocFile(p, format, *out, *prog)
+	if err != nil {
+		fmt.Fprintf(stderr, "gendocs: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", name)
+	return 0
+}
+
+// Writes the documentation of the program prog in format to a file in
+// dir and returns its name.
+func writeDocFile(p *Path, format, dir, prog string) (name string, err error) {
+	f := docFormats[format]
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name = filepath.Join(dir, prog+f.ext)
+	file, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return name, f.write(p, file, prog)
+}
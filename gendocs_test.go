@@ -0,0 +1,76 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenDocs(t *testing.T) {
+	p, _ := newGroupPath()
+	for format, f := range docFormats {
+		dir := filepath.Join(t.TempDir(), "docs")
+		var stdout, stderr strings.Builder
+		if code := genDocs(p, []string{"--format", format, "--out", dir, "--prog", "app"}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Exit code for %s should be 0 but was %d: %s", format, code, stderr.String())
+		}
+		name := filepath.Join(dir, "app"+f.ext)
+		if want := "wrote " + name + "\n"; stdout.String() != want {
+			t.Errorf("Output for %s should be %q but was %q.", format, want, stdout.String())
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want strings.Builder
+		if err := f.write(p, &want, "app"); err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want.String() {
+			t.Errorf("File for %s should be %q but was %q.", format, want.String(), b)
+		}
+	}
+}
+
+func TestGenDocsErrors(t *testing.T) {
+	p, _ := newGroupPath()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{"--format", "pdf", "--prog", "app"}, 2, "must be one of man, markdown, rest, json"},
+		{[]string{"--format", "man"}, 2, "gendocs: --prog is required\n"},
+		{[]string{"--out", file, "--prog", "app"}, 1, "gendocs: mkdir " + file},
+	} {
+		var stdout, stderr strings.Builder
+		if code := genDocs(p, test.args, &stdout, &stderr); code != test.code {
+			t.Errorf("Exit code for %q should be %d but was %d.", test.args, test.code, code)
+		}
+		if !strings.Contains(stderr.String(), test.want) {
+			t.Errorf("Errors for %q should contain %q but were %q.", test.args, test.want, stderr.String())
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("Output for %q should be empty but was %q.", test.args, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Returns the value of the named flag of the command, which must be of
+// type T. The value is taken from flag.Getter, or else parsed from its
+// string form with parse if not nil.
+func getFlag[T any](c *CmdCont, name string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return zero, fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	v := f.Value
+	if m, ok := v.(*mirrorValue); ok {
+		v = m.Value
+	}
+	if x, ok := getValue(v).(T); ok {
+		return x, nil
+	}
+	if parse != nil {
+		if x, err := parse(v.String()); err == nil {
+			return x, nil
+		}
+	}
+	return zero, fmt.Errorf("Flag -%s of command %q is of type %s, not %s", name, c.Name, valueType(v), reflect.TypeOf(&zero).Elem())
+}
+
+// Returns the value of the named string flag, or of any other flag in
+// its string form.
+func (c *CmdCont) GetString(name string) (string, error) {
+	return getFlag(c, name, func(s string) (string, error) { return s, nil })
+}
+
+// Returns the value of the named bool flag. It fails if the flag
+// doesn't exist or isn't a bool, as do the other getters for their
+// types.
+func (c *CmdCont) GetBool(name string) (bool, error) {
+	return getFlag(c, name, strconv.ParseBool)
+}
+
+// Same as GetBool but for int flags, including Count.
+func (c *CmdCont) GetInt(name string) (int, error) {
+	return getFlag(c, name, strconv.Atoi)
+}
+
+// Same as GetBool but for int64 flags, including Bytes.
+func (c *CmdCont) GetInt64(name string) (int64, error) {
+	return getFlag(c, name, func(s string) (int64, error) { return strconv.ParseInt(s, 0, 64) })
+}
+
+// Same as GetBool but for uint flags.
+func (c *CmdCont) GetUint(name string) (uint, error) {
+	return getFlag(c, name, func(s string) (uint, error) {
+		n, err := strconv.ParseUint(s, 0, strconv.IntSize)
+		return uint(n), err
+	})
+}
+
+// Same as GetBool but for uint64 flags.
+func (c *CmdCont) GetUint64(name string) (uint64, error) {
+	return getFlag(c, name, func(s string) (uint64, error) { return strconv.ParseUint(s, 0, 64) })
+}
+
+// Same as GetBool but for float64 flags.
+func (c *CmdCont) GetFloat64(name string) (float64, error) {
+	return getFlag(c, name, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+}
+
+// Same as GetBool but for duration flags.
+func (c *CmdCont) GetDuration(name string) (time.Duration, error) {
+	return getFlag(c, name, time.ParseDuration)
+}
+
+// Same as GetBool but for StringSlice flags.
+func (c *CmdCont) GetStringSlice(name string) ([]string, error) {
+	return getFlag[[]string](c, name, nil)
+}
+
+// Same as GetBool but for IntSlice flags.
+func (c *CmdCont) GetIntSlice(name string) ([]int, error) {
+	return getFlag[[]int](c, name, nil)
+}
+
+// Same as GetBool but for Int64Slice flags.
+func (c *CmdCont) GetInt64Slice(name string) ([]int64, error) {
+	return getFlag[[]int64](c, name, nil)
+}
+
+// Same as GetBool but for DurationSlice flags.
+func (c *CmdCont) GetDurationSlice(name string) ([]time.Duration, error) {
+	return getFlag[[]time.Duration](c, name, nil)
+}
+
+// Same as GetBool but for StringMap flags.
+func (c *CmdCont) GetStringMap(name string) (map[string]string, error) {
+	return getFlag[map[string]string](c, name, nil)
+}
+
+// Same as GetBool but for Time flags.
+func (c *CmdCont) GetTime(name string) (time.Time, error) {
+	return getFlag[time.Time](c, name, nil)
+}
+
+// Same as GetBool but for IP flags.
+func (c *CmdCont) GetIP(name string) (netip.Addr, error) {
+	return getFlag(c, name, netip.ParseAddr)
+}
+
+// Same as GetBool but for CIDR flags.
+func (c *CmdCont) GetCIDR(name string) (netip.Prefix, error) {
+	return getFlag(c, name, netip.ParsePrefix)
+}
+
+// Same as GetBool but for URL flags.
+func (c *CmdCont) GetURL(name string) (*url.URL, error) {
+	return getFlag[*url.URL](c, name, nil)
+}
+
+// Same as GetBool but for Regexp flags.
+func (c *CmdCont) GetRegexp(name string) (*regexp.Regexp, error) {
+	return getFlag[*regexp.Regexp](c, name, nil)
+}
+
+// Same as GetBool but for Level flags.
+func (c *CmdCont) GetLevel(name string) (Level, error) {
+	return getFlag(c, name, ParseLevel)
+}
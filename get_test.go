@@ -0,0 +1,142 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// A flag.Value without Get, read back through its string form.
+type plainValue string
+
+func (v *plainValue) Set(s string) error {
+	*v = plainValue(s)
+	return nil
+}
+
+func (v *plainValue) String() string {
+	return string(*v)
+}
+
+func TestGetters(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{flags: func(fs *flag.FlagSet) {
+		fs.String("env", "", "target environment")
+		fs.Bool("force", false, "skip checks")
+		fs.Int("replicas", 1, "number of replicas")
+		fs.Int64("seed", 0, "random seed")
+		fs.Uint("workers", 0, "number of workers")
+		fs.Uint64("quota", 0, "quota")
+		fs.Float64("ratio", 0, "traffic ratio")
+		fs.Duration("timeout", 0, "timeout")
+		Count(fs, "v", "verbosity")
+		BytesVar(fs, new(int64), "max-size", 0, "maximum size")
+		EnumVar(fs, new(string), "format", "text", []string{"text", "json"}, "output format")
+		StringSliceVar(fs, new([]string), "tag", "tags")
+		IntSliceVar(fs, new([]int), "port", "ports")
+		Int64SliceVar(fs, new([]int64), "id", "ids")
+		DurationSliceVar(fs, new([]time.Duration), "backoff", nil, "backoff")
+		StringMapVar(fs, new(map[string]string), "label", "labels")
+		TimeVar(fs, new(time.Time), "since", time.Time{}, nil, "start time")
+		IPVar(fs, new(netip.Addr), "addr", netip.Addr{}, "address")
+		CIDRVar(fs, new(netip.Prefix), "net", netip.Prefix{}, "network")
+		URLVar(fs, new(*url.URL), "endpoint", "", "API endpoint")
+		RegexpVar(fs, new(*regexp.Regexp), "match", "", "filter")
+		LevelVar(fs, new(Level), "log-level", LevelInfo, "log level")
+		fs.Var(new(plainValue), "plain", "a value without Get")
+		fs.Var(new(plainValue), "plain-int", "a value without Get")
+	}})
+	if _, err := p.Validate("deploy",
+		"-env", "prod", "-force", "-replicas", "3", "-seed", "-7", "-workers", "4", "-quota", "9",
+		"-ratio", "0.5", "-timeout", "1m", "-v", "-v", "-max-size", "2KiB", "-format", "json",
+		"-tag", "a", "-tag", "b", "-port", "80,443", "-id", "5", "-backoff", "1s,2s", "-label", "team=web",
+		"-since", "2024-05-01", "-addr", "10.0.0.1", "-net", "10.0.0.0/8", "-endpoint", "https://example.com/api",
+		"-match", "^a+$", "-log-level", "debug", "-plain", "x", "-plain-int", "12",
+	); err != nil {
+		t.Fatal(err)
+	}
+	get := func(v interface{}, err error) interface{} {
+		if err != nil {
+			t.Error(err)
+		}
+		return v
+	}
+	since, _ := time.Parse("2006-01-02", "2024-05-01")
+	for _, test := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"env", get(c.GetString("env")), "prod"},
+		{"force", get(c.GetBool("force")), true},
+		{"replicas", get(c.GetInt("replicas")), 3},
+		{"seed", get(c.GetInt64("seed")), int64(-7)},
+		{"workers", get(c.GetUint("workers")), uint(4)},
+		{"quota", get(c.GetUint64("quota")), uint64(9)},
+		{"ratio", get(c.GetFloat64("ratio")), 0.5},
+		{"timeout", get(c.GetDuration("timeout")), time.Minute},
+		{"v", get(c.GetInt("v")), 2},
+		{"max-size", get(c.GetInt64("max-size")), int64(2048)},
+		{"format", get(c.GetString("format")), "json"},
+		{"tag", get(c.GetStringSlice("tag")), []string{"a", "b"}},
+		{"port", get(c.GetIntSlice("port")), []int{80, 443}},
+		{"id", get(c.GetInt64Slice("id")), []int64{5}},
+		{"backoff", get(c.GetDurationSlice("backoff")), []time.Duration{time.Second, 2 * time.Second}},
+		{"label", get(c.GetStringMap("label")), map[string]string{"team": "web"}},
+		{"since", get(c.GetTime("since")), since},
+		{"addr", get(c.GetIP("addr")), netip.MustParseAddr("10.0.0.1")},
+		{"net", get(c.GetCIDR("net")), netip.MustParsePrefix("10.0.0.0/8")},
+		{"log-level", get(c.GetLevel("log-level")), LevelDebug},
+		{"plain", get(c.GetString("plain")), "x"},
+		{"plain-int", get(c.GetInt("plain-int")), 12},
+		// the string form of other types
+		{"replicas", get(c.GetString("replicas")), "3"},
+	} {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("The value of -%s should be %#v but was %#v.", test.name, test.want, test.got)
+		}
+	}
+	if u, err := c.GetURL("endpoint"); err != nil || u.String() != "https://example.com/api" {
+		t.Errorf("The URL should be %q but was %v (%v).", "https://example.com/api", u, err)
+	}
+	if re, err := c.GetRegexp("match"); err != nil || !re.MatchString("aaa") {
+		t.Errorf("The regexp should match %q but was %v (%v).", "aaa", re, err)
+	}
+}
+
+func TestGettersErrors(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{flags: func(fs *flag.FlagSet) {
+		fs.String("env", "prod", "target environment")
+		fs.Duration("timeout", time.Second, "timeout")
+	}})
+	if _, err := c.GetInt("missing"); err == nil || err.Error() != `No such flag -missing for command "deploy"` {
+		t.Errorf("An unknown flag should fail but failed with %v.", err)
+	}
+	if _, err := c.GetInt("env"); err == nil || err.Error() != `Flag -env of command "deploy" is of type string, not int` {
+		t.Errorf("A string flag should not be read as int but failed with %v.", err)
+	}
+	if _, err := c.GetBool("timeout"); err == nil || err.Error() != `Flag -timeout of command "deploy" is of type time.Duration, not bool` {
+		t.Errorf("A duration flag should not be read as bool but failed with %v.", err)
+	}
+	if _, err := c.GetStringSlice("env"); err == nil {
+		t.Error("A string flag should not be read as a slice.")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strconv"
+	"time"
+)
+
+// Returns the value of the named global flag of the paths the command
+// was run from, including their persistent flags. Flags of the closest
+// path shadow those of enclosing paths, as for PersistentFlags. The
+// values are available once Run dispatched to the command.
+func (c *CmdCont) Global(name string) (flag.Value, bool) {
+	for _, fs := range c.globals {
+		if f := fs.Lookup(name); f != nil {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Same as Global but returns the value of a bool flag. The second
+// result is false if the flag doesn't exist or isn't a bool.
+func (c *CmdCont) GlobalBool(name string) (bool, bool) {
+	v, ok := c.Global(name)
+	if !ok {
+		return false, false
+	}
+	if b, ok := getValue(v).(bool); ok {
+		return b, true
+	}
+	b, err := strconv.ParseBool(v.String())
+	return b, err == nil
+}
+
+// Same as Global but returns the value in its string form.
+func (c *CmdCont) GlobalString(name string) (string, bool) {
+	v, ok := c.Global(name)
+	if !ok {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// Same as GlobalBool but for int flags.
+func (c *CmdCont) GlobalInt(name string) (int, bool) {
+	v, ok := c.Global(name)
+	if !ok {
+		return 0, false
+	}
+	if n, ok := getValue(v).(int); ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(v.String())
+	return n, err == nil
+}
+
+// Same as GlobalBool but for duration flags.
+func (c *CmdCont) GlobalDuration(name string) (time.Duration, bool) {
+	v, ok := c.Global(name)
+	if !ok {
+		return 0, false
+	}
+	if d, ok := getValue(v).(time.Duration); ok {
+		return d, true
+	}
+	d, err := time.ParseDuration(v.String())
+	return d, err == nil
+}
+
+// Returns the result of Get if v implements flag.Getter.
+func getValue(v flag.Value) interface{} {
+	if g, ok := v.(flag.Getter); ok {
+		return g.Get()
+	}
+	return nil
+}
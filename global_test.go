@@ -0,0 +1,97 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGlobal(t *testing.T) {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	p.Flags.Int("retries", 1, "number of retries")
+	p.Flags.String("region", "eu", "region")
+	p.PersistentFlags().Duration("timeout", time.Second, "timeout")
+	p.PersistentFlags().String("region", "us", "shadowed region")
+
+	var (
+		verbose, verboseOK bool
+		retries            int
+		region             string
+		timeout            time.Duration
+		undefined          []bool
+	)
+	var c *CmdCont
+	c = p.Add("deploy", "deploys the app", &testCmd{
+		run: func(args ...string) error {
+			verbose, verboseOK = c.GlobalBool("verbose")
+			retries, _ = c.GlobalInt("retries")
+			region, _ = c.GlobalString("region")
+			timeout, _ = c.GlobalDuration("timeout")
+			_, ok1 := c.Global("missing")
+			_, ok2 := c.GlobalBool("missing")
+			_, ok3 := c.GlobalInt("region")
+			undefined = []bool{ok1, ok2, ok3}
+			return nil
+		},
+	})
+	if _, err := p.Run("-verbose", "-retries", "3", "deploy", "-timeout", "5s"); err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || !verboseOK {
+		t.Errorf("verbose should be true but was %v (%v).", verbose, verboseOK)
+	}
+	if retries != 3 {
+		t.Errorf("retries should be 3 but was %d.", retries)
+	}
+	if region != "eu" {
+		t.Errorf("region should be %q but was %q.", "eu", region)
+	}
+	if timeout != 5*time.Second {
+		t.Errorf("timeout should be %v but was %v.", 5*time.Second, timeout)
+	}
+	for i, ok := range undefined {
+		if ok {
+			t.Errorf("Lookup %d of an undefined or mistyped flag should fail.", i)
+		}
+	}
+}
+
+func TestGlobalNested(t *testing.T) {
+	var o remoteOpts
+	p := newRemotePath(&o)
+	p.Flags.Bool("verbose", false, "verbose output")
+	remote := p.registry().entries["remote"].sub
+	remote.Flags.String("remote-type", "git", "remote type")
+
+	var namespace, remoteType string
+	var verbose bool
+	var c *CmdCont
+	c = remote.Add("list", "lists remotes", &testCmd{
+		run: func(args ...string) error {
+			namespace, _ = c.GlobalString("namespace")
+			remoteType, _ = c.GlobalString("remote-type")
+			verbose, _ = c.GlobalBool("verbose")
+			return nil
+		},
+	})
+	if _, err := p.Run("-verbose", "remote", "-remote-type", "svn", "list", "-namespace", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "prod" || remoteType != "svn" || !verbose {
+		t.Errorf("Globals should be prod, svn and true but were %q, %q and %v.", namespace, remoteType, verbose)
+	}
+}
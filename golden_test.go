@@ -0,0 +1,190 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Drachenfels-GmbH/command"
+	"github.com/Drachenfels-GmbH/command/commandtest"
+)
+
+// Returns a path using most features of the help renderers.
+func newDocsPath(t *testing.T) (*command.Path, *command.CmdCont) {
+	p := command.NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	c := p.Add("deploy", "deploys the app", command.CmdFunc(func(args []string) error { return nil }), "env")
+	c.Flags.String("host", "localhost", "server host")
+	c.Flags.Int("port", 80, "server port")
+	c.Flags.String("env", "", "target `ENV`")
+	c.Flags.Bool("quiet", false, "suppress output")
+	var tags []string
+	command.StringSliceVar(c.Flags, &tags, "tag", "release tags")
+	var mode string
+	command.EnumVar(c.Flags, &mode, "mode", "safe", []string{"fast", "safe"}, "deploy mode")
+	for _, err := range []error{
+		c.FlagAlias("quiet", "q"),
+		c.FlagGroup("Connection options", "host", "port"),
+		c.DeprecateFlag("tag", "use releases instead"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote := command.NewPath()
+	remote.Add("add", "adds a remote", &remoteAdd{})
+	p.Mount("remote", "manages remotes", remote)
+	return p, c
+}
+
+type remoteAdd struct{}
+
+func (remoteAdd) Flags(fs *flag.FlagSet) {
+	fs.String("name", "origin", "remote name")
+}
+
+func (remoteAdd) Run(args ...string) error {
+	return nil
+}
+
+func TestHelpGolden(t *testing.T) {
+	p, c := newDocsPath(t)
+	commandtest.Golden(t, "testdata/help_deploy.golden", func(w io.Writer) {
+		p.WriteHelp(w, c)
+	})
+}
+
+func TestMarkdownGolden(t *testing.T) {
+	p, _ := newDocsPath(t)
+	commandtest.Golden(t, "testdata/docs.md.golden", func(w io.Writer) {
+		if err := p.WriteMarkdown(w, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestReSTGolden(t *testing.T) {
+	p, _ := newDocsPath(t)
+	commandtest.Golden(t, "testdata/docs.rst.golden", func(w io.Writer) {
+		if err := p.WriteReST(w, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestManGolden(t *testing.T) {
+	p, _ := newDocsPath(t)
+	commandtest.Golden(t, "testdata/app.1.golden", func(w io.Writer) {
+		if err := p.WriteMan(w, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestExplainGolden(t *testing.T) {
+	t.Setenv("APP_TOKEN", "s3cr3t")
+	p := command.NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	p.OnComplete(func(*command.CmdCont, time.Duration, error) {})
+	remote := command.NewPath()
+	remote.PersistentFlags().String("namespace", "default", "namespace")
+	c := remote.Add("add", "adds a remote", &remoteAdd{})
+	c.Flags.String("token", "", "API token")
+	for _, err := range []error{
+		c.FlagAlias("name", "n"),
+		c.MarkSecret("token"),
+		c.BindEnv("token", "APP_TOKEN"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Mount("remote", "manages remotes", remote)
+	commandtest.Golden(t, "testdata/explain.golden", func(w io.Writer) {
+		if err := p.Explain(w, "-verbose", "remote", "add", "-n", "upstream", "-namespace", "prod", "https://example.com/app.git"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// Returns a path with a command reading two bound and one documented
+// environment variable.
+func newEnvDocsPath(t *testing.T) (*command.Path, *command.CmdCont) {
+	p := command.NewPath()
+	c := p.Add("deploy", "deploys the app", command.CmdFunc(func(args []string) error { return nil }))
+	c.Flags.String("env", "dev", "target `ENV`")
+	c.Flags.String("token", "", "API token\nof the deploy account")
+	for _, err := range []error{
+		c.BindEnv("env", "APP_ENV"),
+		c.BindEnv("token", "APP_TOKEN"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Requires(command.EnvSet("APP_TOKEN"))
+	c.EnvDoc("KUBECONFIG", "kubeconfig of the target cluster")
+	p.Add("status", "prints the status", command.CmdFunc(func(args []string) error { return nil }))
+	return p, c
+}
+
+func TestEnvDocsGolden(t *testing.T) {
+	p, c := newEnvDocsPath(t)
+	commandtest.Golden(t, "testdata/help_env.golden", func(w io.Writer) {
+		p.WriteHelp(w, c)
+	})
+	commandtest.Golden(t, "testdata/env.md.golden", func(w io.Writer) {
+		if err := p.WriteMarkdown(w, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	commandtest.Golden(t, "testdata/env.1.golden", func(w io.Writer) {
+		if err := p.WriteMan(w, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// Returns a path with commands mounted three levels deep.
+func newTreePath(t *testing.T) *command.Path {
+	origin := command.NewPath()
+	origin.Add("push", "pushes to the origin", &remoteAdd{})
+	origin.Add("pull", "pulls from the origin", command.CmdFunc(func(args []string) error { return nil }))
+	remote := command.NewPath()
+	remote.Add("add", "adds a remote", &remoteAdd{})
+	remote.Mount("origin", "manages the origin", origin)
+	if err := remote.LoadAliases(map[string]string{"new": "add -name upstream"}); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := newDocsPath(t)
+	p.Mount("remote", "manages remotes", remote)
+	p.Add("debug", "dumps the state", command.CmdFunc(func(args []string) error { return nil })).Hide()
+	if err := p.LoadAliases(map[string]string{"ship": "deploy -env prod"}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTreeGolden(t *testing.T) {
+	p := newTreePath(t)
+	commandtest.Golden(t, "testdata/tree.golden", func(w io.Writer) {
+		if err := p.WriteTree(w, command.TreeOptions{Box: true, Aliases: true, Hidden: true, FlagCounts: true}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
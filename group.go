@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+)
+
+type flagGroup struct {
+	title string
+	names map[string]bool
+}
+
+// Lists the named flags in a section of their own in the help output
+// and generated documentation, e.g. "Connection options". Sections are
+// rendered in the order of the FlagGroup calls, followed by the flags
+// not in any group under "Other flags". Aliases and the inverse of a
+// bool pair may be used to refer to their flag.
+func (c *CmdCont) FlagGroup(title string, names ...string) error {
+	g := flagGroup{title: title, names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		f := c.FlagSet().Lookup(name)
+		if f == nil {
+			return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+		}
+		name = canonicalFlag(c.FlagSet(), f).Name
+		for _, other := range c.groups {
+			if other.names[name] {
+				return fmt.Errorf("Flag -%s of command %q is already in group %q", name, c.Name, other.title)
+			}
+		}
+		g.names[name] = true
+	}
+	c.groups = append(c.groups, g)
+	c.changed()
+	return nil
+}
+
+// A titled list of flags in the help output.
+type flagSection struct {
+	title string
+	flags []helpFlag
+}
+
+// Returns the flags listed in the help output of c, split by flag
+// group. Empty sections are omitted.
+func (p *Path) flagSections(c *CmdCont) []flagSection {
+	flags := collectFlags(c.FlagSet(), p.helpHidden(c))
+	if len(flags) == 0 {
+		return nil
+	}
+	for i := range flags {
+		flags[i].secret = c.isSecret(flags[i].flag)
+		flags[i].env = c.env[flags[i].flag.Name]
+	}
+	if len(c.groups) == 0 {
+		return []flagSection{{title: "Flags", flags: flags}}
+	}
+	sections := make([]flagSection, len(c.groups)+1)
+	for i, g := range c.groups {
+		sections[i].title = g.title
+	}
+	other := &sections[len(c.groups)]
+	other.title = "Other flags"
+	for _, f := range flags {
+		s := other
+		for i, g := range c.groups {
+			if g.names[f.flag.Name] {
+				s = &sections[i]
+				break
+			}
+		}
+		s.flags = append(s.flags, f)
+	}
+	nonEmpty := sections[:0]
+	for _, s := range sections {
+		if len(s.flags) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
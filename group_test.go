@@ -0,0 +1,87 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newGroupPath() (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("host", "localhost", "server host")
+			fs.Int("port", 80, "server port")
+			fs.String("format", "text", "output format")
+			fs.Bool("quiet", false, "suppress output")
+			fs.Bool("force", false, "skip checks")
+			fs.String("env", "", "target environment")
+		},
+	})
+	c.FlagAlias("quiet", "q")
+	if err := c.FlagGroup("Connection options", "host", "port"); err != nil {
+		panic(err)
+	}
+	if err := c.FlagGroup("Output options", "format", "q"); err != nil {
+		panic(err)
+	}
+	return p, c
+}
+
+func TestFlagGroupHelp(t *testing.T) {
+	p, c := newGroupPath()
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	want := `Usage: deploy [flags]
+
+deploys the app
+
+Connection options:
+  --host string
+    	server host (default "localhost")
+  --port int
+    	server port (default 80)
+
+Output options:
+  --format string
+    	output format (default "text")
+  -q, --quiet
+    	suppress output
+
+Other flags:
+  --env string
+    	target environment
+  --force
+    	skip checks
+`
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestFlagGroupErrors(t *testing.T) {
+	_, c := newGroupPath()
+	if err := c.FlagGroup("Misc", "missing"); err == nil {
+		t.Error("Grouping an unknown flag should fail.")
+	}
+	if err := c.FlagGroup("Misc", "quiet"); err == nil {
+		t.Error("Grouping a flag twice should fail.")
+	}
+	if len(c.groups) != 2 {
+		t.Errorf("Failed groups should not be registered but there are %d groups.", len(c.groups))
+	}
+}
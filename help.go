@@ -0,0 +1,248 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Writes the usage of the sub-command c to w: its name, description
+// and flags with their defaults. Run prints it when a command is
+// called with -h. The output is cached until the command changes, see
+// DisableHelpCache.
+func (p *Path) WriteHelp(w io.Writer, c *CmdCont) {
+	if p.helpCacheDisabled() {
+		p.writeHelp(w, c)
+		return
+	}
+	p.writeCachedHelp(w, c)
+}
+
+func (p *Path) writeHelp(w io.Writer, c *CmdCont) {
+	var args string
+	if u := c.argsUsage(); u != "" {
+		args = " " + u
+	}
+	if name := c.CommandPath(); name != "" {
+		fmt.Fprintf(w, "Usage: %s [flags]%s\n", name, args)
+	} else {
+		fmt.Fprintf(w, "Usage: [flags]%s\n", args)
+	}
+	if c.Desc != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Desc)
+	}
+	if long, _ := c.longText(); long != "" {
+		fmt.Fprintf(w, "\n%s\n", long)
+	}
+	for _, s := range p.flagSections(c) {
+		fmt.Fprintf(w, "\n%s:\n", s.title)
+		for _, f := range s.flags {
+			f.write(w)
+		}
+	}
+	writeArgDefaults(w, c)
+	writeEnvSection(w, c)
+	if len(c.examples) > 0 {
+		fmt.Fprintf(w, "\nExamples:\n")
+		for _, e := range c.examples {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+}
+
+// Writes the named flags of c to w with their type and usage, one per
+// line, telling the user what the missing required flags are about.
+func writeMissingFlags(w io.Writer, c *CmdCont, names []string) {
+	missing := make(map[string]bool, len(names))
+	for _, name := range names {
+		missing[name] = true
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range collectFlags(c.FlagSet(), nil) {
+		if !missing[f.flag.Name] {
+			continue
+		}
+		usage := strings.Replace(f.usage, "\n", "\n\t", -1)
+		names := strings.Join(f.names, ", ")
+		if f.typ != "" {
+			names += " " + f.typ
+		}
+		fmt.Fprintf(tw, "  %s\t%s (required)\n", names, usage)
+	}
+	tw.Flush()
+}
+
+// Reports whether f is left out of the help output of c.
+func (p *Path) helpHidden(c *CmdCont) func(*flag.Flag) bool {
+	return func(f *flag.Flag) bool {
+		_, deprecated := c.deprecated[f.Name]
+		return c.hidden[f.Name] || deprecated && !p.showDeprecated
+	}
+}
+
+// A flag as listed in the help output.
+type helpFlag struct {
+	flag *flag.Flag
+	// all names of the flag, formatted as on the command line
+	names      []string
+	typ, usage string
+	secret     bool
+	// the bound environment variable, see BindEnv
+	env string
+}
+
+// Returns the flags of fs in lexicographical order, merging flags with
+// more than one name, such as aliases or -x, --no-x pairs, into one
+// entry. Flags for which hide returns true
+// are omitted.
+func collectFlags(fs *flag.FlagSet, hide func(*flag.Flag) bool) []helpFlag {
+	if hide == nil {
+		hide = func(*flag.Flag) bool { return false }
+	}
+	aliases := make(map[string][]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if v, ok := f.Value.(*aliasValue); ok && !hide(f) {
+			aliases[v.target.Name] = append(aliases[v.target.Name], f.Name)
+		}
+	})
+	var flags []helpFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if hide(f) || canonicalFlag(fs, f) != f {
+			return
+		}
+		var names []string
+		for _, alias := range aliases[f.Name] {
+			names = append(names, flagName(alias))
+		}
+		names = append(names, flagName(f.Name))
+		if v, ok := f.Value.(*boolPairValue); ok && !hide(fs.Lookup(v.pair.inverse)) {
+			names = append(names, flagName(v.pair.inverse))
+			for _, alias := range aliases[v.pair.inverse] {
+				names = append(names, flagName(alias))
+			}
+		}
+		typ, usage := unquoteUsage(f)
+		flags = append(flags, helpFlag{flag: f, names: names, typ: typ, usage: usage})
+	})
+	return flags
+}
+
+// Same as flag.UnquoteUsage but names the type of the value wrapped by
+// FileExpandable and RenameFlag instead of the wrapper.
+func unquoteUsage(f *flag.Flag) (string, string) {
+	if v := unwrapValue(f.Value); v != f.Value {
+		u := *f
+		u.Value = v
+		f = &u
+	}
+	return flag.UnquoteUsage(f)
+}
+
+// Returns the value wrapped by FileExpandable and RenameFlag.
+func unwrapValue(v flag.Value) flag.Value {
+	for {
+		switch w := v.(type) {
+		case *fileExpandValue:
+			v = w.Value
+		case *mirrorValue:
+			v = w.Value
+		default:
+			return v
+		}
+	}
+}
+
+// Returns the flag f is listed under in the help output: the target of
+// an alias, the positive flag of a bool pair, or f itself.
+func canonicalFlag(fs *flag.FlagSet, f *flag.Flag) *flag.Flag {
+	switch v := f.Value.(type) {
+	case *aliasValue:
+		return canonicalFlag(fs, v.target)
+	case *boolPairValue:
+		if v.negate {
+			return fs.Lookup(v.pair.name)
+		}
+	}
+	return f
+}
+
+// Writes f in the layout of flag.PrintDefaults.
+func (f helpFlag) write(w io.Writer) {
+	fmt.Fprintf(w, "  %s", strings.Join(f.names, ", "))
+	if f.typ != "" {
+		fmt.Fprintf(w, " %s", f.typ)
+	}
+	if len(f.names) == 1 && len(f.flag.Name) == 1 && f.typ == "" {
+		// single character flags without a value fit on one line
+		fmt.Fprint(w, "\t")
+	} else {
+		fmt.Fprint(w, "\n    \t")
+	}
+	fmt.Fprint(w, strings.Replace(f.usage, "\n", "\n    \t", -1))
+	if def := f.defValue(); def != "" {
+		fmt.Fprintf(w, " (default %s)", def)
+	}
+	if f.env != "" {
+		fmt.Fprintf(w, " ($%s)", f.env)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// Returns the default value as shown in the help output, or "" if it
+// is the zero value of the flag's type.
+func (f helpFlag) defValue() string {
+	if isZeroValue(f.flag, f.flag.DefValue) {
+		return ""
+	}
+	if f.secret {
+		return redacted
+	}
+	if fmt.Sprintf("%T", f.flag.Value) == "*flag.stringValue" {
+		return fmt.Sprintf("%q", f.flag.DefValue)
+	}
+	return f.flag.DefValue
+}
+
+// Formats a flag name as -x for single characters and --name otherwise.
+func flagName(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// Reports whether value is the zero value of the flag's type, in which
+// case it isn't worth mentioning as the default.
+func isZeroValue(f *flag.Flag, value string) (zero bool) {
+	typ := reflect.TypeOf(f.Value)
+	var z reflect.Value
+	if typ.Kind() == reflect.Ptr {
+		z = reflect.New(typ.Elem())
+	} else {
+		z = reflect.Zero(typ)
+	}
+	defer func() {
+		// String methods may not expect a zero receiver
+		if recover() != nil {
+			zero = value == ""
+		}
+	}()
+	return value == z.Interface().(flag.Value).String()
+}
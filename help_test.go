@@ -0,0 +1,91 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteHelp(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "dev", "target `environment`")
+			fs.Int("port", 0, "port to listen on")
+			fs.Bool("f", false, "force")
+		},
+	})
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	want := `Usage: deploy [flags]
+
+deploys the app
+
+Flags:
+  --env environment
+    	target environment (default "dev")
+  -f	force
+  --port int
+    	port to listen on
+`
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestHelpFlag(t *testing.T) {
+	p := NewPath()
+	c := p.Add("hello", "says hello", CmdFunc(func(args []string) error {
+		return nil
+	}))
+	var out strings.Builder
+	c.Flags.SetOutput(&out)
+	if _, err := p.Run("hello", "-h"); err != flag.ErrHelp {
+		t.Fatalf("-h should return flag.ErrHelp but was %v.", err)
+	}
+	if want := "Usage: hello [flags]\n\nsays hello\n"; out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestWriteMissingFlags(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(io.Discard)
+			fs.String("env", "", "deployment environment")
+			fs.String("target", "", "target `HOST`")
+			fs.Int("replicas", 1, "number of replicas,\nat least one")
+			fs.Bool("confirm", false, "confirm the deployment")
+			fs.Bool("force", false, "skip checks")
+		},
+	}, "env", "target", "replicas", "confirm")
+	c.FlagAlias("target", "t")
+	var out strings.Builder
+	p.SetOutput(&out)
+	p.Execute("deploy", "-force")
+	want := "deploy: required flags not set: --confirm, --env, --replicas, --target\n" +
+		"  --confirm          confirm the deployment (required)\n" +
+		"  --env string       deployment environment (required)\n" +
+		"  --replicas int     number of replicas,\n" +
+		"                     at least one (required)\n" +
+		"  -t, --target HOST  target HOST (required)\n"
+	if out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// The help output of a command as last rendered by WriteHelp.
+type helpCache struct {
+	key  helpKey
+	text string
+}
+
+// The state the help output of a command is rendered from. Metadata
+// set by the methods of CmdCont, such as HideFlag, AddExample or
+// BindEnv, is covered by gen, which they increment. The flags are
+// covered by a hash of their definitions rather than by the FlagSet,
+// so that the cache survives ResetFlags, which Shell calls after every
+// line.
+type helpKey struct {
+	gen            uint64
+	flags          uint64
+	name, desc     string
+	showDeprecated bool
+}
+
+// Disables caching the help output of the commands of p and of the
+// paths mounted below it, see WriteHelp. Tests modifying the fields of
+// registered flags directly can use it to see their changes.
+func (p *Path) DisableHelpCache(disable bool) {
+	p.noHelpCache = disable
+}
+
+func (p *Path) helpCacheDisabled() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.noHelpCache {
+			return true
+		}
+	}
+	return false
+}
+
+// Records a change of the metadata of c shown in its help output.
+func (c *CmdCont) changed() {
+	c.helpGen++
+}
+
+// Writes the help output of c from its cache, rendering it anew if the
+// command changed since it was cached.
+func (p *Path) writeCachedHelp(w io.Writer, c *CmdCont) {
+	key := helpKey{
+		gen:            c.helpGen,
+		flags:          hashFlags(c.FlagSet()),
+		name:           c.Name,
+		desc:           c.Desc,
+		showDeprecated: p.showDeprecated,
+	}
+	if c.help == nil || c.help.key != key {
+		var b strings.Builder
+		p.writeHelp(&b, c)
+		c.help = &helpCache{key: key, text: b.String()}
+	}
+	io.WriteString(w, c.help.text)
+}
+
+// Hashes the names, usages, defaults and types of the flags of fs and
+// the flags aliases refer to.
+func hashFlags(fs *flag.FlagSet) uint64 {
+	// FNV-1a, inlined to hash the strings without copying them
+	h := uint64(14695981039346656037)
+	add := func(s string) {
+		for i := 0; i < len(s); i++ {
+			h = (h ^ uint64(s[i])) * 1099511628211
+		}
+		h = h * 1099511628211 // separator
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		add(f.Name)
+		add(f.Usage)
+		add(f.DefValue)
+		add(reflect.TypeOf(f.Value).String())
+		add(canonicalFlag(fs, f).Name)
+	})
+	return h
+}
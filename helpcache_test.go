@@ -0,0 +1,126 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newHelpPath(flags int) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			for i := 0; i < flags; i++ {
+				fs.String(fmt.Sprintf("opt%02d", i), "value", fmt.Sprintf("option number %d", i))
+			}
+			fs.String("token", "s3cr3t", "API token")
+			fs.Bool("verbose", false, "verbose output")
+		},
+	})
+	return p, c
+}
+
+func renderHelp(p *Path, c *CmdCont) string {
+	var b strings.Builder
+	p.WriteHelp(&b, c)
+	return b.String()
+}
+
+func uncachedHelp(p *Path, c *CmdCont) string {
+	var b strings.Builder
+	p.writeHelp(&b, c)
+	return b.String()
+}
+
+func TestHelpCache(t *testing.T) {
+	p, c := newHelpPath(3)
+	first := renderHelp(p, c)
+	cached := c.help
+	if got := renderHelp(p, c); got != first || c.help != cached {
+		t.Errorf("The help should be served from the cache but was rendered anew.")
+	}
+	if _, err := p.Run("deploy", "-opt01", "x", "-verbose"); err != nil {
+		t.Fatal(err)
+	}
+	p.ResetFlags()
+	if renderHelp(p, c); c.help != cached {
+		t.Error("The cache should survive ResetFlags.")
+	}
+}
+
+func TestHelpCacheInvalidation(t *testing.T) {
+	p, c := newHelpPath(1)
+	for _, test := range []struct {
+		name   string
+		change func() error
+	}{
+		{"MarkSecret", func() error { return c.MarkSecret("token") }},
+		{"HideFlag", func() error { return c.HideFlag("opt00") }},
+		{"BindEnv", func() error { return c.BindEnv("verbose", "APP_VERBOSE") }},
+		{"AutoEnv", func() error { return p.AutoEnv("app") }},
+		{"FlagGroup", func() error { return c.FlagGroup("Output", "verbose") }},
+		{"AddExample", func() error { c.AddExample("app deploy -verbose"); return nil }},
+		{"DeprecateFlag", func() error { return c.DeprecateFlag("verbose", "it is always on") }},
+		{"SetShowDeprecated", func() error { p.SetShowDeprecated(true); return nil }},
+		{"FlagAlias", func() error { return c.FlagAlias("verbose", "v") }},
+		{"Desc", func() error { c.Desc = "deploys the app to production"; return nil }},
+		{"new flag", func() error { c.FlagSet().Int("retries", 3, "retries"); return nil }},
+	} {
+		before := renderHelp(p, c)
+		if err := test.change(); err != nil {
+			t.Fatal(err)
+		}
+		got, want := renderHelp(p, c), uncachedHelp(p, c)
+		if got != want || got == before {
+			t.Errorf("%s should invalidate the cached help %q but it was %q.", test.name, want, got)
+		}
+	}
+}
+
+func TestDisableHelpCache(t *testing.T) {
+	p, c := newHelpPath(1)
+	remote := NewPath()
+	rc := remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	p.DisableHelpCache(true)
+	renderHelp(p, c)
+	renderHelp(remote, rc)
+	if c.help != nil || rc.help != nil {
+		t.Error("DisableHelpCache should disable the cache of p and its mounted paths.")
+	}
+	c.FlagSet().Lookup("verbose").Usage = "chatty output"
+	if got := renderHelp(p, c); !strings.Contains(got, "chatty output") {
+		t.Errorf("The help should be rendered anew but was %q.", got)
+	}
+}
+
+// Measures rendering the help of a command with many flags repeatedly,
+// as by help in a shell.
+func BenchmarkWriteHelp(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cached=%v", cached), func(b *testing.B) {
+			p, c := newHelpPath(50)
+			p.DisableHelpCache(!cached)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.WriteHelp(io.Discard, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Hides the named flag from the help output and from completion. The
+// flag is still accepted on the command line, which makes it suitable
+// for internal tooling or debugging.
+func (c *CmdCont) HideFlag(name string) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.hidden == nil {
+		c.hidden = make(map[string]bool)
+	}
+	c.hidden[name] = true
+	c.changed()
+	return nil
+}
+
+// Same as CmdCont.HideFlag but for the global flags of p, which are
+// left out of the usage printed for -h before the command name, the
+// documentation and completion.
+func (p *Path) HideFlag(name string) error {
+	if p.Flags.Lookup(name) == nil {
+		return fmt.Errorf("No such global flag -%s", name)
+	}
+	if p.hidden == nil {
+		p.hidden = make(map[string]bool)
+		p.Flags.Usage = p.globalUsage
+	}
+	p.hidden[name] = true
+	return nil
+}
+
+// Reports whether the global flag f is hidden, see Path.HideFlag.
+func (p *Path) hideGlobalFlag(f *flag.Flag) bool {
+	return p.hidden[f.Name]
+}
+
+// Writes the global flags that aren't hidden like the default usage
+// of a flag.FlagSet.
+func (p *Path) globalUsage() {
+	w := p.Flags.Output()
+	if p.Flags.Name() == "" {
+		fmt.Fprintf(w, "Usage:\n")
+	} else {
+		fmt.Fprintf(w, "Usage of %s:\n", p.Flags.Name())
+	}
+	for _, f := range collectFlags(p.Flags, p.hideGlobalFlag) {
+		f.write(w)
+	}
+}
+
+// Hides the command from the list of available commands and from
+// completion. It can still be run, which makes it suitable for
+// internal tooling or debugging.
+func (c *CmdCont) Hide() {
+	c.hiddenCmd = true
+}
+
+// Reports whether the command is hidden, see Hide.
+func (c *CmdCont) Hidden() bool {
+	return c.hiddenCmd
+}
+
+// Reports whether f is left out of completion: hidden and deprecated
+// flags aren't advertised.
+func (c *CmdCont) hideFlag(f *flag.Flag) bool {
+	_, deprecated := c.deprecated[f.Name]
+	return c.hidden[f.Name] || deprecated
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestHideFlag(t *testing.T) {
+	var skip bool
+	p := NewPath()
+	c := p.Add("migrate", "migrates the database", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&skip, "unsafe-skip-checks", false, "skip all safety checks")
+			fs.Bool("dry-run", false, "only print the migrations")
+		},
+	})
+	if err := c.HideFlag("unsafe-skip-checks"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("migrate", "--unsafe-skip-checks"); err != nil {
+		t.Fatal(err)
+	}
+	if !skip {
+		t.Error("The hidden flag should still be parsed.")
+	}
+
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if strings.Contains(out.String(), "unsafe-skip-checks") {
+		t.Errorf("Help should omit the hidden flag but was %q.", out.String())
+	}
+	if !strings.Contains(out.String(), "dry-run") {
+		t.Errorf("Help should list the other flags but was %q.", out.String())
+	}
+	for _, word := range []string{"-", "--", "--u"} {
+		for _, candidate := range p.Complete("migrate", word) {
+			if strings.Contains(candidate, "unsafe-skip-checks") {
+				t.Errorf("Completion of %q should omit the hidden flag but was %q.", word, candidate)
+			}
+		}
+	}
+
+	if err := c.HideFlag("missing"); err == nil {
+		t.Error("Hiding an unknown flag should fail.")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The number of lines kept in the history file unless
+// ShellOptions.HistorySize is set.
+const defaultHistorySize = 1000
+
+// Returns the history file conventional for the program, ~/.<app>_history
+// for the program app, or "" if the home directory is unknown.
+func DefaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "."+filepath.Base(os.Args[0])+"_history")
+}
+
+// The command lines of ShellInteractive kept across sessions, the
+// oldest first.
+type shellHistory struct {
+	file    string
+	max     int
+	entries []string
+}
+
+// Reads the history from file, which may not exist yet, keeping the
+// newest max lines.
+func loadHistory(file string, max int) (*shellHistory, error) {
+	if max <= 0 {
+		max = defaultHistorySize
+	}
+	h := &shellHistory{file: file, max: max}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("History: %w", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := s.Text(); strings.TrimSpace(line) != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("History %s: %w", file, err)
+	}
+	if len(h.entries) > max {
+		h.entries = h.entries[len(h.entries)-max:]
+	}
+	return h, nil
+}
+
+// Appends line to the history and its file. The file is rewritten
+// with the newest lines once it holds more than max.
+func (h *shellHistory) add(line string) error {
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.max {
+		h.entries = append(h.entries[:0], h.entries[len(h.entries)-h.max:]...)
+		return h.rewrite()
+	}
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("History: %w", err)
+	}
+	_, err = fmt.Fprintln(f, line)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("History: %w", err)
+	}
+	return nil
+}
+
+// Removes all lines from the history and its file.
+func (h *shellHistory) clear() error {
+	h.entries = nil
+	return h.rewrite()
+}
+
+// Replaces the file with the lines of the history.
+func (h *shellHistory) rewrite() error {
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("History: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range h.entries {
+		fmt.Fprintln(w, line)
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("History: %w", err)
+	}
+	return nil
+}
+
+// Runs the history built-in of the shell with args: without arguments
+// it lists the history numbered from 1, "clear" clears it.
+func (h *shellHistory) command(out io.Writer, args []string) {
+	switch {
+	case len(args) == 0:
+		for i, line := range h.entries {
+			fmt.Fprintf(out, "%5d  %s\n", i+1, line)
+		}
+	case len(args) == 1 && args[0] == "clear":
+		if err := h.clear(); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	default:
+		fmt.Fprintln(out, "Usage: history [clear]")
+	}
+}
+
+// Reports whether args, a command line run by the shell, give a
+// value to a secret flag of c or of a path it is mounted on, see
+// MarkSecret. Such lines aren't added
+// to the history file. Any argument naming a secret flag counts, as
+// the flags may not have been parsed.
+func (c *CmdCont) setsSecret(args []string) bool {
+	if c == nil {
+		return false
+	}
+	// the global flags of the paths c is mounted on are given on the
+	// same line
+	conts := []*CmdCont{c}
+	for q := c.path; q != nil && q.mount != nil; q = q.parent {
+		conts = append(conts, q.mount)
+	}
+	for _, arg := range args {
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name = name[:i]
+		}
+		for _, d := range conts {
+			if len(d.secret) == 0 {
+				continue
+			}
+			n := name
+			if d.normalize != nil {
+				n = d.normalize(n)
+			}
+			if f := d.FlagSet().Lookup(n); f != nil && d.isSecret(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
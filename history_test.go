@@ -0,0 +1,171 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readHistoryFile(t *testing.T, file string) []string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(b))
+}
+
+func TestHistoryLoadAppendCap(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".app_history")
+	h, err := loadHistory(file, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.entries) != 0 {
+		t.Errorf("A missing file should be an empty history but was %q.", h.entries)
+	}
+	for _, line := range []string{"status", "deploy"} {
+		if err := h.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readHistoryFile(t, file); !reflect.DeepEqual(got, []string{"status", "deploy"}) {
+		t.Errorf("The file should contain the added lines but was %q.", got)
+	}
+	if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("The file should have mode 0600 but had %v (%v).", fi.Mode().Perm(), err)
+	}
+
+	if err := os.WriteFile(file, []byte("one\ntwo\n\nthree\nfour\nfive\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = loadHistory(file, 3); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"three", "four", "five"}; !reflect.DeepEqual(h.entries, want) {
+		t.Errorf("The history should keep the newest lines %q but was %q.", want, h.entries)
+	}
+	if err := h.add("six"); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"four", "five", "six"}, readHistoryFile(t, file); !reflect.DeepEqual(got, want) {
+		t.Errorf("The file should be capped to %q but was %q.", want, got)
+	}
+	if err := h.clear(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readHistoryFile(t, file); len(got) != 0 || len(h.entries) != 0 {
+		t.Errorf("The history should be cleared but was %q.", got)
+	}
+}
+
+func TestHistoryShellLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".app_history")
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+	})
+	login := p.Add("login", "logs in", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("user", "", "user name")
+			fs.String("api-token", "", "API token")
+		},
+	})
+	login.MarkSecret("api-token")
+	h, err := loadHistory(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	for _, line := range []string{"deploy -env prod", "login -user me", "login --api_token=s3cr3t", "login -api-token", "destroy"} {
+		p.shellLine(&out, line, h)
+	}
+	want := []string{"deploy -env prod", "login -user me"}
+	if !reflect.DeepEqual(h.entries, want) {
+		t.Errorf("The history should be %q but was %q.", want, h.entries)
+	}
+	if b, _ := os.ReadFile(file); strings.Contains(string(b), "s3cr3t") || strings.Contains(string(b), "api") {
+		t.Errorf("Lines setting secret flags should not be written to the file but it was %q.", b)
+	}
+
+	out.Reset()
+	p.shellLine(&out, "history", h)
+	if want := "    1  deploy -env prod\n    2  login -user me\n"; out.String() != want {
+		t.Errorf("history should print %q but printed %q.", want, out.String())
+	}
+	p.shellLine(&out, "history clear", h)
+	if len(h.entries) != 0 {
+		t.Errorf("history clear should clear the history but it was %q.", h.entries)
+	}
+}
+
+func TestHistoryMountedSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".app_history")
+	remote := NewPath()
+	remote.Flags.String("pw", "", "password of the remote")
+	remote.Add("add", "adds a remote", &testCmd{})
+	p := NewPath()
+	p.Mount("remote", "manages remotes", remote).MarkSecret("pw")
+	h, err := loadHistory(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	for _, line := range []string{"remote add", "remote -pw hunter2 add", "remote --pw=hunter2 add"} {
+		p.shellLine(&out, line, h)
+	}
+	if want := []string{"remote add"}; !reflect.DeepEqual(h.entries, want) {
+		t.Errorf("The history should be %q but was %q.", want, h.entries)
+	}
+	if b, _ := os.ReadFile(file); strings.Contains(string(b), "hunter2") {
+		t.Errorf("Lines setting secret flags of a mounted path should not be written to the file but it was %q.", b)
+	}
+}
+
+func TestShellInteractiveHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".app_history")
+	if err := os.WriteFile(file, []byte("deploy -env prod\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewPath()
+	var envs []string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+		run: func(args ...string) error {
+			envs = append(envs, p.Lookup("deploy").Flags.Lookup("env").Value.String())
+			return nil
+		},
+	})
+	// recalls the line of the previous session with the up arrow
+	term := &testRawTerminal{Reader: strings.NewReader("\x1b[A\rdeploy -env dev\r\x04")}
+	if err := p.ShellInteractive(ShellOptions{Prompt: "> ", Terminal: term, HistoryFile: file}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prod", "dev"}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("Runs should be %q but were %q.", want, envs)
+	}
+	b, _ := os.ReadFile(file)
+	if want := "deploy -env prod\ndeploy -env prod\ndeploy -env dev\n"; string(b) != want {
+		t.Errorf("The history file should be %q but was %q.", want, b)
+	}
+}
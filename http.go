@@ -0,0 +1,234 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Configures the handler returned by HTTPHandler.
+type HTTPOption func(*httpHandler)
+
+// Exposes the named commands over HTTP. The names are full names such
+// as "remote add"; the name of a mounted path exposes all its commands.
+// Without HTTPAllow no command is exposed. A pipeline, see
+// SetPipeSeparator, only runs if all of its stages are exposed.
+func HTTPAllow(names ...string) HTTPOption {
+	return func(h *httpHandler) {
+		h.allowed = append(h.allowed, names...)
+	}
+}
+
+// Limits the number of requests to /run that are running or waiting
+// to run to n, 1 by default. Further requests fail with status 503.
+func HTTPMaxConcurrent(n int) HTTPOption {
+	return func(h *httpHandler) {
+		h.pending = make(chan struct{}, n)
+	}
+}
+
+type httpHandler struct {
+	p       *Path
+	allowed []string
+	// tokens of the requests running or waiting to run
+	pending chan struct{}
+	// held while the path is in use, its commands share their flags
+	running chan struct{}
+}
+
+// Returns a handler exposing the commands of p allowed with HTTPAllow.
+//
+// GET / responds with the Spec of the exposed commands. POST /run runs
+// the command given by a JSON body such as
+//
+//	{"args": ["deploy", "-env", "prod"]}
+//
+// with RunContext and the context of the request. Commands are run one
+// at a time and their flags are reset afterwards. The response streams
+// what the command writes to the streams of EnvFromContext as lines of
+// JSON objects such as {"stream": "stdout", "data": "deployed\n"},
+// followed by a line with the exit code, see ExitCode, and the error,
+// if any, such as {"exit_code": 1, "error": "..."}. Requests for
+// commands that don't exist or aren't exposed fail with status 404
+// without running anything.
+func (p *Path) HTTPHandler(opts ...HTTPOption) http.Handler {
+	h := &httpHandler{p: p, running: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.pending == nil {
+		h.pending = make(chan struct{}, 1)
+	}
+	return h
+}
+
+type httpRunRequest struct {
+	Args []string `json:"args"`
+}
+
+// A line of the response of /run.
+type httpFrame struct {
+	Stream   string `json:"stream,omitempty"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		h.spec(w)
+	case r.URL.Path == "/run" && r.Method == http.MethodPost:
+		h.run(w, r)
+	case r.URL.Path == "/" || r.URL.Path == "/run":
+		httpError(w, http.StatusMethodNotAllowed, "Method not allowed", 2)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *httpHandler) spec(w http.ResponseWriter) {
+	h.running <- struct{}{}
+	s := h.p.Spec()
+	<-h.running
+	commands := s.Commands[:0]
+	for _, c := range s.Commands {
+		if h.allows(c.Name) {
+			commands = append(commands, c)
+		}
+	}
+	s.Commands = commands
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+func (h *httpHandler) run(w http.ResponseWriter, r *http.Request) {
+	var req httpRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "Invalid request: "+err.Error(), 2)
+		return
+	}
+	select {
+	case h.pending <- struct{}{}:
+		defer func() { <-h.pending }()
+	default:
+		httpError(w, http.StatusServiceUnavailable, "Too many requests", 1)
+		return
+	}
+	select {
+	case h.running <- struct{}{}:
+		defer func() { <-h.running }()
+	case <-r.Context().Done():
+		httpError(w, http.StatusServiceUnavailable, r.Context().Err().Error(), 1)
+		return
+	}
+	defer h.p.ResetFlags()
+
+	if !h.allowsArgs(req.Args) {
+		httpError(w, http.StatusNotFound, ErrNoSuchCmd.Error(), h.p.ExitCode(ErrNoSuchCmd))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	s := &httpStream{w: w, enc: json.NewEncoder(w)}
+	ctx := WithEnv(r.Context(), Env{
+		Stdin:  strings.NewReader(""),
+		Stdout: s.stream("stdout"),
+		Stderr: s.stream("stderr"),
+	})
+	_, err := h.p.RunContext(ctx, req.Args...)
+	code := h.p.ExitCode(err)
+	frame := httpFrame{ExitCode: &code}
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	s.write(frame)
+}
+
+// Reports whether args run an exposed command, or only exposed
+// commands if they are a pipeline.
+func (h *httpHandler) allowsArgs(args []string) bool {
+	stages := h.p.splitPipeline(args)
+	if stages == nil {
+		stages = [][]string{args}
+	}
+	for _, stage := range stages {
+		c, _ := h.p.Validate(stage...)
+		h.p.ResetFlags()
+		if c == nil || !h.allowsCommand(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether the command c of the path is exposed.
+func (h *httpHandler) allowsCommand(c *CmdCont) bool {
+	d, ok := h.p.docCommand(c)
+	return ok && h.allows(strings.TrimPrefix(d.name, " "))
+}
+
+func (h *httpHandler) allows(name string) bool {
+	for _, a := range h.allowed {
+		if name == a || strings.HasPrefix(name, a+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func httpError(w http.ResponseWriter, status int, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(httpFrame{ExitCode: &code, Error: msg})
+}
+
+// Writes the output of a command to the response of /run as it is
+// written.
+type httpStream struct {
+	mu  sync.Mutex
+	w   http.ResponseWriter
+	enc *json.Encoder
+}
+
+func (s *httpStream) stream(name string) io.Writer {
+	return writerFunc(func(b []byte) (int, error) {
+		if err := s.write(httpFrame{Stream: name, Data: string(b)}); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	})
+}
+
+func (s *httpStream) write(f httpFrame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(f); err != nil {
+		return err
+	}
+	if fl, ok := s.w.(http.Flusher); ok {
+		fl.Flush()
+	}
+	return nil
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
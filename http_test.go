@@ -0,0 +1,199 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testContextCmd struct {
+	testCmd
+	runContext func(ctx context.Context, args ...string) error
+}
+
+func (c *testContextCmd) RunContext(ctx context.Context, args ...string) error {
+	return c.runContext(ctx, args...)
+}
+
+func newHTTPPath(started chan<- bool) *Path {
+	p := NewPath()
+	var env *string
+	p.Add("deploy", "deploys the app", &testContextCmd{
+		testCmd: testCmd{flags: func(fs *flag.FlagSet) {
+			env = fs.String("env", "", "target environment")
+		}},
+		runContext: func(ctx context.Context, args ...string) error {
+			e := EnvFromContext(ctx)
+			fmt.Fprintf(e.Stdout, "deployed to %s\n", *env)
+			fmt.Fprintln(e.Stderr, "done")
+			return nil
+		},
+	}, "env")
+	p.Add("wait", "waits until canceled", &testContextCmd{
+		runContext: func(ctx context.Context, args ...string) error {
+			started <- true
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	p.Add("destroy", "destroys the app", &testCmd{})
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func postRun(h http.Handler, ctx context.Context, args ...string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(httpRunRequest{Args: args})
+	req := httptest.NewRequest("POST", "/run", strings.NewReader(string(body))).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHTTPHandlerList(t *testing.T) {
+	h := newHTTPPath(nil).HTTPHandler(HTTPAllow("deploy", "remote"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	var s Spec
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range s.Commands {
+		names = append(names, c.Name)
+	}
+	if want := []string{"deploy", "remote", "remote add"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Listed commands should be %q but were %q.", want, names)
+	}
+}
+
+func TestHTTPHandlerRun(t *testing.T) {
+	h := newHTTPPath(nil).HTTPHandler(HTTPAllow("deploy", "remote"))
+	w := postRun(h, context.Background(), "deploy", "-env", "prod")
+	want := `{"stream":"stdout","data":"deployed to prod\n"}
+{"stream":"stderr","data":"done\n"}
+{"exit_code":0}
+`
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("Response should be %q but was %d %q.", want, w.Code, w.Body.String())
+	}
+	w = postRun(h, context.Background(), "deploy")
+	want = `{"exit_code":64,"error":"deploy: required flags not set: --env"}` + "\n"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("Response should be %q but was %d %q.", want, w.Code, w.Body.String())
+	}
+	if w = postRun(h, context.Background(), "remote", "add"); w.Code != http.StatusOK {
+		t.Errorf("Commands of allowed paths should run but status was %d.", w.Code)
+	}
+}
+
+func TestHTTPHandlerUnknown(t *testing.T) {
+	ran := false
+	p := newHTTPPath(nil)
+	p.registry().entries["destroy"].Cmd = &testCmd{run: func(...string) error {
+		ran = true
+		return nil
+	}}
+	h := p.HTTPHandler(HTTPAllow("deploy"))
+	for _, args := range [][]string{{"unknown"}, {"destroy"}, {"remote", "add"}, {}} {
+		w := postRun(h, context.Background(), args...)
+		want := `{"exit_code":64,"error":"No such command."}` + "\n"
+		if w.Code != http.StatusNotFound || w.Body.String() != want {
+			t.Errorf("Response for %q should be 404 %q but was %d %q.", args, want, w.Code, w.Body.String())
+		}
+	}
+	if ran {
+		t.Error("Commands that aren't allowed should not run.")
+	}
+	req := httptest.NewRequest("POST", "/run", strings.NewReader("deploy"))
+	w := httptest.NewRecorder()
+	if h.ServeHTTP(w, req); w.Code != http.StatusBadRequest {
+		t.Errorf("Status of an invalid request should be 400 but was %d.", w.Code)
+	}
+	w = httptest.NewRecorder()
+	if h.ServeHTTP(w, httptest.NewRequest("GET", "/run", nil)); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status of GET /run should be 405 but was %d.", w.Code)
+	}
+}
+
+func TestHTTPHandlerPipeline(t *testing.T) {
+	ran := false
+	p := newHTTPPath(nil)
+	p.registry().entries["destroy"].Cmd = &testContextCmd{runContext: func(context.Context, ...string) error {
+		ran = true
+		return nil
+	}}
+	p.SetPipeSeparator("++")
+	h := p.HTTPHandler(HTTPAllow("deploy"))
+	w := postRun(h, context.Background(), "deploy", "-env", "prod", "++", "destroy")
+	want := `{"exit_code":64,"error":"No such command."}` + "\n"
+	if w.Code != http.StatusNotFound || w.Body.String() != want {
+		t.Errorf("Response should be 404 %q but was %d %q.", want, w.Code, w.Body.String())
+	}
+	if ran {
+		t.Error("Stages that aren't allowed should not run.")
+	}
+}
+
+func TestHTTPHandlerCancel(t *testing.T) {
+	started := make(chan bool)
+	h := newHTTPPath(started).HTTPHandler(HTTPAllow("wait", "deploy"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postRun(h, ctx, "wait")
+	}()
+	<-started
+	if w := postRun(h, context.Background(), "deploy", "-env", "prod"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status beyond the concurrency limit should be 503 but was %d.", w.Code)
+	}
+	cancel()
+	w := <-done
+	want := `{"exit_code":1,"error":"context canceled"}` + "\n"
+	if w.Body.String() != want {
+		t.Errorf("Response should be %q but was %q.", want, w.Body.String())
+	}
+	if w := postRun(h, ctx, "deploy", "-env", "prod"); w.Code != http.StatusServiceUnavailable && w.Body.String() != want {
+		t.Errorf("Canceled request should not run but response was %d %q.", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPHandlerQueue(t *testing.T) {
+	started := make(chan bool)
+	h := newHTTPPath(started).HTTPHandler(HTTPAllow("wait", "deploy"), HTTPMaxConcurrent(2))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postRun(h, ctx, "wait")
+	}()
+	<-started
+	go func() {
+		done <- postRun(h, context.Background(), "deploy", "-env", "prod")
+	}()
+	cancel()
+	<-done
+	if w := <-done; w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "deployed to prod") {
+		t.Errorf("Queued request should run but response was %d %q.", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The commands of a path. Once a registry is published as the
+// snapshot of its path it is never modified: Add and Remove replace
+// it with a modified copy instead, so that Run can look up commands
+// without locking while others are registered concurrently. The
+// dispatch itself isn't safe for concurrent use, see Path.
+type registry struct {
+	entries map[string]*CmdCont
+	// the sorted names of entries
+	names []string
+}
+
+var emptyRegistry = &registry{entries: map[string]*CmdCont{}}
+
+// Returns the current commands of p. The registry must not be
+// modified.
+func (p *Path) registry() *registry {
+	if r := p.snapshot.Load(); r != nil {
+		return r
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := p.building
+	if r == nil {
+		r = emptyRegistry
+	}
+	// later modifications copy r
+	p.snapshot.Store(r)
+	p.building = nil
+	return r
+}
+
+// Applies modify to the commands of p. Until the commands were read
+// for the first time, which typically happens after all of them are
+// registered, they are modified in place; afterwards modify is applied
+// to a copy replacing them.
+func (p *Path) modifyRegistry(modify func(*registry)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r := p.snapshot.Load(); r != nil {
+		c := &registry{entries: make(map[string]*CmdCont, len(r.entries)+1), names: append([]string(nil), r.names...)}
+		for name, cont := range r.entries {
+			c.entries[name] = cont
+		}
+		modify(c)
+		p.snapshot.Store(c)
+		return
+	}
+	if p.building == nil {
+		p.building = &registry{entries: make(map[string]*CmdCont)}
+	}
+	modify(p.building)
+}
+
+// Registers c under name, keeping the sorted index of the names
+// up to date.
+func (p *Path) setEntry(name string, c *CmdCont) {
+	p.modifyRegistry(func(r *registry) {
+		if _, ok := r.entries[name]; !ok {
+			i := sort.SearchStrings(r.names, name)
+			r.names = append(r.names, "")
+			copy(r.names[i+1:], r.names[i:])
+			r.names[i] = name
+		}
+		r.entries[name] = c
+	})
+}
+
+// Removes the command or mounted path registered under name and
+// reports whether there was one. Remove is safe to call while other
+// goroutines run commands of p.
+func (p *Path) Remove(name string) bool {
+	var removed bool
+	p.modifyRegistry(func(r *registry) {
+		if _, removed = r.entries[name]; removed {
+			i := sort.SearchStrings(r.names, name)
+			r.names = append(r.names[:i], r.names[i+1:]...)
+			delete(r.entries, name)
+		}
+	})
+	return removed
+}
+
+// Returns the command or mounted path registered under name, or nil.
+// Lookup is safe to call while other goroutines add or remove
+// commands.
+func (p *Path) Lookup(name string) *CmdCont {
+	return p.registry().entries[name]
+}
+
+// Returns the sorted names of the commands of p starting with prefix.
+// The result is shared with p and must not be modified.
+func (p *Path) namesWithPrefix(prefix string) []string {
+	names := p.registry().names
+	lo := sort.SearchStrings(names, prefix)
+	hi := lo + sort.Search(len(names)-lo, func(i int) bool {
+		return !strings.HasPrefix(names[lo+i], prefix)
+	})
+	return names[lo:hi:hi]
+}
+
+// Runs the command whose name starts with a unique prefix given on the
+// command line, such as "st" for "status", if no command matches the
+// name exactly.
+func (p *Path) SetPrefixMatching(match bool) {
+	p.prefixMatching = match
+}
+
+// Returns the command of p the name of which is uniquely identified by
+// the prefix name, if prefix matching is enabled.
+func (p *Path) lookupPrefix(name string) (*CmdCont, bool) {
+	if !p.prefixMatching || name == "" {
+		return nil, false
+	}
+	if names := p.namesWithPrefix(name); len(names) == 1 {
+		return p.Lookup(names[0]), true
+	}
+	return nil, false
+}
+
+// Returns the names of the commands of p similar to the unknown
+// command name, the most similar first. Typos rarely affect the first
+// character, so only the commands starting with the same character
+// are considered, which saves computing the distance to all commands
+// of large paths. Commands of mounted paths are suggested with their
+// path, such as "remote add" for add, see suggestNested.
+func (p *Path) suggestCommands(name string) []string {
+	if name == "" {
+		return nil
+	}
+	distances := make(map[string]int)
+	for _, c := range p.namesWithPrefix(name[:1]) {
+		if d := editDistance(name, c); similarName(name, c, d) {
+			distances[c] = d
+		}
+	}
+	p.suggestNested(name, distances)
+	if len(distances) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(distances))
+	for c := range distances {
+		names = append(names, c)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if distances[names[i]] != distances[names[j]] {
+			return distances[names[i]] < distances[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxSuggestions {
+		names = names[:maxSuggestions]
+	}
+	return names
+}
+
+// Adds the commands of the paths mounted to p whose names are similar
+// to name to distances, keyed by their path below p. Exact matches
+// have the distance 0 and come first. Hidden and disabled commands and
+// the commands of hidden and disabled paths are left out.
+func (p *Path) suggestNested(name string, distances map[string]int) {
+	r := p.registry()
+	for _, mount := range r.names {
+		m := r.entries[mount]
+		if m.sub == nil || !m.listed() {
+			continue
+		}
+		names := []string{m.Name}
+		m.sub.Walk(func(c *CmdCont, depth int) error {
+			if !c.listed() {
+				return SkipPath
+			}
+			names = append(names[:depth+1], c.Name)
+			if c.sub != nil {
+				return nil
+			}
+			if d := editDistance(name, c.Name); similarName(name, c.Name, d) {
+				distances[strings.Join(names, " ")] = d
+			}
+			return nil
+		})
+	}
+}
+
+// Reports whether candidate is worth suggesting for the unknown name at
+// the edit distance d: for a few typos not amounting to the whole name,
+// or if it starts with a name of at least three characters.
+func similarName(name, candidate string, d int) bool {
+	return d <= 2 && d < len(name) && d < len(candidate) || len(name) >= 3 && strings.HasPrefix(candidate, name)
+}
+
+// Formats the suggestions of an UnknownCommandError.
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, " or ")
+}
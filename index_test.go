@@ -0,0 +1,268 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNameIndex(t *testing.T) {
+	p := NewPath()
+	for _, name := range []string{"status", "deploy", "destroy", "stash", "deploy"} {
+		p.Add(name, "", &testCmd{})
+	}
+	p.Mount("remote", "", NewPath())
+	p.AddE("diff", "", &testCmd{}, "missing")
+	if want := []string{"deploy", "destroy", "remote", "stash", "status"}; !reflect.DeepEqual(p.registry().names, want) {
+		t.Errorf("Index should be %q but was %q.", want, p.registry().names)
+	}
+	for prefix, want := range map[string][]string{
+		"de":  {"deploy", "destroy"},
+		"sta": {"stash", "status"},
+		"":    {"deploy", "destroy", "remote", "stash", "status"},
+		"x":   {},
+	} {
+		if got := p.namesWithPrefix(prefix); !reflect.DeepEqual(got, want) {
+			t.Errorf("Commands starting with %q should be %q but were %q.", prefix, want, got)
+		}
+	}
+}
+
+func TestPrefixMatching(t *testing.T) {
+	p := NewPath()
+	var ran string
+	for _, name := range []string{"status", "stash", "deploy"} {
+		name := name
+		p.Add(name, "", &testCmd{run: func(args ...string) error {
+			ran = name
+			return nil
+		}})
+	}
+	if _, err := p.Run("dep"); !errors.Is(err, ErrNoSuchCmd) {
+		t.Errorf("Prefixes should not match unless enabled but error was %v.", err)
+	}
+	p.SetPrefixMatching(true)
+	if _, err := p.Run("dep"); err != nil || ran != "deploy" {
+		t.Errorf("Prefix dep should run deploy but ran %q with error %v.", ran, err)
+	}
+	if _, err := p.Run("sta"); !errors.Is(err, ErrNoSuchCmd) {
+		t.Errorf("Ambiguous prefixes should fail but error was %v.", err)
+	}
+}
+
+func TestSuggestCommands(t *testing.T) {
+	p := NewPath()
+	for _, name := range []string{"status", "stash", "deploy", "start"} {
+		p.Add(name, "", &testCmd{})
+	}
+	_, err := p.Run("stauts")
+	var unknown *UnknownCommandError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Suggestions, []string{"start", "status"}) {
+		t.Fatalf("Suggestions should be %q but error was %#v.", []string{"start", "status"}, err)
+	}
+	if want := `No such command "stauts", did you mean "start" or "status"?`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if got := p.suggestCommands("sta"); !reflect.DeepEqual(got, []string{"start", "stash", "status"}) {
+		t.Errorf("Suggestions for a prefix should be %q but were %q.", []string{"start", "stash", "status"}, got)
+	}
+}
+
+func TestSuggestNestedCommands(t *testing.T) {
+	remote := NewPath()
+	for _, name := range []string{"add", "remove", "prune"} {
+		remote.Add(name, "", &testCmd{})
+	}
+	remote.Lookup("prune").Hide()
+	config := NewPath()
+	config.Add("adds", "", &testCmd{})
+	config.Add("remove", "", &testCmd{}).EnabledIf(func() (bool, string) { return false, "read-only" })
+	p := NewPath()
+	p.Add("ad", "", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	p.Mount("config", "manages the config", config)
+	tests := []struct {
+		name string
+		want []string
+	}{
+		// exact nested matches first, then by distance and name
+		{"add", []string{"remote add", "ad", "config adds"}},
+		{"rmove", []string{"remote remove", "remote"}},
+		{"prune", nil},
+	}
+	for _, test := range tests {
+		_, err := p.Run(test.name)
+		var unknown *UnknownCommandError
+		if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Suggestions, test.want) {
+			t.Errorf("Suggestions for %q should be %q but error was %v.", test.name, test.want, err)
+		}
+	}
+	_, err := p.Run("rmove")
+	if want := `No such command "rmove", did you mean "remote remove" or "remote"?`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+}
+
+func newLargePath(n int) *Path {
+	p := NewPath()
+	for i := 0; i < n; i++ {
+		p.Add(fmt.Sprintf("op%05d", i), "a generated command", &testCmd{})
+	}
+	return p
+}
+
+func BenchmarkRunLarge(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		p := newLargePath(n)
+		name := fmt.Sprintf("op%05d", n/2)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Run(name); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSuggestCommands(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		p := newLargePath(n)
+		p.Add("status", "", &testCmd{})
+		p.Add("stash", "", &testCmd{})
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if len(p.suggestCommands("stauts")) == 0 {
+					b.Fatal("no suggestions")
+				}
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	p := NewPath()
+	p.Add("status", "", &testCmd{})
+	p.Add("deploy", "", &testCmd{})
+	if _, err := p.Run("status"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := p.registry()
+	if !p.Remove("status") || p.Remove("status") {
+		t.Error("Remove should report whether the command existed.")
+	}
+	if _, err := p.Run("status"); !errors.Is(err, ErrNoSuchCmd) {
+		t.Errorf("Removed commands should not run but error was %v.", err)
+	}
+	if want := []string{"deploy"}; !reflect.DeepEqual(p.registry().names, want) {
+		t.Errorf("Index should be %q but was %q.", want, p.registry().names)
+	}
+	if snapshot.entries["status"] == nil || len(snapshot.names) != 2 {
+		t.Error("Remove should not modify a published registry.")
+	}
+}
+
+// Adds and removes commands while other goroutines look up commands
+// and one runs them. Run the test with -race. A path serves one
+// dispatch at a time, so only a single goroutine runs commands.
+func TestRegistryConcurrent(t *testing.T) {
+	p := NewPath()
+	p.Add("stable", "", &testCmd{})
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if p.Lookup("stable") == nil {
+					t.Error("Lookup should find the stable command.")
+					return
+				}
+				if got := p.Complete("stab"); len(got) != 1 {
+					t.Errorf("Completions should be the stable command but were %q.", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if _, err := p.Run("stable"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("plugin%d", i%10)
+		if i%20 < 10 {
+			p.Add(name, "a plugin", &testCmd{})
+		} else {
+			p.Remove(name)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// A registry guarded by a mutex for comparison with the snapshot of
+// Path.
+type mutexRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]*CmdCont
+}
+
+func (r *mutexRegistry) lookup(name string) *CmdCont {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entries[name]
+}
+
+func BenchmarkLookupParallel(b *testing.B) {
+	p := newLargePath(1000)
+	b.Run("snapshot", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if p.Lookup("op00500") == nil {
+					b.Fatal("no command")
+				}
+			}
+		})
+	})
+	r := &mutexRegistry{entries: p.registry().entries}
+	b.Run("mutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if r.lookup("op00500") == nil {
+					b.Fatal("no command")
+				}
+			}
+		})
+	})
+}
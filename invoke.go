@@ -0,0 +1,44 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "context"
+
+// Same as Run but returns the result of commands implementing
+// ResultRenderer instead of writing it to their standard output, for
+// programs embedding the commands. The result is nil for other commands
+// and if the command fails.
+func (p *Path) Invoke(args ...string) (interface{}, *CmdCont, error) {
+	return p.InvokeContext(context.Background(), args...)
+}
+
+// Same as Invoke but runs the command like RunContext.
+func (p *Path) InvokeContext(ctx context.Context, args ...string) (interface{}, *CmdCont, error) {
+	var result interface{}
+	cont, err := p.RunContext(context.WithValue(ctx, invokeKey{}, &result), args...)
+	return result, cont, err
+}
+
+// The key of the context of Invoke, holding a pointer to the result.
+type invokeKey struct{}
+
+// Stores v as the result of Invoke if ctx was passed to it.
+func storeResult(ctx context.Context, v interface{}) bool {
+	result, ok := ctx.Value(invokeKey{}).(*interface{})
+	if ok {
+		*result = v
+	}
+	return ok
+}
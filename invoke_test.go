@@ -0,0 +1,60 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInvoke(t *testing.T) {
+	p := NewPath()
+	release := testRelease{Name: "v1.2", Tags: []string{"stable"}}
+	p.Add("release", "shows a release", &testRenderer{result: release})
+	var ran bool
+	p.Add("deploy", "deploys the app", &testCmd{run: func(args ...string) error {
+		ran = true
+		return nil
+	}})
+	p.Add("fail", "fails", &testRenderer{result: release, err: errors.New("unreachable")})
+
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdout: &out})
+	v, c, err := p.InvokeContext(ctx, "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, release) || c.Name != "release" {
+		t.Errorf("Invoke should return %v of release but was %v of %v.", release, v, c.Name)
+	}
+	if out.Len() > 0 {
+		t.Errorf("The result should not be written but was %q.", out.String())
+	}
+
+	v, c, err = p.Invoke("deploy")
+	if err != nil || !ran || v != nil || c.Name != "deploy" {
+		t.Errorf("Invoke should run deploy without a result but was %v, %v (ran: %v).", v, err, ran)
+	}
+	if v, _, err = p.Invoke("fail"); v != nil || err == nil {
+		t.Errorf("Invoke should fail without a result but was %v, %v.", v, err)
+	}
+
+	if out := runRendered(t, p, "release"); out != "v1.2 (stable)\n" {
+		t.Errorf("Run should write the result but was %q.", out)
+	}
+}
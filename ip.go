@@ -0,0 +1,116 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+)
+
+// Defines an IP address flag with specified name, default value, and
+// usage string. The argument p points to a netip.Addr variable in
+// which to store the value of the flag. Both IPv4 and IPv6 addresses
+// are accepted.
+func IPVar(fs *flag.FlagSet, p *netip.Addr, name string, value netip.Addr, usage string) {
+	ipVar(fs, p, name, value, usage, 0)
+}
+
+// Same as IPVar but only accepts IPv4 addresses. IPv4-mapped IPv6
+// addresses are stored as IPv4 addresses.
+func IPv4Var(fs *flag.FlagSet, p *netip.Addr, name string, value netip.Addr, usage string) {
+	ipVar(fs, p, name, value, usage, 4)
+}
+
+// Same as IPVar but only accepts IPv6 addresses.
+func IPv6Var(fs *flag.FlagSet, p *netip.Addr, name string, value netip.Addr, usage string) {
+	ipVar(fs, p, name, value, usage, 6)
+}
+
+func ipVar(fs *flag.FlagSet, p *netip.Addr, name string, value netip.Addr, usage string, version int) {
+	*p = value
+	fs.Var(&ipValue{p: p, version: version}, name, usage)
+}
+
+type ipValue struct {
+	p *netip.Addr
+	// 4 or 6 to restrict the accepted addresses
+	version int
+}
+
+func (v *ipValue) Set(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return errors.New("not an IP address")
+	}
+	switch {
+	case v.version == 4 && addr.Is4In6():
+		addr = addr.Unmap()
+	case v.version == 4 && !addr.Is4():
+		return errors.New("not an IPv4 address")
+	case v.version == 6 && !addr.Is6():
+		return errors.New("not an IPv6 address")
+	}
+	*v.p = addr
+	return nil
+}
+
+func (v *ipValue) String() string {
+	if v.p == nil || !v.p.IsValid() {
+		return ""
+	}
+	return v.p.String()
+}
+
+func (v *ipValue) Get() interface{} {
+	return *v.p
+}
+
+// Defines a CIDR prefix flag with specified name, default value, and
+// usage string, such as 10.0.0.0/8. The argument p points to a
+// netip.Prefix variable in which to store the value of the flag.
+// Prefixes with host bits set, such as 10.1.2.3/8, are rejected.
+func CIDRVar(fs *flag.FlagSet, p *netip.Prefix, name string, value netip.Prefix, usage string) {
+	*p = value
+	fs.Var(&cidrValue{p: p}, name, usage)
+}
+
+type cidrValue struct {
+	p *netip.Prefix
+}
+
+func (v *cidrValue) Set(s string) error {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return errors.New("not a CIDR prefix")
+	}
+	if masked := prefix.Masked(); masked != prefix {
+		return fmt.Errorf("host bits are set, use %s", masked)
+	}
+	*v.p = prefix
+	return nil
+}
+
+func (v *cidrValue) String() string {
+	if v.p == nil || !v.p.IsValid() {
+		return ""
+	}
+	return v.p.String()
+}
+
+func (v *cidrValue) Get() interface{} {
+	return *v.p
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestIPVar(t *testing.T) {
+	tests := []struct {
+		version int
+		value   string
+		want    string
+	}{
+		{0, "192.168.0.1", "192.168.0.1"},
+		{0, "2001:DB8::1", "2001:db8::1"},
+		{4, "10.0.0.1", "10.0.0.1"},
+		{4, "::ffff:10.0.0.1", "10.0.0.1"},
+		{6, "fe80::1", "fe80::1"},
+		{0, "256.0.0.1", ""},
+		{0, "example.com", ""},
+		{4, "::1", ""},
+		{6, "10.0.0.1", ""},
+	}
+	for _, test := range tests {
+		var addr netip.Addr
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		switch test.version {
+		case 4:
+			IPv4Var(fs, &addr, "addr", netip.Addr{}, "address")
+		case 6:
+			IPv6Var(fs, &addr, "addr", netip.Addr{}, "address")
+		default:
+			IPVar(fs, &addr, "addr", netip.Addr{}, "address")
+		}
+		err := fs.Parse([]string{"-addr", test.value})
+		if test.want == "" {
+			if err == nil || !strings.Contains(err.Error(), "-addr") {
+				t.Errorf("%q should fail naming the flag but was %v.", test.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr.String() != test.want {
+			t.Errorf("%q should be stored as %q but was %q.", test.value, test.want, addr)
+		}
+	}
+}
+
+func TestCIDRVar(t *testing.T) {
+	var prefix netip.Prefix
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	CIDRVar(fs, &prefix, "net", netip.Prefix{}, "network")
+	for value, want := range map[string]string{
+		"10.0.0.0/8":     "10.0.0.0/8",
+		"2001:db8::/32":  "2001:db8::/32",
+		"10.0.0.1/8":     "host bits are set, use 10.0.0.0/8",
+		"10.0.0.0":       "not a CIDR prefix",
+		"10.0.0.0/33":    "not a CIDR prefix",
+		"2001:db8::1/32": "host bits are set, use 2001:db8::/32",
+	} {
+		err := fs.Set("net", value)
+		if err != nil {
+			if err.Error() != want {
+				t.Errorf("%q should fail with %q but was %q.", value, want, err)
+			}
+			continue
+		}
+		if prefix.String() != want {
+			t.Errorf("%q should be stored as %q but was %q.", value, want, prefix)
+		}
+	}
+}
+
+func TestIPHelp(t *testing.T) {
+	var addr netip.Addr
+	var prefix, none netip.Prefix
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	IPVar(fs, &addr, "addr", netip.MustParseAddr("2001:0db8::0001"), "address")
+	CIDRVar(fs, &prefix, "net", netip.MustParsePrefix("10.0.0.0/8"), "network")
+	CIDRVar(fs, &none, "other", netip.Prefix{}, "other network")
+	fs.PrintDefaults()
+	want := "  -addr value\n    \taddress (default 2001:db8::1)\n" +
+		"  -net value\n    \tnetwork (default 10.0.0.0/8)\n" +
+		"  -other value\n    \tother network\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
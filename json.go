@@ -0,0 +1,81 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// Defines a flag with specified name and usage string whose value is a
+// JSON document, such as --selector '{"app":"web"}'. Setting the flag
+// unmarshals the document into the value target points to, replacing
+// its previous content. The help output shows the initial value of
+// target as the default. Together with FileExpandable the document can
+// also be read from a file.
+func JSONVar(fs *flag.FlagSet, target interface{}, name, usage string) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		panic("command: target of JSON flag -" + name + " must be a non-nil pointer")
+	}
+	def := reflect.New(rv.Elem().Type()).Elem()
+	def.Set(rv.Elem())
+	fs.Var(&jsonValue{target: rv, def: def}, name, usage)
+}
+
+type jsonValue struct {
+	target reflect.Value
+	// the initial value of target
+	def reflect.Value
+}
+
+func (v *jsonValue) Set(s string) error {
+	p := reflect.New(v.target.Elem().Type())
+	if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+		var syntax *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntax):
+			return fmt.Errorf("invalid JSON at offset %d: %v", syntax.Offset, err)
+		case errors.As(err, &typ):
+			return fmt.Errorf("invalid JSON at offset %d: %v", typ.Offset, err)
+		}
+		return err
+	}
+	v.target.Elem().Set(p.Elem())
+	return nil
+}
+
+func (v *jsonValue) String() string {
+	if !v.target.IsValid() {
+		return ""
+	}
+	b, err := json.Marshal(v.target.Interface())
+	if err != nil || string(b) == "null" {
+		return ""
+	}
+	return string(b)
+}
+
+func (v *jsonValue) Get() interface{} {
+	return v.target.Elem().Interface()
+}
+
+func (v *jsonValue) Reset() {
+	v.target.Elem().Set(v.def)
+}
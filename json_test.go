@@ -0,0 +1,81 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type selector struct {
+	App  string `json:"app"`
+	Tier string `json:"tier,omitempty"`
+}
+
+func TestJSONVarStruct(t *testing.T) {
+	sel := selector{App: "api", Tier: "backend"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	JSONVar(fs, &sel, "selector", "label selector")
+	if err := fs.Parse([]string{"-selector", `{"app":"web"}`}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (selector{App: "web"}); sel != want {
+		t.Errorf("selector should be %+v but was %+v.", want, sel)
+	}
+	if got := fs.Lookup("selector").Value.String(); got != `{"app":"web"}` {
+		t.Errorf("String should re-marshal the value but was %q.", got)
+	}
+}
+
+func TestJSONVarMap(t *testing.T) {
+	labels := map[string]int{"old": 1}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	JSONVar(fs, &labels, "limits", "resource limits")
+	fs.PrintDefaults()
+	if want := `(default {"old":1})`; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+	if err := fs.Parse([]string{"-limits", `{"cpu": 2, "memory": 512}`}); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"cpu": 2, "memory": 512}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("limits should be %v but was %v.", want, labels)
+	}
+}
+
+func TestJSONVarInvalid(t *testing.T) {
+	for value, want := range map[string]string{
+		`{"app": web}`: "invalid JSON at offset 9",
+		`{"app": 1}`:   "invalid JSON at offset 9",
+		`{"app": "x"`:  "unexpected end of JSON input",
+	} {
+		sel := selector{App: "api"}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		JSONVar(fs, &sel, "selector", "label selector")
+		err := fs.Parse([]string{"-selector", value})
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.Contains(err.Error(), "-selector") {
+			t.Errorf("%q should fail with %q but was %v.", value, want, err)
+		}
+		if sel.App != "api" {
+			t.Errorf("A failed Set should not change the target but was %+v.", sel)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "flag"
+
+// Defines a string flag with specified name, default func, and usage
+// string. The argument p points to a string variable in which to
+// store the value of the flag. Run calls value to compute the default
+// only if the flag isn't set by any source for the command being run,
+// so expensive or machine specific defaults, such as the current git
+// branch, aren't computed for other commands. The help output shows
+// the default as "computed".
+func LazyStringVar(fs *flag.FlagSet, p *string, name string, value func() string, usage string) {
+	LazyStringVarHint(fs, p, name, value, "computed", usage)
+}
+
+// Same as LazyStringVar but shows hint as the default in the help
+// output, such as "current branch".
+func LazyStringVarHint(fs *flag.FlagSet, p *string, name string, value func() string, hint, usage string) {
+	*p = ""
+	fs.Var(&lazyStringValue{p: p, value: value}, name, usage)
+	fs.Lookup(name).DefValue = hint
+}
+
+// Flag values with a default computed by Run implement lazyDefault.
+type lazyDefault interface {
+	flag.Value
+	setDefault()
+}
+
+type lazyStringValue struct {
+	p     *string
+	value func() string
+}
+
+func (v *lazyStringValue) Set(s string) error {
+	*v.p = s
+	return nil
+}
+
+func (v *lazyStringValue) setDefault() {
+	*v.p = v.value()
+}
+
+func (v *lazyStringValue) Reset() {
+	*v.p = ""
+}
+
+func (v *lazyStringValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *lazyStringValue) Get() interface{} {
+	return *v.p
+}
+
+// Computes the defaults of the flags of fs without a source.
+func applyLazyDefaults(fs *flag.FlagSet, sources map[string]Source) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if v, ok := f.Value.(lazyDefault); ok {
+			if _, set := sources[f.Name]; !set {
+				v.setDefault()
+			}
+		}
+	})
+}
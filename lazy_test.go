@@ -0,0 +1,95 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newLazyPath(branch *string, calls *int) *Path {
+	p := NewPath()
+	p.Add("push", "pushes a branch", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			LazyStringVarHint(fs, branch, "branch", func() string {
+				*calls++
+				return "main"
+			}, "current branch", "branch to push")
+		},
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	return p
+}
+
+func TestLazyStringVar(t *testing.T) {
+	var branch string
+	var calls int
+	if _, err := newLazyPath(&branch, &calls).Run("push"); err != nil {
+		t.Fatal(err)
+	}
+	if branch != "main" || calls != 1 {
+		t.Errorf("The default should be computed once but branch was %q after %d calls.", branch, calls)
+	}
+}
+
+func TestLazyStringVarNotCalled(t *testing.T) {
+	for _, args := range [][]string{
+		{"push", "-branch", "dev"},
+		{"status"},
+	} {
+		var branch string
+		var calls int
+		p := newLazyPath(&branch, &calls)
+		var help strings.Builder
+		p.WriteHelp(&help, p.registry().entries["push"])
+		if _, err := p.Run(args...); err != nil {
+			t.Fatal(err)
+		}
+		if calls != 0 {
+			t.Errorf("%q should not compute the default.", args)
+		}
+		if !strings.Contains(help.String(), "(default current branch)") {
+			t.Errorf("Help should show the hint but was %q.", help.String())
+		}
+	}
+}
+
+func TestLazyStringVarConfig(t *testing.T) {
+	var branch string
+	var calls int
+	p := newLazyPath(&branch, &calls)
+	if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"push": {"branch": "release"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("push"); err != nil {
+		t.Fatal(err)
+	}
+	if branch != "release" || calls != 0 {
+		t.Errorf("The configured branch should win but branch was %q after %d calls.", branch, calls)
+	}
+}
+
+func TestLazyStringVarHelp(t *testing.T) {
+	var s string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	LazyStringVar(fs, &s, "host", func() string { return "example" }, "host name")
+	fs.PrintDefaults()
+	if want := "host name (default computed)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+}
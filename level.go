@@ -0,0 +1,133 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Level is a log level as set by LevelVar. Greater levels are more
+// severe, and the values match those of slog.Level.
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+var levels = []Level{LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Parses a level name case-insensitively. "warning" is accepted for
+// LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(s)
+	if s == "warning" {
+		return LevelWarn, nil
+	}
+	for _, l := range levels {
+		if s == l.String() {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("must be one of %s", strings.Join(levelNames(), ", "))
+}
+
+func levelNames() []string {
+	names := make([]string, len(levels))
+	for i, l := range levels {
+		names[i] = l.String()
+	}
+	return names
+}
+
+// Defines a log level flag with specified name, default value, and
+// usage string, such as --log-level. The argument p points to a Level
+// variable in which to store the value of the flag. The level names
+// are appended to the usage string and offered for completion.
+func LevelVar(fs *flag.FlagSet, p *Level, name string, value Level, usage string) {
+	*p = value
+	fs.Var(&levelValue{p: p}, name, levelUsage(usage))
+}
+
+// Same as LevelVar but stores the level in lv, so that it takes effect
+// for all slog handlers using lv as their level.
+func SlogLevelVar(fs *flag.FlagSet, lv *slog.LevelVar, name string, value Level, usage string) {
+	lv.Set(slog.Level(value))
+	fs.Var(&levelValue{slog: lv}, name, levelUsage(usage))
+}
+
+func levelUsage(usage string) string {
+	return fmt.Sprintf("%s (%s)", usage, strings.Join(levelNames(), "|"))
+}
+
+type levelValue struct {
+	p    *Level
+	slog *slog.LevelVar
+}
+
+func (v *levelValue) Set(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	if v.slog != nil {
+		v.slog.Set(slog.Level(l))
+	} else {
+		*v.p = l
+	}
+	return nil
+}
+
+func (v *levelValue) level() Level {
+	if v.slog != nil {
+		return Level(v.slog.Level())
+	}
+	return *v.p
+}
+
+func (v *levelValue) String() string {
+	if v.p == nil && v.slog == nil {
+		return ""
+	}
+	return v.level().String()
+}
+
+func (v *levelValue) Get() interface{} {
+	return v.level()
+}
+
+// The values offered for completion.
+func (v *levelValue) completions() []string {
+	return levelNames()
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLevelVar(t *testing.T) {
+	for value, want := range map[string]Level{
+		"debug":   LevelDebug,
+		"INFO":    LevelInfo,
+		"Warn":    LevelWarn,
+		"warning": LevelWarn,
+		"error":   LevelError,
+	} {
+		var l Level
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		LevelVar(fs, &l, "log-level", LevelInfo, "log level")
+		if err := fs.Parse([]string{"-log-level", value}); err != nil {
+			t.Fatal(err)
+		}
+		if l != want {
+			t.Errorf("%q should set %v but was %v.", value, want, l)
+		}
+	}
+	if !(LevelError > LevelWarn && LevelWarn > LevelInfo && LevelInfo > LevelDebug) {
+		t.Error("Levels should be ordered by severity.")
+	}
+}
+
+func TestLevelVarInvalid(t *testing.T) {
+	var l Level
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	LevelVar(fs, &l, "log-level", LevelWarn, "log level")
+	err := fs.Parse([]string{"-log-level", "verbose"})
+	if err == nil || !strings.Contains(err.Error(), "must be one of debug, info, warn, error") {
+		t.Errorf("verbose should be rejected but was %v.", err)
+	}
+	if l != LevelWarn {
+		t.Errorf("The level should stay %v but was %v.", LevelWarn, l)
+	}
+}
+
+func TestLevelVarHelp(t *testing.T) {
+	p := NewPath()
+	p.Add("serve", "serves the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			var l Level
+			LevelVar(fs, &l, "log-level", LevelInfo, "log level")
+		},
+	})
+	var out strings.Builder
+	p.WriteHelp(&out, p.registry().entries["serve"])
+	if want := "log level (debug|info|warn|error) (default info)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+	want := []string{"warn"}
+	if got := p.Complete("serve", "--log-level", "w"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Completion should be %q but was %q.", want, got)
+	}
+}
+
+func TestSlogLevelVar(t *testing.T) {
+	var lv slog.LevelVar
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	SlogLevelVar(fs, &lv, "log-level", LevelWarn, "log level")
+	if lv.Level() != slog.LevelWarn {
+		t.Errorf("The default should set %v but was %v.", slog.LevelWarn, lv.Level())
+	}
+	if err := fs.Parse([]string{"-log-level", "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if lv.Level() != slog.LevelDebug {
+		t.Errorf("The slog level should be %v but was %v.", slog.LevelDebug, lv.Level())
+	}
+	h := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: &lv})
+	if !h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("Handlers using the level var should log debug messages.")
+	}
+}
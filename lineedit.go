@@ -0,0 +1,310 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Control keys understood by the lineEditor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyNewline   = 10
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Edits lines read key by key from a terminal in raw mode with the
+// Emacs key bindings of readline. The terminal is expected to
+// translate newlines written to it.
+type lineEditor struct {
+	r      io.Reader
+	w      io.Writer
+	prompt string
+	// returns the byte offset of the word being completed in line and
+	// the candidates replacing it
+	complete func(line string) (int, []string)
+	history  []string
+
+	buf []rune
+	pos int
+	// the entry of history shown, len(history) for the current line
+	hist int
+}
+
+// Reads a line. Ctrl-C discards the line and starts a new one, Ctrl-D
+// on an empty line or the end of the input return io.EOF.
+func (e *lineEditor) readLine() (string, error) {
+	e.buf, e.pos, e.hist = e.buf[:0], 0, len(e.history)
+	fmt.Fprint(e.w, e.prompt)
+	for {
+		r, err := e.readRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, keyNewline:
+			fmt.Fprint(e.w, "\n")
+			line := string(e.buf)
+			if strings.TrimSpace(line) != "" {
+				e.history = append(e.history, line)
+			}
+			return line, nil
+		case keyCtrlC:
+			fmt.Fprint(e.w, "^C\n", e.prompt)
+			e.buf, e.pos, e.hist = e.buf[:0], 0, len(e.history)
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.w, "\n")
+				return "", io.EOF
+			}
+			e.deleteRange(e.pos, e.pos+1)
+		case keyCtrlA:
+			e.moveTo(0)
+		case keyCtrlE:
+			e.moveTo(len(e.buf))
+		case keyCtrlB:
+			e.moveTo(e.pos - 1)
+		case keyCtrlF:
+			e.moveTo(e.pos + 1)
+		case keyBackspace, keyDelete:
+			e.deleteRange(e.pos-1, e.pos)
+		case keyCtrlK:
+			e.deleteRange(e.pos, len(e.buf))
+		case keyCtrlU:
+			e.deleteRange(0, e.pos)
+		case keyCtrlW:
+			e.deleteRange(e.wordStart(), e.pos)
+		case keyCtrlL:
+			fmt.Fprint(e.w, "\x1b[H\x1b[2J")
+			e.redraw()
+		case keyCtrlP:
+			e.showHistory(e.hist - 1)
+		case keyCtrlN:
+			e.showHistory(e.hist + 1)
+		case keyTab:
+			e.completeWord()
+		case keyEscape:
+			if err := e.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if r >= ' ' {
+				e.insert(string(r))
+			}
+		}
+	}
+}
+
+// Handles the escape sequences of the arrow, home, end and delete
+// keys. Other sequences are ignored.
+func (e *lineEditor) escape() error {
+	r, err := e.readRune()
+	if err != nil || r != '[' && r != 'O' {
+		return err
+	}
+	var param []rune
+	for {
+		if r, err = e.readRune(); err != nil {
+			return err
+		}
+		if r < '0' || r > '9' && r != ';' {
+			break
+		}
+		param = append(param, r)
+	}
+	switch {
+	case r == 'A':
+		e.showHistory(e.hist - 1)
+	case r == 'B':
+		e.showHistory(e.hist + 1)
+	case r == 'C':
+		e.moveTo(e.pos + 1)
+	case r == 'D':
+		e.moveTo(e.pos - 1)
+	case r == 'H' || r == '~' && (string(param) == "1" || string(param) == "7"):
+		e.moveTo(0)
+	case r == 'F' || r == '~' && (string(param) == "4" || string(param) == "8"):
+		e.moveTo(len(e.buf))
+	case r == '~' && string(param) == "3":
+		e.deleteRange(e.pos, e.pos+1)
+	}
+	return nil
+}
+
+// Reads a single UTF-8 encoded key one byte at a time, so that no input
+// beyond it is consumed.
+func (e *lineEditor) readRune() (rune, error) {
+	var b [utf8.UTFMax]byte
+	n := 0
+	for {
+		m, err := e.r.Read(b[n : n+1])
+		n += m
+		if n > 0 && (utf8.FullRune(b[:n]) || n == len(b)) {
+			r, _ := utf8.DecodeRune(b[:n])
+			return r, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if m == 0 {
+			return 0, io.ErrNoProgress
+		}
+	}
+}
+
+func (e *lineEditor) insert(s string) {
+	r := []rune(s)
+	e.buf = append(e.buf[:e.pos], append(r, e.buf[e.pos:]...)...)
+	e.pos += len(r)
+	e.redraw()
+}
+
+// Deletes the runes from i up to j, which are clamped to the line.
+func (e *lineEditor) deleteRange(i, j int) {
+	i, j = max(i, 0), min(j, len(e.buf))
+	if i >= j {
+		return
+	}
+	e.buf = append(e.buf[:i], e.buf[j:]...)
+	if e.pos > j {
+		e.pos -= j - i
+	} else if e.pos > i {
+		e.pos = i
+	}
+	e.redraw()
+}
+
+func (e *lineEditor) moveTo(pos int) {
+	if pos = min(max(pos, 0), len(e.buf)); pos != e.pos {
+		e.pos = pos
+		e.redraw()
+	}
+}
+
+// Returns the start of the word before the cursor, skipping the spaces
+// preceding the cursor.
+func (e *lineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && e.buf[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && e.buf[i-1] != ' ' {
+		i--
+	}
+	return i
+}
+
+// Replaces the line with the history entry i, or with an empty line if
+// i is past the newest entry.
+func (e *lineEditor) showHistory(i int) {
+	if i < 0 || i > len(e.history) || i == e.hist {
+		return
+	}
+	e.hist = i
+	e.buf = e.buf[:0]
+	if i < len(e.history) {
+		e.buf = append(e.buf, []rune(e.history[i])...)
+	}
+	e.pos = len(e.buf)
+	e.redraw()
+}
+
+// Completes the word before the cursor. A single candidate replaces the
+// word, several ones are extended to their common prefix or listed if
+// the word is already the common prefix.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	line := string(e.buf[:e.pos])
+	start, candidates := e.complete(line)
+	if len(candidates) == 0 {
+		return
+	}
+	word := line[start:]
+	var repl string
+	switch prefix := commonPrefix(candidates); {
+	case len(candidates) == 1:
+		repl = quoteArg(prefix)
+		if !strings.HasSuffix(prefix, "=") {
+			repl += " "
+		}
+	case len(prefix) > len(unquoteWord(word)):
+		repl = quoteArg(prefix)
+	default:
+		fmt.Fprint(e.w, "\n", strings.Join(candidates, "  "), "\n")
+		e.redraw()
+		return
+	}
+	rest := e.buf[e.pos:]
+	e.buf = append([]rune(line[:start]+repl), rest...)
+	e.pos = len(e.buf) - len(rest)
+	e.redraw()
+}
+
+// Writes the prompt and the line and moves the cursor to its position.
+func (e *lineEditor) redraw() {
+	fmt.Fprintf(e.w, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.w, "\x1b[%dD", n)
+	}
+}
+
+func commonPrefix(s []string) string {
+	prefix := s[0]
+	for _, c := range s[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			_, n := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-n]
+		}
+	}
+	return prefix
+}
+
+// Quotes s for SplitArgs if it contains spaces, quotes or backslashes.
+func quoteArg(s string) string {
+	if !strings.ContainsAny(s, " \t'\"\\") {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Returns the possibly incomplete word w as split by SplitArgs.
+func unquoteWord(w string) string {
+	for _, closing := range []string{"", "'", `"`} {
+		if args, err := SplitArgs(w + closing); err == nil {
+			return strings.Join(args, " ")
+		}
+	}
+	return w
+}
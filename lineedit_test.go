@@ -0,0 +1,109 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(in string) (*lineEditor, *strings.Builder) {
+	out := new(strings.Builder)
+	return &lineEditor{r: strings.NewReader(in), w: out, prompt: "> "}, out
+}
+
+func TestLineEditor(t *testing.T) {
+	for _, test := range []struct {
+		in, line string
+	}{
+		{"status\r", "status"},
+		{"tatus\x01s\r", "status"},
+		{"stat\x02\x02\x06\x06us\r", "status"},
+		{"statux\x7fs\r", "status"},
+		{"sx\x02\x04\x05tatus\r", "status"},
+		{"deploy -env prod\x0b\x17st\x01\x0bstatus\r", "status"},
+		{"deploy -env\x15status\r", "status"},
+		{"deploy -env  \x17\x17status\r", "status"},
+		{"tatus\x1b[D\x1b[D\x1b[H\x1b[Fs\x1b[1~s\r", "statuss"},
+		{"sstatus\x1b[H\x1b[3~\r", "status"},
+		{"stätus\x02\x02\x02\x7fa\r", "status"},
+		{"deploy\x03status\n", "status"},
+	} {
+		e, _ := newTestEditor(test.in)
+		if line, err := e.readLine(); err != nil || line != test.line {
+			t.Errorf("Line of %q should be %q but was %q, %v.", test.in, test.line, line, err)
+		}
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	e, _ := newTestEditor("status\rdeploy\r\x10\x10\r\x1b[A\x1b[A\x1b[A\x1b[B\r\x10\x0e\r")
+	for _, want := range []string{"status", "deploy", "status", "deploy", ""} {
+		if line, err := e.readLine(); err != nil || line != want {
+			t.Errorf("Line should be %q but was %q, %v.", want, line, err)
+		}
+	}
+}
+
+func TestLineEditorEOF(t *testing.T) {
+	e, out := newTestEditor("status\x03\x04")
+	if _, err := e.readLine(); err != io.EOF {
+		t.Errorf("Ctrl-D on an empty line should return io.EOF but was %v.", err)
+	}
+	if !strings.Contains(out.String(), "^C\n> ") {
+		t.Errorf("Ctrl-C should start a new line but output was %q.", out.String())
+	}
+	e, _ = newTestEditor("stat")
+	if _, err := e.readLine(); err != io.EOF {
+		t.Errorf("End of the input should return io.EOF but was %v.", err)
+	}
+}
+
+func TestLineEditorComplete(t *testing.T) {
+	complete := func(line string) (int, []string) {
+		start := strings.LastIndexByte(line, ' ') + 1
+		var matches []string
+		for _, c := range []string{"deploy", "destroy", "status", "-env=", "my app"} {
+			if strings.HasPrefix(c, unquoteWord(line[start:])) {
+				matches = append(matches, c)
+			}
+		}
+		return start, matches
+	}
+	for _, test := range []struct {
+		in, line string
+	}{
+		{"st\t\r", "status "},
+		{"de\t\r", "de"},
+		{"d\t\r", "de"},
+		{"-e\t\r", "-env="},
+		{"x\t\r", "x"},
+		{"st x\x01\x06\x06\t\r", "status  x"},
+		{"m\t\r", "'my app' "},
+	} {
+		e, _ := newTestEditor(test.in)
+		e.complete = complete
+		if line, err := e.readLine(); err != nil || line != test.line {
+			t.Errorf("Line of %q should be %q but was %q, %v.", test.in, test.line, line, err)
+		}
+	}
+	e, out := newTestEditor("de\t\r")
+	e.complete = complete
+	e.readLine()
+	if !strings.Contains(out.String(), "\ndeploy  destroy\n") {
+		t.Errorf("Completion should list the candidates but output was %q.", out.String())
+	}
+}
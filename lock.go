@@ -0,0 +1,144 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// How often a contended lock is retried while waiting for it, see
+// SetLockTimeout.
+const lockPollInterval = 50 * time.Millisecond
+
+type exclusiveLock struct {
+	path *template.Template
+	wait time.Duration
+}
+
+// Makes sure only one invocation of the command runs at a time, across
+// processes. Before running the command Run acquires an advisory lock
+// on the file at path, flock on Unix and LockFileEx on Windows, and
+// writes the process ID to it. If another invocation holds the lock,
+// Run fails with an AlreadyRunningError, or waits for the lock first,
+// see SetLockTimeout. The lock is released when the command returns,
+// fails or panics; the file is left in place.
+//
+// path is a text/template executed with the field Command, the full
+// name of the command with dashes for spaces, such as
+// "/tmp/myapp-{{.Command}}.lock" for "/tmp/myapp-remote-add.lock".
+func (c *CmdCont) ExclusiveLock(path string) error {
+	t, err := template.New(c.Name).Parse(path)
+	if err != nil {
+		return fmt.Errorf("Invalid lock file of command %q: %w", c.Name, err)
+	}
+	if c.lock == nil {
+		c.lock = &exclusiveLock{}
+	}
+	c.lock.path = t
+	return nil
+}
+
+// Waits up to d for the lock of ExclusiveLock instead of failing
+// immediately if another invocation holds it. It has no effect without
+// ExclusiveLock.
+func (c *CmdCont) SetLockTimeout(d time.Duration) {
+	if c.lock == nil {
+		c.lock = &exclusiveLock{}
+	}
+	c.lock.wait = d
+}
+
+// Returned by Run if another invocation of the command holds its lock,
+// see ExclusiveLock.
+type AlreadyRunningError struct {
+	Command string
+	// the lock file
+	Path string
+	// the process holding the lock, 0 if unknown
+	PID int
+	// how long Run waited for the lock, see SetLockTimeout
+	Waited time.Duration
+}
+
+func (e *AlreadyRunningError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: already running", e.Command)
+	if e.PID != 0 {
+		fmt.Fprintf(&b, " as process %d", e.PID)
+	}
+	if e.Waited > 0 {
+		fmt.Fprintf(&b, " after waiting %v", e.Waited)
+	}
+	fmt.Fprintf(&b, " (lock file %s)", e.Path)
+	return b.String()
+}
+
+// Acquires the lock of c and returns a function releasing it.
+func (c *CmdCont) acquireLock(ctx context.Context) (func(), error) {
+	var path strings.Builder
+	data := struct{ Command string }{strings.Replace(c.CommandPath(), " ", "-", -1)}
+	if err := c.lock.path.Execute(&path, data); err != nil {
+		return nil, fmt.Errorf("%s: lock file: %w", c.CommandPath(), err)
+	}
+	f, err := os.OpenFile(path.String(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("%s: lock file: %w", c.CommandPath(), err)
+	}
+	start := now()
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: lock file %s: %w", c.CommandPath(), f.Name(), err)
+		}
+		if locked {
+			break
+		}
+		waited := now().Sub(start)
+		if waited >= c.lock.wait {
+			pid := readLockPID(f)
+			f.Close()
+			return nil, &AlreadyRunningError{Command: c.CommandPath(), Path: f.Name(), PID: pid, Waited: waited}
+		}
+		if err := sleep(ctx, lockPollInterval); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// Returns the process ID written to the lock file by its holder, or 0.
+func readLockPID(f *os.File) int {
+	b, err := io.ReadAll(io.NewSectionReader(f, 0, 32))
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return pid
+}
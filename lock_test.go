@@ -0,0 +1,129 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Returns a path with a command holding the lock on file while it runs
+// until release is closed. It reports on locked when it has the lock.
+func newLockedPath(t *testing.T, file string, locked chan<- bool, release <-chan bool) *Path {
+	p := NewPath()
+	c := p.Add("migrate", "migrates the database", &testCmd{run: func(args ...string) error {
+		if locked != nil {
+			locked <- true
+			<-release
+		}
+		return nil
+	}})
+	if err := c.ExclusiveLock(file); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExclusiveLock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "{{.Command}}.lock")
+	locked, release := make(chan bool), make(chan bool)
+	done := make(chan error)
+	go func() {
+		_, err := newLockedPath(t, file, locked, release).Run("migrate")
+		done <- err
+	}()
+	<-locked
+
+	_, err := newLockedPath(t, file, nil, nil).Run("migrate")
+	var e *AlreadyRunningError
+	if !errors.As(err, &e) {
+		t.Fatalf("A second invocation should fail with an AlreadyRunningError but failed with %v.", err)
+	}
+	want := filepath.Join(filepath.Dir(file), "migrate.lock")
+	if e.PID != os.Getpid() || e.Path != want || e.Command != "migrate" {
+		t.Errorf("The error should name the holding process %d and the lock file %q but was %+v.", os.Getpid(), want, e)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newLockedPath(t, file, nil, nil).Run("migrate"); err != nil {
+		t.Errorf("The lock should be released when the command returns but running failed with %v.", err)
+	}
+}
+
+func TestExclusiveLockWait(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "migrate.lock")
+	locked, release := make(chan bool), make(chan bool)
+	done := make(chan error)
+	go func() {
+		_, err := newLockedPath(t, file, locked, release).Run("migrate")
+		done <- err
+	}()
+	<-locked
+
+	p := newLockedPath(t, file, nil, nil)
+	p.Lookup("migrate").SetLockTimeout(100 * time.Millisecond)
+	_, err := p.Run("migrate")
+	var e *AlreadyRunningError
+	if !errors.As(err, &e) || e.Waited < 100*time.Millisecond {
+		t.Errorf("Run should fail after waiting for the lock but failed with %v.", err)
+	}
+
+	p.Lookup("migrate").SetLockTimeout(10 * time.Second)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	if _, err := p.Run("migrate"); err != nil {
+		t.Errorf("Run should wait for the lock but failed with %v.", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExclusiveLockReleasedOnFailure(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "migrate.lock")
+	p := NewPath()
+	p.SetRecoverPanics(true)
+	fail := errors.New("failed")
+	c := p.Add("migrate", "migrates the database", &testCmd{run: func(args ...string) error {
+		if len(args) > 0 {
+			panic("boom")
+		}
+		return fail
+	}})
+	if err := c.ExclusiveLock(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("migrate"); err != fail {
+		t.Fatalf("Run should fail with the error of the command but failed with %v.", err)
+	}
+	if _, err := p.Run("migrate", "panic"); err == nil {
+		t.Fatal("Run should fail with the recovered panic.")
+	}
+	if _, err := newLockedPath(t, file, nil, nil).Run("migrate"); err != nil {
+		t.Errorf("The lock should be released after the failures but running failed with %v.", err)
+	}
+
+	if err := c.ExclusiveLock("{{.Command"); err == nil {
+		t.Error("ExclusiveLock should fail for an invalid template.")
+	}
+}
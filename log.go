@@ -0,0 +1,44 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// Receives the informational messages of the package, such as the
+// warnings about deprecated flags. A *log.Logger is a Logger. Every
+// message starts with a level hint such as "warning: ".
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Sends the informational messages of Run to l instead of writing them
+// to Output. Mounted paths log to the logger of the enclosing path
+// unless set themselves. If l is nil, which is the default, warnings
+// are written to Output without level hint.
+func (p *Path) SetLogger(l Logger) {
+	p.logger = l
+}
+
+// Logs an error of the package that doesn't affect the result of Run.
+func (p *Path) logError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	for q := p; q != nil; q = q.parent {
+		if q.logger != nil {
+			q.logger.Printf("error: %s", msg)
+			return
+		}
+	}
+	fmt.Fprintln(p.Output(), msg)
+}
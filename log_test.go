@@ -0,0 +1,67 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestLoggerDeprecation(t *testing.T) {
+	var colour, color string
+	p, c := newColorPath(&colour, &color)
+	if err := c.DeprecateFlag("colour", "use --color instead"); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	p.SetOutput(&out)
+	var l recordingLogger
+	p.SetLogger(&l)
+	if _, err := p.Run("show", "-colour", "never"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"warning: Flag --colour is deprecated, use --color instead"}; !reflect.DeepEqual(l.messages, want) {
+		t.Errorf("Messages should be %q but were %q.", want, l.messages)
+	}
+	if out.Len() > 0 {
+		t.Errorf("Nothing should be written to the output but was %q.", out.String())
+	}
+}
+
+func TestLoggerMounted(t *testing.T) {
+	var colour, color string
+	sub, c := newColorPath(&colour, &color)
+	c.DeprecateFlag("colour", "use --color instead")
+	p := NewPath()
+	p.Mount("ui", "user interface", sub)
+	var l recordingLogger
+	p.SetLogger(&l)
+	if _, err := p.Run("ui", "show", "-colour", "never"); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.messages) != 1 {
+		t.Errorf("Mounted paths should log to the enclosing logger but got %q.", l.messages)
+	}
+}
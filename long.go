@@ -0,0 +1,75 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// The long description of a command read from a file, see LongFromFS.
+type longHelp struct {
+	fsys fs.FS
+	path string
+	once sync.Once
+	text string
+	err  error
+}
+
+// Reads the long description of the command from the file at path in
+// fsys, such as an embed.FS, when the help of the command is first
+// written. The text is shown below the description in the help output
+// and the Markdown documentation as it is, so Markdown headings and
+// code fences stay readable on a terminal. A first line repeating the
+// description, such as a "# " heading, is left out. A missing file is
+// reported by Check, the help output leaves the text out then.
+func (c *CmdCont) LongFromFS(fsys fs.FS, path string) {
+	c.long = &longHelp{fsys: fsys, path: path}
+	c.changed()
+}
+
+// Returns the long description of c, reading it on the first call, or
+// "" if it has none.
+func (c *CmdCont) longText() (string, error) {
+	if c.long == nil {
+		return "", nil
+	}
+	l := c.long
+	l.once.Do(func() {
+		var b []byte
+		if b, l.err = fs.ReadFile(l.fsys, l.path); l.err == nil {
+			l.text = trimLongTitle(string(b), c.Desc)
+		}
+	})
+	return l.text, l.err
+}
+
+// Removes a first line repeating desc, such as a Markdown heading, its
+// underline and the empty lines following it, and surrounding empty
+// lines.
+func trimLongTitle(text, desc string) string {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	text = strings.TrimLeft(text, "\n")
+	first, rest, _ := strings.Cut(text, "\n")
+	title := strings.TrimSpace(strings.TrimLeft(first, "#"))
+	if desc != "" && strings.EqualFold(strings.TrimSuffix(title, "."), strings.TrimSuffix(desc, ".")) {
+		text = rest
+		if underline, after, _ := strings.Cut(text, "\n"); underline != "" && strings.Trim(underline, "=-") == "" {
+			text = after
+		}
+	}
+	return strings.Trim(text, "\n")
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"embed"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/long
+var longFS embed.FS
+
+func TestLongFromFS(t *testing.T) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{})
+	c.LongFromFS(longFS, "testdata/long/deploy.md")
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	want := "Usage: deploy [flags]\n\ndeploys the app\n\n" +
+		"Deploys the current build to the target environment and waits until\nthe new version serves requests.\n\n" +
+		"## Rollback\n\n    app deploy -env prod -version v1.2\n\n```\napp status\n```\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+	if problems := p.Check(); len(problems) != 0 {
+		t.Errorf("An existing file should not be a problem but was %v.", problems)
+	}
+}
+
+func TestLongFromFSMissing(t *testing.T) {
+	p := NewPath()
+	c := p.Add("status", "prints the status", &testCmd{})
+	c.LongFromFS(longFS, "testdata/long/status.md")
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if want := "Usage: status [flags]\n\nprints the status\n"; out.String() != want {
+		t.Errorf("Help should leave out the missing text and be %q but was %q.", want, out.String())
+	}
+	problems := p.Check()
+	if len(problems) != 1 || problems[0].Severity != SeverityError ||
+		problems[0].Message != "long description: open testdata/long/status.md: file does not exist" {
+		t.Errorf("Check should report the missing file but reported %v.", problems)
+	}
+}
+
+func TestTrimLongTitle(t *testing.T) {
+	for _, test := range []struct {
+		text, want string
+	}{
+		{"# Deploys the app.\n\nText\n", "Text"},
+		{"Deploys the app\n===============\n\nText", "Text"},
+		{"\r\nDEPLOYS THE APP\r\nText\r\n", "Text"},
+		{"# Deployment\n\nText\n", "# Deployment\n\nText"},
+		{"Text\n", "Text"},
+	} {
+		if got := trimLongTitle(test.text, "deploys the app"); got != test.want {
+			t.Errorf("The text of %q should be %q but was %q.", test.text, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Registers a command running the command lines steps one after the
+// other, each dispatched by p like the arguments of Run:
+//
+//	p.AddMacro("release", "builds, tests and publishes the app", [][]string{
+//		{"build"},
+//		{"test"},
+//		{"publish", "--latest"},
+//	})
+//
+// The macro stops at the first failing step and returns a
+// MacroStepError. The flags of the commands of the steps are reset
+// after each step, so a command can be used by several steps. A macro
+// running itself, directly or through other macros, fails. Check
+// reports steps invoking unknown commands or recursive macros.
+func (p *Path) AddMacro(name, desc string, steps [][]string) *CmdCont {
+	return p.Add(name, desc, &macroCmd{path: p, name: name, steps: steps})
+}
+
+type macroCmd struct {
+	path  *Path
+	name  string
+	steps [][]string
+	// the macro is running, to detect recursion
+	running bool
+}
+
+func (m *macroCmd) Flags(fs *flag.FlagSet) {
+}
+
+func (m *macroCmd) Run(args ...string) error {
+	return m.RunContext(context.Background(), args...)
+}
+
+func (m *macroCmd) RunContext(ctx context.Context, args ...string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%s: macro takes no arguments", m.name)
+	}
+	if m.running {
+		return fmt.Errorf("%s: macro invokes itself", m.name)
+	}
+	m.running = true
+	defer func() { m.running = false }()
+	for i, step := range m.steps {
+		c, err := m.path.RunContext(ctx, step...)
+		if c != nil {
+			c.resetFlags()
+		}
+		if err != nil {
+			return &MacroStepError{Macro: m.name, Step: i + 1, Args: step, Err: err}
+		}
+	}
+	return nil
+}
+
+// Returned by the commands of AddMacro if a step fails. The message has
+// the form
+//
+//	release: step 2 "test -race" failed: ...
+type MacroStepError struct {
+	Macro string
+	// the 1-based number of the step
+	Step int
+	// the command line of the step
+	Args []string
+	Err  error
+}
+
+func (e *MacroStepError) Error() string {
+	return fmt.Sprintf("%s: step %d %q failed: %v", e.Macro, e.Step, strings.Join(e.Args, " "), e.Err)
+}
+
+func (e *MacroStepError) Unwrap() error {
+	return e.Err
+}
+
+// Returns the problems of the steps of m: steps not starting with a
+// registered command and steps running m again, see Check.
+func (m *macroCmd) check() []string {
+	var problems []string
+	for i, step := range m.steps {
+		c, unknown := m.path.resolveStep(step)
+		if c == nil && unknown == "" {
+			problems = append(problems, fmt.Sprintf("step %d must start with a command name", i+1))
+		} else if c == nil {
+			problems = append(problems, fmt.Sprintf("step %d invokes unknown command %q", i+1, unknown))
+		} else if mc, ok := c.Cmd.(*macroCmd); ok && mc.invokes(m, map[*macroCmd]bool{}) {
+			problems = append(problems, fmt.Sprintf("step %d invokes the macro recursively through %q", i+1, c.Name))
+		}
+	}
+	return problems
+}
+
+// Reports whether m or the macros of its steps run target.
+func (m *macroCmd) invokes(target *macroCmd, seen map[*macroCmd]bool) bool {
+	if m == target {
+		return true
+	}
+	if seen[m] {
+		return false
+	}
+	seen[m] = true
+	for _, step := range m.steps {
+		if c, _ := m.path.resolveStep(step); c != nil {
+			if mc, ok := c.Cmd.(*macroCmd); ok && mc.invokes(target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the command a step starting with a command name runs,
+// following aliases and mounted paths, or the name that isn't a
+// command, empty if the step doesn't start with a name.
+func (p *Path) resolveStep(step []string) (*CmdCont, string) {
+	step = p.expandAlias(step)
+	if len(step) == 0 || strings.HasPrefix(step[0], "-") {
+		return nil, ""
+	}
+	c := p.Lookup(step[0])
+	if c == nil {
+		return nil, step[0]
+	}
+	if c.sub != nil && len(step) > 1 && !strings.HasPrefix(step[1], "-") {
+		return c.sub.resolveStep(step[1:])
+	}
+	return c, ""
+}
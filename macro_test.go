@@ -0,0 +1,125 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Returns a path with the commands build, test and publish, recording
+// their invocations in calls.
+func newMacroPath(calls *[]string) *Path {
+	p := NewPath()
+	for _, name := range []string{"build", "test", "publish"} {
+		name := name
+		var latest bool
+		p.Add(name, name+"s the app", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&latest, "latest", false, "tag as latest")
+			},
+			run: func(args ...string) error {
+				call := name
+				if latest {
+					call += " --latest"
+				}
+				*calls = append(*calls, call)
+				if name == "test" && len(args) > 0 && args[0] == "fail" {
+					return errors.New("tests failed")
+				}
+				return nil
+			},
+		})
+	}
+	return p
+}
+
+func TestMacro(t *testing.T) {
+	var calls []string
+	p := newMacroPath(&calls)
+	p.AddMacro("release", "releases the app", [][]string{
+		{"build", "--latest"},
+		{"test"},
+		{"build"},
+		{"publish", "--latest"},
+	})
+	c, err := p.Run("release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "release" {
+		t.Errorf("Run should return release but was %q.", c.Name)
+	}
+	if want := []string{"build --latest", "test", "build", "publish --latest"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Steps should run %q but ran %q.", want, calls)
+	}
+	if problems := p.Check(); len(problems) > 0 {
+		t.Errorf("Check should not report problems but was %v.", problems)
+	}
+}
+
+func TestMacroFailure(t *testing.T) {
+	var calls []string
+	p := newMacroPath(&calls)
+	p.AddMacro("release", "releases the app", [][]string{{"build"}, {"test", "fail"}, {"publish"}})
+	_, err := p.Run("release")
+	var stepErr *MacroStepError
+	if !errors.As(err, &stepErr) || stepErr.Step != 2 {
+		t.Fatalf("Run should fail in step 2 but was %v.", err)
+	}
+	if want := `release: step 2 "test fail" failed: tests failed`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+	if want := []string{"build", "test"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Steps should run %q but ran %q.", want, calls)
+	}
+}
+
+func TestMacroRecursion(t *testing.T) {
+	var calls []string
+	p := newMacroPath(&calls)
+	p.AddMacro("release", "releases the app", [][]string{{"build"}, {"ship"}})
+	p.AddMacro("ship", "ships the app", [][]string{{"publish"}, {"release"}})
+	p.AddMacro("broken", "is broken", [][]string{{"deploy"}, {"--latest"}})
+	var msgs []string
+	for _, problem := range p.Check() {
+		msgs = append(msgs, problem.String())
+	}
+	want := []string{
+		`broken: error: step 1 invokes unknown command "deploy"`,
+		"broken: error: step 2 must start with a command name",
+		`release: error: step 2 invokes the macro recursively through "ship"`,
+		`ship: error: step 2 invokes the macro recursively through "release"`,
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("Check should report %q but was %q.", want, msgs)
+	}
+	_, err := p.Run("release")
+	var concurrent *ConcurrentInvocationError
+	if !errors.As(err, &concurrent) || concurrent.Command != "release" {
+		t.Errorf("Run should fail for the recursion but was %v.", err)
+	}
+	if want := []string{"build", "publish"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Steps should run %q but ran %q.", want, calls)
+	}
+	p.SetAllowReentry(true)
+	_, err = p.Run("release")
+	if err == nil || !strings.HasSuffix(err.Error(), "release: macro invokes itself") {
+		t.Errorf("Run should fail for the recursion but was %v.", err)
+	}
+}
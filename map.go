@@ -0,0 +1,112 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Defines a map[string]string flag with specified name and usage
+// string, which is given as key=value and may be repeated. The value
+// is split on the first '=', so values may contain '=' themselves.
+// The argument p points to the map storing the pairs; its initial
+// contents are the default, which is replaced on the first occurrence
+// of the flag. Later occurrences of a key overwrite earlier ones.
+func StringMapVar(fs *flag.FlagSet, p *map[string]string, name, usage string) {
+	fs.Var(&stringMapValue{p: p, def: copyStringMap(*p)}, name, usage)
+}
+
+// Same as StringMapVar but fails if a key is given more than once.
+func StringMapVarStrict(fs *flag.FlagSet, p *map[string]string, name, usage string) {
+	fs.Var(&stringMapValue{p: p, strict: true, def: copyStringMap(*p)}, name, usage)
+}
+
+type stringMapValue struct {
+	p      *map[string]string
+	strict bool
+	set    bool
+	// the initial contents of p
+	def map[string]string
+}
+
+func (v *stringMapValue) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return fmt.Errorf("%q is not of the form key=value", s)
+	}
+	key, value := s[:i], s[i+1:]
+	if key == "" {
+		return fmt.Errorf("%q has an empty key", s)
+	}
+	if !v.set || *v.p == nil {
+		*v.p = make(map[string]string)
+		v.set = true
+	}
+	if _, dup := (*v.p)[key]; dup && v.strict {
+		return fmt.Errorf("key %q given more than once", key)
+	}
+	(*v.p)[key] = value
+	return nil
+}
+
+// Renders the pairs sorted by key.
+func (v *stringMapValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(*v.p))
+	for k := range *v.p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + (*v.p)[k]
+	}
+	return strings.Join(keys, ",")
+}
+
+func (v *stringMapValue) Get() interface{} {
+	return *v.p
+}
+
+func (v *stringMapValue) IsRepeatable() bool {
+	return true
+}
+
+func (v *stringMapValue) Reset() {
+	*v.p = copyStringMap(v.def)
+	v.set = false
+}
+
+func (v *stringMapValue) snapshot() func() {
+	values, set := copyStringMap(*v.p), v.set
+	return func() {
+		*v.p, v.set = copyStringMap(values), set
+	}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStringMap(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	labels := map[string]string{"team": "ops"}
+	StringMapVar(fs, &labels, "label", "labels")
+	err := fs.Parse([]string{"-label", "app=web", "-label", "tier=frontend", "-label", "query=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"app": "web", "tier": "frontend", "query": "a=b"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("labels should be %v but were %v.", want, labels)
+	}
+	if s := fs.Lookup("label").Value.String(); s != "app=web,query=a=b,tier=frontend" {
+		t.Errorf("String should render sorted keys but was %q.", s)
+	}
+}
+
+func TestStringMapDuplicates(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var labels map[string]string
+	StringMapVar(fs, &labels, "label", "labels")
+	if err := fs.Parse([]string{"-label", "app=web", "-label", "app=api"}); err != nil {
+		t.Fatal(err)
+	}
+	if labels["app"] != "api" {
+		t.Errorf("Later occurrences should overwrite but app was %q.", labels["app"])
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	StringMapVarStrict(fs, &labels, "label", "labels")
+	err := fs.Parse([]string{"-label", "app=web", "-label", "app=api"})
+	if err == nil || !strings.Contains(err.Error(), `key "app" given more than once`) {
+		t.Errorf("Duplicate keys should fail in strict mode but was %v.", err)
+	}
+}
+
+func TestStringMapInvalid(t *testing.T) {
+	for _, arg := range []string{"app", "=web"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(new(strings.Builder))
+		var labels map[string]string
+		StringMapVar(fs, &labels, "label", "labels")
+		if err := fs.Parse([]string{"-label", arg}); err == nil {
+			t.Errorf("%q should fail.", arg)
+		}
+	}
+}
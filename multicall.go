@@ -0,0 +1,47 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sets the prefix stripped from the program name by RunMultiCall, such
+// as "myapp-" for a link named myapp-deploy.
+func (p *Path) SetMultiCallPrefix(prefix string) {
+	p.multiCallPrefix = prefix
+}
+
+// Runs the command the program was invoked as, for binaries installed
+// under the names of their commands like busybox. If the base name of
+// argv0, without the prefix set by SetMultiCallPrefix and an .exe
+// extension, is the name of a command, the command is run with args.
+// Otherwise args are run like by Run, so that the program can still be
+// invoked under its own name:
+//
+//	p.RunMultiCall(os.Args[0], os.Args[1:])
+func (p *Path) RunMultiCall(argv0 string, args []string) (*CmdCont, error) {
+	name := filepath.Base(argv0)
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name, ok := strings.CutPrefix(name, p.multiCallPrefix); ok {
+		if _, ok := p.registry().entries[name]; ok {
+			return p.Run(append([]string{name}, args...)...)
+		}
+	}
+	return p.Run(args...)
+}
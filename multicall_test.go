@@ -0,0 +1,58 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunMultiCall(t *testing.T) {
+	var got []string
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{
+		run: func(args ...string) error {
+			got = append([]string{"status"}, args...)
+			return nil
+		},
+	})
+	p.Add("deploy", "deploys the app", &testCmd{
+		run: func(args ...string) error {
+			got = append([]string{"deploy"}, args...)
+			return nil
+		},
+	})
+	for _, test := range []struct {
+		prefix, argv0 string
+		args, want    []string
+	}{
+		{"", "/usr/local/bin/status", []string{"now"}, []string{"status", "now"}},
+		{"", `status.exe`, nil, []string{"status"}},
+		{"myapp-", "bin/myapp-deploy", []string{"api"}, []string{"deploy", "api"}},
+		{"myapp-", "bin/deploy", []string{"status"}, []string{"status"}},
+		{"", "/usr/bin/myapp", []string{"deploy", "web"}, []string{"deploy", "web"}},
+	} {
+		got = nil
+		p.SetMultiCallPrefix(test.prefix)
+		if _, err := p.RunMultiCall(test.argv0, test.args); err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Invocation of %s %q should run %q but ran %q, %v.", test.argv0, test.args, test.want, got, err)
+		}
+	}
+	var usage *UsageError
+	if _, err := p.RunMultiCall("/usr/bin/myapp", nil); !errors.As(err, &usage) {
+		t.Errorf("Invocation under the program name without arguments should fail with a UsageError but was %v.", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+)
+
+// The default flag name normalization of commands: underscores are
+// translated to dashes, so --dry_run and --dry-run both set the flag
+// dry-run.
+func NormalizeUnderscores(name string) string {
+	return strings.Replace(name, "_", "-", -1)
+}
+
+// Sets the function normalizing flag names given on the command line.
+// A flag name that isn't registered is replaced by the registered name
+// with the same normalized form, if there is exactly one. Help and
+// completion always show the registered names. A nil fn disables
+// normalization. The default is NormalizeUnderscores.
+func (c *CmdCont) SetNormalizeFunc(fn func(name string) string) {
+	c.normalize = fn
+}
+
+// Replaces the flag names in args that aren't registered by their
+// registered spelling according to the normalize func of c.
+func (c *CmdCont) normalizeArgs(args []string) []string {
+	if c.normalize == nil || len(args) == 0 {
+		return args
+	}
+	// the registered flags by normalized name, only computed if a name
+	// isn't registered
+	var names map[string]string
+	var ambiguous map[string]bool
+	var normalized []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		dashes := "-"
+		if strings.HasPrefix(arg, "--") {
+			dashes = "--"
+		}
+		name, value := arg[len(dashes):], ""
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value = name[:j], name[j:]
+		}
+		f := c.FlagSet().Lookup(name)
+		if f == nil {
+			if names == nil {
+				names, ambiguous = c.normalizedNames()
+			}
+			n := c.normalize(name)
+			if registered, ok := names[n]; ok && !ambiguous[n] {
+				if normalized == nil {
+					normalized = append([]string(nil), args...)
+				}
+				normalized[i] = dashes + registered + value
+				f = c.FlagSet().Lookup(registered)
+			}
+		}
+		if f != nil && value == "" && !isBoolFlag(f) {
+			// skip the value of the flag
+			i++
+		}
+	}
+	if normalized == nil {
+		return args
+	}
+	return normalized
+}
+
+// Returns the flags of c by normalized name and the normalized names
+// shared by several flags.
+func (c *CmdCont) normalizedNames() (map[string]string, map[string]bool) {
+	names := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	c.FlagSet().VisitAll(func(f *flag.Flag) {
+		n := c.normalize(f.Name)
+		if _, ok := names[n]; ok {
+			ambiguous[n] = true
+		}
+		names[n] = f.Name
+	})
+	return names, ambiguous
+}
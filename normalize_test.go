@@ -0,0 +1,103 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type normalizeOpts struct {
+	dryRun bool
+	maxAge int
+	args   []string
+}
+
+func newNormalizePath(o *normalizeOpts) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&o.dryRun, "dry-run", false, "only print the changes")
+			fs.IntVar(&o.maxAge, "max_age", 0, "maximum age")
+		},
+		run: func(args ...string) error {
+			o.args = args
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestNormalizeUnderscores(t *testing.T) {
+	for _, args := range [][]string{
+		{"--dry-run", "--max_age", "3"},
+		{"--dry_run", "--max-age", "3"},
+		{"-dry_run=true", "-max-age=3"},
+	} {
+		var o normalizeOpts
+		p, _ := newNormalizePath(&o)
+		if _, err := p.Run(append([]string{"deploy"}, args...)...); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if !o.dryRun || o.maxAge != 3 {
+			t.Errorf("%q should set dry-run and max_age but were %v and %d.", args, o.dryRun, o.maxAge)
+		}
+	}
+}
+
+func TestNormalizeSkipsValues(t *testing.T) {
+	var o normalizeOpts
+	p, _ := newNormalizePath(&o)
+	if _, err := p.Run("deploy", "--max-age", "3", "--", "--dry_run"); err != nil {
+		t.Fatal(err)
+	}
+	if o.dryRun {
+		t.Error("Arguments after -- should not be normalized.")
+	}
+	if want := []string{"--dry_run"}; !reflect.DeepEqual(o.args, want) {
+		t.Errorf("args should be %q but were %q.", want, o.args)
+	}
+}
+
+func TestNormalizeFunc(t *testing.T) {
+	var o normalizeOpts
+	p, c := newNormalizePath(&o)
+	c.SetNormalizeFunc(func(name string) string {
+		return NormalizeUnderscores(strings.ToLower(name))
+	})
+	if _, err := p.Run("deploy", "--Dry-Run", "--MAX-AGE", "5"); err != nil {
+		t.Fatal(err)
+	}
+	if !o.dryRun || o.maxAge != 5 {
+		t.Errorf("dry-run and max_age should be set but were %v and %d.", o.dryRun, o.maxAge)
+	}
+
+	c.SetNormalizeFunc(nil)
+	if _, err := p.Run("deploy", "--dry_run"); err == nil {
+		t.Error("--dry_run should fail without normalization.")
+	}
+}
+
+func TestNormalizeHelp(t *testing.T) {
+	var o normalizeOpts
+	p, c := newNormalizePath(&o)
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if !strings.Contains(out.String(), "--max_age int") || !strings.Contains(out.String(), "--dry-run\n") {
+		t.Errorf("Help should show the registered names:\n%s", out.String())
+	}
+}
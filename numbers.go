@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "flag"
+
+// Returns args with a "--" inserted before the first argument among
+// the flags that is a negative number, such as -5 or -2.5, so the flag
+// package takes it and all arguments following it as positional
+// arguments instead of failing on an undefined flag. The number of
+// arguments following the inserted "--" is returned as well, or -1 if
+// none was inserted.
+//
+// A number is a single dash followed by digits, optionally with a
+// fractional part of a dot and more digits. It remains a flag if the
+// digits following the dash, such as 1 for -1, are the name of a flag
+// or alias of c or of a global or persistent flag visible to it, or,
+// with SetCombinedShorts, if the first digit is. Values of flags, as in
+// -offset -5, are left to the flag package.
+func (c *CmdCont) markNumbers(args []string, inherited []*flag.FlagSet) ([]string, int) {
+	lookup := func(name string) *flag.Flag {
+		if f := c.FlagSet().Lookup(name); f != nil {
+			return f
+		}
+		if fs := lookupInherited(inherited, name); fs != nil {
+			return fs.Lookup(name)
+		}
+		return nil
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		if isNegativeNumber(arg) && lookup(arg[1:]) == nil && (!c.combineShorts || lookup(arg[1:2]) == nil) {
+			marked := make([]string, 0, len(args)+1)
+			marked = append(append(append(marked, args[:i]...), "--"), args[i:]...)
+			return marked, len(args) - i
+		}
+		name := arg[1:]
+		if name[0] == '-' {
+			name = name[1:]
+		}
+		hasValue := false
+		for j := 0; j < len(name); j++ {
+			if name[j] == '=' {
+				name, hasValue = name[:j], true
+				break
+			}
+		}
+		if f := lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+			// skip the value of the flag
+			i++
+		}
+	}
+	return args, -1
+}
+
+// Reports whether arg has the form -123 or -1.5.
+func isNegativeNumber(arg string) bool {
+	digits := func(s string) int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		return n
+	}
+	s := arg[1:]
+	n := digits(s)
+	if n == 0 {
+		return false
+	}
+	if s = s[n:]; s == "" {
+		return true
+	}
+	return s[0] == '.' && len(s) > 1 && digits(s[1:]) == len(s)-1
+}
+
+// Removes the "--" inserted by markNumbers from args, which is followed
+// by n arguments.
+func unmarkNumbers(args []string, n int) []string {
+	if n < 0 {
+		return args
+	}
+	i := len(args) - n - 1
+	return append(append(make([]string, 0, len(args)-1), args[:i]...), args[i+1:]...)
+}
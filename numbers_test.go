@@ -0,0 +1,86 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type adjustOpts struct {
+	by      string
+	verbose bool
+	one     bool
+	args    []string
+}
+
+func newAdjustPath(o *adjustOpts) *Path {
+	p := NewPath()
+	p.Flags.BoolVar(&o.verbose, "v", false, "verbose output")
+	c := p.Add("adjust", "adjusts the volume", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&o.by, "by", "", "adjust by `STEPS`")
+			fs.BoolVar(&o.one, "1", false, "single step")
+		},
+		run: func(args ...string) error {
+			o.args = args
+			return nil
+		},
+	})
+	c.Flags.SetOutput(new(strings.Builder))
+	return p
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	tests := []struct {
+		args        []string
+		want        adjustOpts
+		passthrough []string
+	}{
+		{[]string{"adjust", "-5"}, adjustOpts{args: []string{"-5"}}, nil},
+		{[]string{"adjust", "-2.5", "-v"}, adjustOpts{args: []string{"-2.5", "-v"}}, nil},
+		{[]string{"adjust", "-v", "-10", "x"}, adjustOpts{verbose: true, args: []string{"-10", "x"}}, nil},
+		{[]string{"adjust", "-by", "-3", "-7"}, adjustOpts{by: "-3", args: []string{"-7"}}, nil},
+		{[]string{"adjust", "-1"}, adjustOpts{one: true, args: []string{}}, nil},
+		{[]string{"adjust", "-1", "-12"}, adjustOpts{one: true, args: []string{"-12"}}, nil},
+		{[]string{"adjust", "-5", "--", "-1"}, adjustOpts{args: []string{"-5", "--", "-1"}}, []string{"-1"}},
+	}
+	for _, test := range tests {
+		var o adjustOpts
+		p := newAdjustPath(&o)
+		if _, err := p.Run(test.args...); err != nil {
+			t.Errorf("Run(%q) failed: %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(o, test.want) {
+			t.Errorf("Run(%q) should set %+v but set %+v.", test.args, test.want, o)
+		}
+		if pt := p.Lookup("adjust").PassthroughArgs(); !reflect.DeepEqual(pt, test.passthrough) {
+			t.Errorf("Run(%q) should pass through %q but passed through %q.", test.args, test.passthrough, pt)
+		}
+	}
+}
+
+func TestNegativeNumbersNotNumbers(t *testing.T) {
+	for _, arg := range []string{"-5x", "-1.", "-.5", "-1.2.3", "--5"} {
+		var o adjustOpts
+		p := newAdjustPath(&o)
+		if _, err := p.Run("adjust", arg); err == nil {
+			t.Errorf("Run(%q) should fail with an undefined flag.", arg)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "time"
+
+// Registers fn to be called at the end of every Run, for example to
+// emit metrics. fn receives the command, the duration of its Run method
+// and the error returned by Run. If the invocation fails before the
+// command runs, such as for invalid flags, d is zero, and c is nil if
+// no command matched. The callbacks of mounted paths are called before
+// those of the enclosing paths, each in registration order. A panic in
+// fn is recovered and doesn't affect the other callbacks or the error
+// returned by Run.
+func (p *Path) OnComplete(fn func(c *CmdCont, d time.Duration, err error)) {
+	p.completed = append(p.completed, fn)
+}
+
+func (p *Path) notifyComplete(c *CmdCont, d time.Duration, err error) {
+	for q := p; q != nil; q = q.parent {
+		for _, fn := range q.completed {
+			callComplete(fn, c, d, err)
+		}
+	}
+	p.writeAudit(c, d, err)
+}
+
+func callComplete(fn func(*CmdCont, time.Duration, error), c *CmdCont, d time.Duration, err error) {
+	defer func() {
+		recover()
+	}()
+	fn(c, d, err)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type completion struct {
+	name string
+	d    time.Duration
+}
+
+func TestOnComplete(t *testing.T) {
+	defer func(fn func() time.Time) { now = fn }(now)
+	clock := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	errFailed := errors.New("failed")
+	tests := []struct {
+		args []string
+		want completion
+		fail bool
+	}{
+		{[]string{"deploy", "-env", "prod"}, completion{"deploy", time.Second}, false},
+		{[]string{"deploy", "-env", "prod", "fail"}, completion{"deploy", time.Second}, true},
+		{[]string{"deploy", "-force"}, completion{"deploy", 0}, true},
+		{[]string{"destroy"}, completion{"", 0}, true},
+		{[]string{"remote", "add"}, completion{"add", time.Second}, false},
+	}
+	for _, test := range tests {
+		p := NewPath()
+		p.Add("deploy", "deploys the app", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.SetOutput(io.Discard)
+				fs.String("env", "", "target environment")
+			},
+			run: func(args ...string) error {
+				if len(args) > 0 {
+					return errFailed
+				}
+				return nil
+			},
+		})
+		remote := NewPath()
+		remote.Add("add", "adds a remote", &testCmd{})
+		p.Mount("remote", "manages remotes", remote)
+		var got []completion
+		var errs []error
+		record := func(c *CmdCont, d time.Duration, err error) {
+			var name string
+			if c != nil {
+				name = c.Name
+			}
+			got = append(got, completion{name, d})
+			errs = append(errs, err)
+		}
+		p.OnComplete(record)
+		p.OnComplete(func(c *CmdCont, d time.Duration, err error) {
+			panic("broken metrics")
+		})
+		p.OnComplete(record)
+		_, err := p.Run(test.args...)
+		if want := []completion{test.want, test.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q should complete with %v but was %v.", test.args, want, got)
+		}
+		for _, e := range errs {
+			if e != err {
+				t.Errorf("%q should complete with error %v but was %v.", test.args, err, e)
+			}
+		}
+		if (err != nil) != test.fail {
+			t.Errorf("%q should fail=%v but was %v.", test.args, test.fail, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+type flagCallback struct {
+	name string
+	fn   func(value string) error
+}
+
+// Registers fn to be called with the value of the named flag when Run
+// runs the command with the flag set, on the command line, by an
+// environment variable or by the configuration, such as to change the
+// working directory for --chdir. The callbacks are called in
+// registration order after the flags were validated and before the
+// requirements are checked and the command runs; the first error
+// aborts the run. Flags holding their default don't trigger callbacks.
+func (c *CmdCont) OnFlagSet(name string, fn func(value string) error) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	c.onFlagSet = append(c.onFlagSet, flagCallback{name: name, fn: fn})
+	return nil
+}
+
+// Calls the callbacks of OnFlagSet for the flags set in this run.
+func (c *CmdCont) notifyFlagsSet() error {
+	for _, cb := range c.onFlagSet {
+		if !c.FlagWasSet(cb.name) {
+			continue
+		}
+		if err := cb.fn(c.FlagSet().Lookup(cb.name).Value.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
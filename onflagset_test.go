@@ -0,0 +1,101 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func newOnFlagSetPath(ran *bool) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("chdir", "", "working directory")
+			fs.String("profile", "default", "profile to load")
+			fs.Bool("dry-run", false, "only print the changes")
+		},
+		run: func(args ...string) error {
+			*ran = true
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestOnFlagSet(t *testing.T) {
+	var ran bool
+	p, c := newOnFlagSetPath(&ran)
+	var calls []string
+	for _, name := range []string{"profile", "chdir", "dry-run"} {
+		name := name
+		if err := c.OnFlagSet(name, func(value string) error {
+			calls = append(calls, name+"="+value)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.BindEnv("dry-run", "COMMAND_TEST_DRY_RUN"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COMMAND_TEST_DRY_RUN", "true")
+	if _, err := p.Run("deploy", "-chdir", "/srv", "-profile", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"profile=prod", "chdir=/srv", "dry-run=true"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("The callbacks should be called as %q but were called as %q.", want, calls)
+	}
+
+	calls = nil
+	os.Unsetenv("COMMAND_TEST_DRY_RUN")
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Flags holding their defaults should not trigger callbacks but triggered %q.", calls)
+	}
+	calls = nil
+	if _, err := p.Validate("deploy", "-chdir", "/srv"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Validate should not trigger callbacks but triggered %q.", calls)
+	}
+}
+
+func TestOnFlagSetError(t *testing.T) {
+	var ran bool
+	p, c := newOnFlagSetPath(&ran)
+	fail := errors.New("no such directory")
+	var profile bool
+	c.OnFlagSet("chdir", func(string) error { return fail })
+	c.OnFlagSet("profile", func(string) error {
+		profile = true
+		return nil
+	})
+	if _, err := p.Run("deploy", "-chdir", "/missing", "-profile", "prod"); err != fail {
+		t.Errorf("Run should fail with %v but failed with %v.", fail, err)
+	}
+	if ran || profile {
+		t.Error("An error of a callback should abort the run.")
+	}
+	if err := c.OnFlagSet("missing", func(string) error { return nil }); err == nil {
+		t.Error("Registering a callback for an unknown flag should fail.")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Commands implementing ResultRenderer are run with RunResulting
+// instead of their Run method. The result is written to the standard
+// output of the command, see EnvFromContext, in the format selected by
+// the --output flag, see OutputFlag, or returned by Invoke.
+type ResultRenderer interface {
+	Cmd
+	RunResulting(args ...string) (interface{}, error)
+}
+
+// The formats accepted by the --output flag.
+var outputFormats = []string{"text", "json", "yaml"}
+
+// Defines the flag --output selecting the format of the result of the
+// command: text, json or yaml. Commands implementing ResultRenderer
+// are rendered in that format, other commands can query it with
+// OutputFormat.
+func (c *CmdCont) OutputFlag() {
+	outputVar(c.FlagSet())
+}
+
+// Same as the OutputFlag of CmdCont but defines a persistent flag,
+// accepted by all commands of p and of the paths mounted below it.
+func (p *Path) OutputFlag() {
+	outputVar(p.PersistentFlags())
+}
+
+// The value of the --output flag, which tells OutputFormat it apart
+// from other flags of the same name.
+type outputValue struct {
+	enumValue
+}
+
+func outputVar(fs *flag.FlagSet) {
+	format := "text"
+	fs.Var(&outputValue{enumValue{p: &format, choices: outputFormats}}, "output",
+		fmt.Sprintf("output format (%s)", strings.Join(outputFormats, "|")))
+}
+
+// Renders the text format of the results of c with the template text,
+// see text/template, instead of their String method.
+func (c *CmdCont) SetOutputTemplate(text string) error {
+	t, err := template.New(c.Name).Parse(text)
+	if err != nil {
+		return fmt.Errorf("Invalid output template for command %q: %w", c.Name, err)
+	}
+	c.outputTemplate = t
+	return nil
+}
+
+// Returns the format selected by the --output flag of c, or of the
+// closest path for which OutputFlag was called, after Run parsed the
+// flags. Without such a flag the format is text.
+func (c *CmdCont) OutputFormat() string {
+	for _, fs := range append([]*flag.FlagSet{c.FlagSet()}, c.globals...) {
+		if f := fs.Lookup("output"); f != nil {
+			if v, ok := f.Value.(*outputValue); ok {
+				return v.String()
+			}
+		}
+	}
+	return "text"
+}
+
+// Runs the ResultRenderer r of cont and writes its result, unless it
+// is returned by Invoke.
+func runResulting(ctx context.Context, cont *CmdCont, r ResultRenderer, args []string) error {
+	v, err := r.RunResulting(args...)
+	if err != nil || storeResult(ctx, v) {
+		return err
+	}
+	return cont.writeResult(EnvFromContext(ctx).Stdout, v)
+}
+
+// Writes v to w in the OutputFormat of c.
+func (c *CmdCont) writeResult(w io.Writer, v interface{}) error {
+	switch c.OutputFormat() {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(w, v)
+	}
+	if c.outputTemplate != nil {
+		return c.outputTemplate.Execute(w, v)
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		_, err := fmt.Fprintln(w, s.String())
+		return err
+	}
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+// Writes v as a YAML document. Values are converted like by
+// encoding/json, so that the json tags of structs apply.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	var out strings.Builder
+	writeYAMLNode(&out, tree, "")
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// Writes the node v at the current position of b, indenting its
+// following lines by indent.
+func writeYAMLNode(b *strings.Builder, v interface{}, indent string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}\n")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(indent)
+			}
+			b.WriteString(yamlScalar(k) + ":")
+			if isYAMLBlock(v[k]) {
+				b.WriteString("\n" + indent + "  ")
+				writeYAMLNode(b, v[k], indent+"  ")
+			} else {
+				b.WriteString(" ")
+				writeYAMLNode(b, v[k], indent)
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]\n")
+			return
+		}
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteString(indent)
+			}
+			b.WriteString("- ")
+			writeYAMLNode(b, elem, indent+"  ")
+		}
+	default:
+		b.WriteString(yamlScalar(v) + "\n")
+	}
+}
+
+// Reports whether v is written as a block of its own lines.
+func isYAMLBlock(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlNeedsQuotes(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// Reports whether s would not be read back as the same plain string.
+func yamlNeedsQuotes(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "~", "null", "true", "false", "yes", "no", "on", "off":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") || strings.HasSuffix(s, " ") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return true
+		}
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":")
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testRelease struct {
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	Latest bool     `json:"latest"`
+}
+
+func (r testRelease) String() string {
+	return fmt.Sprintf("%s (%s)", r.Name, strings.Join(r.Tags, ", "))
+}
+
+type testRenderer struct {
+	result interface{}
+	err    error
+}
+
+func (c *testRenderer) Flags(fs *flag.FlagSet) {}
+
+func (c *testRenderer) Run(args ...string) error {
+	return fmt.Errorf("Run should not be called")
+}
+
+func (c *testRenderer) RunResulting(args ...string) (interface{}, error) {
+	return c.result, c.err
+}
+
+func runRendered(t *testing.T, p *Path, args ...string) string {
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdout: &out})
+	if _, err := p.RunContext(ctx, args...); err != nil {
+		t.Fatal(err)
+	}
+	p.ResetFlags()
+	return out.String()
+}
+
+func TestResultRenderer(t *testing.T) {
+	p := NewPath()
+	release := testRelease{Name: "v1.2", Tags: []string{"stable", "1.2: lts"}, Latest: true}
+	c := p.Add("release", "shows a release", &testRenderer{result: release})
+	c.OutputFlag()
+	for _, test := range []struct {
+		format, want string
+	}{
+		{"text", "v1.2 (stable, 1.2: lts)\n"},
+		{"json", "{\n  \"name\": \"v1.2\",\n  \"tags\": [\n    \"stable\",\n    \"1.2: lts\"\n  ],\n  \"latest\": true\n}\n"},
+		{"yaml", "latest: true\nname: v1.2\ntags:\n  - stable\n  - \"1.2: lts\"\n"},
+	} {
+		if out := runRendered(t, p, "release", "--output", test.format); out != test.want {
+			t.Errorf("Output in %s should be %q but was %q.", test.format, test.want, out)
+		}
+	}
+	if out := runRendered(t, p, "release"); out != "v1.2 (stable, 1.2: lts)\n" {
+		t.Errorf("Output should default to text but was %q.", out)
+	}
+
+	if err := c.SetOutputTemplate("{{.Name}}{{range .Tags}} [{{.}}]{{end}}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if out := runRendered(t, p, "release"); out != "v1.2 [stable] [1.2: lts]\n" {
+		t.Errorf("Output of the template should be %q but was %q.", "v1.2 [stable] [1.2: lts]\n", out)
+	}
+	if err := c.SetOutputTemplate("{{.Name"); err == nil {
+		t.Error("SetOutputTemplate should fail for an invalid template.")
+	}
+}
+
+func TestResultRendererYAMLRoundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"empty":  "",
+		"number": "42",
+		"nested": []interface{}{map[string]interface{}{"a": "b", "c": []interface{}{}}, "- dash"},
+		"none":   nil,
+	}
+	var out strings.Builder
+	if err := writeYAML(&out, v); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseYAML(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("Output %q should be valid YAML: %v", out.String(), err)
+	}
+	if fmt.Sprint(parsed) != fmt.Sprint(v) {
+		t.Errorf("YAML %q should parse as %v but was %v.", out.String(), v, parsed)
+	}
+}
+
+func TestOutputFlagPath(t *testing.T) {
+	p := NewPath()
+	p.OutputFlag()
+	p.Add("release", "shows a release", &testRenderer{result: []string{"v1", "v2"}})
+	if out := runRendered(t, p, "release", "--output=json"); out != "[\n  \"v1\",\n  \"v2\"\n]\n" {
+		t.Errorf("Output should be JSON but was %q.", out)
+	}
+	if out := runRendered(t, p, "--output", "yaml", "release"); out != "- v1\n- v2\n" {
+		t.Errorf("Output should be YAML but was %q.", out)
+	}
+	_, err := p.Run("release", "--output", "xml")
+	if err == nil || !strings.Contains(err.Error(), "must be one of text, json, yaml") {
+		t.Errorf("Unknown formats should be rejected but error was %v.", err)
+	}
+}
+
+func TestResultRendererError(t *testing.T) {
+	p := NewPath()
+	p.Add("release", "shows a release", &testRenderer{err: fmt.Errorf("no releases")})
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdout: &out})
+	if _, err := p.RunContext(ctx, "release"); err == nil || err.Error() != "no releases" {
+		t.Errorf("Error should be %q but was %v.", "no releases", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Output should be empty but was %q.", out.String())
+	}
+}
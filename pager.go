@@ -0,0 +1,125 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// The pager used if $PAGER isn't set.
+const defaultPager = "less -FRX"
+
+// Sets the command line of the pager for the help and the listing of
+// commands, overriding $PAGER. An empty command restores the default.
+func (p *Path) SetPager(command string) {
+	p.pager = command
+}
+
+// Disables the pager of p and of the paths mounted below it.
+func (p *Path) DisablePager(disable bool) {
+	p.noPager = disable
+}
+
+// Defines the global flag --no-pager disabling the pager.
+func (p *Path) NoPagerFlag() {
+	p.noPagerFlag = p.Flags.Bool("no-pager", false, "do not pipe help output into a pager")
+}
+
+// Returns the command line of the pager or nil if the output of p
+// isn't paged.
+func (p *Path) pagerCommand() []string {
+	var command string
+	for q := p; q != nil; q = q.parent {
+		if q.noPager || q.noPagerFlag != nil && *q.noPagerFlag {
+			return nil
+		}
+		if command == "" {
+			command = q.pager
+		}
+	}
+	if command == "" {
+		env, ok := os.LookupEnv("PAGER")
+		if !ok {
+			env = defaultPager
+		}
+		command = env
+	}
+	args, err := SplitArgs(command)
+	if err != nil || len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	return args
+}
+
+// Writes the output of write to w, through the pager if w is a
+// terminal and the output doesn't fit onto it. If the pager can't be
+// started the output is written to w directly.
+func (p *Path) page(w io.Writer, write func(io.Writer)) {
+	var buf bytes.Buffer
+	write(&buf)
+	args := p.pagerCommand()
+	if args == nil || !p.isTerminalWriter(w) || bytes.Count(buf.Bytes(), []byte("\n")) < terminalHeight(w) {
+		w.Write(buf.Bytes())
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		w.Write(buf.Bytes())
+		return
+	}
+	// the pager may quit before reading all of the output, the broken
+	// pipe is not an error
+	in.Write(buf.Bytes())
+	in.Close()
+	cmd.Wait()
+}
+
+// Returns the number of lines of the terminal w, $LINES or 24 if w
+// isn't a terminal, such as when ForceInteractive is set.
+func terminalHeight(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if h, _, err := terminalSize(f.Fd()); err == nil && h > 0 {
+			return h
+		}
+	}
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 0 {
+		return h
+	}
+	return 24
+}
+
+// Returns the number of columns of the terminal w, $COLUMNS or 80 if w
+// isn't a terminal.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if _, cols, err := terminalSize(f.Fd()); err == nil && cols > 0 {
+			return cols
+		}
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Runs as the pager of the tests if $COMMAND_TEST_PAGER is set: copies
+// its input to its output after a header, or quits without reading the
+// input if it is "quit".
+func TestHelperPager(t *testing.T) {
+	switch os.Getenv("COMMAND_TEST_PAGER") {
+	case "":
+		return
+	case "quit":
+	default:
+		fmt.Println("paged:")
+		io.Copy(os.Stdout, os.Stdin)
+	}
+	os.Exit(0)
+}
+
+func newPagerPath(t *testing.T, mode string) *Path {
+	t.Setenv("COMMAND_TEST_PAGER", mode)
+	t.Setenv("LINES", "3")
+	p := NewPath()
+	p.SetPager(quoteArg(os.Args[0]) + " -test.run=^TestHelperPager$")
+	p.ForceInteractive(true)
+	return p
+}
+
+func TestPager(t *testing.T) {
+	p := newPagerPath(t, "copy")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+			fs.Bool("force", false, "skip the checks")
+		},
+	})
+	var out strings.Builder
+	c.Flags.SetOutput(&out)
+	p.Run("deploy", "-h")
+	var help strings.Builder
+	p.WriteHelp(&help, c)
+	if want := "paged:\n" + help.String(); out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestPagerShortOutput(t *testing.T) {
+	p := newPagerPath(t, "copy")
+	var out strings.Builder
+	p.page(&out, func(w io.Writer) { io.WriteString(w, "one\ntwo\n") })
+	if want := "one\ntwo\n"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestPagerDisabled(t *testing.T) {
+	long := func(w io.Writer) { io.WriteString(w, "1\n2\n3\n4\n5\n") }
+	for name, disable := range map[string]func(*Path){
+		"not interactive": func(p *Path) { p.ForceInteractive(false) },
+		"DisablePager":    func(p *Path) { p.DisablePager(true) },
+		"--no-pager": func(p *Path) {
+			p.NoPagerFlag()
+			p.Flags.Parse([]string{"--no-pager"})
+		},
+		"cat": func(p *Path) { p.SetPager("cat") },
+	} {
+		p := newPagerPath(t, "copy")
+		disable(p)
+		var out strings.Builder
+		p.page(&out, long)
+		if want := "1\n2\n3\n4\n5\n"; out.String() != want {
+			t.Errorf("Output with %s should be %q but was %q.", name, want, out.String())
+		}
+	}
+}
+
+func TestPagerFailure(t *testing.T) {
+	p := newPagerPath(t, "copy")
+	p.SetPager("command-test-no-such-pager")
+	var out strings.Builder
+	p.page(&out, func(w io.Writer) { io.WriteString(w, "1\n2\n3\n4\n5\n") })
+	if want := "1\n2\n3\n4\n5\n"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+
+	// the pager quits before reading all of the output
+	p = newPagerPath(t, "quit")
+	out.Reset()
+	p.page(&out, func(w io.Writer) { io.WriteString(w, strings.Repeat("line\n", 1<<20)) })
+	if out.Len() != 0 {
+		t.Errorf("Output should be empty but was %d bytes.", out.Len())
+	}
+}
+
+func TestPagerEnv(t *testing.T) {
+	p := NewPath()
+	t.Setenv("PAGER", "more -s")
+	if args := p.pagerCommand(); strings.Join(args, " ") != "more -s" {
+		t.Errorf("Pager should be %q but was %q.", "more -s", args)
+	}
+	os.Unsetenv("PAGER")
+	if args := p.pagerCommand(); strings.Join(args, " ") != defaultPager {
+		t.Errorf("Pager should be %q but was %q.", defaultPager, args)
+	}
+}
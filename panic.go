@@ -0,0 +1,97 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Recovers panics of the Run methods of the commands of p and returns
+// them as a PanicError instead of crashing the program. Mounted paths
+// recover if the enclosing path does.
+func (p *Path) SetRecoverPanics(recover bool) {
+	p.recoverPanics = recover
+}
+
+func (p *Path) recovers() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.recoverPanics {
+			return true
+		}
+	}
+	return false
+}
+
+// Returned by Run for a panic of a command if enabled with
+// SetRecoverPanics. The message is kept short for users, the stack of
+// the panic is available from Stack and written to the trace.
+type PanicError struct {
+	Command string
+	// the value passed to panic
+	Value interface{}
+	stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("internal error in command %s", e.Command)
+}
+
+// Returns the stack of the goroutine at the time of the panic, starting
+// with the panicking function.
+func (e *PanicError) Stack() []byte {
+	return e.stack
+}
+
+// Same as cont.Run but recovers panics if enabled for p, passes ctx to
+// commands implementing ContextCmd, renders the result of those
+// implementing ResultRenderer and passes cont to those implementing
+// CmdWithCont.
+func (p *Path) runCommand(ctx context.Context, cont *CmdCont, args []string) (err error) {
+	if p.recovers() {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Command: cont.Name, Value: v, stack: panicStack(debug.Stack())}
+			}
+		}()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c, ok := cont.Cmd.(ContextCmd); ok {
+		return c.RunContext(withCmdCont(ctx, p, cont), args...)
+	}
+	if r, ok := cont.Cmd.(ResultRenderer); ok {
+		return runResulting(ctx, cont, r, args)
+	}
+	if c, ok := cont.Cmd.(CmdWithCont); ok {
+		return c.RunCont(cont, args...)
+	}
+	return cont.Run(args...)
+}
+
+// Removes the frames of debug.Stack, the recovering function and panic
+// from a stack captured in a deferred function.
+func panicStack(stack []byte) []byte {
+	lines := bytes.SplitAfter(stack, []byte("\n"))
+	for i := 1; i+1 < len(lines); i += 2 {
+		if bytes.HasPrefix(lines[i], []byte("panic(")) {
+			return bytes.Join(append(lines[:1:1], lines[i+2:]...), nil)
+		}
+	}
+	return stack
+}
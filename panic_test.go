@@ -0,0 +1,80 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func explode(args ...string) error {
+	var m map[string]int
+	m[args[0]]++
+	return nil
+}
+
+func newPanicPath() *Path {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{run: explode})
+	p.SetRecoverPanics(true)
+	return p
+}
+
+func TestRecoverPanics(t *testing.T) {
+	p := newPanicPath()
+	var out strings.Builder
+	p.SetOutput(&out)
+	if code := p.Execute("deploy", "eu"); code != 1 {
+		t.Errorf("A panic should exit with 1 but was %d.", code)
+	}
+	if want := "internal error in command deploy\n"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+
+	_, err := newPanicPath().Run("deploy", "eu")
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Error should be a PanicError but was %#v.", err)
+	}
+	stack := string(panicErr.Stack())
+	if !strings.HasPrefix(stack, "goroutine ") || !strings.Contains(stack, "command.explode(") {
+		t.Errorf("Stack should contain the panicking function but was %q.", stack)
+	}
+	if strings.Contains(stack, "runtime/debug.Stack") || strings.Contains(stack, "panic(") {
+		t.Errorf("Stack should not contain the recovery frames but was %q.", stack)
+	}
+}
+
+func TestRecoverPanicsTrace(t *testing.T) {
+	p := newPanicPath()
+	var trace strings.Builder
+	p.SetTrace(&trace)
+	p.Run("deploy", "eu")
+	if want := "trace: deploy: panic: assignment to entry in nil map\ngoroutine "; !strings.Contains(trace.String(), want) {
+		t.Errorf("Trace should contain %q but was %q.", want, trace.String())
+	}
+}
+
+func TestRecoverPanicsDisabled(t *testing.T) {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{run: explode})
+	defer func() {
+		if recover() == nil {
+			t.Error("Panics should not be recovered by default.")
+		}
+	}()
+	p.Run("deploy", "eu")
+}
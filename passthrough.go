@@ -0,0 +1,41 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+// Returns the arguments following the "--" given to the last Run of c,
+// such as ls and -l for "wrap -v -- ls -l", or nil if the command line
+// had no "--". The arguments passed to the Run method of the command
+// include them, along with a "--" following other positional
+// arguments, as before.
+func (c *CmdCont) PassthroughArgs() []string {
+	_, tail := splitPassthrough(c.parsedArgs, c.FlagSet().Args())
+	return tail
+}
+
+// Splits the positional arguments rest, parsed from args by the flag
+// package, into those before the first "--" and those after it. tail
+// is nil if there is no "--".
+func splitPassthrough(args, rest []string) (before, tail []string) {
+	// the flag package drops the "--" ending the flags
+	if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+		return rest[:0], rest[:len(rest):len(rest)]
+	}
+	for i, arg := range rest {
+		if arg == "--" {
+			return rest[:i], rest[i+1 : len(rest) : len(rest)]
+		}
+	}
+	return rest, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newWrapPath(got *[]string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("wrap", "runs a program with the environment", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("v", false, "verbose output")
+		},
+		run: func(args ...string) error {
+			*got = args
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestPassthroughArgs(t *testing.T) {
+	tests := []struct {
+		args        []string
+		run         []string
+		passthrough []string
+	}{
+		{[]string{"wrap", "-v", "--", "ls", "-l", "--all"}, []string{"ls", "-l", "--all"}, []string{"ls", "-l", "--all"}},
+		{[]string{"wrap", "prod", "--", "-v", "--"}, []string{"prod", "--", "-v", "--"}, []string{"-v", "--"}},
+		{[]string{"wrap", "--"}, []string{}, []string{}},
+		{[]string{"wrap", "-v", "ls", "-l"}, []string{"ls", "-l"}, nil},
+		{[]string{"wrap"}, []string{}, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		p, c := newWrapPath(&got)
+		if _, err := p.Run(test.args...); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, " ") != strings.Join(test.run, " ") {
+			t.Errorf("%q should run with %q but was %q.", test.args, test.run, got)
+		}
+		tail := c.PassthroughArgs()
+		if (tail == nil) != (test.passthrough == nil) || strings.Join(tail, " ") != strings.Join(test.passthrough, " ") {
+			t.Errorf("The passthrough args of %q should be %#v but were %#v.", test.args, test.passthrough, tail)
+		}
+	}
+}
+
+func TestPassthroughArgsExplain(t *testing.T) {
+	var got []string
+	p, _ := newWrapPath(&got)
+	var out strings.Builder
+	if err := p.Explain(&out, "wrap", "prod", "--", "ls", "-l"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "args: [\"prod\"]\npassthrough args: [\"ls\" \"-l\"]\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("The explanation should contain %q but was %q.", want, out.String())
+	}
+	var trace strings.Builder
+	p.SetTrace(&trace)
+	if _, err := p.Run("wrap", "-v", "--", "ls", "-l"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "trace: wrap: args before \"--\" [], passthrough args [\"ls\" \"-l\"]\n"; !strings.Contains(trace.String(), want) {
+		t.Errorf("The trace should contain %q but was %q.", want, trace.String())
+	}
+}
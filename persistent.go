@@ -0,0 +1,132 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Returns the persistent flags of p, which are accepted by p and by
+// every command and nested path below it, before or after the command
+// name. Flags are looked up from the innermost level outwards: a flag
+// defined by the command itself, or by the global flags of a path,
+// shadows a persistent flag of the same name, and the persistent flags
+// of the closest path shadow those of the paths enclosing it.
+func (p *Path) PersistentFlags() *flag.FlagSet {
+	if p.persistent == nil {
+		p.persistent = flag.NewFlagSet("", flag.ContinueOnError)
+	}
+	return p.persistent
+}
+
+// Sets the flags in args that aren't defined by own but by one of the
+// inherited flag sets, and returns the remaining arguments for own to
+// parse. Like flag.FlagSet.Parse it stops at the first non-flag
+// argument or at "--".
+func parseInherited(args []string, own *flag.FlagSet, inherited []*flag.FlagSet) ([]string, error) {
+	if len(inherited) == 0 {
+		return args, nil
+	}
+	// allocated when the first inherited flag is consumed; until then
+	// the remaining arguments are a prefix of args
+	var rest []string
+	keep := func(arg string) {
+		if rest != nil {
+			rest = append(rest, arg)
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			if rest == nil {
+				return args, nil
+			}
+			return append(rest, args[i:]...), nil
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, hasValue := "", false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		if f := own.Lookup(name); f != nil || name == "" {
+			keep(arg)
+			if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				keep(args[i])
+			}
+			continue
+		}
+		fs := lookupInherited(inherited, name)
+		if fs == nil {
+			// left for own to report as undefined
+			keep(arg)
+			continue
+		}
+		if rest == nil {
+			rest = append(make([]string, 0, len(args)), args[:i]...)
+		}
+		f := fs.Lookup(name)
+		if !hasValue {
+			if isBoolFlag(f) {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag needs an argument: -%s", name)
+			}
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
+		}
+	}
+	if rest == nil {
+		return args, nil
+	}
+	return rest, nil
+}
+
+// Records the global flags of p and of the paths enclosing it that
+// were set after their sources were resolved as given on the command
+// line, which are the flags given after the command name.
+func (p *Path) markForwardedFlags() {
+	for q := p; q != nil; q = q.parent {
+		if q.sources == nil {
+			continue
+		}
+		q.Flags.Visit(func(f *flag.Flag) {
+			if _, ok := q.sources[f.Name]; !ok {
+				markSource(q.Flags, q.sources, f.Name, SourceCLI)
+			}
+		})
+	}
+}
+
+// Returns the first of sets defining the named flag.
+func lookupInherited(sets []*flag.FlagSet, name string) *flag.FlagSet {
+	for _, fs := range sets {
+		if fs.Lookup(name) != nil {
+			return fs
+		}
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,209 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+type remoteOpts struct {
+	namespace string
+	debug     bool
+	name      string
+	args      []string
+}
+
+// Returns a path with a `remote add` command below it and a persistent
+// namespace flag on the root.
+func newRemotePath(o *remoteOpts) *Path {
+	p := NewPath()
+	p.PersistentFlags().StringVar(&o.namespace, "namespace", "default", "namespace")
+	p.PersistentFlags().BoolVar(&o.debug, "debug", false, "debug output")
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&o.name, "name", "", "remote name")
+		},
+		run: func(args ...string) error {
+			o.args = args
+			return nil
+		},
+	})
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func TestPersistentFlags(t *testing.T) {
+	tests := []struct {
+		args      []string
+		namespace string
+		debug     bool
+		rest      []string
+	}{
+		{[]string{"remote", "add", "x"}, "default", false, []string{"x"}},
+		{[]string{"--namespace", "prod", "remote", "add", "x"}, "prod", false, []string{"x"}},
+		{[]string{"remote", "--namespace=prod", "add"}, "prod", false, []string{}},
+		{[]string{"remote", "add", "--name", "origin", "--namespace", "prod", "-debug", "x"}, "prod", true, []string{"x"}},
+		{[]string{"remote", "add", "x", "--namespace", "prod"}, "default", false, []string{"x", "--namespace", "prod"}},
+		{[]string{"remote", "add", "--", "--namespace", "prod"}, "default", false, []string{"--namespace", "prod"}},
+	}
+	for _, test := range tests {
+		var o remoteOpts
+		cont, err := newRemotePath(&o).Run(test.args...)
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		if cont.Name != "add" {
+			t.Errorf("%q: Run should return the leaf %q but was %q.", test.args, "add", cont.Name)
+		}
+		if o.namespace != test.namespace {
+			t.Errorf("%q: namespace should be %q but was %q.", test.args, test.namespace, o.namespace)
+		}
+		if o.debug != test.debug {
+			t.Errorf("%q: debug should be %v but was %v.", test.args, test.debug, o.debug)
+		}
+		if !reflect.DeepEqual(o.args, test.rest) {
+			t.Errorf("%q: args should be %q but were %q.", test.args, test.rest, o.args)
+		}
+	}
+}
+
+func TestPersistentFlagsShadowed(t *testing.T) {
+	var o remoteOpts
+	var leaf string
+	p := newRemotePath(&o)
+	p.Add("get", "gets a resource", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&leaf, "namespace", "", "namespace of the resource")
+		},
+	})
+	if _, err := p.Run("--namespace", "a", "get", "--namespace", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if o.namespace != "a" {
+		t.Errorf("Persistent namespace should be %q but was %q.", "a", o.namespace)
+	}
+	if leaf != "b" {
+		t.Errorf("Command namespace should be %q but was %q.", "b", leaf)
+	}
+}
+
+func TestPersistentFlagsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"remote", "add", "--namespace"},
+		{"remote", "add", "--debug=maybe"},
+		{"remote", "add", "--unknown"},
+	} {
+		var o remoteOpts
+		if _, err := newRemotePath(&o).Run(args...); err == nil {
+			t.Errorf("%q should fail.", args)
+		}
+	}
+}
+
+func TestPersistentFlagsNotInherited(t *testing.T) {
+	var o remoteOpts
+	p := newRemotePath(&o)
+	remote := p.registry().entries["remote"].sub
+	remote.PersistentFlags().String("token", "", "access token")
+	if _, err := p.Run("--token", "x", "remote", "add"); err == nil {
+		t.Error("Persistent flags of a nested path should not be accepted above it.")
+	}
+	if _, err := p.Run("remote", "add", "--token", "x"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGlobalFlagsForwarded(t *testing.T) {
+	type result struct {
+		verbose, force bool
+		name           string
+		args           []string
+	}
+	newPath := func(r *result) *Path {
+		p := NewPath()
+		p.Flags.BoolVar(&r.verbose, "verbose", false, "verbose output")
+		remote := NewPath()
+		remote.Flags.BoolVar(&r.force, "force", false, "skip checks")
+		remote.Add("add", "adds a remote", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&r.name, "name", "", "remote name")
+			},
+			run: func(args ...string) error {
+				r.args = args
+				return nil
+			},
+		})
+		p.Mount("remote", "manages remotes", remote)
+		return p
+	}
+	for _, test := range []struct {
+		args []string
+		want result
+	}{
+		{[]string{"-verbose", "remote", "add", "x"}, result{verbose: true, args: []string{"x"}}},
+		{[]string{"remote", "add", "-verbose", "x"}, result{verbose: true, args: []string{"x"}}},
+		{[]string{"remote", "add", "-name", "o", "--verbose", "-force", "x"}, result{verbose: true, force: true, name: "o", args: []string{"x"}}},
+		{[]string{"remote", "add", "-verbose=false", "-name=o"}, result{name: "o", args: []string{}}},
+		{[]string{"-verbose=false", "remote", "add", "-verbose"}, result{verbose: true, args: []string{}}},
+		// flags after the first argument or "--" are arguments
+		{[]string{"remote", "add", "x", "-verbose"}, result{args: []string{"x", "-verbose"}}},
+		{[]string{"remote", "add", "--", "-verbose"}, result{args: []string{"-verbose"}}},
+		{[]string{"remote", "add", "-force", "--", "-verbose"}, result{force: true, args: []string{"-verbose"}}},
+	} {
+		var r result
+		if _, err := newPath(&r).Run(test.args...); err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, test.want) {
+			t.Errorf("%q: the result should be %+v but was %+v.", test.args, test.want, r)
+		}
+	}
+
+	var r result
+	p := newPath(&r)
+	if _, err := p.Run("remote", "add", "-verbose", "-colour"); err == nil {
+		t.Error("Flags unknown to all flag sets should fail.")
+	}
+	if _, err := p.Run("-force", "remote", "add"); err == nil {
+		t.Error("Global flags of a nested path should not be accepted above it.")
+	}
+	if _, err := p.Run("remote", "add", "-verbose"); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.sources["verbose"]; s != SourceCLI {
+		t.Errorf("The source of a forwarded flag should be %v but was %v.", SourceCLI, s)
+	}
+}
+
+func TestGlobalFlagsShadowed(t *testing.T) {
+	var global, leaf bool
+	p := NewPath()
+	p.Flags.BoolVar(&global, "verbose", false, "verbose output")
+	p.Add("status", "prints the status", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&leaf, "verbose", false, "list every file")
+		},
+	})
+	if _, err := p.Run("status", "-verbose"); err != nil {
+		t.Fatal(err)
+	}
+	if global || !leaf {
+		t.Errorf("The flag of the command should shadow the global flag but global was %v and the command's %v.", global, leaf)
+	}
+}
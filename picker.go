@@ -0,0 +1,137 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Lets Run ask which command to run if none is given and p has no
+// root command, instead of failing with ErrCmdUsage. It shows a
+// numbered menu of the commands that aren't hidden on the standard
+// output of the Env of the invocation, see WithEnv, reads the number
+// or name of a command from its standard input, then prompts for the
+// required flags of the chosen command that are not set by any other
+// source before running it. An empty choice cancels with ErrCmdUsage.
+// The picker is only shown if both streams are terminals, see
+// ForceInteractive. It applies to the paths mounted to p as well.
+func (p *Path) EnableInteractivePicker() {
+	p.picker = true
+}
+
+func (p *Path) pickerEnabled() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.picker {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the picker can interact with the user through the
+// streams of env.
+func (p *Path) canPick(env Env) bool {
+	if forced, ok := p.forcedInteractive(); ok {
+		return forced
+	}
+	in, ok := env.Stdin.(*os.File)
+	return ok && IsTerminal(in) && p.isTerminalWriter(env.Stdout)
+}
+
+// Shows the menu of the commands of p and returns the name of the one
+// chosen, or "" if the user cancelled.
+func (p *Path) pickCommand(env Env) (string, error) {
+	r := p.registry()
+	var names []string
+	tw := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range r.names {
+		if c := r.entries[name]; c.listed() {
+			names = append(names, name)
+			fmt.Fprintf(tw, "  %d) %s\t%s\n", len(names), name, c.Desc)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	fmt.Fprintln(env.Stdout, "Commands:")
+	tw.Flush()
+	for {
+		fmt.Fprint(env.Stdout, "Run which command? ")
+		choice, err := readLine(env.Stdin)
+		if err == io.EOF {
+			fmt.Fprintln(env.Stdout)
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Reading the command: %w", err)
+		}
+		choice = strings.TrimSpace(choice)
+		if choice == "" {
+			return "", nil
+		}
+		if i, err := strconv.Atoi(choice); err == nil && i >= 1 && i <= len(names) {
+			return names[i-1], nil
+		}
+		if containsString(names, choice) {
+			return choice, nil
+		}
+		fmt.Fprintf(env.Stdout, "No command %q, enter a number between 1 and %d.\n", choice, len(names))
+	}
+}
+
+// The key of the context of a command chosen with the picker, holding
+// its container.
+type pickedKey struct{}
+
+// Prompts through the streams of the Env of ctx for the required flags
+// of c not set by any source, if c was chosen with the picker.
+func (p *Path) promptPicked(ctx context.Context, c *CmdCont) error {
+	if picked, _ := ctx.Value(pickedKey{}).(*CmdCont); picked != c {
+		return nil
+	}
+	env := EnvFromContext(ctx)
+	for _, name := range c.missingFlags() {
+		f := c.FlagSet().Lookup(name)
+		if f == nil {
+			continue
+		}
+		_, usage := flag.UnquoteUsage(f)
+		if usage == "" {
+			usage = name
+		}
+		fmt.Fprintf(env.Stdout, "%s (%s): ", usage, flagName(name))
+		v, err := readLine(env.Stdin)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("Reading flag -%s: %w", name, err)
+		}
+		if err != nil || v == "" {
+			// left for the check of the required flags to report
+			continue
+		}
+		if err := c.FlagSet().Set(name, v); err != nil {
+			return fmt.Errorf("Invalid value for flag -%s: %w", name, err)
+		}
+		markSource(c.FlagSet(), c.sources, name, SourcePrompt)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newPickerPath(env *string) *Path {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(env, "env", "", "target environment")
+		},
+	}, "env")
+	p.Add("status", "prints the status", &testCmd{})
+	p.Add("debug", "dumps the state", &testCmd{}).Hide()
+	p.EnableInteractivePicker()
+	p.ForceInteractive(true)
+	return p
+}
+
+func TestInteractivePicker(t *testing.T) {
+	var env string
+	p := newPickerPath(&env)
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader("7\n1\nprod\n"), Stdout: &out})
+	c, err := p.RunContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil || c.Name != "deploy" {
+		t.Fatalf("The picker should run deploy but ran %v.", c)
+	}
+	if env != "prod" {
+		t.Errorf("-env should be %q but was %q.", "prod", env)
+	}
+	if c.ValueSource("env") != SourcePrompt {
+		t.Errorf("-env should come from %v but came from %v.", SourcePrompt, c.ValueSource("env"))
+	}
+	want := "Commands:\n" +
+		"  1) deploy  deploys the app\n" +
+		"  2) status  prints the status\n" +
+		"Run which command? No command \"7\", enter a number between 1 and 2.\n" +
+		"Run which command? target environment (--env): "
+	if out.String() != want {
+		t.Errorf("The picker should print %q but printed %q.", want, out.String())
+	}
+
+	out.Reset()
+	ctx = WithEnv(context.Background(), Env{Stdin: strings.NewReader("status\n"), Stdout: &out})
+	if c, err := p.RunContext(ctx); err != nil || c.Name != "status" {
+		t.Errorf("The picker should run status by name but ran %v: %v", c, err)
+	}
+}
+
+func TestInteractivePickerCancel(t *testing.T) {
+	var env string
+	p := newPickerPath(&env)
+	for _, input := range []string{"\n", ""} {
+		var out strings.Builder
+		ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader(input), Stdout: &out})
+		if _, err := p.RunContext(ctx); !errors.Is(err, ErrCmdUsage) {
+			t.Errorf("Cancelling the picker with %q should fail with ErrCmdUsage but returned %v.", input, err)
+		}
+	}
+}
+
+func TestInteractivePickerMissingFlag(t *testing.T) {
+	var env string
+	p := newPickerPath(&env)
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader("deploy\n\n"), Stdout: &out})
+	var me *MissingFlagsError
+	if _, err := p.RunContext(ctx); !errors.As(err, &me) {
+		t.Errorf("An empty value for a required flag should fail with a MissingFlagsError but returned %v.", err)
+	}
+}
+
+func TestInteractivePickerNotInteractive(t *testing.T) {
+	var env string
+	p := newPickerPath(&env)
+	p.ForceInteractive(false)
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader("1\nprod\n"), Stdout: &out})
+	if _, err := p.RunContext(ctx); !errors.Is(err, ErrCmdUsage) {
+		t.Errorf("Run should fail with ErrCmdUsage without a terminal but returned %v.", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("The picker should not be shown without a terminal but printed %q.", out.String())
+	}
+}
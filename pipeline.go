@@ -0,0 +1,283 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Splits the arguments of Run at sep into the stages of a pipeline,
+// which is run by RunPipeline: with "++" the arguments
+//
+//	export -table users ++ transform ++ upload -bucket backups
+//
+// pipe the output of export through transform into upload. The empty
+// separator, the default, disables pipelines.
+func (p *Path) SetPipeSeparator(sep string) {
+	p.pipeSep = sep
+}
+
+// Returns the stages of the pipeline in args, or nil if args don't
+// contain the pipe separator of p.
+func (p *Path) splitPipeline(args []string) [][]string {
+	if p.pipeSep == "" || !containsString(args, p.pipeSep) {
+		return nil
+	}
+	var stages [][]string
+	start := 0
+	for i, arg := range args {
+		if arg == p.pipeSep {
+			stages = append(stages, args[start:i])
+			start = i + 1
+		}
+	}
+	return append(stages, args[start:])
+}
+
+// Returned by RunPipeline for a failing stage.
+type PipelineError struct {
+	// the 1-based number of the stage
+	Stage int
+	// the command line of the stage
+	Args []string
+	Err  error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("stage %d %q failed: %v", e.Stage, strings.Join(e.Args, " "), e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// Runs the command lines of stages concurrently like a shell pipeline,
+// connecting the output of each stage to the input of the next one.
+// The streams are those of the Env of the context, see EnvFromContext,
+// so only commands implementing ContextCmd or ResultRenderer can be
+// piped. The first stage reads the Stdin of ctx and the last one
+// writes to its Stdout; all stages share Stderr.
+//
+// The pipes are unbuffered, a write of a stage blocks until the next
+// stage reads it. Once a stage returns, the next one reads EOF and
+// writes of the previous one fail with io.ErrClosedPipe. If a stage
+// fails, the context of the other stages is canceled and the error is
+// returned as a PipelineError; the errors of several failing stages
+// are joined. Errors caused by the failure, io.ErrClosedPipe of a
+// stage writing to a stage that returned and the cancellation, aren't
+// reported. As in a shell, a stage returning before it read all of its
+// input doesn't fail the pipeline.
+//
+// The global flags of p are given before the first stage and apply to
+// all stages; they aren't accepted after the command of a stage.
+// Parsing the arguments and checking the requirements of the stages
+// isn't concurrent, only their Run methods are. The commands of the
+// stages must not dispatch other commands, and a command can't appear
+// in more than one stage. The returned containers are those of the
+// stages, nil for a stage that didn't match a command.
+func (p *Path) RunPipeline(ctx context.Context, stages ...[]string) ([]*CmdCont, error) {
+	p.beginDispatch()
+	defer p.endDispatch()
+	if len(stages) > 0 {
+		// the commands of the stages may read the global flags while
+		// the next stages are parsed, so they are parsed only once
+		inherited, globals := p.globalSets(nil, nil)
+		if _, err := p.parseGlobals(ctx, stages[0], inherited, globals); err != nil {
+			return nil, err
+		}
+		stages = append([][]string{p.Flags.Args()}, stages[1:]...)
+		ctx = context.WithValue(ctx, pipeGlobalsKey{}, p)
+	}
+	if err := p.checkPipeline(stages); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	env := EnvFromContext(ctx)
+	mu := new(sync.Mutex)
+	ps := make([]*pipeStage, len(stages))
+	for i := range ps {
+		ps[i] = &pipeStage{mu: mu, ready: make(chan struct{})}
+		if i > 0 {
+			ps[i].stdin, ps[i-1].stdout = io.Pipe()
+		}
+	}
+
+	conts := make([]*CmdCont, len(stages))
+	errs := make([]error, len(stages))
+	var wg sync.WaitGroup
+	for i, args := range stages {
+		s := ps[i]
+		stageEnv := env
+		if s.stdin != nil {
+			stageEnv.Stdin = s.stdin
+		}
+		if s.stdout != nil {
+			stageEnv.Stdout = s.stdout
+		}
+		sctx := context.WithValue(WithEnv(ctx, stageEnv), pipeStageKey{}, s)
+		wg.Add(1)
+		go func(i int, args []string) {
+			defer wg.Done()
+			// start the stages in order, so a stage doesn't wait for the
+			// input of a stage that can't start
+			if i > 0 {
+				<-ps[i-1].ready
+			}
+			mu.Lock()
+			p.beginDispatch()
+			conts[i], errs[i] = p.run(sctx, args, nil, nil, true)
+			p.endDispatch()
+			mu.Unlock()
+			s.start()
+			s.close()
+			if errs[i] != nil && !pipeFailure(errs[i]) {
+				cancel()
+			}
+		}(i, args)
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, err := range errs {
+		if err != nil && !pipeFailure(err) {
+			failed = append(failed, &PipelineError{Stage: i + 1, Args: stages[i], Err: err})
+		}
+	}
+	if len(failed) == 0 && ctx.Err() != nil {
+		// the context of the caller is done
+		for i, err := range errs {
+			if err != nil {
+				failed = append(failed, &PipelineError{Stage: i + 1, Args: stages[i], Err: err})
+			}
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return conts, nil
+	case 1:
+		return conts, failed[0]
+	}
+	return conts, errors.Join(failed...)
+}
+
+// Reports if err of a stage was caused by another one returning: a
+// write to a stage that returned, or the cancellation of the context
+// after a stage failed.
+func pipeFailure(err error) bool {
+	return errors.Is(err, io.ErrClosedPipe) || errors.Is(err, context.Canceled)
+}
+
+// Checks that the stages can be run by RunPipeline.
+func (p *Path) checkPipeline(stages [][]string) error {
+	seen := make(map[*CmdCont]int, len(stages))
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return fmt.Errorf("Pipeline stage %d is empty", i+1)
+		}
+		c, _ := p.resolveStep(stage)
+		if c == nil || c.sub != nil {
+			// fails when the stage is run
+			continue
+		}
+		if j, ok := seen[c]; ok {
+			return fmt.Errorf("Pipeline stages %d and %d both run %q", j+1, i+1, c.CommandPath())
+		}
+		seen[c] = i
+		switch c.Cmd.(type) {
+		case *macroCmd:
+			return fmt.Errorf("Pipeline stage %d: macro %q can't be piped", i+1, c.CommandPath())
+		case ContextCmd, ResultRenderer:
+		default:
+			return fmt.Errorf("Pipeline stage %d: command %q doesn't implement ContextCmd or ResultRenderer, its streams can't be piped", i+1, c.CommandPath())
+		}
+	}
+	return nil
+}
+
+// The key of the context of the stages of a pipeline, holding the path
+// whose global flags were parsed for all stages.
+type pipeGlobalsKey struct{}
+
+// Reports if the global flags of p were parsed for all stages of the
+// pipeline ctx belongs to.
+func sharesGlobals(ctx context.Context, p *Path) bool {
+	q, _ := ctx.Value(pipeGlobalsKey{}).(*Path)
+	return q == p
+}
+
+type pipeStageKey struct{}
+
+// A stage of a pipeline run by RunPipeline. The dispatch of the stage
+// holds mu, except while the command runs.
+type pipeStage struct {
+	mu    *sync.Mutex
+	ready chan struct{}
+	once  sync.Once
+	// the pipe from the previous stage and to the next one, nil for
+	// the first and the last stage
+	stdin  *io.PipeReader
+	stdout *io.PipeWriter
+	// the command of the stage, once it runs
+	cont *CmdCont
+}
+
+// Returns the stage of the pipeline in ctx if cont is its command, nil
+// otherwise.
+func stageFromContext(ctx context.Context, cont *CmdCont) *pipeStage {
+	s, _ := ctx.Value(pipeStageKey{}).(*pipeStage)
+	if s == nil {
+		return nil
+	}
+	if s.cont == nil {
+		s.cont = cont
+	}
+	if s.cont != cont {
+		return nil
+	}
+	return s
+}
+
+// Called before the command of s runs, lets the other stages continue.
+func (s *pipeStage) running() {
+	s.mu.Unlock()
+	s.start()
+}
+
+// Called once the command of s returned. The streams are closed before
+// waiting for the other stages, which may wait for the input of s.
+func (s *pipeStage) returned() {
+	s.close()
+	s.mu.Lock()
+}
+
+// Lets the next stage start.
+func (s *pipeStage) start() {
+	s.once.Do(func() { close(s.ready) })
+}
+
+func (s *pipeStage) close() {
+	if s.stdout != nil {
+		s.stdout.Close()
+	}
+	if s.stdin != nil {
+		s.stdin.Close()
+	}
+}
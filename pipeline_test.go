@@ -0,0 +1,186 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newPipelinePath(transform func(line string) (string, error), upload func(ctx context.Context, r io.Reader, w io.Writer) error) *Path {
+	p := NewPath()
+	p.SetPipeSeparator("++")
+	var rows *int
+	p.Add("export", "exports the rows", &testContextCmd{
+		testCmd: testCmd{flags: func(fs *flag.FlagSet) {
+			rows = fs.Int("rows", 3, "number of rows")
+		}},
+		runContext: func(ctx context.Context, args ...string) error {
+			for i := 1; i <= *rows; i++ {
+				if _, err := fmt.Fprintf(EnvFromContext(ctx).Stdout, "row %d\n", i); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	p.Add("transform", "transforms the rows", &testContextCmd{
+		runContext: func(ctx context.Context, args ...string) error {
+			env := EnvFromContext(ctx)
+			s := bufio.NewScanner(env.Stdin)
+			for s.Scan() {
+				line, err := transform(s.Text())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(env.Stdout, line)
+			}
+			return s.Err()
+		},
+	})
+	p.Add("upload", "uploads the rows", &testContextCmd{
+		runContext: func(ctx context.Context, args ...string) error {
+			env := EnvFromContext(ctx)
+			return upload(ctx, env.Stdin, env.Stdout)
+		},
+	})
+	return p
+}
+
+func upper(line string) (string, error) {
+	return strings.ToUpper(line), nil
+}
+
+func TestPipeline(t *testing.T) {
+	p := newPipelinePath(upper, func(ctx context.Context, r io.Reader, w io.Writer) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "uploaded %q", b)
+		return nil
+	})
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader(""), Stdout: &out})
+	cont, err := p.RunContext(ctx, "export", "-rows", "2", "++", "transform", "++", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `uploaded "ROW 1\nROW 2\n"`; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+	if cont == nil || cont.Name != "upload" {
+		t.Errorf("Run should return the last stage but was %v.", cont)
+	}
+
+	// the flags of all stages are reset by the next dispatch
+	out.Reset()
+	if _, err := p.RunContext(ctx, "export", "++", "upload"); err != nil {
+		t.Fatal(err)
+	}
+	if want := `uploaded "row 1\nrow 2\nrow 3\n"`; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestPipelineFailure(t *testing.T) {
+	var read string
+	p := newPipelinePath(func(line string) (string, error) {
+		if line == "row 2" {
+			return "", errors.New("bad row")
+		}
+		return line, nil
+	}, func(ctx context.Context, r io.Reader, w io.Writer) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		read = string(b)
+		// the remaining stages are canceled
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader(""), Stdout: io.Discard})
+	_, err := p.RunContext(ctx, "export", "-rows", "1000", "++", "transform", "++", "upload")
+	var pe *PipelineError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Error should be a PipelineError but was %v.", err)
+	}
+	if want := `stage 2 "transform" failed: bad row`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if pe.Stage != 2 {
+		t.Errorf("Stage should be 2 but was %d.", pe.Stage)
+	}
+	if want := "row 1\n"; read != want {
+		t.Errorf("The last stage should read %q up to EOF but read %q.", want, read)
+	}
+}
+
+func TestPipelineCheck(t *testing.T) {
+	p := newPipelinePath(upper, func(ctx context.Context, r io.Reader, w io.Writer) error {
+		return nil
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	for _, tt := range []struct {
+		stages [][]string
+		want   string
+	}{
+		{[][]string{{"export"}, {}}, "Pipeline stage 2 is empty"},
+		{[][]string{{"export"}, {"status"}}, `Pipeline stage 2: command "status" doesn't implement ContextCmd or ResultRenderer, its streams can't be piped`},
+		{[][]string{{"transform"}, {"transform"}}, `Pipeline stages 1 and 2 both run "transform"`},
+	} {
+		_, err := p.RunPipeline(context.Background(), tt.stages...)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Error of %q should be %q but was %v.", tt.stages, tt.want, err)
+		}
+	}
+}
+
+func TestPipelineGlobalFlags(t *testing.T) {
+	var p *Path
+	var verbose *bool
+	p = newPipelinePath(upper, func(ctx context.Context, r io.Reader, w io.Writer) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "uploaded %d bytes, verbose %v", len(b), *verbose)
+		return nil
+	})
+	verbose = p.Flags.Bool("verbose", false, "verbose output")
+	var out strings.Builder
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader(""), Stdout: &out})
+	if _, err := p.RunContext(ctx, "-verbose", "export", "++", "transform", "++", "upload"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "uploaded 18 bytes, verbose true"; out.String() != want {
+		t.Errorf("Output should be %q but was %q.", want, out.String())
+	}
+
+	// the global flags are parsed once, before the stages run
+	p.SetOutput(io.Discard)
+	_, err := p.RunContext(ctx, "export", "++", "transform", "-verbose", "++", "upload")
+	var pe *PipelineError
+	if !errors.As(err, &pe) || pe.Stage != 2 {
+		t.Errorf("Global flags of a later stage should fail the stage but the error was %v.", err)
+	}
+}
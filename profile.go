@@ -0,0 +1,91 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Defines the hidden global flags --cpuprofile and --memprofile, so
+// that users can profile a slow command without a special build:
+//
+//	app --cpuprofile cpu.out --memprofile mem.out export
+//
+// Run creates the files before running the command, profiles the CPU
+// while the command runs and writes a heap profile after it returned,
+// even if it failed. The profiles are read with go tool pprof.
+func (p *Path) EnableProfiling() {
+	p.Flags.String("cpuprofile", "", "write a CPU profile to `file`")
+	p.Flags.String("memprofile", "", "write a heap profile to `file`")
+	p.HideFlag("cpuprofile")
+	p.HideFlag("memprofile")
+	p.profiling = true
+}
+
+// Creates the profiles selected by the profiling flags and starts
+// CPU profiling. Returns a function stopping it and writing the heap
+// profile.
+func (p *Path) startProfiling() (func() error, error) {
+	cpuName := p.Flags.Lookup("cpuprofile").Value.String()
+	memName := p.Flags.Lookup("memprofile").Value.String()
+	var cpu, mem *os.File
+	var err error
+	if cpuName != "" {
+		if cpu, err = os.Create(cpuName); err != nil {
+			return nil, fmt.Errorf("CPU profile: %w", err)
+		}
+	}
+	if memName != "" {
+		if mem, err = os.Create(memName); err != nil {
+			if cpu != nil {
+				cpu.Close()
+			}
+			return nil, fmt.Errorf("Heap profile: %w", err)
+		}
+	}
+	if cpu != nil {
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			cpu.Close()
+			if mem != nil {
+				mem.Close()
+			}
+			return nil, fmt.Errorf("CPU profile: %w", err)
+		}
+	}
+	return func() error {
+		var err error
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if cerr := cpu.Close(); cerr != nil {
+				err = fmt.Errorf("CPU profile: %w", cerr)
+			}
+		}
+		if mem != nil {
+			// record the allocations up to now
+			runtime.GC()
+			werr := pprof.WriteHeapProfile(mem)
+			if cerr := mem.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil && err == nil {
+				err = fmt.Errorf("Heap profile: %w", werr)
+			}
+		}
+		return err
+	}, nil
+}
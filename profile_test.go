@@ -0,0 +1,82 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out")
+	p := NewPath()
+	p.EnableProfiling()
+	errExport := errors.New("export failed")
+	p.Add("export", "exports the data", CmdFunc(func(args []string) error {
+		if _, err := os.Stat(cpu); err != nil {
+			t.Errorf("The CPU profile should be created before the command runs: %v", err)
+		}
+		return errExport
+	}))
+	if _, err := p.Run("--cpuprofile", cpu, "--memprofile", mem, "export"); err != errExport {
+		t.Fatalf("Run should fail with %v but was %v.", errExport, err)
+	}
+	for _, name := range []string{cpu, mem} {
+		if fi, err := os.Stat(name); err != nil || fi.Size() == 0 {
+			t.Errorf("Profile %s should not be empty but was %v.", filepath.Base(name), err)
+		}
+	}
+}
+
+func TestProfilingCreateError(t *testing.T) {
+	p := NewPath()
+	p.EnableProfiling()
+	ran := false
+	p.Add("export", "exports the data", CmdFunc(func(args []string) error {
+		ran = true
+		return nil
+	}))
+	missing := filepath.Join(t.TempDir(), "missing", "mem.out")
+	_, err := p.Run("--memprofile", missing, "export")
+	if err == nil || !strings.HasPrefix(err.Error(), "Heap profile: ") || ran {
+		t.Errorf("Run should fail before the command runs but was %v (ran: %v).", err, ran)
+	}
+}
+
+func TestProfilingHidden(t *testing.T) {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	p.EnableProfiling()
+	p.Add("export", "exports the data", CmdFunc(func(args []string) error { return nil }))
+	if got, want := p.Complete("-"), []string{"--verbose"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Completion should be %q but was %q.", want, got)
+	}
+	var out strings.Builder
+	p.Flags.SetOutput(&out)
+	if _, err := p.Run("-h"); err == nil {
+		t.Error("Run should fail for -h.")
+	}
+	if strings.Contains(out.String(), "profile") || !strings.Contains(out.String(), "-verbose") {
+		t.Errorf("Usage should only list -verbose but was %q.", out.String())
+	}
+	if err := p.HideFlag("debug"); err == nil {
+		t.Error("HideFlag should fail for an unknown flag.")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Terminal reads the values of missing flags interactively.
+type Terminal interface {
+	// Reports whether the input is an interactive terminal.
+	IsTerminal() bool
+	// Writes prompt and reads a line of input. The input is not echoed
+	// unless echo is set.
+	ReadLine(prompt string, echo bool) (string, error)
+}
+
+// Sets the terminal used to prompt for missing flags. If t is nil,
+// prompts are written to the Path's output and read from os.Stdin.
+func (p *Path) SetTerminal(t Terminal) {
+	p.term = t
+}
+
+func (p *Path) terminal() Terminal {
+	if p.term == nil {
+		return &stdTerminal{in: os.Stdin, out: p.Output()}
+	}
+	return p.term
+}
+
+// Prompts for the value of the named flag if it is required but
+// wasn't given by any source and standard input is a terminal. The
+// prompt is the usage string of the flag. The input of secret flags,
+// see MarkSecret, is not echoed. Without a terminal the missing flag
+// is reported as usual. ForceInteractive overrides the detection of
+// the terminal.
+func (c *CmdCont) PromptMissing(name string) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.prompt == nil {
+		c.prompt = make(map[string]bool)
+	}
+	c.prompt[name] = true
+	return nil
+}
+
+// Prompts for the missing required flags of c that are set up for it.
+func (p *Path) promptMissing(c *CmdCont) error {
+	if len(c.prompt) == 0 {
+		return nil
+	}
+	var t Terminal
+	for _, name := range c.RequiredFlags {
+		if _, set := c.sources[name]; set || !c.prompt[name] {
+			continue
+		}
+		if t == nil {
+			t = p.terminal()
+			if forced, ok := p.forcedInteractive(); ok && !forced || !ok && !t.IsTerminal() {
+				return nil
+			}
+		}
+		f := c.FlagSet().Lookup(name)
+		_, usage := flag.UnquoteUsage(f)
+		if usage == "" {
+			usage = name
+		}
+		v, err := t.ReadLine(usage+": ", !c.isSecret(f))
+		if err != nil {
+			return fmt.Errorf("Reading flag -%s: %w", name, err)
+		}
+		if err := c.FlagSet().Set(name, v); err != nil {
+			return fmt.Errorf("Invalid value for flag -%s: %w", name, err)
+		}
+		markSource(c.FlagSet(), c.sources, name, SourcePrompt)
+	}
+	return nil
+}
+
+// Prompts on out and reads from in.
+type stdTerminal struct {
+	in  *os.File
+	out io.Writer
+}
+
+func (t *stdTerminal) IsTerminal() bool {
+	return isTerminal(t.in.Fd())
+}
+
+func (t *stdTerminal) ReadLine(prompt string, echo bool) (string, error) {
+	fmt.Fprint(t.out, prompt)
+	if !echo {
+		// input that isn't read from a terminal, see ForceInteractive,
+		// isn't echoed anyway
+		restore, err := disableEcho(t.in.Fd())
+		if err != nil && err != errNoTerminal {
+			return "", err
+		}
+		if err == nil {
+			defer fmt.Fprintln(t.out)
+			defer restore()
+		}
+	}
+	return readLine(t.in)
+}
+
+// Reads a line from r one byte at a time, so that no input beyond the
+// line is consumed.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return "", io.ErrNoProgress
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+var errNoTerminal = errors.New("not a terminal")
@@ -0,0 +1,120 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// A Terminal answering prompts from a list of lines.
+type fakeTerminal struct {
+	tty     bool
+	lines   []string
+	prompts []string
+	echoes  []bool
+}
+
+func (t *fakeTerminal) IsTerminal() bool {
+	return t.tty
+}
+
+func (t *fakeTerminal) ReadLine(prompt string, echo bool) (string, error) {
+	t.prompts = append(t.prompts, prompt)
+	t.echoes = append(t.echoes, echo)
+	line := t.lines[0]
+	t.lines = t.lines[1:]
+	return line, nil
+}
+
+func newPromptPath(user, password *string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("login", "logs in", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(user, "user", "", "account name")
+			fs.StringVar(password, "password", "", "account `password`")
+		},
+	}, "user", "password")
+	c.MarkSecret("password")
+	c.PromptMissing("user")
+	c.PromptMissing("password")
+	return p, c
+}
+
+func TestPromptMissing(t *testing.T) {
+	var user, password string
+	p, c := newPromptPath(&user, &password)
+	term := &fakeTerminal{tty: true, lines: []string{"hunter2"}}
+	p.SetTerminal(term)
+	if _, err := p.Run("login", "--user", "gopher"); err != nil {
+		t.Fatal(err)
+	}
+	if user != "gopher" || password != "hunter2" {
+		t.Errorf("user and password should be %q and %q but were %q and %q.", "gopher", "hunter2", user, password)
+	}
+	if want := []string{"account password: "}; strings.Join(term.prompts, "|") != strings.Join(want, "|") {
+		t.Errorf("Prompts should be %q but were %q.", want, term.prompts)
+	}
+	if term.echoes[0] {
+		t.Error("Secret input should not be echoed.")
+	}
+	if s := c.ValueSource("password"); s != SourcePrompt {
+		t.Errorf("Source of password should be %v but was %v.", SourcePrompt, s)
+	}
+}
+
+func TestPromptMissingEcho(t *testing.T) {
+	var user, password string
+	p, _ := newPromptPath(&user, &password)
+	term := &fakeTerminal{tty: true, lines: []string{"gopher", "hunter2"}}
+	p.SetTerminal(term)
+	if _, err := p.Run("login"); err != nil {
+		t.Fatal(err)
+	}
+	if len(term.echoes) != 2 || !term.echoes[0] || term.echoes[1] {
+		t.Errorf("Only the user should be echoed but echoes were %v.", term.echoes)
+	}
+}
+
+func TestPromptMissingNoTerminal(t *testing.T) {
+	var user, password string
+	p, _ := newPromptPath(&user, &password)
+	term := &fakeTerminal{}
+	p.SetTerminal(term)
+	_, err := p.Run("login", "--user", "gopher")
+	if err == nil || !strings.Contains(err.Error(), "required flags not set") {
+		t.Errorf("Run should report the missing flag but was %v.", err)
+	}
+	if len(term.prompts) > 0 {
+		t.Errorf("There should be no prompts without a terminal but were %q.", term.prompts)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	for in, want := range map[string]string{
+		"hunter2\nrest": "hunter2",
+		"hunter2\r\n":   "hunter2",
+		"hunter2":       "hunter2",
+	} {
+		got, err := readLine(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%q should read %q but was %q.", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// Suppresses the informational output of the package, for command
+// lines used in scripts: the usage printed for invalid flags, the
+// warnings, even if SetPrintWarnings is enabled or a Logger is set, the
+// suggestions of the errors printed by Execute and the flags listed
+// along with a MissingFlagsError. The errors returned by Run are
+// unchanged, so their exit codes are too. The help requested with -h
+// and the output of the commands are still written. Mounted paths are
+// quiet if the enclosing path is.
+func (p *Path) SetQuiet(quiet bool) {
+	p.quiet = quiet
+}
+
+// Defines the global flag --quiet enabling SetQuiet while Run is
+// running the command line setting it.
+func (p *Path) QuietFlag() {
+	p.quietFlag = p.Flags.Bool("quiet", false, "only print the output of the command and terse errors")
+}
+
+// Reports whether p or an enclosing path is quiet, see SetQuiet and
+// QuietFlag.
+func (p *Path) Quiet() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.quiet || q.quietFlag != nil && *q.quietFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether p or an enclosing path may be quiet when parsing
+// flags, which they can set themselves with QuietFlag.
+func (p *Path) mayBeQuiet() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.quiet || q.quietFlag != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses args with fs like its Parse method, but leaves out the message
+// and usage the flag package prints for invalid flags if p is quiet,
+// which may be decided by the flag --quiet among args. fs must continue
+// on errors.
+func (p *Path) parseQuietly(fs *flag.FlagSet, args []string) error {
+	usage := fs.Usage
+	restore := discardOutput(fs)
+	fs.Usage = func() {}
+	err := fs.Parse(args)
+	restore()
+	fs.Usage = usage
+	switch {
+	case err == nil:
+	case err == flag.ErrHelp:
+		printUsage(fs)
+	case !p.Quiet():
+		fmt.Fprintln(fs.Output(), err)
+		printUsage(fs)
+	}
+	return err
+}
+
+// Prints the usage of fs like the flag package does for -h.
+func printUsage(fs *flag.FlagSet) {
+	if fs.Usage != nil {
+		fs.Usage()
+		return
+	}
+	if fs.Name() == "" {
+		fmt.Fprintf(fs.Output(), "Usage:\n")
+	} else {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+	}
+	fs.PrintDefaults()
+}
+
+// Writes the error err returned by Run for the command c to w like
+// writeError, but without suggestions and usage if p is quiet.
+func (p *Path) writeError(w io.Writer, c *CmdCont, err error) {
+	if !p.Quiet() {
+		writeError(w, c, err)
+		return
+	}
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	fmt.Fprintln(w, terseError(err))
+}
+
+// Returns the message of err without the suggestions of unknown
+// commands and flags.
+func terseError(err error) string {
+	switch e := err.(type) {
+	case *UnknownCommandError:
+		terse := *e
+		terse.Suggestions = nil
+		return terse.Error()
+	case *FlagParseError:
+		var s *suggestionError
+		if errors.As(e.Err, &s) {
+			return s.err.Error()
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newQuietPath(out *strings.Builder) *Path {
+	p := NewPath()
+	p.SetOutput(out)
+	p.QuietFlag()
+	deploy := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(out)
+			fs.String("env", "", "target environment")
+			fs.Bool("force", false, "deploy even if checks fail")
+		},
+	}, "env")
+	deploy.DeprecateFlag("force", "checks can't be skipped anymore")
+	p.Add("status", "prints the status", &testCmd{})
+	return p
+}
+
+func TestQuiet(t *testing.T) {
+	tests := []struct {
+		args    []string
+		code    int
+		verbose string
+		quiet   string
+	}{
+		{[]string{"stauts"}, ExitUsage,
+			"No such command \"stauts\", did you mean \"status\"?\n",
+			"No such command \"stauts\".\n"},
+		{[]string{"deploy", "-env", "prod", "-force"}, 0,
+			"Flag --force is deprecated, checks can't be skipped anymore\n",
+			""},
+		{[]string{"deploy"}, ExitUsage,
+			"deploy: required flags not set: --env\n  --env string  target environment (required)\n",
+			"deploy: required flags not set: --env\n"},
+		{[]string{"deploy", "-evn", "prod"}, ExitUsage,
+			"flag provided but not defined: -evn\nUsage: deploy [flags]\n",
+			"flag provided but not defined: -evn\n"},
+	}
+	for _, test := range tests {
+		var verbose, quiet strings.Builder
+		if code := newQuietPath(&verbose).Execute(test.args...); code != test.code {
+			t.Errorf("%q should exit with %d but was %d.", test.args, test.code, code)
+		}
+		if !strings.HasPrefix(verbose.String(), test.verbose) {
+			t.Errorf("The output of %q should start with %q but was %q.", test.args, test.verbose, verbose.String())
+		}
+		args := append([]string{"-quiet"}, test.args...)
+		if code := newQuietPath(&quiet).Execute(args...); code != test.code {
+			t.Errorf("%q should exit with %d but was %d.", args, test.code, code)
+		}
+		if quiet.String() != test.quiet {
+			t.Errorf("The output of %q should be %q but was %q.", args, test.quiet, quiet.String())
+		}
+	}
+}
+
+func TestQuietWarnings(t *testing.T) {
+	var out strings.Builder
+	p := newQuietPath(&out)
+	p.SetQuiet(true)
+	p.SetPrintWarnings(true)
+	if code := p.Execute("deploy", "-env", "prod", "-force"); code != 0 || out.Len() > 0 {
+		t.Errorf("Quiet mode should win over the warnings, but exited with %d and printed %q.", code, out.String())
+	}
+	if w := p.Warnings(); len(w) != 1 || w[0].Code != WarnDeprecatedFlag {
+		t.Errorf("The warnings should still be recorded but were %v.", w)
+	}
+}
+
+func TestQuietHelp(t *testing.T) {
+	var out strings.Builder
+	p := newQuietPath(&out)
+	if code := p.Execute("-quiet", "deploy", "-h"); code != 0 || !strings.Contains(out.String(), "target environment") {
+		t.Errorf("The requested help should be printed in quiet mode, but exited with %d and printed %q.", code, out.String())
+	}
+	out.Reset()
+	p.ResetFlags()
+	if p.Quiet() {
+		t.Error("Resetting the flags should reset --quiet.")
+	}
+	p.SetQuiet(true)
+	sub := NewPath()
+	sub.Add("list", "lists the remotes", &testCmd{})
+	p.Mount("remote", "manages remotes", sub)
+	if !sub.Quiet() {
+		t.Error("Mounted paths should be quiet if the enclosing path is.")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// Lets a command be dispatched again while a dispatch of it is in
+// progress, such as by a command running itself through Run. Both
+// dispatches share the flags of the command, so the values of the
+// outer one are restored when the inner one returns, see
+// SnapshotFlags. By default Run fails with a ConcurrentInvocationError
+// instead. Commands dispatching other
+// commands of the path are always supported.
+func (p *Path) SetAllowReentry(allow bool) {
+	p.allowReentry = allow
+}
+
+// Reports whether p or an enclosing path allows reentry, see
+// SetAllowReentry.
+func (p *Path) allowsReentry() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.allowReentry {
+			return true
+		}
+	}
+	return false
+}
+
+// The flags of a dispatch in progress when its command is dispatched
+// again, restored when the inner dispatch ends.
+type outerDispatch struct {
+	p       *Path
+	flags   FlagSnapshot
+	sources map[string]Source
+}
+
+// Marks cont as dispatched by p until leave, or reports false if a
+// dispatch of cont is in progress, in this or another goroutine, and p
+// doesn't allow reentry. The outer dispatch is returned if reentered.
+func (p *Path) enter(cont *CmdCont) (*outerDispatch, bool) {
+	n := cont.dispatching.Add(1)
+	if n == 1 {
+		return nil, true
+	}
+	if !p.allowsReentry() {
+		cont.dispatching.Add(-1)
+		return nil, false
+	}
+	return &outerDispatch{p: p, flags: cont.SnapshotFlags(), sources: cont.sources}, true
+}
+
+// Ends the dispatch of cont and restores the flags of outer, if any,
+// see enter.
+func (cont *CmdCont) leave(outer *outerDispatch) {
+	if outer != nil {
+		if err := outer.flags.Restore(); err != nil {
+			outer.p.logError("%v", err)
+		}
+		cont.sources = outer.sources
+	}
+	cont.dispatching.Add(-1)
+}
+
+// Returned by Run for a command that is dispatched again while a
+// dispatch of it is in progress, such as by a command running itself,
+// see SetAllowReentry.
+type ConcurrentInvocationError struct {
+	Command string
+}
+
+func (e *ConcurrentInvocationError) Error() string {
+	return fmt.Sprintf("%salready running, it can't be run again before it returns", commandPrefix(e.Command))
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+// Returns a path whose command deploy runs the command lines of steps
+// through the path and records the value of -env before and after.
+func newReentryPath(steps [][]string, envs *[]string, target *string) *Path {
+	p := NewPath()
+	var env *string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			env = fs.String("env", "dev", "target environment")
+		},
+		run: func(args ...string) error {
+			*envs = append(*envs, *env)
+			for _, step := range steps {
+				if _, err := p.Run(step...); err != nil {
+					return err
+				}
+			}
+			*envs = append(*envs, *env)
+			return nil
+		},
+	})
+	p.Add("migrate", "migrates the database", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(target, "target", "latest", "target version")
+		},
+	})
+	return p
+}
+
+func TestNestedDispatch(t *testing.T) {
+	var envs []string
+	var target string
+	p := newReentryPath([][]string{{"migrate", "-target", "42"}}, &envs, &target)
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 2 || envs[0] != "prod" || envs[1] != "prod" {
+		t.Errorf("-env should stay %q during the nested dispatch but was %q.", "prod", envs)
+	}
+	if target != "42" {
+		t.Errorf("-target should be %q but was %q.", "42", target)
+	}
+	if _, err := p.Run("migrate"); err != nil {
+		t.Fatal(err)
+	}
+	if target != "latest" {
+		t.Errorf("-target should be reset to %q but was %q.", "latest", target)
+	}
+	envs = nil
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) == 0 || envs[0] != "dev" {
+		t.Errorf("-env should be reset to %q but was %q.", "dev", envs)
+	}
+}
+
+func TestReentrantDispatch(t *testing.T) {
+	var envs []string
+	p := newReentryPath([][]string{{"deploy", "-env", "qa"}}, &envs, new(string))
+	_, err := p.Run("deploy", "-env", "prod")
+	var concurrent *ConcurrentInvocationError
+	if !errors.As(err, &concurrent) || concurrent.Command != "deploy" {
+		t.Fatalf("Run should fail with a ConcurrentInvocationError but was %v.", err)
+	}
+	if want := "deploy: already running, it can't be run again before it returns"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if len(envs) != 1 || envs[0] != "prod" {
+		t.Errorf("The inner dispatch should not run but -env was %q.", envs)
+	}
+	if _, err := p.Run("migrate"); err != nil {
+		t.Errorf("The command should be released after the failed run but failed with %v.", err)
+	}
+	envs = nil
+	p.SetAllowReentry(true)
+	steps := 0
+	p.Lookup("migrate").Cmd = &testCmd{run: func(args ...string) error {
+		if steps++; steps < 3 {
+			_, err := p.Run("migrate")
+			return err
+		}
+		return nil
+	}}
+	if _, err := p.Run("migrate"); err != nil || steps != 3 {
+		t.Errorf("Reentry should be allowed but ran %d times with error %v.", steps, err)
+	}
+}
+
+func TestReentrantMountedDispatch(t *testing.T) {
+	remote := NewPath()
+	p := NewPath()
+	p.Mount("remote", "manages remotes", remote)
+	remote.Add("list", "lists the remotes", &testCmd{})
+	remote.Add("sync", "syncs the remotes", &testCmd{run: func(args ...string) error {
+		if _, err := p.Run("remote", "list"); err != nil {
+			return err
+		}
+		_, err := p.Run("remote", "sync")
+		return err
+	}})
+	var concurrent *ConcurrentInvocationError
+	if _, err := p.Run("remote", "sync"); !errors.As(err, &concurrent) || concurrent.Command != "remote sync" {
+		t.Errorf("Run should fail with a ConcurrentInvocationError but was %v.", err)
+	}
+}
+
+func TestReentrantDispatchRestoresFlags(t *testing.T) {
+	p := NewPath()
+	p.SetAllowReentry(true)
+	var env *string
+	var envs []string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			env = fs.String("env", "dev", "target environment")
+		},
+		run: func(args ...string) error {
+			if *env == "prod" {
+				if _, err := p.Run("deploy", "-env", "qa"); err != nil {
+					return err
+				}
+			}
+			envs = append(envs, *env)
+			return nil
+		},
+	})
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"qa", "prod"}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("-env should be %q after the inner dispatch but was %q.", want, envs)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"regexp"
+)
+
+// Defines a regular expression flag with specified name, default
+// pattern, and usage string. The argument p points to a
+// *regexp.Regexp variable in which to store the compiled value of the
+// flag. An empty pattern stores nil. Invalid patterns are rejected at
+// parse time; an invalid default panics like regexp.MustCompile.
+func RegexpVar(fs *flag.FlagSet, p **regexp.Regexp, name, value, usage string) {
+	regexpVar(fs, p, name, value, usage, regexp.Compile)
+}
+
+// Same as RegexpVar but compiles the patterns with
+// regexp.CompilePOSIX.
+func RegexpVarPOSIX(fs *flag.FlagSet, p **regexp.Regexp, name, value, usage string) {
+	regexpVar(fs, p, name, value, usage, regexp.CompilePOSIX)
+}
+
+func regexpVar(fs *flag.FlagSet, p **regexp.Regexp, name, value, usage string, compile func(string) (*regexp.Regexp, error)) {
+	v := &regexpValue{p: p, compile: compile}
+	if err := v.Set(value); err != nil {
+		panic("command: default of flag -" + name + ": " + err.Error())
+	}
+	fs.Var(v, name, usage)
+}
+
+type regexpValue struct {
+	p       **regexp.Regexp
+	compile func(string) (*regexp.Regexp, error)
+}
+
+func (v *regexpValue) Set(s string) error {
+	if s == "" {
+		*v.p = nil
+		return nil
+	}
+	re, err := v.compile(s)
+	if err != nil {
+		return err
+	}
+	*v.p = re
+	return nil
+}
+
+func (v *regexpValue) String() string {
+	if v.p == nil || *v.p == nil {
+		return ""
+	}
+	return (*v.p).String()
+}
+
+func (v *regexpValue) Get() interface{} {
+	return *v.p
+}
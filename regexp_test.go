@@ -0,0 +1,77 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRegexpVar(t *testing.T) {
+	var re *regexp.Regexp
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegexpVar(fs, &re, "match", "", "filter pattern")
+	if re != nil {
+		t.Errorf("An empty default should be nil but was %v.", re)
+	}
+	if err := fs.Parse([]string{"-match", "^web-[0-9]+$"}); err != nil {
+		t.Fatal(err)
+	}
+	if re == nil || !re.MatchString("web-12") || re.MatchString("db-1") {
+		t.Errorf("match should be compiled from the pattern but was %v.", re)
+	}
+}
+
+func TestRegexpVarInvalid(t *testing.T) {
+	var re *regexp.Regexp
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegexpVar(fs, &re, "match", "", "filter pattern")
+	err := fs.Parse([]string{"-match", "web-(\\d+"})
+	if err == nil || !strings.Contains(err.Error(), "-match") || !strings.Contains(err.Error(), "web-(") {
+		t.Errorf("The error should name the flag and pattern but was %v.", err)
+	}
+	if re != nil {
+		t.Errorf("match should stay nil but was %v.", re)
+	}
+}
+
+func TestRegexpVarPOSIX(t *testing.T) {
+	var re *regexp.Regexp
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegexpVarPOSIX(fs, &re, "match", "a+", "filter pattern")
+	if got := re.FindString("baaa"); got != "aaa" {
+		t.Errorf("The default should be compiled, finding %q but found %q.", "aaa", got)
+	}
+	if err := fs.Parse([]string{"-match", "\\d"}); err == nil {
+		t.Error("Perl classes are not supported by POSIX patterns.")
+	}
+}
+
+func TestRegexpVarHelp(t *testing.T) {
+	var re *regexp.Regexp
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	RegexpVar(fs, &re, "match", "^web-", "filter pattern")
+	fs.PrintDefaults()
+	if want := "(default ^web-)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+}
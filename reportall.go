@@ -0,0 +1,109 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Makes Run check all flags, requirements and positional arguments of
+// a command before failing, instead of failing with the first error,
+// so that the user fixes an invocation in one go. More than one failure
+// is returned in a ValidationError. Applies to the paths mounted to p.
+func (p *Path) SetReportAllErrors(all bool) {
+	p.reportAllErrors = all
+}
+
+// Reports whether p or an enclosing path reports all errors, see
+// SetReportAllErrors.
+func (p *Path) ReportsAllErrors() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.reportAllErrors {
+			return true
+		}
+	}
+	return false
+}
+
+// Returned by Run for invocations failing more than one check if
+// enabled by SetReportAllErrors. errors.Is and errors.As match each of
+// the errors. The message lists them:
+//
+//	deploy: 2 problems:
+//	  - required flags not set: --env
+//	  - expected arguments <target>, got 0
+type ValidationError struct {
+	Command string
+	Errs    []error
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%d problems:", commandPrefix(e.Command), len(e.Errs))
+	for _, err := range e.Errs {
+		msg := strings.TrimPrefix(err.Error(), commandPrefix(e.Command))
+		b.WriteString("\n  - ")
+		b.WriteString(strings.Replace(msg, "\n", "\n    ", -1))
+	}
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Errs
+}
+
+// Returns nil for no errors, the error itself for one, or else a
+// ValidationError of c.
+func validationError(c *CmdCont, errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return &ValidationError{Command: c.CommandPath(), Errs: errs}
+}
+
+// Returns the errors of the required flags and the validators of c.
+func (c *CmdCont) flagErrors() []error {
+	var errs []error
+	if missing := c.missingFlags(); len(missing) > 0 {
+		errs = append(errs, &MissingFlagsError{Command: c.CommandPath(), Flags: missing})
+	}
+	if empty := c.emptyRequiredFlags(); len(empty) > 0 {
+		sort.Strings(empty)
+		errs = append(errs, &MissingFlagsError{Command: c.CommandPath(), Flags: empty, Empty: true})
+	}
+	return append(errs, c.validatorErrors()...)
+}
+
+// Checks the flags, the requirements and the number of positional
+// arguments of c and returns all failures, see SetReportAllErrors.
+// Arguments read from standard input by StdinArgs are only counted
+// once the other checks passed.
+func (c *CmdCont) validate() error {
+	errs := c.flagErrors()
+	if err := c.checkRequirements(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.stdinArgs == 0 {
+		if err := c.setArgValues(c.FlagSet().Args()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return validationError(c, errs)
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Returns a path whose command deploy takes one argument and requires
+// the flag -env, a valid -port and the variable DEPLOY_TOKEN.
+func newReportAllPath(opts *deployOpts, ran *bool) *Path {
+	p := newDeployPath(opts, "env")
+	c := p.Lookup("deploy")
+	c.Cmd = &testCmd{flags: c.Cmd.(*testCmd).flags, run: func(args ...string) error {
+		*ran = true
+		return nil
+	}}
+	c.RequiredArg("target")
+	c.ValidateFlag("port", func(value string) error {
+		if value == "0" {
+			return errors.New("must not be 0")
+		}
+		return nil
+	})
+	c.Requires(EnvSet("DEPLOY_TOKEN"))
+	return p
+}
+
+func TestReportAllErrors(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "")
+	var opts deployOpts
+	var ran bool
+	p := newReportAllPath(&opts, &ran)
+	p.SetReportAllErrors(true)
+	_, err := p.Run("deploy", "-env", "", "-port", "0")
+	var e *ValidationError
+	if !errors.As(err, &e) || len(e.Errs) != 3 {
+		t.Fatalf("Run should fail with 3 errors but was %v.", err)
+	}
+	var arg *ArgError
+	var positional *PositionalArgError
+	var requirements *RequirementsError
+	if !errors.As(err, &arg) || !errors.As(err, &positional) || !errors.As(err, &requirements) {
+		t.Errorf("errors.As should find each error but was %v.", err)
+	}
+	if !errors.Is(err, ErrCmdUsage) {
+		t.Error("The error should match ErrCmdUsage.")
+	}
+	if ran {
+		t.Error("The command should not run.")
+	}
+	want := `deploy: 3 problems:
+  - invalid value "0" for flag -port: must not be 0
+  - requirements not met:
+      $DEPLOY_TOKEN set: set to an empty value
+        set the environment variable DEPLOY_TOKEN
+  - expected arguments <target>, got 0`
+	if err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+}
+
+func TestReportAllErrorsMissingFlags(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "")
+	var opts deployOpts
+	var ran bool
+	p := newReportAllPath(&opts, &ran)
+	p.SetReportAllErrors(true)
+	var out strings.Builder
+	p.SetOutput(&out)
+	if code := p.Execute("deploy", "-port", "0"); code != ExitUsage {
+		t.Errorf("Execute should exit with %d but exited with %d.", ExitUsage, code)
+	}
+	for _, want := range []string{
+		"deploy: 4 problems:\n  - required flags not set: --env\n",
+		"\n  --env string  target environment (required)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("The output should contain %q but was %q.", want, out.String())
+		}
+	}
+	var missing *MissingFlagsError
+	if _, err := p.Validate("deploy", "-port", "0", "web"); !errors.As(err, &missing) || !errors.As(err, new(*ArgError)) {
+		t.Errorf("Validate should report the missing flag and the invalid value but was %v.", err)
+	}
+}
+
+func TestReportAllErrorsSingle(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "s3cr3t")
+	var opts deployOpts
+	var ran bool
+	p := newReportAllPath(&opts, &ran)
+	p.SetReportAllErrors(true)
+	_, err := p.Run("deploy", "-env", "prod")
+	if want := "deploy: expected arguments <target>, got 0"; err == nil || err.Error() != want {
+		t.Errorf("A single error should be returned as is but was %v.", err)
+	}
+	if _, err := p.Run("deploy", "-env", "prod", "web"); err != nil || !ran {
+		t.Errorf("A valid invocation should run but failed with %v.", err)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "")
+	var opts deployOpts
+	var ran bool
+	p := newReportAllPath(&opts, &ran)
+	_, err := p.Run("deploy", "-port", "0")
+	if want := "deploy: required flags not set: --env"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// A runtime requirement of a command, such as a binary it executes.
+type Requirement struct {
+	// what is required, such as "docker on $PATH"; requirements
+	// without a name are left out of the spec and the documentation
+	Name string
+	// tells the user how to meet the requirement
+	Hint string
+	// returns why the requirement isn't met, or nil
+	Check func() error
+	// the environment variable required by EnvSet, listed in the
+	// Environment section of the help
+	env string
+}
+
+// Returns a copy of r with the hint replaced.
+func (r Requirement) WithHint(hint string) Requirement {
+	r.Hint = hint
+	return r
+}
+
+// Requires the executable name to be found in the directories of $PATH.
+func BinaryOnPath(name string) Requirement {
+	return Requirement{
+		Name: name + " on $PATH",
+		Hint: "install " + name + " or add its directory to $PATH",
+		Check: func() error {
+			_, err := exec.LookPath(name)
+			if errors.Is(err, exec.ErrNotFound) {
+				return errors.New("not found")
+			}
+			return err
+		},
+	}
+}
+
+// Requires the environment variable name to be set to a non-empty
+// value.
+func EnvSet(name string) Requirement {
+	return Requirement{
+		Name: "$" + name + " set",
+		Hint: "set the environment variable " + name,
+		env:  name,
+		Check: func() error {
+			v, ok := os.LookupEnv(name)
+			switch {
+			case !ok:
+				return errors.New("not set")
+			case v == "":
+				return errors.New("set to an empty value")
+			}
+			return nil
+		},
+	}
+}
+
+// Requires the file or directory path to exist. Unlike the validator
+// FileExists it checks a fixed path rather than the value of a flag.
+func PathExists(path string) Requirement {
+	return Requirement{
+		Name: path + " exists",
+		Hint: "create " + path,
+		Check: func() error {
+			_, err := os.Stat(path)
+			if errors.Is(err, os.ErrNotExist) {
+				return errors.New("does not exist")
+			}
+			return err
+		},
+	}
+}
+
+// Requires check to return nil. The requirement has neither a name nor
+// a hint, which can be set on the returned Requirement.
+func Custom(check func() error) Requirement {
+	return Requirement{Check: check}
+}
+
+// Declares requirements of the command. Run checks them after the
+// flags were parsed and validated and fails with a RequirementsError
+// listing all unmet requirements instead of running the command. The
+// doctor command checks them without running anything, see
+// AddDoctorCommand.
+func (c *CmdCont) Requires(req ...Requirement) {
+	c.requirements = append(c.requirements, req...)
+	c.changed()
+}
+
+// Returns a RequirementsError if requirements of c aren't met.
+func (c *CmdCont) checkRequirements() error {
+	var e *RequirementsError
+	for _, r := range c.requirements {
+		if err := r.Check(); err != nil {
+			if e == nil {
+				e = &RequirementsError{Command: c.CommandPath()}
+			}
+			e.Requirements = append(e.Requirements, r)
+			e.Errs = append(e.Errs, err)
+		}
+	}
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+// Returns the requirements of c that have a name, which are listed in
+// the spec and the documentation.
+func (c *CmdCont) namedRequirements() []Requirement {
+	var named []Requirement
+	for _, r := range c.requirements {
+		if r.Name != "" {
+			named = append(named, r)
+		}
+	}
+	return named
+}
+
+// Describes r and why it isn't met.
+func requirementMessage(r Requirement, err error) string {
+	if r.Name == "" {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s: %v", r.Name, err)
+}
+
+// Returned by Run if requirements of the command aren't met, see
+// Requires.
+type RequirementsError struct {
+	Command string
+	// the unmet requirements in the order they were declared
+	Requirements []Requirement
+	// why each of the Requirements isn't met
+	Errs []error
+}
+
+func (e *RequirementsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%srequirements not met:", commandPrefix(e.Command))
+	for i, r := range e.Requirements {
+		fmt.Fprintf(&b, "\n  %s", requirementMessage(r, e.Errs[i]))
+		if r.Hint != "" {
+			fmt.Fprintf(&b, "\n    %s", r.Hint)
+		}
+	}
+	return b.String()
+}
+
+func (e *RequirementsError) Unwrap() []error {
+	return e.Errs
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBinaryOnPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	if err := BinaryOnPath("docker").Check(); err != nil {
+		t.Errorf("docker should be found but wasn't: %v", err)
+	}
+	r := BinaryOnPath("kubectl")
+	if err := r.Check(); err == nil || err.Error() != "not found" {
+		t.Errorf("kubectl should not be found but the error was %v.", err)
+	}
+	if r.Name != "kubectl on $PATH" {
+		t.Errorf("The name should be %q but was %q.", "kubectl on $PATH", r.Name)
+	}
+}
+
+func TestEnvSet(t *testing.T) {
+	r := EnvSet("COMMAND_TEST_KUBECONFIG")
+	os.Unsetenv("COMMAND_TEST_KUBECONFIG")
+	if err := r.Check(); err == nil || err.Error() != "not set" {
+		t.Errorf("An unset variable should fail with \"not set\" but failed with %v.", err)
+	}
+	t.Setenv("COMMAND_TEST_KUBECONFIG", "")
+	if err := r.Check(); err == nil || err.Error() != "set to an empty value" {
+		t.Errorf("An empty variable should fail with \"set to an empty value\" but failed with %v.", err)
+	}
+	t.Setenv("COMMAND_TEST_KUBECONFIG", "/etc/kube")
+	if err := r.Check(); err != nil {
+		t.Errorf("A set variable should pass but failed with %v.", err)
+	}
+}
+
+func TestPathExists(t *testing.T) {
+	dir := t.TempDir()
+	if err := PathExists(dir).Check(); err != nil {
+		t.Errorf("An existing directory should pass but failed with %v.", err)
+	}
+	if err := PathExists(filepath.Join(dir, "missing")).Check(); err == nil || err.Error() != "does not exist" {
+		t.Errorf("A missing file should fail with \"does not exist\" but failed with %v.", err)
+	}
+}
+
+func TestCustom(t *testing.T) {
+	fail := errors.New("license expired")
+	r := Custom(func() error { return fail }).WithHint("renew the license")
+	if err := r.Check(); err != fail {
+		t.Errorf("The check should fail with %v but failed with %v.", fail, err)
+	}
+	if r.Name != "" || r.Hint != "renew the license" {
+		t.Errorf("The requirement should only have the hint but was %+v.", r)
+	}
+}
+
+func TestRunRequirements(t *testing.T) {
+	fail := errors.New("license expired")
+	ran := false
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{run: func(args ...string) error {
+		ran = true
+		return nil
+	}}, "env")
+	c.Flags.String("env", "", "target environment")
+	c.Requires(
+		EnvSet("COMMAND_TEST_UNSET"),
+		Requirement{Name: "git on $PATH", Check: func() error { return nil }},
+		Custom(func() error { return fail }).WithHint("renew the license"),
+	)
+	os.Unsetenv("COMMAND_TEST_UNSET")
+
+	// flags are validated first
+	var missing *MissingFlagsError
+	if _, err := p.Run("deploy"); !errors.As(err, &missing) {
+		t.Errorf("Missing flags should be reported first but the error was %v.", err)
+	}
+	_, err := p.Run("deploy", "-env", "prod")
+	if ran {
+		t.Error("The command should not run with unmet requirements.")
+	}
+	var e *RequirementsError
+	if !errors.As(err, &e) {
+		t.Fatalf("Run should fail with a RequirementsError but failed with %v.", err)
+	}
+	want := "deploy: requirements not met:\n" +
+		"  $COMMAND_TEST_UNSET set: not set\n" +
+		"    set the environment variable COMMAND_TEST_UNSET\n" +
+		"  license expired\n" +
+		"    renew the license"
+	if err.Error() != want {
+		t.Errorf("The error should be %q but was %q.", want, err.Error())
+	}
+	if !errors.Is(err, fail) {
+		t.Error("The error should wrap the errors of the checks.")
+	}
+
+	t.Setenv("COMMAND_TEST_UNSET", "1")
+	fail = nil
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil || !ran {
+		t.Errorf("The command should run once the requirements are met but failed with %v.", err)
+	}
+}
+
+func TestRequirementsDocs(t *testing.T) {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{}).Requires(
+		BinaryOnPath("docker"),
+		Custom(func() error { return nil }),
+	)
+	s := p.Spec()
+	want := []RequirementSpec{{Name: "docker on $PATH", Hint: "install docker or add its directory to $PATH"}}
+	if got := s.Commands[0].Requirements; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("The spec should list the requirements %+v but listed %+v.", want, got)
+	}
+	var out strings.Builder
+	if err := p.WriteMarkdown(&out, "app"); err != nil {
+		t.Fatal(err)
+	}
+	if md := "\n### Requirements\n\n- docker on $PATH: install docker or add its directory to $PATH\n"; !strings.HasSuffix(out.String(), md) {
+		t.Errorf("The Markdown should end with %q but was %q.", md, out.String())
+	}
+}
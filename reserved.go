@@ -0,0 +1,40 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// The names of the built-in commands by name, which are reserved.
+var builtins = map[string]string{
+	completeCmd: "completion",
+}
+
+// Disables the built-in command of the given name, such as "__complete"
+// for shell completion, so that a command of that name can be
+// registered with AddE.
+func (p *Path) OverrideBuiltin(name string) {
+	if p.overridden == nil {
+		p.overridden = make(map[string]bool)
+	}
+	p.overridden[name] = true
+}
+
+// Fails if name is reserved for a built-in command of p.
+func (p *Path) checkReserved(name string) error {
+	if builtin, ok := builtins[name]; ok && !p.overridden[name] {
+		return fmt.Errorf("name '%s' is reserved; disable the built-in %s or choose another name", name, builtin)
+	}
+	return nil
+}
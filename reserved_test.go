@@ -0,0 +1,64 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReservedNames(t *testing.T) {
+	p := NewPath()
+	_, err := p.AddE(completeCmd, "my completion", &testCmd{})
+	if want := "name '__complete' is reserved; disable the built-in completion or choose another name"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if p.registry().entries[completeCmd] != nil {
+		t.Error("A reserved name should not be registered.")
+	}
+
+	// a command registered with Add is reported by Check
+	p.Add(completeCmd, "my completion", &testCmd{})
+	want := []Problem{{completeCmd, SeverityError, "name '__complete' is reserved; disable the built-in completion or choose another name", ""}}
+	if problems := withoutCallers(t, p.Check()); !reflect.DeepEqual(problems, want) {
+		t.Errorf("Problems should be %v but were %v.", want, problems)
+	}
+}
+
+func TestOverrideBuiltin(t *testing.T) {
+	p := NewPath()
+	p.OverrideBuiltin(completeCmd)
+	var called []string
+	if _, err := p.AddE(completeCmd, "my completion", &testCmd{
+		run: func(args ...string) error {
+			called = args
+			return nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	p.stdout = &out
+	if _, err := p.Run(completeCmd, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(called, []string{"x"}) || out.Len() > 0 {
+		t.Errorf("The registered command should run instead of the built-in but got %q and %q.", called, out.String())
+	}
+	if problems := p.Check(); len(problems) > 0 {
+		t.Errorf("An overridden built-in should not be a problem but got %v.", problems)
+	}
+}
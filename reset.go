@@ -0,0 +1,220 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+)
+
+// Flag values which can't be restored by passing their default to Set,
+// such as slices, or which track whether they were set, implement
+// Resetter. Reset restores the default of the value; the flags are
+// reset with it instead of Set, see SetResetFlags.
+type Resetter interface {
+	flag.Value
+	Reset()
+}
+
+// Sets whether Run, RunContext and Validate restore the flags to their
+// defaults before dispatching, as if ResetFlags was called. It is on
+// by default, so that a command run a second time in the same process,
+// such as by Shell or in tests, doesn't see the values of the first
+// run. The flags aren't reset before the first dispatch or the first
+// one after ResetFlags, which keeps values set by the program, nor by
+// dispatches from within a running command.
+func (p *Path) SetResetFlags(reset bool) {
+	p.noReset = !reset
+}
+
+// Resets the flags if an earlier dispatch may have set them.
+func (p *Path) beginDispatch() {
+	if p.running == 0 && p.dispatched && !p.noReset {
+		p.resetDispatched()
+	}
+	p.dispatched = true
+	p.running++
+}
+
+func (p *Path) endDispatch() {
+	p.running--
+}
+
+// Restores the flags set by the last dispatch, which are the global
+// and persistent flags of p and the flags of the command it matched.
+// The other commands weren't parsed, so the reset doesn't depend on
+// the number of commands.
+func (p *Path) resetDispatched() {
+	if p.Flags.NFlag() > 0 {
+		p.Flags = resetFlagSet(p.Flags, nil)
+	}
+	if p.persistent != nil && p.persistent.NFlag() > 0 {
+		p.persistent = resetFlagSet(p.persistent, nil)
+	}
+	p.sources = nil
+	for _, c := range p.earlier {
+		c.resetDispatched()
+	}
+	p.earlier = p.earlier[:0]
+	if p.last != nil {
+		p.last.resetDispatched()
+		p.last = nil
+	}
+}
+
+// Records that cont was matched by the current dispatch of p. The
+// command matched by an enclosing dispatch, from which a command runs
+// another one, is kept for resetDispatched.
+func (p *Path) setLast(cont *CmdCont) {
+	nested := p.running > 1 || p.root().running > 1
+	if nested && p.last != nil && p.last != cont && !containsCmd(p.earlier, p.last) {
+		p.earlier = append(p.earlier, p.last)
+	}
+	p.last = cont
+}
+
+func containsCmd(list []*CmdCont, c *CmdCont) bool {
+	for _, e := range list {
+		if e == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Restores the flags of c set by the last dispatch.
+func (c *CmdCont) resetDispatched() {
+	if c.sub != nil {
+		c.sub.resetDispatched()
+		c.Flags = c.sub.Flags
+	} else if c.registered && c.Flags.NFlag() > 0 {
+		c.Flags = resetFlagSet(c.Flags, c.Cmd.Flags)
+		c.showDefaultValues()
+	}
+	c.globals = nil
+	c.sources = nil
+}
+
+// Restores the flags of all commands of p and its mounted paths to
+// their defaults and forgets which of them were set, so that a command
+// can be run again without seeing the values of an earlier run. The
+// flags of each command are registered anew by calling the Flags
+// method of its Cmd; aliases, renamed flags and file expansion are
+// carried over. Flags that weren't registered yet, see FlagSet, stay
+// unregistered. Global and persistent flags are set to their defaults.
+func (p *Path) ResetFlags() {
+	p.Flags = resetFlagSet(p.Flags, nil)
+	if p.persistent != nil {
+		p.persistent = resetFlagSet(p.persistent, nil)
+	}
+	p.sources = nil
+	p.dispatched = false
+	p.last = nil
+	p.earlier = p.earlier[:0]
+	for _, c := range p.registry().entries {
+		c.resetFlags()
+	}
+	if p.rootCmd != nil {
+		p.rootCmd.resetFlags()
+	}
+}
+
+func (c *CmdCont) resetFlags() {
+	if c.sub != nil {
+		c.sub.ResetFlags()
+		c.Flags = c.sub.Flags
+	} else if c.registered {
+		c.Flags = resetFlagSet(c.Flags, c.Cmd.Flags)
+		c.showDefaultValues()
+	}
+	c.globals = nil
+	c.sources = nil
+	c.warned = nil
+	c.argValues = nil
+	c.parsedArgs = nil
+}
+
+// Returns a copy of old with all flags restored to their defaults. If
+// register is not nil, it defines the flags of the copy and only the
+// flags it doesn't define, such as aliases, are taken from old.
+func resetFlagSet(old *flag.FlagSet, register func(*flag.FlagSet)) *flag.FlagSet {
+	// Restore the variables first, as register may use their current
+	// values as defaults.
+	old.VisitAll(func(f *flag.Flag) {
+		resetValue(f.Value, f.DefValue)
+	})
+	fs := flag.NewFlagSet(old.Name(), old.ErrorHandling())
+	fs.Usage = old.Usage
+	if out := old.Output(); out != os.Stderr {
+		fs.SetOutput(out)
+	}
+	if register != nil {
+		register(fs)
+	}
+	old.VisitAll(func(f *flag.Flag) {
+		if nf := fs.Lookup(f.Name); nf != nil {
+			nf.Value = rewrapValue(f.Value, nf.Value)
+			return
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.DefValue
+	})
+	fs.VisitAll(func(f *flag.Flag) {
+		retargetValue(f.Value, fs)
+	})
+	return fs
+}
+
+func resetValue(v flag.Value, def string) {
+	switch v := v.(type) {
+	case *aliasValue:
+		// reset with the flag it refers to
+	case *mirrorValue:
+		resetValue(v.Value, def)
+	case *fileExpandValue:
+		resetValue(v.Value, def)
+	case Resetter:
+		v.Reset()
+	default:
+		// Values rejecting their own default, such as an enum
+		// without a default, are registered anew anyway.
+		v.Set(def)
+	}
+}
+
+// Applies the wrappers of old, added after the registration of a
+// flag, to v.
+func rewrapValue(old, v flag.Value) flag.Value {
+	switch old := old.(type) {
+	case *mirrorValue:
+		return &mirrorValue{Value: rewrapValue(old.Value, v), target: old.target}
+	case *fileExpandValue:
+		return &fileExpandValue{rewrapValue(old.Value, v)}
+	}
+	return v
+}
+
+// Points aliases and renamed flags in v to the flags of fs.
+func retargetValue(v flag.Value, fs *flag.FlagSet) {
+	switch v := v.(type) {
+	case *aliasValue:
+		v.target = fs.Lookup(v.target.Name)
+	case *mirrorValue:
+		v.target = fs.Lookup(v.target.Name)
+		retargetValue(v.Value, fs)
+	case *fileExpandValue:
+		retargetValue(v.Value, fs)
+	}
+}
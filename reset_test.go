@@ -0,0 +1,209 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+type resetOpts struct {
+	Env     string   `flag:"env"`
+	Tags    []string `flag:"tag"`
+	Verbose bool     `flag:"verbose"`
+}
+
+func TestResetFlags(t *testing.T) {
+	opts := resetOpts{Env: "dev", Tags: []string{"web"}}
+	var mode string
+	var force *bool
+	p := NewPath()
+	verbose := p.Flags.Bool("verbose", false, "verbose output")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			if err := FlagsFromStruct(fs, &opts); err != nil {
+				t.Fatal(err)
+			}
+			EnumVar(fs, &mode, "mode", "", []string{"fast", "safe"}, "deploy mode")
+			force = fs.Bool("force", false, "skip checks")
+		},
+	})
+	if err := c.FlagAlias("env", "e"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameFlag("yes", "force"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("-verbose", "deploy", "-e", "prod", "-tag", "api", "-mode", "fast", "-yes"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Env != "prod" || !*force {
+		t.Fatalf("The first run should set the flags but was %+v, %v.", opts, *force)
+	}
+
+	p.ResetFlags()
+	if *verbose || opts.Env != "dev" || !reflect.DeepEqual(opts.Tags, []string{"web"}) || mode != "" || *force {
+		t.Errorf("ResetFlags should restore the defaults but was %v, %+v, %q, %v.", *verbose, opts, mode, *force)
+	}
+	if _, err := p.Run("deploy", "-tag", "db"); err != nil {
+		t.Fatal(err)
+	}
+	if *verbose || opts.Env != "dev" || !reflect.DeepEqual(opts.Tags, []string{"db"}) || mode != "" || *force {
+		t.Errorf("The second run should only see its own flags but was %v, %+v, %q, %v.", *verbose, opts, mode, *force)
+	}
+	if f := c.Flags.Lookup("env"); f.DefValue != "dev" {
+		t.Errorf("The default of -env should be %q but was %q.", "dev", f.DefValue)
+	}
+
+	p.ResetFlags()
+	if _, err := p.Run("deploy", "-e", "qa", "-yes"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Env != "qa" || !*force {
+		t.Errorf("Aliases and renamed flags should survive ResetFlags but was %+v, %v.", opts, *force)
+	}
+}
+
+func TestResetFlagsMounted(t *testing.T) {
+	var name string
+	var tags []string
+	remote := NewPath()
+	dryRun := remote.Flags.Bool("dry-run", false, "only print the changes")
+	remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&name, "name", "origin", "remote name")
+			StringSliceVar(fs, &tags, "tag", "remote tags")
+		},
+	})
+	p := NewPath()
+	p.Mount("remote", "manages remotes", remote)
+	if _, err := p.Run("remote", "-dry-run", "add", "-name", "upstream", "-tag", "a"); err != nil {
+		t.Fatal(err)
+	}
+	p.ResetFlags()
+	if _, err := p.Run("remote", "add", "-tag", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if *dryRun || name != "origin" || !reflect.DeepEqual(tags, []string{"b"}) {
+		t.Errorf("The second run should only see its own flags but was %v, %q, %q.", *dryRun, name, tags)
+	}
+	if remote.Flags != p.registry().entries["remote"].Flags {
+		t.Error("The mounted command should share the global flags of the mounted path.")
+	}
+}
+
+func TestResetBetweenRuns(t *testing.T) {
+	var env, namespace string
+	var tags []string
+	remote := NewPath()
+	remote.PersistentFlags().StringVar(&namespace, "namespace", "default", "namespace")
+	remote.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&env, "env", "dev", "target environment")
+			StringSliceVar(fs, &tags, "tag", "release tags")
+		},
+	}, "env")
+	p := NewPath()
+	verbose := p.Flags.Bool("verbose", false, "verbose output")
+	p.Mount("remote", "manages remotes", remote)
+	if _, err := p.Run("-verbose", "remote", "deploy", "-env", "prod", "-tag", "a", "-namespace", "ops"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := p.Run("remote", "deploy", "-tag", "b")
+	var missing *MissingFlagsError
+	if !errors.As(err, &missing) {
+		t.Errorf("-env set by the first run should be missing in the second but error was %v.", err)
+	}
+	if *verbose || env != "dev" || namespace != "default" || !reflect.DeepEqual(tags, []string{"b"}) {
+		t.Errorf("The second run should only see its own flags but was %v, %q, %q, %q.", *verbose, env, namespace, tags)
+	}
+	if _, err := p.Run("remote", "deploy", "-env", "qa"); err != nil {
+		t.Fatal(err)
+	}
+	if env != "qa" || tags != nil {
+		t.Errorf("The defaults should be restored exactly but were %q, %#v.", env, tags)
+	}
+}
+
+func TestResetBetweenRunsDisabled(t *testing.T) {
+	var env string
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&env, "env", "dev", "target environment")
+		},
+	}, "env")
+	p.SetResetFlags(false)
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil || env != "prod" {
+		t.Errorf("Without resets the flags should keep their values but were %q, %v.", env, err)
+	}
+}
+
+func TestResetBetweenRunsKeepsProgramValues(t *testing.T) {
+	p := NewPath()
+	p.Flags.String("region", "eu", "region")
+	var nested string
+	var env *string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			env = fs.String("env", "dev", "target environment")
+		},
+		run: func(args ...string) error {
+			if _, err := p.Run("status"); err != nil {
+				return err
+			}
+			nested = *env
+			return nil
+		},
+	})
+	p.Add("status", "prints the status", &testCmd{})
+	p.Flags.Set("region", "us")
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.Flags.Lookup("region").Value.String(); v != "us" {
+		t.Errorf("The first run should keep values set before it but region was %q.", v)
+	}
+	if nested != "prod" {
+		t.Errorf("A nested run should not reset the running command but env was %q.", nested)
+	}
+}
+
+func TestResetValues(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	var color bool
+	BoolWithInverseVar(fs, &color, "color", true, "colored output")
+	var labels map[string]string
+	StringMapVar(fs, &labels, "label", "labels")
+	var selector map[string]string
+	JSONVar(fs, &selector, "selector", "selector")
+	var backoff []int
+	IntSliceVar(fs, &backoff, "backoff", "backoff")
+	if err := fs.Parse([]string{"-no-color", "-label", "a=b", "-selector", `{"app":"web"}`, "-backoff", "1,2"}); err != nil {
+		t.Fatal(err)
+	}
+	fs = resetFlagSet(fs, nil)
+	if !color || labels != nil || selector != nil || backoff != nil {
+		t.Errorf("The flags should be reset but were %v, %v, %v, %v.", color, labels, selector, backoff)
+	}
+	if err := fs.Parse([]string{"-color"}); err != nil {
+		t.Errorf("-color should be accepted after a reset but failed with %v.", err)
+	}
+}
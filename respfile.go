@@ -0,0 +1,88 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The maximum depth of response files referring to other response
+// files.
+const maxResponseFileDepth = 8
+
+// Replaces arguments of the form @file given to Run, RunContext and
+// Validate by the arguments read from the file, for command lines
+// exceeding the limits of the operating system. The arguments in the
+// file are separated by spaces, tabs and newlines and split like
+// SplitArgs on each line, so quotes don't span lines. The file may
+// contain @file arguments of its own, up to 8 levels deep. The
+// arguments are expanded before the command is looked up, so the file
+// may contain the command name. An argument starting with @@ is passed
+// with the first @ removed and not expanded.
+func (p *Path) SetResponseFiles(enabled bool) {
+	p.responseFiles = enabled
+}
+
+// Returns args with response files expanded. depth is the number of
+// response files the arguments were read from.
+func expandResponseFiles(args []string, depth int) ([]string, error) {
+	i := 0
+	for i < len(args) && !strings.HasPrefix(args[i], "@") {
+		i++
+	}
+	if i == len(args) {
+		return args, nil
+	}
+	expanded := append([]string(nil), args[:i]...)
+	for _, arg := range args[i:] {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			if depth == maxResponseFileDepth {
+				return nil, fmt.Errorf("Response file %s: nested more than %d levels deep", arg[1:], maxResponseFileDepth)
+			}
+			fileArgs, err := readResponseFile(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			if fileArgs, err = expandResponseFiles(fileArgs, depth+1); err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+func readResponseFile(name string) ([]string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("Response file: %w", err)
+	}
+	var args []string
+	for i, line := range strings.Split(string(b), "\n") {
+		lineArgs, err := SplitArgs(strings.TrimSuffix(line, "\r"))
+		if err != nil {
+			return nil, fmt.Errorf("Response file %s, line %d: %w", name, i+1, err)
+		}
+		args = append(args, lineArgs...)
+	}
+	return args, nil
+}
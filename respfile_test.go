@@ -0,0 +1,87 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeResponseFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	flags := writeResponseFile(t, dir, "flags.txt", "-tag 'release candidate'\r\n-tag \"a b\"\n")
+	main := writeResponseFile(t, dir, "main.txt", "build -v\n@"+flags+"\n\n  -tag nightly\n")
+	p := NewPath()
+	var tags []string
+	var verbose bool
+	var got []string
+	p.Add("build", "builds the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			StringSliceVar(fs, &tags, "tag", "image tags")
+			fs.BoolVar(&verbose, "v", false, "verbose output")
+		},
+		run: func(args ...string) error {
+			got = args
+			return nil
+		},
+	})
+	if _, err := p.Run("@" + main); err == nil {
+		t.Error("Response files should be disabled by default.")
+	}
+	p.SetResponseFiles(true)
+	if _, err := p.Run("@"+main, "@@home", "@"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"release candidate", "a b", "nightly"}; !verbose || !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags should be %q but were %q (verbose: %v).", want, tags, verbose)
+	}
+	if want := []string{"@home", "@"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arguments should be %q but were %q.", want, got)
+	}
+}
+
+func TestResponseFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	loop := filepath.Join(dir, "loop.txt")
+	writeResponseFile(t, dir, "loop.txt", "@"+loop)
+	quote := writeResponseFile(t, dir, "quote.txt", "build\n-tag 'open\n")
+	p := NewPath()
+	p.Add("build", "builds the app", &testCmd{})
+	p.SetResponseFiles(true)
+	for _, test := range []struct {
+		arg, err string
+	}{
+		{"@" + filepath.Join(dir, "missing.txt"), "Response file: open " + filepath.Join(dir, "missing.txt")},
+		{"@" + loop, "nested more than 8 levels deep"},
+		{"@" + quote, "quote.txt, line 2: unterminated ' quote"},
+	} {
+		_, err := p.Run(test.arg)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Run(%q) should fail with %q but was %v.", test.arg, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Runs the command again if it fails with an error for which retryIf
+// returns true, or any error if retryIf is nil, for commands calling
+// flaky services. The command is run at most attempts times, waiting
+// backoff before the second attempt and twice as long before each
+// following one. Before each attempt the flags are restored to the
+// values they were parsed to, see SnapshotFlags. Waiting stops when
+// the context passed to RunContext is done. If the command was run
+// more than once or waiting was stopped, its last error is returned in
+// a RetryError.
+func (c *CmdCont) Retry(attempts int, backoff time.Duration, retryIf func(error) bool) {
+	c.retry = &retryPolicy{attempts: attempts, backoff: backoff, retryIf: retryIf}
+}
+
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+	retryIf  func(error) bool
+}
+
+// Waits for d unless ctx is done before. Replaced by tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Same as runCommand but retries the command as configured by Retry.
+func (p *Path) runRetrying(ctx context.Context, cont *CmdCont, args []string, tr io.Writer) error {
+	r := cont.retry
+	snapshot := cont.SnapshotFlags()
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		err := p.runCommand(ctx, cont, args)
+		if err == nil || attempt >= r.attempts || r.retryIf != nil && !r.retryIf(err) {
+			if err != nil && attempt > 1 {
+				err = &RetryError{Command: cont.Name, Attempts: attempt, Err: err}
+			}
+			return err
+		}
+		if tr != nil {
+			fmt.Fprintf(tr, "trace: %s: attempt %d failed, retrying in %v: %v\n", cont.Name, attempt, backoff, err)
+		}
+		if cerr := sleep(ctx, backoff); cerr != nil {
+			return &RetryError{Command: cont.Name, Attempts: attempt, Err: err, Stopped: cerr}
+		}
+		if rerr := snapshot.Restore(); rerr != nil {
+			return rerr
+		}
+		backoff *= 2
+	}
+}
+
+// Returned by Run for commands configured with Retry that failed after
+// more than one attempt or whose retries were stopped by their
+// context. The message has one of the forms
+//
+//	deploy: failed after 3 attempts: ...
+//	deploy: retrying stopped after 1 attempt: context canceled: ...
+type RetryError struct {
+	Command  string
+	Attempts int
+	// the error of the last attempt
+	Err error
+	// the error of the context that stopped the retries, if any
+	Stopped error
+}
+
+func (e *RetryError) Error() string {
+	attempts := fmt.Sprintf("%d attempts", e.Attempts)
+	if e.Attempts == 1 {
+		attempts = "1 attempt"
+	}
+	if e.Stopped != nil {
+		return fmt.Sprintf("%sretrying stopped after %s: %v: %v", commandPrefix(e.Command), attempts, e.Stopped, e.Err)
+	}
+	return fmt.Sprintf("%sfailed after %s: %v", commandPrefix(e.Command), attempts, e.Err)
+}
+
+// Returns the error of the last attempt and the error of the context,
+// if any, so that errors.Is matches both.
+func (e *RetryError) Unwrap() []error {
+	if e.Stopped != nil {
+		return []error{e.Err, e.Stopped}
+	}
+	return []error{e.Err}
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("service unavailable")
+
+// Replaces sleep by a function recording the durations, which returns
+// the error of ctx if it is done.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	var sleeps []time.Duration
+	prev := sleep
+	t.Cleanup(func() { sleep = prev })
+	sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	return &sleeps
+}
+
+// Adds the command fetch to p, which fails with the errors in errs
+// one after the other and then succeeds. The limit of each attempt is
+// recorded in limits; the command decrements its flag variable.
+func addFlaky(p *Path, limits *[]int, errs ...error) *CmdCont {
+	var limit int
+	return p.Add("fetch", "fetches the data", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.IntVar(&limit, "limit", 10, "maximum number of items")
+		},
+		run: func(args ...string) error {
+			*limits = append(*limits, limit)
+			limit--
+			if len(*limits) <= len(errs) {
+				return errs[len(*limits)-1]
+			}
+			return nil
+		},
+	})
+}
+
+func TestRetry(t *testing.T) {
+	sleeps := recordSleeps(t)
+	p := NewPath()
+	var limits []int
+	c := addFlaky(p, &limits, errFlaky, errFlaky)
+	c.Retry(3, 100*time.Millisecond, func(err error) bool { return errors.Is(err, errFlaky) })
+	if _, err := p.Run("fetch", "-limit", "5"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 5, 5}; !reflect.DeepEqual(limits, want) {
+		t.Errorf("Every attempt should see the limit %v but was %v.", want, limits)
+	}
+	if want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}; !reflect.DeepEqual(*sleeps, want) {
+		t.Errorf("Backoff should be %v but was %v.", want, *sleeps)
+	}
+
+	limits = nil
+	c = addFlaky(p, &limits, errFlaky, errFlaky, errFlaky)
+	c.Retry(3, time.Millisecond, nil)
+	_, err := p.Run("fetch")
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || !errors.Is(err, errFlaky) {
+		t.Fatalf("Run should fail after 3 attempts but was %v.", err)
+	}
+	if want := "fetch: failed after 3 attempts: service unavailable"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	sleeps := recordSleeps(t)
+	p := NewPath()
+	var limits []int
+	errFatal := errors.New("permission denied")
+	c := addFlaky(p, &limits, errFatal)
+	c.Retry(3, time.Millisecond, func(err error) bool { return errors.Is(err, errFlaky) })
+	if _, err := p.Run("fetch"); err != errFatal {
+		t.Errorf("Run should fail with %v but was %v.", errFatal, err)
+	}
+	if len(limits) != 1 || len(*sleeps) != 0 {
+		t.Errorf("The command should run once without waiting but ran %d times.", len(limits))
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	recordSleeps(t)
+	p := NewPath()
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	c := p.Add("fetch", "fetches the data", CmdFunc(func(args []string) error {
+		attempts++
+		cancel()
+		return errFlaky
+	}))
+	c.Retry(3, time.Hour, nil)
+	_, err := p.RunContext(ctx, "fetch")
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errFlaky) || attempts != 1 {
+		t.Fatalf("Run should stop after the canceled attempt but was %v after %d attempts.", err, attempts)
+	}
+	if want := "fetch: retrying stopped after 1 attempt: context canceled: service unavailable"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+}
+
+func TestRetrySleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("Sleep should be interrupted by the context but was %v.", err)
+	}
+	if err := sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Sleep should succeed but was %v.", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Registers cmd as the root command of p, which Run runs when no
+// command is given, such as for "app --status-format json". The root
+// command parses the whole argument list, accepting the global and
+// persistent flags along with its own. It is run if the arguments are
+// empty or start with a flag and no registered command or alias
+// follows the global flags; a command given by name always takes
+// precedence. The help of p describes the usage of the root command
+// above the list of commands.
+func (p *Path) SetRoot(cmd Cmd, requiredFlags ...string) *CmdCont {
+	c := &CmdCont{
+		Cmd:           cmd,
+		RequiredFlags: requiredFlags,
+		Flags:         flag.NewFlagSet(p.Flags.Name(), flag.ContinueOnError),
+		normalize:     NormalizeUnderscores,
+		path:          p,
+	}
+	c.Flags.Usage = func() {
+		p.page(c.Flags.Output(), p.writeAvailableCommands)
+	}
+	p.rootCmd = c
+	return c
+}
+
+// Returns the root command of p, or nil, see SetRoot.
+func (p *Path) Root() *CmdCont {
+	return p.rootCmd
+}
+
+func (c *CmdCont) isRoot() bool {
+	return c.path != nil && c.path.rootCmd == c
+}
+
+// Reports whether args invoke the root command of p: they are empty or
+// start with a flag, and the global flags are followed by neither a
+// command nor an alias. Flags unknown to the global flag sets may be
+// flags of the root command, which ends the search.
+func (p *Path) invokesRoot(args []string, inherited []*flag.FlagSet) bool {
+	sets := append([]*flag.FlagSet{p.Flags}, inherited...)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return i+1 == len(args) || !p.isCommandName(args[i+1])
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return i > 0 && !p.isCommandName(arg)
+		}
+		name := strings.TrimLeft(arg, "-")
+		hasValue := strings.IndexByte(name, '=') >= 0
+		if hasValue {
+			name = name[:strings.IndexByte(name, '=')]
+		}
+		fs := lookupInherited(sets, name)
+		if fs == nil {
+			return true
+		}
+		if !hasValue && !isBoolFlag(fs.Lookup(name)) {
+			i++
+		}
+	}
+	return true
+}
+
+func (p *Path) isCommandName(name string) bool {
+	if _, ok := p.registry().entries[name]; ok {
+		return true
+	}
+	if _, ok := p.aliases[name]; ok {
+		return true
+	}
+	_, ok := p.lookupPrefix(name)
+	return ok
+}
+
+// Parses the global flags in args along with the flags of the root
+// command and runs it if exec is set.
+func (p *Path) runRoot(ctx context.Context, args []string, inherited, globals []*flag.FlagSet, exec bool) (d time.Duration, err error) {
+	cont := p.rootCmd
+	p.setLast(cont)
+	outer, ok := p.enter(cont)
+	if !ok {
+		return 0, &ConcurrentInvocationError{Command: cont.CommandPath()}
+	}
+	defer cont.leave(outer)
+	rest, err := parseInherited(args, cont.FlagSet(), append([]*flag.FlagSet{p.Flags}, inherited...))
+	if err != nil {
+		return 0, newFlagParseError(p.Flags.Name(), err)
+	}
+	if p.sources, err = resolveFlags(EnvFromContext(ctx), p.Flags, p.env, p.config.global); err != nil {
+		return 0, err
+	}
+	applyLazyDefaults(p.Flags, p.sources)
+	p.applyVerbosity()
+	if exec && p.profiling {
+		stop, perr := p.startProfiling()
+		if perr != nil {
+			return 0, perr
+		}
+		defer func() {
+			if serr := stop(); err == nil {
+				err = serr
+			}
+		}()
+	}
+	tr := p.tracer()
+	if tr != nil {
+		fmt.Fprintf(tr, "trace: path %q: running the root command\n", p.Flags.Name())
+		traceFlags(tr, "", p.Flags, p.sources, nil)
+	}
+	return p.runLeaf(ctx, cont, rest, globals, exec, tr)
+}
+
+// Writes the usage of the root command of p followed by a blank line,
+// if p has one.
+func (p *Path) writeRootUsage(w io.Writer) {
+	if p.rootCmd == nil {
+		return
+	}
+	p.writeHelp(w, p.rootCmd)
+	fmt.Fprintln(w)
+}
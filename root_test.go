@@ -0,0 +1,134 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newRootPath() (p *Path, format *string, root, status *[]string) {
+	p = NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	format = new(string)
+	root, status = new([]string), new([]string)
+	p.SetRoot(&testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(format, "status-format", "text", "format of the status")
+		},
+		run: func(args ...string) error {
+			*root = append(args, "ran")
+			return nil
+		},
+	})
+	p.Add("status", "prints the status", &testCmd{run: func(args ...string) error {
+		*status = append(args, "ran")
+		return nil
+	}})
+	return p, format, root, status
+}
+
+func TestRoot(t *testing.T) {
+	for _, test := range []struct {
+		args         []string
+		root, status []string
+		format       string
+		verbose      bool
+	}{
+		{args: nil, root: []string{"ran"}, format: "text"},
+		{args: []string{"--status-format", "json"}, root: []string{"ran"}, format: "json"},
+		{args: []string{"-verbose", "--status-format=json", "-verbose"}, root: []string{"ran"}, format: "json", verbose: true},
+		{args: []string{"-verbose"}, root: []string{"ran"}, format: "text", verbose: true},
+		{args: []string{"-verbose", "file"}, root: []string{"file", "ran"}, format: "text", verbose: true},
+		// the flags of the root aren't registered
+		{args: []string{"status"}, status: []string{"ran"}},
+		{args: []string{"-verbose", "status", "x"}, status: []string{"x", "ran"}, verbose: true},
+		{args: []string{"--", "status"}, status: []string{"ran"}},
+	} {
+		p, format, root, status := newRootPath()
+		if _, err := p.Run(test.args...); err != nil {
+			t.Errorf("Run(%q) failed: %v", test.args, err)
+			continue
+		}
+		if strings.Join(*root, ",") != strings.Join(test.root, ",") || strings.Join(*status, ",") != strings.Join(test.status, ",") {
+			t.Errorf("Run(%q) should run the root with %q and status with %q but ran them with %q and %q.", test.args, test.root, test.status, *root, *status)
+		}
+		if *format != test.format {
+			t.Errorf("Run(%q) should set the format %q but set %q.", test.args, test.format, *format)
+		}
+		if verbose := p.Flags.Lookup("verbose").Value.String() == "true"; verbose != test.verbose {
+			t.Errorf("Run(%q) should set -verbose to %v but set it to %v.", test.args, test.verbose, verbose)
+		}
+	}
+}
+
+func TestRootErrors(t *testing.T) {
+	p, _, _, _ := newRootPath()
+	if _, err := p.Run("destroy"); !errors.Is(err, ErrNoSuchCmd) {
+		t.Errorf("An unknown command should not run the root but failed with %v.", err)
+	}
+	if _, err := p.Run("--status", "json"); err == nil || !strings.Contains(err.Error(), "flag provided but not defined: -status") {
+		t.Errorf("An unknown flag should fail but failed with %v.", err)
+	}
+	if _, err := p.Run("status", "--status-format", "json"); err == nil {
+		t.Error("The flags of the root should not be accepted by commands.")
+	}
+
+	q := NewPath()
+	q.Add("status", "prints the status", &testCmd{})
+	q.SetRoot(&testCmd{flags: func(fs *flag.FlagSet) {
+		fs.String("env", "", "target environment")
+	}}, "env")
+	if _, err := q.Run(); err == nil || err.Error() != "required flags not set: --env" {
+		t.Errorf("The required flags of the root should be checked but the error was %v.", err)
+	}
+}
+
+func TestRootHelp(t *testing.T) {
+	p, _, _, _ := newRootPath()
+	var out strings.Builder
+	p.writeAvailableCommands(&out)
+	want := "Usage: [flags]\n" +
+		"\n" +
+		"Flags:\n" +
+		"  --status-format string\n" +
+		"    \tformat of the status (default \"text\")\n" +
+		"\n" +
+		"Available commands:\n" +
+		"\tstatus\tprints the status\n"
+	if out.String() != want {
+		t.Errorf("The help should be %q but was %q.", want, out.String())
+	}
+}
+
+func TestRootMounted(t *testing.T) {
+	var ran bool
+	p := NewPath()
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	c := remote.SetRoot(&testCmd{run: func(args ...string) error {
+		ran = true
+		return nil
+	}})
+	p.Mount("remote", "manages remotes", remote)
+	if _, err := p.Run("remote"); err != nil || !ran {
+		t.Errorf("The root of the mounted path should run but failed with %v.", err)
+	}
+	if got := c.CommandPath(); got != "remote" {
+		t.Errorf("The command path of the root should be %q but was %q.", "remote", got)
+	}
+}
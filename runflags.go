@@ -0,0 +1,106 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Runs the named command, such as "deploy" or "remote add" for a
+// command of a mounted path, for programs embedding the commands,
+// without assembling a command line. The command is run like with Run,
+// with its flags set to the values in flags, which are passed to Set
+// of the flag, and the positional arguments args, which are never
+// parsed as flags. flags may also set global and persistent flags
+// visible to the command. An unknown flag or a value Set rejects fails
+// with a FlagParseError naming the flag, several of them with all of
+// these errors joined. Required flags, validators, requirements and
+// declared arguments are checked as for Run, and flags not in flags
+// keep their defaults, even if an earlier invocation set them.
+func (p *Path) RunWithFlags(name string, flags map[string]string, args []string) (*CmdCont, error) {
+	return p.RunWithFlagsContext(context.Background(), name, flags, args)
+}
+
+// Same as RunWithFlags but runs the command like RunContext.
+func (p *Path) RunWithFlagsContext(ctx context.Context, name string, flags map[string]string, args []string) (*CmdCont, error) {
+	if c := p.lookupPath(name); c != nil && c.sub != nil {
+		return nil, fmt.Errorf("%q is a mounted path, not a command", name)
+	}
+	words := strings.Fields(name)
+	ctx = context.WithValue(ctx, flagValuesKey{}, &flagValues{values: flags})
+	p.beginDispatch()
+	defer p.endDispatch()
+	return p.run(ctx, append(words, args...), nil, nil, true)
+}
+
+// The key of the context of RunWithFlags, holding the flag values.
+type flagValuesKey struct{}
+
+type flagValues struct {
+	values map[string]string
+	// set once the values were applied, commands run by the command
+	// parse their arguments as usual
+	taken bool
+}
+
+// Returns the flag values passed to RunWithFlags with ctx if they
+// weren't applied to a command yet. ok is false otherwise.
+func takeFlagValues(ctx context.Context) (values map[string]string, ok bool) {
+	v, _ := ctx.Value(flagValuesKey{}).(*flagValues)
+	if v == nil || v.taken {
+		return nil, false
+	}
+	v.taken = true
+	return v.values, true
+}
+
+// Sets the flags of c or of the inherited flag sets to values, in the
+// order of their names.
+func (p *Path) setFlagValues(c *CmdCont, values map[string]string, inherited []*flag.FlagSet) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	forwarded := false
+	for _, name := range names {
+		value := values[name]
+		fs := c.FlagSet()
+		if fs.Lookup(name) == nil {
+			if fs = lookupInherited(inherited, name); fs == nil {
+				err := suggestFlags(fmt.Errorf("flag provided but not defined: -%s", name), append([]*flag.FlagSet{c.FlagSet()}, inherited...), c.hideFlag)
+				errs = append(errs, newFlagParseError(c.Name, err))
+				continue
+			}
+			forwarded = true
+		}
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, newFlagParseError(c.Name, fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)))
+		}
+	}
+	if forwarded {
+		p.markForwardedFlags()
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
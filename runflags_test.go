@@ -0,0 +1,132 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func newInvokePath(opts *deployOpts, args *[]string) *Path {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			opts.env = fs.String("env", "dev", "target environment")
+			opts.target = fs.String("target", "", "deployment target")
+			opts.port = fs.Int("port", 80, "port to listen on")
+		},
+		run: func(a ...string) error {
+			*args = a
+			return nil
+		},
+	}, "target")
+	c.FlagAlias("port", "p")
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("name", "origin", "remote name")
+		},
+	})
+	p.Mount("remote", "manages remotes", remote)
+	return p
+}
+
+func TestRunWithFlags(t *testing.T) {
+	var opts deployOpts
+	var args []string
+	p := newInvokePath(&opts, &args)
+	flags := map[string]string{"env": "prod", "target": "eu", "p": "8080", "verbose": "true"}
+	c, err := p.RunWithFlags("deploy", flags, []string{"-x", "--", "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "prod" || *opts.target != "eu" || *opts.port != 8080 {
+		t.Errorf("RunWithFlags should set the flags but set -env %q, -target %q and -port %d.", *opts.env, *opts.target, *opts.port)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("RunWithFlags should set the global flag -verbose but it was %q.", v)
+	}
+	if want := []string{"-x", "--", "app"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Arguments should be %q but were %q.", want, args)
+	}
+	if want := []string{"app"}; !reflect.DeepEqual(c.PassthroughArgs(), want) {
+		t.Errorf("Passthrough arguments should be %q but were %q.", want, c.PassthroughArgs())
+	}
+	if c.ValueSource("env") != SourceCLI {
+		t.Errorf("-env should come from %v but came from %v.", SourceCLI, c.ValueSource("env"))
+	}
+
+	if _, err := p.RunWithFlags("deploy", map[string]string{"target": "us"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *opts.env != "dev" || *opts.port != 80 || len(args) != 0 {
+		t.Errorf("A second RunWithFlags should start from the defaults but had -env %q, -port %d and arguments %q.", *opts.env, *opts.port, args)
+	}
+
+	if _, err := p.RunWithFlags("remote add", map[string]string{"name": "upstream"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.lookupPath("remote add").Flags.Lookup("name").Value.String(); v != "upstream" {
+		t.Errorf("-name should be %q but was %q.", "upstream", v)
+	}
+	if _, err := p.RunWithFlags("remote", nil, nil); err == nil {
+		t.Error("RunWithFlags should fail for a mounted path.")
+	}
+}
+
+func TestRunWithFlagsBadValues(t *testing.T) {
+	var opts deployOpts
+	var args []string
+	p := newInvokePath(&opts, &args)
+	_, err := p.RunWithFlags("deploy", map[string]string{"target": "eu", "port": "http"}, nil)
+	var fe *FlagParseError
+	if !errors.As(err, &fe) || fe.Flag != "port" {
+		t.Fatalf("RunWithFlags should fail with a FlagParseError for -port but returned %v.", err)
+	}
+	if want := `invalid value "http" for flag -port: parse error`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if args != nil {
+		t.Error("The command should not run with an invalid flag value.")
+	}
+
+	_, err = p.RunWithFlags("deploy", map[string]string{"port": "http", "prot": "1", "verbose": "maybe"}, nil)
+	var flags []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		if errors.As(e, &fe) {
+			flags = append(flags, fe.Flag)
+		}
+	}
+	if want := []string{"port", "prot", "verbose"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("The errors should name the flags %q but named %q in %q.", want, flags, err)
+	}
+}
+
+func TestRunWithFlagsMissingRequired(t *testing.T) {
+	var opts deployOpts
+	var args []string
+	p := newInvokePath(&opts, &args)
+	_, err := p.RunWithFlags("deploy", map[string]string{"env": "prod"}, []string{"app"})
+	var me *MissingFlagsError
+	if !errors.As(err, &me) || !reflect.DeepEqual(me.Flags, []string{"target"}) {
+		t.Errorf("RunWithFlags should fail with a MissingFlagsError for -target but returned %v.", err)
+	}
+	if args != nil {
+		t.Error("The command should not run with a required flag missing.")
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Replaces the values of secret flags.
+const redacted = "****"
+
+// Marks the named flag as secret, such as a password. Its default is
+// shown as **** in the help output and generated documentation, and
+// its value is redacted from the errors returned by Run and from the
+// messages printed while parsing. The command sees the actual value.
+func (c *CmdCont) MarkSecret(name string) error {
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.secret == nil {
+		c.secret = make(map[string]bool)
+	}
+	c.secret[canonicalFlag(c.FlagSet(), f).Name] = true
+	c.changed()
+	return nil
+}
+
+func (c *CmdCont) isSecret(f *flag.Flag) bool {
+	return c.secret[canonicalFlag(c.FlagSet(), f).Name]
+}
+
+// Reports if f is a global flag of p marked secret on the command p is
+// mounted as.
+func (p *Path) isSecret(f *flag.Flag) bool {
+	return p.mount != nil && p.mount.isSecret(f)
+}
+
+// Parses args with the messages of the flag package redacted, see
+// Path.parseFlagSet.
+func (c *CmdCont) parseFlags(p *Path, args []string) error {
+	if len(c.secret) > 0 {
+		out := c.FlagSet().Output()
+		c.FlagSet().SetOutput(&redactWriter{w: out, secrets: c.secretValues(args)})
+		defer c.FlagSet().SetOutput(out)
+	}
+	return p.parseFlagSet(c.FlagSet(), args)
+}
+
+// The value of a secret flag and the name it was given with.
+type secretValue struct {
+	flag  string
+	value string
+}
+
+// Values shorter than this are only redacted where they are given as the
+// value of their flag or quoted, as in the messages of the flag package,
+// so that a secret such as "e" doesn't mangle the rest of the text.
+const minRedactLen = 4
+
+// Returns the values of secret flags, given in args or currently set.
+func (c *CmdCont) secretValues(args []string) []secretValue {
+	var values []secretValue
+	add := func(name, v string) {
+		if v != "" {
+			values = append(values, secretValue{flag: name, value: v})
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, hasValue := "", false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		f := c.FlagSet().Lookup(name)
+		if f == nil {
+			continue
+		}
+		if !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if c.isSecret(f) {
+			add(name, value)
+		}
+	}
+	c.FlagSet().VisitAll(func(f *flag.Flag) {
+		if c.isSecret(f) {
+			add(f.Name, f.Value.String())
+		}
+	})
+	return values
+}
+
+// Returns err with the values of secret flags in args redacted from its
+// message. Errors containing a secret are replaced rather than wrapped,
+// so that errors.As doesn't return the secret: ArgErrors and
+// FlagParseErrors are copied with a redacted value and cause, other
+// errors are replaced by one with the redacted message, which still
+// matches ErrCmdUsage if err did.
+func (c *CmdCont) redactError(err error, args []string) error {
+	if len(c.secret) == 0 {
+		return err
+	}
+	return redactErr(err, c.secretValues(c.normalizeArgs(args)))
+}
+
+func redactErr(err error, secrets []secretValue) error {
+	msg := redact(err.Error(), secrets)
+	if msg == err.Error() {
+		return err
+	}
+	switch e := err.(type) {
+	case *ArgError:
+		r := *e
+		r.Value = redactValue(e.Value, secrets)
+		r.Err = redactErr(e.Err, secrets)
+		return &r
+	case *FlagParseError:
+		r := *e
+		r.Err = redactErr(e.Err, secrets)
+		return &r
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, redactErr(err, secrets))
+		}
+		return errors.Join(errs...)
+	}
+	return &redactedError{msg: msg, usage: errors.Is(err, ErrCmdUsage)}
+}
+
+type redactedError struct {
+	msg   string
+	usage bool
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Is(target error) bool {
+	return e.usage && target == ErrCmdUsage
+}
+
+// Returns the value v of a flag with secrets redacted.
+func redactValue(v string, secrets []secretValue) string {
+	for _, secret := range secrets {
+		if v == secret.value {
+			return redacted
+		}
+	}
+	return redact(v, secrets)
+}
+
+// Redacts the secrets from s: the values following their flags, as in
+// "-password=x" and "-password x", quoted values and, unless they are
+// shorter than minRedactLen, the values found as a word of s.
+func redact(s string, secrets []secretValue) string {
+	for _, secret := range secrets {
+		v := secret.value
+		s = strings.Replace(s, strconv.Quote(v), strconv.Quote(redacted), -1)
+		for _, sep := range []string{"=", " "} {
+			prefix := "-" + secret.flag + sep
+			s = replaceWord(s, prefix+v, prefix+redacted, false)
+		}
+		if len(v) >= minRedactLen {
+			s = replaceWord(s, v, redacted, true)
+		}
+	}
+	return s
+}
+
+// Replaces old in s by new where it is followed by a word boundary
+// and, if start is set, preceded by one.
+func replaceWord(s, old, new string, start bool) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			break
+		}
+		end := i + len(old)
+		if (end == len(s) || isWordBoundary(s[end])) && (!start || i == 0 || isWordBoundary(s[i-1])) {
+			b.WriteString(s[:i])
+			b.WriteString(new)
+		} else {
+			b.WriteString(s[:i+1])
+			end = i + 1
+		}
+		s = s[end:]
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+func isWordBoundary(c byte) bool {
+	return strings.IndexByte(" \t\n\"'=,;:()[]", c) >= 0
+}
+
+// Redacts secrets from the messages written to w. Each message must be
+// passed to a single Write.
+type redactWriter struct {
+	w       io.Writer
+	secrets []secretValue
+}
+
+func (r *redactWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(r.w, redact(string(b), r.secrets)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newSecretPath(password *string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("login", "logs in", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(password, "password", "hunter2", "account password")
+			fs.Int("pin", 0, "account pin")
+			fs.String("user", "", "account name")
+		},
+	})
+	c.MarkSecret("password")
+	c.MarkSecret("pin")
+	return p, c
+}
+
+func TestSecretHelp(t *testing.T) {
+	var password string
+	p, c := newSecretPath(&password)
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	p.WriteMarkdown(&out, "app")
+	p.WriteMan(&out, "app")
+	if strings.Contains(out.String(), "hunter2") {
+		t.Errorf("Help should not contain the secret:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "(default ****)") {
+		t.Errorf("Help should show a redacted default:\n%s", out.String())
+	}
+}
+
+func TestSecretErrors(t *testing.T) {
+	var password string
+	p, c := newSecretPath(&password)
+	c.ValidateFlag("password", func(string) error {
+		return errors.New("too short")
+	})
+	_, err := p.Run("login", "--password", "s3cr3t-value")
+	if err == nil {
+		t.Fatal("Run should fail validation.")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-value") || !strings.Contains(err.Error(), "****") {
+		t.Errorf("The error should redact the secret but was %q.", err)
+	}
+	if password != "s3cr3t-value" {
+		t.Errorf("password should be %q but was %q.", "s3cr3t-value", password)
+	}
+
+	var out strings.Builder
+	c.Flags.SetOutput(&out)
+	_, err = p.Run("login", "--pin=98x76")
+	if err == nil {
+		t.Fatal("Run should fail to parse the pin.")
+	}
+	for _, s := range []string{err.Error(), out.String()} {
+		if strings.Contains(s, "98x76") {
+			t.Errorf("Parse errors should redact the secret but was %q.", s)
+		}
+	}
+	if c.Flags.Output() != &out {
+		t.Error("The output of the flags should be restored after parsing.")
+	}
+}
+
+func TestSecretUnknownFlag(t *testing.T) {
+	var password string
+	_, c := newSecretPath(&password)
+	if err := c.MarkSecret("token"); err == nil {
+		t.Error("Marking an unknown flag secret should fail.")
+	}
+}
+
+func TestSecretShortValue(t *testing.T) {
+	var password string
+	p, c := newSecretPath(&password)
+	c.ValidateFlag("password", func(v string) error {
+		return fmt.Errorf("%q is too short, see the user guide", v)
+	})
+	_, err := p.Run("login", "-password", "e")
+	want := `invalid value "****" for flag -password: "****" is too short, see the user guide`
+	if err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	var ae *ArgError
+	if !errors.As(err, &ae) || ae.Value != redacted {
+		t.Errorf("The ArgError should carry the redacted value but was %#v.", ae)
+	}
+
+	var out strings.Builder
+	c.Flags.SetOutput(&out)
+	_, err = p.Run("login", "-pin", "x")
+	want = `invalid value "****" for flag -pin: parse error`
+	if err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if !errors.Is(err, ErrCmdUsage) {
+		t.Errorf("Error should still match ErrCmdUsage but was %v.", err)
+	}
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("Output should contain %q but was %q.", want, out.String())
+	}
+}
+
+func TestRedact(t *testing.T) {
+	secrets := []secretValue{{flag: "password", value: "e"}, {flag: "token", value: "s3cr3t"}}
+	for _, tt := range []struct{ in, want string }{
+		{"-password=e -password e", "-password=**** -password ****"},
+		{"-password eagle", "-password eagle"},
+		{`invalid value "e" for flag -password: parse error`, `invalid value "****" for flag -password: parse error`},
+		{"token s3cr3t, not s3cr3ts", "token ****, not s3cr3ts"},
+	} {
+		if got := redact(tt.in, secrets); got != tt.want {
+			t.Errorf("Redacted %q should be %q but was %q.", tt.in, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Runs the commands read line by line from in until the end of the
+// input or a line consisting of exit or quit. Before each line prompt
+// is written to out. The lines are split into arguments with SplitArgs
+// and run like by Run, errors are written to out like by Execute
+// without ending the shell. Empty lines are ignored. The flags are
+// reset after every line, so no values leak into the next one, see
+// ResetFlags. Registered commands named exit or quit take precedence.
+func (p *Path) Shell(in io.Reader, out io.Writer, prompt string) error {
+	s := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, prompt)
+		if !s.Scan() {
+			return s.Err()
+		}
+		if p.shellLine(out, s.Text(), nil) {
+			return nil
+		}
+	}
+}
+
+// Runs the command line of a shell and reports whether it ends the
+// shell. The line is added to h unless h is nil, the line doesn't
+// match a command or it sets a secret flag; the history built-in lists
+// and clears h.
+func (p *Path) shellLine(out io.Writer, line string, h *shellHistory) bool {
+	args, err := SplitArgs(line)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+	if len(args) == 1 && (args[0] == "exit" || args[0] == "quit") && p.registry().entries[args[0]] == nil {
+		return true
+	}
+	if h != nil && args[0] == "history" && p.registry().entries["history"] == nil {
+		h.command(out, args[1:])
+		return false
+	}
+	defer p.ResetFlags()
+	c, err := p.Run(args...)
+	p.writeError(out, c, err)
+	if h != nil && c != nil && !c.setsSecret(args) {
+		if err := h.add(line); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+	return false
+}
+
+// The terminal ShellInteractive edits the command lines on.
+type RawTerminal interface {
+	// Reads the keys typed, or the lines if the terminal can't be
+	// switched to raw mode.
+	io.Reader
+	io.Writer
+	// Switches the terminal to raw mode, in which keys are neither
+	// echoed nor interpreted, and returns a func restoring the previous
+	// mode. Fails with an error if the input isn't a terminal.
+	MakeRaw() (func(), error)
+}
+
+// Options for ShellInteractive.
+type ShellOptions struct {
+	// written before each line
+	Prompt string
+	// os.Stdin and os.Stdout if nil
+	Terminal RawTerminal
+	// The file the history of command lines is loaded from and each
+	// line run is appended to, such as DefaultHistoryFile. Lines not
+	// matching a command or setting a secret flag aren't added. There
+	// is no history across sessions if empty.
+	HistoryFile string
+	// The number of lines kept in HistoryFile, 1000 if not positive.
+	HistorySize int
+}
+
+// Same as Shell but edits the lines on a terminal: Tab completes
+// command names, flags and their values like Complete, the keys of
+// readline's Emacs mode move the cursor, edit the line and walk the
+// history, Ctrl-C discards the current line and Ctrl-D on an empty line
+// ends the shell. The terminal is in raw mode only while lines are
+// edited. If the input isn't a terminal or ForceInteractive(false) is
+// set, ShellInteractive falls back to Shell.
+//
+// With a HistoryFile the history is kept across sessions, and the
+// built-in history lists it and history clear clears it, unless a
+// command of that name is registered. The file is created with mode
+// 0600.
+func (p *Path) ShellInteractive(opts ShellOptions) error {
+	t := opts.Terminal
+	if t == nil {
+		t = &rawStdTerminal{in: os.Stdin, out: os.Stdout}
+	}
+	if forced, ok := p.forcedInteractive(); ok && !forced {
+		return p.Shell(t, t, opts.Prompt)
+	}
+	var h *shellHistory
+	if opts.HistoryFile != "" {
+		var err error
+		if h, err = loadHistory(opts.HistoryFile, opts.HistorySize); err != nil {
+			return err
+		}
+	}
+	restore, err := t.MakeRaw()
+	if err != nil {
+		return p.Shell(t, t, opts.Prompt)
+	}
+	e := &lineEditor{r: t, w: t, prompt: opts.Prompt, complete: p.completeLine}
+	if h != nil {
+		e.history = append(e.history, h.entries...)
+	}
+	for {
+		line, err := e.readLine()
+		restore()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if p.shellLine(t, line, h) {
+			return nil
+		}
+		if h != nil {
+			// drops lines left out of the history and cleared ones
+			e.history = append(e.history[:0], h.entries...)
+		}
+		if restore, err = t.MakeRaw(); err != nil {
+			return err
+		}
+	}
+}
+
+// Returns the byte offset in line where the word being completed
+// starts and the candidates for it, see Complete. A line ending with a
+// space completes a new word.
+func (p *Path) completeLine(line string) (int, []string) {
+	start := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '\'' && c != '\'':
+		case c == '\\' && quote != '\'':
+			i++
+		case c == quote:
+			quote = 0
+		case quote == '"':
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ' ' || c == '\t':
+			start = i + 1
+		}
+	}
+	args, err := SplitArgs(line[:start])
+	if err != nil {
+		return 0, nil
+	}
+	return start, p.Complete(append(args, unquoteWord(line[start:]))...)
+}
+
+// Reads keys from os.Stdin and writes to os.Stdout.
+type rawStdTerminal struct {
+	in  *os.File
+	out *os.File
+}
+
+func (t *rawStdTerminal) Read(b []byte) (int, error) {
+	return t.in.Read(b)
+}
+
+func (t *rawStdTerminal) Write(b []byte) (int, error) {
+	return t.out.Write(b)
+}
+
+func (t *rawStdTerminal) MakeRaw() (func(), error) {
+	return makeRaw(t.in.Fd())
+}
@@ -0,0 +1,171 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShell(t *testing.T) {
+	p := NewPath()
+	var envs []string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+			fs.Bool("force", false, "skip the checks")
+		},
+		run: func(args ...string) error {
+			envs = append(envs, p.registry().entries["deploy"].Flags.Lookup("env").Value.String()+" "+
+				p.registry().entries["deploy"].Flags.Lookup("force").Value.String()+" "+strings.Join(args, ","))
+			return nil
+		},
+	}, "env")
+	in := strings.NewReader(`deploy -env prod -force "my app"
+
+deploy
+destroy
+deploy -env 'dev
+deploy -env dev
+quit
+deploy -env never
+`)
+	var out strings.Builder
+	if err := p.Shell(in, &out, "> "); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prod true my app", "dev false "}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("Runs should be %q but were %q.", want, envs)
+	}
+	for _, want := range []string{
+		"> > > deploy: required flags not set: --env\n",
+		"  --env string  target environment (required)\n",
+		"> No such command \"destroy\".\n",
+		"> unterminated ' quote at offset 12\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output should contain %q but was %q.", want, out.String())
+		}
+	}
+	if !strings.HasSuffix(out.String(), "> > ") {
+		t.Errorf("Output should end after quit but was %q.", out.String())
+	}
+}
+
+func TestShellEOF(t *testing.T) {
+	p := NewPath()
+	runs := 0
+	p.Add("exit", "exits the app", &testCmd{
+		run: func(...string) error {
+			runs++
+			return nil
+		},
+	})
+	var out strings.Builder
+	if err := p.Shell(strings.NewReader("exit\nexit"), &out, "$ "); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 2 {
+		t.Errorf("Registered exit command should run 2 times but ran %d times.", runs)
+	}
+	if out.String() != "$ $ $ " {
+		t.Errorf("Output should be %q but was %q.", "$ $ $ ", out.String())
+	}
+}
+
+func TestShellCompleteLine(t *testing.T) {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+	})
+	c.FlagChoices("env", "prod", "my env")
+	p.Add("destroy", "destroys the app", &testCmd{})
+	for _, test := range []struct {
+		line       string
+		start      int
+		candidates []string
+	}{
+		{"", 0, []string{"deploy", "destroy"}},
+		{"de", 0, []string{"deploy", "destroy"}},
+		{"-verbose dep", 9, []string{"deploy"}},
+		{"deploy -", 7, []string{"--env"}},
+		{"deploy -env ", 12, []string{"my env", "prod"}},
+		{"deploy -env 'my", 12, []string{"my env"}},
+		{`deploy -env my\ `, 12, []string{"my env"}},
+		{"destroy x ", 10, nil},
+	} {
+		start, candidates := p.completeLine(test.line)
+		if start != test.start || !reflect.DeepEqual(candidates, test.candidates) {
+			t.Errorf("Completion of %q should be %d, %q but was %d, %q.", test.line, test.start, test.candidates, start, candidates)
+		}
+	}
+}
+
+// A RawTerminal reading keys from a string.
+type testRawTerminal struct {
+	io.Reader
+	strings.Builder
+	raw, restored int
+	err           error
+}
+
+func (t *testRawTerminal) MakeRaw() (func(), error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	t.raw++
+	return func() { t.restored++ }, nil
+}
+
+func TestShellInteractive(t *testing.T) {
+	p := NewPath()
+	var envs []string
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+		run: func(args ...string) error {
+			envs = append(envs, p.registry().entries["deploy"].Flags.Lookup("env").Value.String())
+			return nil
+		},
+	})
+	term := &testRawTerminal{Reader: strings.NewReader("dep\t--e\tprod\rdestroy\x03dep\t\r\x04")}
+	if err := p.ShellInteractive(ShellOptions{Prompt: "> ", Terminal: term}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prod", ""}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("Runs should be %q but were %q.", want, envs)
+	}
+	if term.raw != 3 || term.restored != 3 {
+		t.Errorf("Terminal should be switched to raw mode and back 3 times but was %d and %d times.", term.raw, term.restored)
+	}
+	if !strings.Contains(term.String(), "destroy\x1b[K^C\n> ") {
+		t.Errorf("Ctrl-C should discard the line but output was %q.", term.String())
+	}
+
+	term = &testRawTerminal{Reader: strings.NewReader("deploy -env dev\nexit\n"), err: errNoTerminal}
+	if err := p.ShellInteractive(ShellOptions{Prompt: "> ", Terminal: term}); err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 3 || envs[2] != "dev" {
+		t.Errorf("Shell should fall back to reading lines but ran %q.", envs)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Enables getopt style clusters of single character flags, so -xvf file
+// means -x -v -f file. Every character of a cluster must be a single
+// character flag or alias of the command, or a global or persistent
+// flag visible to it. A flag taking a value ends the cluster, the rest
+// of the cluster or else the next argument is its value, as in -ofile
+// and -o file. Flags registered with a longer name, such as -verbose,
+// and flags starting with -- are left untouched.
+func (c *CmdCont) SetCombinedShorts(combine bool) {
+	c.combineShorts = combine
+}
+
+// Splits the clusters of single character flags in args into separate
+// flags if enabled for c.
+func (c *CmdCont) splitShorts(args []string, inherited []*flag.FlagSet) ([]string, error) {
+	if !c.combineShorts {
+		return args, nil
+	}
+	lookup := func(name string) *flag.Flag {
+		if f := c.FlagSet().Lookup(name); f != nil {
+			return f
+		}
+		if fs := lookupInherited(inherited, name); fs != nil {
+			return fs.Lookup(name)
+		}
+		return nil
+	}
+	var split []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(split, args[i:]...), nil
+		}
+		name := strings.TrimLeft(arg, "-")
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name = name[:j]
+		}
+		if f := lookup(name); f != nil || strings.HasPrefix(arg, "--") || len([]rune(name)) < 2 {
+			split = append(split, arg)
+			if f != nil && !strings.Contains(arg, "=") && !isBoolFlag(f) && i+1 < len(args) {
+				// skip the value of the flag
+				i++
+				split = append(split, args[i])
+			}
+			continue
+		}
+		cluster := arg[1:]
+		for j, r := range cluster {
+			f := lookup(string(r))
+			if f == nil {
+				return nil, fmt.Errorf("flag provided but not defined: -%c in -%s", r, cluster)
+			}
+			rest := cluster[j+len(string(r)):]
+			if isBoolFlag(f) {
+				if !strings.HasPrefix(rest, "=") {
+					split = append(split, "-"+string(r))
+					continue
+				}
+				split = append(split, "-"+string(r)+rest)
+			} else if rest != "" {
+				split = append(split, "-"+string(r), rest)
+			} else if i+1 < len(args) {
+				i++
+				split = append(split, "-"+string(r), args[i])
+			} else {
+				return nil, fmt.Errorf("flag needs an argument: -%c in -%s", r, cluster)
+			}
+			break
+		}
+	}
+	return split, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tarOpts struct {
+	extract bool
+	verbose int
+	file    string
+	output  string
+	debug   bool
+	args    []string
+}
+
+func newTarPath(o *tarOpts) (*Path, *CmdCont) {
+	p := NewPath()
+	p.PersistentFlags().BoolVar(&o.debug, "d", false, "debug output")
+	c := p.Add("tar", "handles archives", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&o.extract, "x", false, "extract")
+			CountVar(fs, &o.verbose, "verbose", "verbose output")
+			fs.StringVar(&o.file, "f", "", "archive `FILE`")
+			fs.StringVar(&o.output, "output", "", "output directory")
+		},
+		run: func(args ...string) error {
+			if len(args) > 0 {
+				o.args = args
+			}
+			return nil
+		},
+	})
+	c.FlagAlias("verbose", "v")
+	c.FlagAlias("output", "o")
+	c.Flags.SetOutput(new(strings.Builder))
+	c.SetCombinedShorts(true)
+	return p, c
+}
+
+func TestCombinedShorts(t *testing.T) {
+	tests := []struct {
+		args []string
+		want tarOpts
+	}{
+		{[]string{"-xvf", "a.tar"}, tarOpts{extract: true, verbose: 1, file: "a.tar"}},
+		{[]string{"-xvfa.tar"}, tarOpts{extract: true, verbose: 1, file: "a.tar"}},
+		{[]string{"-vvv"}, tarOpts{verbose: 3}},
+		{[]string{"-xv", "-f", "a.tar", "rest"}, tarOpts{extract: true, verbose: 1, file: "a.tar", args: []string{"rest"}}},
+		{[]string{"-of", "out"}, tarOpts{output: "f", args: []string{"out"}}},
+		{[]string{"-xo", "-f"}, tarOpts{extract: true, output: "-f"}},
+		{[]string{"-fo"}, tarOpts{file: "o"}},
+		{[]string{"-vx=false"}, tarOpts{verbose: 1}},
+		{[]string{"-xd"}, tarOpts{extract: true, debug: true}},
+		{[]string{"-verbose", "-f=a.tar"}, tarOpts{verbose: 1, file: "a.tar"}},
+		{[]string{"--verbose", "--output", "-xv"}, tarOpts{verbose: 1, output: "-xv"}},
+		{[]string{"-f", "-xv"}, tarOpts{file: "-xv"}},
+		{[]string{"-x", "--", "-vf"}, tarOpts{extract: true, args: []string{"-vf"}}},
+		{[]string{"-x", "file", "-vf"}, tarOpts{extract: true, args: []string{"file", "-vf"}}},
+	}
+	for _, test := range tests {
+		var o tarOpts
+		p, _ := newTarPath(&o)
+		if _, err := p.Run(append([]string{"tar"}, test.args...)...); err != nil {
+			t.Errorf("%q should parse but failed: %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(o, test.want) {
+			t.Errorf("%q should parse to %+v but was %+v.", test.args, test.want, o)
+		}
+	}
+}
+
+func TestCombinedShortsErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-xzf", "a.tar"}, "flag provided but not defined: -z in -xzf"},
+		{[]string{"-xvf"}, "flag needs an argument: -f in -xvf"},
+		{[]string{"-xé"}, "flag provided but not defined: -é in -xé"},
+	}
+	for _, test := range tests {
+		var o tarOpts
+		p, _ := newTarPath(&o)
+		_, err := p.Run(append([]string{"tar"}, test.args...)...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q should fail with %q but was %v.", test.args, test.err, err)
+		}
+	}
+}
+
+func TestCombinedShortsDisabled(t *testing.T) {
+	var o tarOpts
+	p, c := newTarPath(&o)
+	c.SetCombinedShorts(false)
+	if _, err := p.Run("tar", "-xv"); err == nil {
+		t.Error("Clusters should not be split by default.")
+	}
+}
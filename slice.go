@@ -0,0 +1,290 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defines a []string flag with specified name and usage string. The
+// argument p points to a []string variable in which to store the
+// values of the flag; its initial contents are the default. Every
+// occurrence of the flag appends to the slice, replacing the default
+// on the first one. A single occurrence may hold several values
+// separated by commas.
+func StringSliceVar(fs *flag.FlagSet, p *[]string, name, usage string) {
+	StringSliceVarSep(fs, p, name, ",", usage)
+}
+
+// Same as StringSliceVar but splits values on sep instead of commas,
+// for values that legitimately contain commas; String joins them with
+// sep. Values not containing newlines, for example, are kept whole
+// with sep "\n". Panics if sep is empty, as String wouldn't round-trip.
+func StringSliceVarSep(fs *flag.FlagSet, p *[]string, name, sep, usage string) {
+	if sep == "" {
+		panic("command: separator of flag -" + name + " must not be empty")
+	}
+	fs.Var(&stringSliceValue{p: p, sep: sep, def: append([]string(nil), *p...)}, name, usage)
+}
+
+// Defines a []string flag like StringSliceVar and returns the address
+// of the slice storing its values.
+func StringSlice(fs *flag.FlagSet, name, usage string) *[]string {
+	p := new([]string)
+	StringSliceVar(fs, p, name, usage)
+	return p
+}
+
+type stringSliceValue struct {
+	p   *[]string
+	sep string
+	set bool
+	// the initial contents of p
+	def []string
+}
+
+func (v *stringSliceValue) Set(s string) error {
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, strings.Split(s, v.sep)...)
+	return nil
+}
+
+func (v *stringSliceValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(*v.p, v.sep)
+}
+
+func (v *stringSliceValue) Get() interface{} {
+	return *v.p
+}
+
+func (v *stringSliceValue) IsRepeatable() bool {
+	return true
+}
+
+func (v *stringSliceValue) Reset() {
+	*v.p = append([]string(nil), v.def...)
+	v.set = false
+}
+
+func (v *stringSliceValue) snapshot() func() {
+	values, set := append([]string(nil), *v.p...), v.set
+	return func() {
+		*v.p, v.set = append([]string(nil), values...), set
+	}
+}
+
+// Defines a []int flag with specified name and usage string, which
+// accepts repeated occurrences and comma separated lists like
+// StringSliceVar. Each element is parsed like an int flag.
+func IntSliceVar(fs *flag.FlagSet, p *[]int, name, usage string) {
+	fs.Var(&intSliceValue{p: p, def: append([]int(nil), *p...)}, name, usage)
+}
+
+// Defines a []int64 flag like IntSliceVar.
+func Int64SliceVar(fs *flag.FlagSet, p *[]int64, name, usage string) {
+	fs.Var(&int64SliceValue{p: p, def: append([]int64(nil), *p...)}, name, usage)
+}
+
+type intSliceValue struct {
+	p   *[]int
+	set bool
+	def []int
+}
+
+func (v *intSliceValue) Set(s string) error {
+	var vals []int
+	for i, elem := range strings.Split(s, ",") {
+		n, err := strconv.ParseInt(strings.TrimSpace(elem), 0, strconv.IntSize)
+		if err != nil {
+			return sliceElemError(elem, i, err)
+		}
+		vals = append(vals, int(n))
+	}
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, vals...)
+	return nil
+}
+
+func (v *intSliceValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	elems := make([]string, len(*v.p))
+	for i, n := range *v.p {
+		elems[i] = strconv.Itoa(n)
+	}
+	return strings.Join(elems, ",")
+}
+
+func (v *intSliceValue) Get() interface{} {
+	return *v.p
+}
+
+func (v *intSliceValue) IsRepeatable() bool {
+	return true
+}
+
+func (v *intSliceValue) Reset() {
+	*v.p = append([]int(nil), v.def...)
+	v.set = false
+}
+
+func (v *intSliceValue) snapshot() func() {
+	values, set := append([]int(nil), *v.p...), v.set
+	return func() {
+		*v.p, v.set = append([]int(nil), values...), set
+	}
+}
+
+type int64SliceValue struct {
+	p   *[]int64
+	set bool
+	def []int64
+}
+
+func (v *int64SliceValue) Set(s string) error {
+	var vals []int64
+	for i, elem := range strings.Split(s, ",") {
+		n, err := strconv.ParseInt(strings.TrimSpace(elem), 0, 64)
+		if err != nil {
+			return sliceElemError(elem, i, err)
+		}
+		vals = append(vals, n)
+	}
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, vals...)
+	return nil
+}
+
+func (v *int64SliceValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	elems := make([]string, len(*v.p))
+	for i, n := range *v.p {
+		elems[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(elems, ",")
+}
+
+func (v *int64SliceValue) Get() interface{} {
+	return *v.p
+}
+
+func (v *int64SliceValue) IsRepeatable() bool {
+	return true
+}
+
+func (v *int64SliceValue) Reset() {
+	*v.p = append([]int64(nil), v.def...)
+	v.set = false
+}
+
+func (v *int64SliceValue) snapshot() func() {
+	values, set := append([]int64(nil), *v.p...), v.set
+	return func() {
+		*v.p, v.set = append([]int64(nil), values...), set
+	}
+}
+
+// Defines a []time.Duration flag with specified name, default value,
+// and usage string, which accepts repeated occurrences and comma
+// separated lists like StringSliceVar, as in -backoff 1s,5s,30s. Each
+// element is parsed by time.ParseDuration.
+func DurationSliceVar(fs *flag.FlagSet, p *[]time.Duration, name string, value []time.Duration, usage string) {
+	*p = append([]time.Duration(nil), value...)
+	fs.Var(&durationSliceValue{p: p, def: append([]time.Duration(nil), value...)}, name, usage)
+}
+
+type durationSliceValue struct {
+	p   *[]time.Duration
+	set bool
+	def []time.Duration
+}
+
+func (v *durationSliceValue) Set(s string) error {
+	var vals []time.Duration
+	for i, elem := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(elem))
+		if err != nil {
+			return fmt.Errorf("element %q at position %d: invalid duration", elem, i+1)
+		}
+		vals = append(vals, d)
+	}
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, vals...)
+	return nil
+}
+
+func (v *durationSliceValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	elems := make([]string, len(*v.p))
+	for i, d := range *v.p {
+		elems[i] = d.String()
+	}
+	return strings.Join(elems, ",")
+}
+
+func (v *durationSliceValue) Get() interface{} {
+	return *v.p
+}
+
+func (v *durationSliceValue) IsRepeatable() bool {
+	return true
+}
+
+func (v *durationSliceValue) Reset() {
+	*v.p = append([]time.Duration(nil), v.def...)
+	v.set = false
+}
+
+func (v *durationSliceValue) snapshot() func() {
+	values, set := append([]time.Duration(nil), *v.p...), v.set
+	return func() {
+		*v.p, v.set = append([]time.Duration(nil), values...), set
+	}
+}
+
+// Reports an invalid element of a comma separated list, i being the
+// zero based index of the element.
+func sliceElemError(elem string, i int, err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	return fmt.Errorf("element %q at position %d: %v", elem, i+1, err)
+}
@@ -0,0 +1,223 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStringSlice(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"default"}},
+		{[]string{"-s", "a", "-s", "b"}, []string{"a", "b"}},
+		{[]string{"-s", "a,b,c"}, []string{"a", "b", "c"}},
+		{[]string{"-s", "a,b", "-s=c", "-s", "d,e"}, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		s := []string{"default"}
+		StringSliceVar(fs, &s, "s", "values")
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(s, test.want) {
+			t.Errorf("%q should parse to %q but was %q.", test.args, test.want, s)
+		}
+	}
+}
+
+func TestStringSliceSep(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var colon, whole []string
+	StringSliceVarSep(fs, &colon, "path", ":", "search path")
+	StringSliceVarSep(fs, &whole, "query", "\n", "queries")
+	err := fs.Parse([]string{"-path", "/bin:/usr/bin", "-query", "a,b", "-query", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/bin", "/usr/bin"}; !reflect.DeepEqual(colon, want) {
+		t.Errorf("path should be %q but was %q.", want, colon)
+	}
+	if want := []string{"a,b", "c"}; !reflect.DeepEqual(whole, want) {
+		t.Errorf("query should be %q but was %q.", want, whole)
+	}
+
+	// String round-trips through Set with the separator
+	for _, name := range []string{"path", "query"} {
+		f := fs.Lookup(name)
+		var values []string
+		fs2 := flag.NewFlagSet("test", flag.ContinueOnError)
+		StringSliceVarSep(fs2, &values, name, f.Value.(*stringSliceValue).sep, "")
+		if err := fs2.Set(name, f.Value.String()); err != nil {
+			t.Fatal(err)
+		}
+		if want := f.Value.(flag.Getter).Get(); !reflect.DeepEqual(values, want) {
+			t.Errorf("Round-trip of -%s should yield %q but was %q.", name, want, values)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("An empty separator should panic.")
+		}
+	}()
+	StringSliceVarSep(fs, new([]string), "bad", "", "")
+}
+
+func TestStringSliceString(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := StringSlice(fs, "s", "values")
+	*p = []string{"a", "b"}
+	f := fs.Lookup("s")
+	if f.Value.String() != "a,b" {
+		t.Errorf("String should be %q but was %q.", "a,b", f.Value.String())
+	}
+
+	// String round-trips through Set
+	fs2 := flag.NewFlagSet("test", flag.ContinueOnError)
+	q := StringSlice(fs2, "s", "values")
+	if err := fs2.Set("s", f.Value.String()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*q, *p) {
+		t.Errorf("Round-trip should yield %q but was %q.", *p, *q)
+	}
+	if got := fs.Lookup("s").Value.(flag.Getter).Get(); !reflect.DeepEqual(got, *p) {
+		t.Errorf("Get should return %q but was %q.", *p, got)
+	}
+}
+
+func TestIntSlice(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	ints := []int{1, 2, 3}
+	var longs []int64
+	IntSliceVar(fs, &ints, "n", "numbers")
+	Int64SliceVar(fs, &longs, "l", "large numbers")
+	if s := fs.Lookup("n").Value.String(); s != "1,2,3" {
+		t.Errorf("Default should render as %q but was %q.", "1,2,3", s)
+	}
+	err := fs.Parse([]string{"-n", "4,5", "-n", "6", "-l", "9000000000, 0x10", "-l=-7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{4, 5, 6}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("n should be %v but was %v.", want, ints)
+	}
+	if want := []int64{9000000000, 16, -7}; !reflect.DeepEqual(longs, want) {
+		t.Errorf("l should be %v but was %v.", want, longs)
+	}
+}
+
+func TestIntSliceNegative(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var ints []int
+	IntSliceVar(fs, &ints, "n", "numbers")
+	if err := fs.Parse([]string{"-n", "-1,-2", "-n", "-3", "rest"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{-1, -2, -3}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("n should be %v but was %v.", want, ints)
+	}
+	if want := []string{"rest"}; !reflect.DeepEqual(fs.Args(), want) {
+		t.Errorf("Arguments should be %q but were %q.", want, fs.Args())
+	}
+}
+
+func TestIntSliceBadElement(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	ints := []int{1}
+	IntSliceVar(fs, &ints, "n", "numbers")
+	err := fs.Parse([]string{"-n", "1,x,3"})
+	if err == nil {
+		t.Fatal("An invalid element should fail.")
+	}
+	if !strings.Contains(err.Error(), `element "x" at position 2`) {
+		t.Errorf("Error should name the element and its position but was %q.", err)
+	}
+	if want := []int{1}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("n should keep its default %v but was %v.", want, ints)
+	}
+}
+
+func TestDurationSlice(t *testing.T) {
+	def := []time.Duration{time.Second}
+	tests := []struct {
+		args []string
+		want []time.Duration
+	}{
+		{nil, def},
+		{[]string{"-backoff", "1s", "-backoff", "5s"}, []time.Duration{time.Second, 5 * time.Second}},
+		{[]string{"-backoff", "1s, 5s,30s"}, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}},
+		{[]string{"-backoff", "100ms", "-backoff=1m,1h"}, []time.Duration{100 * time.Millisecond, time.Minute, time.Hour}},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var backoff []time.Duration
+		DurationSliceVar(fs, &backoff, "backoff", def, "retry delays")
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(backoff, test.want) {
+			t.Errorf("%q should parse to %v but was %v.", test.args, test.want, backoff)
+		}
+	}
+	if want := []time.Duration{time.Second}; !reflect.DeepEqual(def, want) {
+		t.Errorf("The default should not be modified but was %v.", def)
+	}
+}
+
+func TestDurationSliceBadElement(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	var backoff []time.Duration
+	DurationSliceVar(fs, &backoff, "backoff", []time.Duration{time.Second}, "retry delays")
+	err := fs.Parse([]string{"-backoff", "1s,5,30s"})
+	if err == nil {
+		t.Fatal("An invalid element should fail.")
+	}
+	if !strings.Contains(err.Error(), `element "5" at position 2: invalid duration`) {
+		t.Errorf("Error should name the element and its position but was %q.", err)
+	}
+	if want := []time.Duration{time.Second}; !reflect.DeepEqual(backoff, want) {
+		t.Errorf("backoff should keep its default %v but was %v.", want, backoff)
+	}
+}
+
+func TestDurationSliceHelp(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	var backoff, timeouts []time.Duration
+	DurationSliceVar(fs, &backoff, "backoff", []time.Duration{time.Second, 90 * time.Second}, "retry delays")
+	DurationSliceVar(fs, &timeouts, "timeouts", nil, "timeouts")
+	fs.PrintDefaults()
+	if want := "retry delays (default 1s,1m30s)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+	if strings.Contains(out.String(), "timeouts (default") {
+		t.Errorf("Help should not show an empty default but was %q.", out.String())
+	}
+	if timeouts != nil {
+		t.Errorf("timeouts should be empty but was %v.", timeouts)
+	}
+}
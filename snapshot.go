@@ -0,0 +1,108 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// The values of the flags of a command at one point in time, see
+// SnapshotFlags.
+type FlagSnapshot struct {
+	command string
+	flags   []flagState
+}
+
+type flagState struct {
+	flag  *flag.Flag
+	value string
+	// restores the exact state of values implementing snapshotter
+	restore func()
+}
+
+// Flag values whose state can't be restored from their string form,
+// such as repeatable flags, implement snapshotter. snapshot returns a
+// function restoring the current state.
+type snapshotter interface {
+	flag.Value
+	snapshot() func()
+}
+
+// Captures the current values of all flags of the command, so that a
+// caller running the command more than once, such as to retry it, can
+// give every attempt the same flags even if the command changes their
+// variables. See FlagSnapshot.Restore.
+func (c *CmdCont) SnapshotFlags() FlagSnapshot {
+	s := FlagSnapshot{command: c.CommandPath()}
+	c.FlagSet().VisitAll(func(f *flag.Flag) {
+		v := snapshotValue(f.Value)
+		if v == nil {
+			// restored with the flag it refers to
+			return
+		}
+		st := flagState{flag: f, value: v.String()}
+		if sv, ok := v.(snapshotter); ok {
+			st.restore = sv.snapshot()
+		}
+		s.flags = append(s.flags, st)
+	})
+	return s
+}
+
+// Returns the value holding the state of v, or nil if v refers to
+// another flag.
+func snapshotValue(v flag.Value) flag.Value {
+	switch w := v.(type) {
+	case *aliasValue:
+		return nil
+	case *mirrorValue:
+		return snapshotValue(w.Value)
+	case *fileExpandValue:
+		return snapshotValue(w.Value)
+	}
+	return v
+}
+
+// Restores the flags to the values captured by SnapshotFlags. The flag
+// types of this package are restored exactly; other values that
+// changed are passed their captured string form, see flag.Value. Fails
+// naming the flags whose values don't survive the round trip through
+// String and Set, such as custom repeatable values; they keep the
+// value Set left them with.
+func (s FlagSnapshot) Restore() error {
+	var failed []string
+	for _, st := range s.flags {
+		v := snapshotValue(st.flag.Value)
+		if st.restore != nil {
+			st.restore()
+			continue
+		}
+		if v.String() == st.value {
+			continue
+		}
+		if r, ok := v.(Resetter); ok {
+			r.Reset()
+		}
+		if err := v.Set(st.value); err != nil || v.String() != st.value {
+			failed = append(failed, flagName(st.flag.Name))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Flags %s of command %q can't be restored from their string values", strings.Join(failed, ", "), s.command)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Normalizes its flag variables like some commands do before using
+// them, which a second run must not see.
+type normalizingCmd struct {
+	env    string
+	tags   []string
+	labels map[string]string
+	inputs []string
+}
+
+func (c *normalizingCmd) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.env, "env", "", "target environment")
+	StringSliceVar(fs, &c.tags, "tag", "tags")
+	StringMapVar(fs, &c.labels, "label", "labels")
+}
+
+func (c *normalizingCmd) Run(args ...string) error {
+	c.inputs = append(c.inputs, c.env+" "+strings.Join(c.tags, ",")+" "+c.labels["team"])
+	c.env = strings.ToUpper(c.env)
+	c.tags = append(c.tags, "normalized")
+	c.labels["team"] = "unknown"
+	return nil
+}
+
+func TestSnapshotFlags(t *testing.T) {
+	cmd := &normalizingCmd{}
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", cmd)
+	if _, err := p.Validate("deploy", "-env", "prod", "-tag", "a", "-tag", "b", "-label", "team=web"); err != nil {
+		t.Fatal(err)
+	}
+	s := c.SnapshotFlags()
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := s.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"prod a,b web", "prod a,b web"}; !reflect.DeepEqual(cmd.inputs, want) {
+		t.Errorf("Both attempts should see the flags %q but saw %q.", want, cmd.inputs)
+	}
+}
+
+// Drops everything after the first comma, so its string form doesn't
+// survive Set.
+type truncatingValue struct {
+	values []string
+}
+
+func (v *truncatingValue) Set(s string) error {
+	v.values = append(v.values, strings.SplitN(s, ",", 2)[0])
+	return nil
+}
+
+func (v *truncatingValue) String() string {
+	return strings.Join(v.values, ",")
+}
+
+func TestSnapshotFlagsNotRoundTrippable(t *testing.T) {
+	v := &truncatingValue{}
+	var env string
+	p := NewPath()
+	c := p.Add("deploy", "deploys the app", &testCmd{flags: func(fs *flag.FlagSet) {
+		fs.Var(v, "host", "target hosts")
+		fs.StringVar(&env, "env", "", "target environment")
+	}})
+	if _, err := p.Validate("deploy", "-host", "a", "-host", "b", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	s := c.SnapshotFlags()
+	if err := s.Restore(); err != nil {
+		t.Errorf("Restoring unchanged values should succeed but failed with %v.", err)
+	}
+	v.values = nil
+	env = "dev"
+	err := s.Restore()
+	if want := `Flags --host of command "deploy" can't be restored from their string values`; err == nil || err.Error() != want {
+		t.Errorf("Restore should fail with %q but failed with %v.", want, err)
+	}
+	if env != "prod" {
+		t.Errorf("The other flags should be restored but env was %q.", env)
+	}
+}
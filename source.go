@@ -0,0 +1,176 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+)
+
+// Source tells where the value of a flag came from. Run resolves every
+// flag from the first source providing a value, in the order
+// SourceCLI, SourceEnv, SourceConfig, SourcePrompt and SourceDefault,
+// so a greater Source always wins over a lesser one.
+type Source int
+
+const (
+	// The declared default of the flag.
+	SourceDefault Source = iota
+	// Entered at the prompt for a missing flag, see PromptMissing.
+	SourcePrompt
+	// A configuration file loaded into the Path.
+	SourceConfig
+	// An environment variable bound with BindEnv.
+	SourceEnv
+	// The command line.
+	SourceCLI
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourcePrompt:
+		return "prompt"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCLI:
+		return "cli"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// Binds the named flag of the command to the environment variable
+// envVar. If the flag isn't set on the command line, Run sets it to
+// the value of the variable, if present, before consulting the
+// configuration.
+func (c *CmdCont) BindEnv(name, envVar string) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	if c.env == nil {
+		c.env = make(map[string]string)
+	}
+	c.env[name] = envVar
+	c.changed()
+	return nil
+}
+
+// Same as CmdCont.BindEnv but for the global flags of p.
+func (p *Path) BindEnv(name, envVar string) error {
+	if p.Flags.Lookup(name) == nil {
+		return fmt.Errorf("No such global flag -%s", name)
+	}
+	if p.env == nil {
+		p.env = make(map[string]string)
+	}
+	p.env[name] = envVar
+	return nil
+}
+
+// Returns where the value of the named flag came from in the last Run
+// of the command. Unknown flags report SourceDefault.
+func (c *CmdCont) ValueSource(name string) Source {
+	return c.sources[name]
+}
+
+// Reports whether the named flag was set in the last Run of the
+// command by any source but its default, such as --limit 0 given on
+// the command line. ValueSource tells which source set it.
+func (c *CmdCont) FlagWasSet(name string) bool {
+	_, ok := c.sources[name]
+	return ok
+}
+
+// Returns the sorted names of the flags set in the last Run of the
+// command, see FlagWasSet.
+func (c *CmdCont) SetFlags() []string {
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Same as CmdCont.ValueSource but for the global flags of p.
+func (p *Path) ValueSource(name string) Source {
+	return p.sources[name]
+}
+
+// Sets all flags of fs not set on the command line from the
+// environment variables bound in env, looked up in the Vars of e, and
+// then from the configured values. Returns the source of every flag
+// that doesn't have its default value.
+func resolveFlags(e Env, fs *flag.FlagSet, env map[string]string, values map[string]configValue) (map[string]Source, error) {
+	sources := make(map[string]Source)
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = SourceCLI
+		if v, ok := f.Value.(interface{ partner() string }); ok {
+			sources[v.partner()] = SourceCLI
+		}
+	})
+	for _, name := range sortedKeys(env) {
+		if _, ok := sources[name]; ok {
+			continue
+		}
+		v, ok := EnvLookup(e, env[name])
+		if !ok {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return nil, fmt.Errorf("Invalid value %q of $%s for flag -%s: %w", v, env[name], name, err)
+		}
+		markSource(fs, sources, name, SourceEnv)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if _, ok := sources[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range values[name].values {
+			if err := fs.Set(name, v); err != nil {
+				return nil, fmt.Errorf("Invalid config value %q for flag -%s: %w", v, name, err)
+			}
+		}
+		markSource(fs, sources, name, SourceConfig)
+	}
+	return sources, nil
+}
+
+// Records src for the named flag and the flag related to it, if any.
+func markSource(fs *flag.FlagSet, sources map[string]Source, name string, src Source) {
+	sources[name] = src
+	if v, ok := fs.Lookup(name).Value.(interface{ partner() string }); ok {
+		if _, ok := sources[v.partner()]; !ok {
+			sources[v.partner()] = src
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValueSourcePrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		env    string
+		config bool
+		want   string
+		source Source
+	}{
+		{"default", nil, "", false, "dev", SourceDefault},
+		{"config over default", nil, "", true, "config", SourceConfig},
+		{"env over default", nil, "env", false, "env", SourceEnv},
+		{"env over config", nil, "env", true, "env", SourceEnv},
+		{"cli over config", []string{"-env", "cli"}, "", true, "cli", SourceCLI},
+		{"cli over env", []string{"-env", "cli"}, "env", false, "cli", SourceCLI},
+		{"cli over all", []string{"-env", "cli"}, "env", true, "cli", SourceCLI},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var opts deployOpts
+			p := newDeployPath(&opts)
+			c := p.registry().entries["deploy"]
+			if err := c.BindEnv("env", "TEST_DEPLOY_ENV"); err != nil {
+				t.Fatal(err)
+			}
+			if test.env != "" {
+				t.Setenv("TEST_DEPLOY_ENV", test.env)
+			}
+			if test.config {
+				err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"deploy": {"env": "config"}}}`))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := p.Run(append([]string{"deploy"}, test.args...)...); err != nil {
+				t.Fatal(err)
+			}
+			if *opts.env != test.want {
+				t.Errorf("env should be %q but was %q.", test.want, *opts.env)
+			}
+			if s := c.ValueSource("env"); s != test.source {
+				t.Errorf("Source of env should be %v but was %v.", test.source, s)
+			}
+			if s := c.ValueSource("port"); s != SourceDefault {
+				t.Errorf("Source of port should be %v but was %v.", SourceDefault, s)
+			}
+		})
+	}
+}
+
+func TestBindEnvGlobal(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	if err := p.BindEnv("verbose", "TEST_VERBOSE"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_VERBOSE", "true")
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.Flags.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose should be %q but was %q.", "true", v)
+	}
+	if s := p.ValueSource("verbose"); s != SourceEnv {
+		t.Errorf("Source of verbose should be %v but was %v.", SourceEnv, s)
+	}
+}
+
+func TestBindEnvRequired(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts, "target")
+	p.registry().entries["deploy"].BindEnv("target", "TEST_DEPLOY_TARGET")
+	if _, err := p.Run("deploy"); err == nil {
+		t.Error("Run should fail without the required target.")
+	}
+	t.Setenv("TEST_DEPLOY_TARGET", "eu")
+	if _, err := p.Run("deploy"); err != nil {
+		t.Errorf("A target from the environment should satisfy the requirement: %v", err)
+	}
+}
+
+func TestBindEnvErrors(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	if err := c.BindEnv("missing", "X"); err == nil {
+		t.Error("Binding an unknown flag should fail.")
+	}
+	if err := p.BindEnv("missing", "X"); err == nil {
+		t.Error("Binding an unknown global flag should fail.")
+	}
+	c.BindEnv("port", "TEST_DEPLOY_PORT")
+	t.Setenv("TEST_DEPLOY_PORT", "eighty")
+	_, err := p.Run("deploy")
+	if err == nil || !strings.Contains(err.Error(), "TEST_DEPLOY_PORT") {
+		t.Errorf("An invalid value should fail naming the variable but was %v.", err)
+	}
+}
+
+func TestFlagWasSet(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	c.BindEnv("target", "TEST_DEPLOY_TARGET")
+	t.Setenv("TEST_DEPLOY_TARGET", "eu")
+	var wasSet map[string]bool
+	var set []string
+	c.Cmd.(*testCmd).run = func(args ...string) error {
+		wasSet = map[string]bool{}
+		for _, name := range []string{"env", "port", "target"} {
+			wasSet[name] = c.FlagWasSet(name)
+		}
+		set = c.SetFlags()
+		return nil
+	}
+	if _, err := p.Run("deploy", "-port", "80"); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"env": false, "port": true, "target": true}; !reflect.DeepEqual(wasSet, want) {
+		t.Errorf("FlagWasSet should report %v but was %v.", want, wasSet)
+	}
+	if want := []string{"port", "target"}; !reflect.DeepEqual(set, want) {
+		t.Errorf("SetFlags should be %q but was %q.", want, set)
+	}
+	if s := c.ValueSource("target"); s != SourceEnv {
+		t.Errorf("Source of target should be %v but was %v.", SourceEnv, s)
+	}
+}
+
+func TestFlagWasSetSources(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		args   []string
+		env    string
+		config string
+		set    bool
+		source Source
+	}{
+		{name: "cli", args: []string{"-env", "dev"}, set: true, source: SourceCLI},
+		{name: "env", env: "dev", set: true, source: SourceEnv},
+		{name: "config", config: `{"commands": {"deploy": {"env": "dev"}}}`, set: true, source: SourceConfig},
+		{name: "default", source: SourceDefault},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var opts deployOpts
+			p := newDeployPath(&opts)
+			c := p.registry().entries["deploy"]
+			c.BindEnv("env", "TEST_DEPLOY_ENV")
+			if test.env != "" {
+				t.Setenv("TEST_DEPLOY_ENV", test.env)
+			}
+			if test.config != "" {
+				if err := p.LoadConfigJSON(strings.NewReader(test.config)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var set bool
+			var source Source
+			c.Cmd.(*testCmd).run = func(args ...string) error {
+				set, source = c.FlagWasSet("env"), c.ValueSource("env")
+				return nil
+			}
+			if _, err := p.Run(append([]string{"deploy"}, test.args...)...); err != nil {
+				t.Fatal(err)
+			}
+			if set != test.set {
+				t.Errorf("FlagWasSet should report %v but was %v.", test.set, set)
+			}
+			if source != test.source {
+				t.Errorf("Source of env should be %v but was %v.", test.source, source)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Describes the commands and flags of a Path, so that versions of a
+// command line interface can be compared with DiffSpecs. Specs are
+// written and read as JSON.
+type Spec struct {
+	// the global and persistent flags
+	Flags []FlagSpec `json:"flags,omitempty"`
+	// all commands including the paths mounted to the path and their
+	// commands, sorted by name
+	Commands []CommandSpec `json:"commands"`
+}
+
+type CommandSpec struct {
+	// the name including the names of the paths it is mounted to,
+	// such as "remote add"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// the flags of the command or the global and persistent flags of
+	// a mounted path
+	Flags []FlagSpec `json:"flags,omitempty"`
+	// the named requirements of the command, see Requires
+	Requirements []RequirementSpec `json:"requirements,omitempty"`
+	// the feature the command is gated by, see Feature
+	Feature string `json:"feature,omitempty"`
+}
+
+type RequirementSpec struct {
+	Name string `json:"name"`
+	Hint string `json:"hint,omitempty"`
+}
+
+type FlagSpec struct {
+	Name string `json:"name"`
+	// the Go type of the value, such as "string" or "[]time.Duration"
+	Type       string   `json:"type"`
+	Usage      string   `json:"usage,omitempty"`
+	Default    string   `json:"default,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+	Persistent bool     `json:"persistent,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+}
+
+// Returns the spec of the commands registered to p.
+func (p *Path) Spec() *Spec {
+	return p.spec(true)
+}
+
+// Returns the spec of p, leaving out hidden commands and the commands
+// of hidden paths unless hidden is set.
+func (p *Path) spec(hidden bool) *Spec {
+	s := &Spec{Flags: p.globalSpecFlags()}
+	// the name of the hidden path whose commands are skipped
+	skip := ""
+	for _, d := range p.docCommands("") {
+		if skip != "" && strings.HasPrefix(d.name, skip) {
+			continue
+		}
+		if d.cont.hiddenCmd && !hidden {
+			skip = d.name + " "
+			continue
+		}
+		cs := CommandSpec{Name: strings.TrimPrefix(d.name, " "), Description: d.cont.Desc, Feature: d.cont.feature}
+		if d.cont.sub != nil {
+			cs.Flags = d.cont.sub.globalSpecFlags()
+		} else {
+			cs.Flags = specFlags(d.cont.FlagSet(), d.cont.RequiredFlags, false)
+		}
+		for _, r := range d.cont.namedRequirements() {
+			cs.Requirements = append(cs.Requirements, RequirementSpec{Name: r.Name, Hint: r.Hint})
+		}
+		s.Commands = append(s.Commands, cs)
+	}
+	return s
+}
+
+func (p *Path) globalSpecFlags() []FlagSpec {
+	flags := specFlags(p.Flags, nil, false)
+	if p.persistent != nil {
+		flags = append(flags, specFlags(p.persistent, nil, true)...)
+	}
+	return flags
+}
+
+// Returns the flags of fs sorted by name. Aliases are listed with the
+// flag they refer to.
+func specFlags(fs *flag.FlagSet, required []string, persistent bool) []FlagSpec {
+	aliases := make(map[string][]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if v, ok := f.Value.(*aliasValue); ok {
+			aliases[v.target.Name] = append(aliases[v.target.Name], f.Name)
+		}
+	})
+	var flags []FlagSpec
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*aliasValue); ok {
+			return
+		}
+		flags = append(flags, FlagSpec{
+			Name:       f.Name,
+			Type:       valueType(f.Value),
+			Usage:      f.Usage,
+			Default:    f.DefValue,
+			Required:   containsString(required, f.Name),
+			Persistent: persistent,
+			Aliases:    aliases[f.Name],
+		})
+	})
+	return flags
+}
+
+// Returns the Go type of the values of v.
+func valueType(v flag.Value) string {
+	switch w := v.(type) {
+	case *mirrorValue:
+		return valueType(w.Value)
+	case *fileExpandValue:
+		return valueType(w.Value)
+	}
+	if g, ok := v.(flag.Getter); ok {
+		if x := g.Get(); x != nil {
+			return reflect.TypeOf(x).String()
+		}
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// Writes s as indented JSON.
+func (s *Spec) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Reads a spec written by WriteJSON.
+func ReadSpecJSON(r io.Reader) (*Spec, error) {
+	var s Spec
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("Invalid spec: %w", err)
+	}
+	return &s, nil
+}
+
+// How a Change affects the users of a command line interface.
+type ChangeKind int
+
+const (
+	// Only the documentation changed, such as a description.
+	Cosmetic ChangeKind = iota
+	// Something was added, existing invocations keep working.
+	Additive
+	// Existing invocations may fail or behave differently.
+	Breaking
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Additive:
+		return "additive"
+	case Breaking:
+		return "breaking"
+	}
+	return "cosmetic"
+}
+
+// A difference between two specs found by DiffSpecs.
+type Change struct {
+	Kind ChangeKind
+	// the changed command, or the command of the changed flag; empty
+	// for global flags
+	Command string
+	// the changed flag, empty for changes of commands
+	Flag string
+	// describes the change, such as "flag -env removed"
+	Message string
+}
+
+func (c Change) String() string {
+	if c.Command == "" {
+		return fmt.Sprintf("%s: %s", c.Kind, c.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", c.Kind, c.Command, c.Message)
+}
+
+// Returns the changes from old to new, sorted by command and flag.
+// Removed commands, flags and aliases, changed types and defaults and
+// newly required flags and commands newly gated by a feature are
+// Breaking; added commands, flags and aliases, flags that are no longer
+// required and commands no longer gated are Additive; changed
+// descriptions and usages are Cosmetic.
+func DiffSpecs(old, new *Spec) []Change {
+	var changes []Change
+	changes = diffFlags(changes, "", old.Flags, new.Flags)
+	oldCmds := make(map[string]CommandSpec, len(old.Commands))
+	for _, c := range old.Commands {
+		oldCmds[c.Name] = c
+	}
+	newCmds := make(map[string]CommandSpec, len(new.Commands))
+	for _, c := range new.Commands {
+		newCmds[c.Name] = c
+	}
+	for _, oc := range old.Commands {
+		nc, ok := newCmds[oc.Name]
+		if !ok {
+			changes = append(changes, Change{Breaking, oc.Name, "", "command removed"})
+			continue
+		}
+		if oc.Description != nc.Description {
+			changes = append(changes, Change{Cosmetic, oc.Name, "", fmt.Sprintf("description changed from %q to %q", oc.Description, nc.Description)})
+		}
+		switch {
+		case oc.Feature != nc.Feature && nc.Feature != "":
+			changes = append(changes, Change{Breaking, oc.Name, "", fmt.Sprintf("command is now gated by feature %q", nc.Feature)})
+		case oc.Feature != nc.Feature:
+			changes = append(changes, Change{Additive, oc.Name, "", fmt.Sprintf("command is no longer gated by feature %q", oc.Feature)})
+		}
+		changes = diffFlags(changes, oc.Name, oc.Flags, nc.Flags)
+	}
+	for _, nc := range new.Commands {
+		if _, ok := oldCmds[nc.Name]; !ok {
+			changes = append(changes, Change{Additive, nc.Name, "", "command added"})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Command != changes[j].Command {
+			return changes[i].Command < changes[j].Command
+		}
+		return changes[i].Flag < changes[j].Flag
+	})
+	return changes
+}
+
+func diffFlags(changes []Change, cmd string, old, new []FlagSpec) []Change {
+	add := func(kind ChangeKind, flag, format string, args ...interface{}) {
+		changes = append(changes, Change{kind, cmd, flag, fmt.Sprintf(format, args...)})
+	}
+	newFlags := make(map[string]FlagSpec, len(new))
+	for _, f := range new {
+		newFlags[f.Name] = f
+	}
+	oldFlags := make(map[string]bool, len(old))
+	for _, of := range old {
+		oldFlags[of.Name] = true
+		nf, ok := newFlags[of.Name]
+		if !ok {
+			add(Breaking, of.Name, "flag -%s removed", of.Name)
+			continue
+		}
+		if of.Type != nf.Type {
+			add(Breaking, of.Name, "flag -%s changed type from %s to %s", of.Name, of.Type, nf.Type)
+		}
+		if of.Default != nf.Default {
+			add(Breaking, of.Name, "flag -%s changed default from %q to %q", of.Name, of.Default, nf.Default)
+		}
+		switch {
+		case !of.Required && nf.Required:
+			add(Breaking, of.Name, "flag -%s is now required", of.Name)
+		case of.Required && !nf.Required:
+			add(Additive, of.Name, "flag -%s is no longer required", of.Name)
+		}
+		if of.Persistent && !nf.Persistent {
+			add(Breaking, of.Name, "flag -%s is no longer persistent", of.Name)
+		}
+		for _, a := range of.Aliases {
+			if !containsString(nf.Aliases, a) {
+				add(Breaking, of.Name, "alias -%s of flag -%s removed", a, of.Name)
+			}
+		}
+		for _, a := range nf.Aliases {
+			if !containsString(of.Aliases, a) {
+				add(Additive, of.Name, "alias -%s of flag -%s added", a, of.Name)
+			}
+		}
+		if of.Usage != nf.Usage {
+			add(Cosmetic, of.Name, "usage of flag -%s changed from %q to %q", of.Name, of.Usage, nf.Usage)
+		}
+	}
+	for _, nf := range new {
+		if oldFlags[nf.Name] {
+			continue
+		}
+		if nf.Required {
+			add(Breaking, nf.Name, "required flag -%s added", nf.Name)
+		} else {
+			add(Additive, nf.Name, "flag -%s added", nf.Name)
+		}
+	}
+	return changes
+}
+
+// Compares the spec in the named file, written by Spec.WriteJSON, to
+// the spec of p and returns the changes, for failing a build on
+// Breaking changes.
+func (p *Path) DiffSpecFile(name string) ([]Change, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	old, err := ReadSpecJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return DiffSpecs(old, p.Spec()), nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpec(t *testing.T) {
+	p := NewPath()
+	p.PersistentFlags().String("namespace", "default", "namespace")
+	c := p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+			var backoff []time.Duration
+			DurationSliceVar(fs, &backoff, "backoff", nil, "retry delays")
+		},
+	}, "env")
+	c.FlagAlias("env", "e")
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	want := &Spec{
+		Flags: []FlagSpec{{Name: "namespace", Type: "string", Usage: "namespace", Default: "default", Persistent: true}},
+		Commands: []CommandSpec{
+			{Name: "deploy", Description: "deploys the app", Flags: []FlagSpec{
+				{Name: "backoff", Type: "[]time.Duration", Usage: "retry delays"},
+				{Name: "env", Type: "string", Usage: "target environment", Required: true, Aliases: []string{"e"}},
+			}},
+			{Name: "remote", Description: "manages remotes"},
+			{Name: "remote add", Description: "adds a remote"},
+		},
+	}
+	spec := p.Spec()
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("Spec should be %+v but was %+v.", want, spec)
+	}
+
+	name := filepath.Join(t.TempDir(), "spec.json")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.WriteJSON(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	changes, err := p.DiffSpecFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) > 0 {
+		t.Errorf("The written spec should match the path but differs by %v.", changes)
+	}
+}
+
+func TestDiffSpecs(t *testing.T) {
+	old := &Spec{
+		Flags: []FlagSpec{{Name: "verbose", Type: "bool", Default: "false"}},
+		Commands: []CommandSpec{
+			{Name: "deploy", Description: "deploys the app", Flags: []FlagSpec{
+				{Name: "env", Type: "string", Usage: "environment", Aliases: []string{"e"}},
+				{Name: "port", Type: "int", Default: "80"},
+				{Name: "region", Type: "string", Required: true},
+				{Name: "tag", Type: "string"},
+			}},
+			{Name: "destroy", Description: "destroys the app"},
+		},
+	}
+	new := &Spec{
+		Commands: []CommandSpec{
+			{Name: "deploy", Description: "deploys the application", Flags: []FlagSpec{
+				{Name: "env", Type: "string", Usage: "target environment", Required: true, Aliases: []string{"environment"}},
+				{Name: "force", Type: "bool", Default: "false"},
+				{Name: "port", Type: "uint", Default: "8080"},
+				{Name: "region", Type: "string"},
+				{Name: "replicas", Type: "int", Required: true},
+			}},
+			{Name: "status", Description: "prints the status"},
+		},
+	}
+	var got []string
+	for _, c := range DiffSpecs(old, new) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`breaking: flag -verbose removed`,
+		`cosmetic: deploy: description changed from "deploys the app" to "deploys the application"`,
+		`breaking: deploy: flag -env is now required`,
+		`breaking: deploy: alias -e of flag -env removed`,
+		`additive: deploy: alias -environment of flag -env added`,
+		`cosmetic: deploy: usage of flag -env changed from "environment" to "target environment"`,
+		`additive: deploy: flag -force added`,
+		`breaking: deploy: flag -port changed type from int to uint`,
+		`breaking: deploy: flag -port changed default from "80" to "8080"`,
+		`additive: deploy: flag -region is no longer required`,
+		`breaking: deploy: required flag -replicas added`,
+		`breaking: deploy: flag -tag removed`,
+		`breaking: destroy: command removed`,
+		`additive: status: command added`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSpecs should return\n%s\nbut was\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
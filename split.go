@@ -0,0 +1,97 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Splits a command line into arguments like a POSIX shell, without
+// expanding variables, such as $HOME, or globs. Arguments are
+// separated by Unicode whitespace. Single quotes preserve their
+// contents; within double quotes and outside of quotes a backslash
+// escapes the next character. Quotes may enclose an empty argument and
+// may be adjacent to other parts of an argument, such as in
+// --env="prod eu". Lines that can't be split fail with a SplitError.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	// the offset of the opening quote
+	start := 0
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case quote == '\'' && r != '\'':
+			arg.WriteString(line[i : i+size])
+		case r == '\\' && quote != '\'':
+			if i+size == len(line) {
+				return nil, &SplitError{Offset: i}
+			}
+			i += size
+			_, size = utf8.DecodeRuneInString(line[i:])
+			arg.WriteString(line[i : i+size])
+			inArg = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == '"':
+			arg.WriteString(line[i : i+size])
+		case r == '\'' || r == '"':
+			quote = r
+			start = i
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteString(line[i : i+size])
+			inArg = true
+		}
+		i += size
+	}
+	if quote != 0 {
+		return nil, &SplitError{Offset: start, Quote: byte(quote)}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// Returned by SplitArgs for a line ending within quotes or with a
+// backslash. The message has one of the forms
+//
+//	unterminated ' quote at offset 4
+//	trailing backslash at offset 8
+type SplitError struct {
+	// the byte offset of the opening quote or of the backslash
+	Offset int
+	// the unterminated quote, 0 for a trailing backslash
+	Quote byte
+}
+
+func (e *SplitError) Error() string {
+	if e.Quote != 0 {
+		return fmt.Sprintf("unterminated %c quote at offset %d", e.Quote, e.Offset)
+	}
+	return fmt.Sprintf("trailing backslash at offset %d", e.Offset)
+}
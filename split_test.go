@@ -0,0 +1,90 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{" \t\n ", nil},
+		{"app", []string{"app"}},
+		{"app deploy  -env\tprod", []string{"app", "deploy", "-env", "prod"}},
+		{"  app  deploy  ", []string{"app", "deploy"}},
+		{"app\ndeploy\r\n", []string{"app", "deploy"}},
+		// no-break space, ideographic space and line separator
+		{"app\u00a0deploy\u3000-env\u2028prod", []string{"app", "deploy", "-env", "prod"}},
+		{"app 'a\u3000b'", []string{"app", "a\u3000b"}},
+		{"größe straße", []string{"größe", "straße"}},
+		{`app\ größe`, []string{"app größe"}},
+		{`app -msg "hello world" 'it''s'`, []string{"app", "-msg", "hello world", "its"}},
+		{`app "" ''`, []string{"app", "", ""}},
+		{`""`, []string{""}},
+		{`'' ""`, []string{"", ""}},
+		{`a""b`, []string{"ab"}},
+		{`app 'a\b' "a\"b" a\ b`, []string{"app", `a\b`, `a"b`, "a b"}},
+		{`"a\\b" a\\b`, []string{`a\b`, `a\b`}},
+		{`"it's" 'say "hi"'`, []string{"it's", `say "hi"`}},
+		{`'a\'`, []string{`a\`}},
+		{`\'a\'`, []string{"'a'"}},
+		{`app --env="prod eu"`, []string{"app", "--env=prod eu"}},
+		{`echo $HOME "$PATH" '${USER}'`, []string{"echo", "$HOME", "$PATH", "${USER}"}},
+		{`a*b ~ ?`, []string{"a*b", "~", "?"}},
+		{"\"multi\nline\"", []string{"multi\nline"}},
+		{"\\\n", []string{"\n"}},
+		{"\x00 a", []string{"\x00", "a"}},
+	}
+	for _, test := range tests {
+		args, err := SplitArgs(test.line)
+		if err != nil {
+			t.Errorf("SplitArgs(%q) failed: %v", test.line, err)
+		} else if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("SplitArgs(%q) should be %q but was %q.", test.line, test.args, args)
+		}
+	}
+}
+
+func TestSplitArgsErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		err  SplitError
+		msg  string
+	}{
+		{`app "prod`, SplitError{Offset: 4, Quote: '"'}, `unterminated " quote at offset 4`},
+		{`app 'prod`, SplitError{Offset: 4, Quote: '\''}, `unterminated ' quote at offset 4`},
+		{`'`, SplitError{Offset: 0, Quote: '\''}, `unterminated ' quote at offset 0`},
+		{`größe "`, SplitError{Offset: 8, Quote: '"'}, `unterminated " quote at offset 8`},
+		{`'a' "b" 'c`, SplitError{Offset: 8, Quote: '\''}, `unterminated ' quote at offset 8`},
+		{`"it's`, SplitError{Offset: 0, Quote: '"'}, `unterminated " quote at offset 0`},
+		{`app prod\`, SplitError{Offset: 8}, `trailing backslash at offset 8`},
+		{`"a\`, SplitError{Offset: 2}, `trailing backslash at offset 2`},
+	}
+	for _, test := range tests {
+		_, err := SplitArgs(test.line)
+		var e *SplitError
+		if !errors.As(err, &e) || *e != test.err {
+			t.Errorf("SplitArgs(%q) should fail with %+v but was %v.", test.line, test.err, err)
+		} else if err.Error() != test.msg {
+			t.Errorf("Error should be %q but was %q.", test.msg, err.Error())
+		}
+	}
+}
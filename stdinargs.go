@@ -0,0 +1,79 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The maximum number of arguments StdinArgs reads by default.
+const defaultStdinArgs = 10000
+
+// Replaces the first positional argument "-" of the command by the lines
+// read from its standard input, see EnvFromContext, for piping such as
+//
+//	find . -name '*.tmp' | app delete -
+//
+// Blank lines are skipped. Reading more than max arguments fails, a
+// non-positive max allows 10000. A "-" following "--" is passed as is.
+func (c *CmdCont) StdinArgs(max int) {
+	if max <= 0 {
+		max = defaultStdinArgs
+	}
+	c.stdinArgs = max
+}
+
+// Returns the positional arguments of the command parsed from args,
+// with "-" replaced by the lines of standard input if enabled by
+// StdinArgs.
+func (c *CmdCont) readStdinArgs(ctx context.Context, args []string) ([]string, error) {
+	rest := c.FlagSet().Args()
+	if c.stdinArgs == 0 {
+		return rest, nil
+	}
+	// the flag package drops the "--" ending the flags
+	if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+		return rest, nil
+	}
+	i := 0
+	for i < len(rest) && rest[i] != "-" && rest[i] != "--" {
+		i++
+	}
+	if i == len(rest) || rest[i] == "--" {
+		return rest, nil
+	}
+	var lines []string
+	s := bufio.NewScanner(EnvFromContext(ctx).Stdin)
+	for s.Scan() {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(lines) == c.stdinArgs {
+			return nil, fmt.Errorf("%smore than %d arguments on standard input", commandPrefix(c.Name), c.stdinArgs)
+		}
+		lines = append(lines, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%sreading arguments from standard input: %w", commandPrefix(c.Name), err)
+	}
+	expanded := make([]string, 0, len(rest)-1+len(lines))
+	expanded = append(expanded, rest[:i]...)
+	expanded = append(expanded, lines...)
+	return append(expanded, rest[i+1:]...), nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newDeletePath(got *[]string) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("delete", "deletes files", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("force", false, "ignore missing files")
+		},
+		run: func(args ...string) error {
+			*got = args
+			return nil
+		},
+	})
+	return p, c
+}
+
+func runWithStdin(p *Path, stdin string, args ...string) error {
+	ctx := WithEnv(context.Background(), Env{Stdin: strings.NewReader(stdin)})
+	_, err := p.RunContext(ctx, args...)
+	return err
+}
+
+func TestStdinArgs(t *testing.T) {
+	var got []string
+	p, c := newDeletePath(&got)
+	c.StdinArgs(0)
+	for _, test := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"delete", "-force", "-"}, []string{"a.tmp", "b c.tmp", "d.tmp"}},
+		{[]string{"delete", "first", "-", "last"}, []string{"first", "a.tmp", "b c.tmp", "d.tmp", "last"}},
+		{[]string{"delete", "--", "-"}, []string{"-"}},
+		{[]string{"delete", "first", "--", "-"}, []string{"first", "--", "-"}},
+		{[]string{"delete", "a"}, []string{"a"}},
+	} {
+		if err := runWithStdin(p, "a.tmp\n\n  \nb c.tmp\r\nd.tmp", test.args...); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Arguments of %q should be %q but were %q.", test.args, test.want, got)
+		}
+	}
+}
+
+func TestStdinArgsDisabled(t *testing.T) {
+	var got []string
+	p, _ := newDeletePath(&got)
+	if err := runWithStdin(p, "a.tmp\n", "delete", "-"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arguments should be %q but were %q.", want, got)
+	}
+}
+
+func TestStdinArgsMax(t *testing.T) {
+	var got []string
+	p, c := newDeletePath(&got)
+	c.StdinArgs(2)
+	if err := runWithStdin(p, "a\nb\n\n", "delete", "-"); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	err := runWithStdin(p, "a\nb\nc\n", "delete", "-")
+	if want := "delete: more than 2 arguments on standard input"; err == nil || err.Error() != want {
+		t.Errorf("Run should fail with %q but was %v.", want, err)
+	}
+	if got != nil {
+		t.Errorf("The command should not run but got %q.", got)
+	}
+}
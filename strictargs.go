@@ -0,0 +1,92 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Makes Run reject positional arguments for the command, which takes
+// none, with an UnexpectedArgsError.
+func (c *CmdCont) NoArgs() {
+	c.noArgs = true
+}
+
+// Exempts the command from SetStrictArgs, for commands reading their
+// positional arguments without declaring them.
+func (c *CmdCont) ArbitraryArgs() {
+	c.anyArgs = true
+}
+
+// Makes all commands of p and of the paths mounted to it reject
+// positional arguments, as if NoArgs was called for them, unless they
+// declare positional arguments with RequiredArg, OptionalArg or
+// VariadicArg, read them from standard input with StdinArgs, take
+// typed arguments, such as commands of TypedFunc, or are exempted with
+// ArbitraryArgs.
+func (p *Path) SetStrictArgs(strict bool) {
+	p.strictArgs = strict
+}
+
+// Reports whether p or an enclosing path is strict, see SetStrictArgs.
+func (p *Path) strict() bool {
+	for q := p; q != nil; q = q.parent {
+		if q.strictArgs {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether c takes no positional arguments, see NoArgs and
+// SetStrictArgs. Commands with declared arguments are checked by
+// setArgValues instead.
+func (c *CmdCont) takesNoArgs() bool {
+	if c.noArgs {
+		return true
+	}
+	if c.anyArgs || c.stdinArgs != 0 || c.path == nil || !c.path.strict() {
+		return false
+	}
+	_, typed := c.Cmd.(argsUsager)
+	return !typed
+}
+
+// Returned by Run for positional arguments given to a command taking
+// none, see NoArgs and SetStrictArgs. The message has the form
+//
+//	status: unexpected arguments "extra", "junk"
+type UnexpectedArgsError struct {
+	Command string
+	Args    []string
+}
+
+func (e *UnexpectedArgsError) Error() string {
+	quoted := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	noun := "arguments"
+	if len(e.Args) == 1 {
+		noun = "argument"
+	}
+	return fmt.Sprintf("%sunexpected %s %s", commandPrefix(e.Command), noun, strings.Join(quoted, ", "))
+}
+
+func (e *UnexpectedArgsError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
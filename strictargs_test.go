@@ -0,0 +1,114 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func newStrictPath(ran map[string][]string) *Path {
+	p := NewPath()
+	p.SetStrictArgs(true)
+	for _, name := range []string{"status", "exec", "copy", "read"} {
+		name := name
+		p.Add(name, "runs "+name, &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.Bool("verbose", false, "verbose output")
+			},
+			run: func(args ...string) error {
+				ran[name] = args
+				return nil
+			},
+		})
+	}
+	p.Lookup("exec").ArbitraryArgs()
+	p.Lookup("copy").VariadicArg("file", 1)
+	p.Lookup("read").StdinArgs(10)
+	return p
+}
+
+func TestStrictArgs(t *testing.T) {
+	ran := make(map[string][]string)
+	p := newStrictPath(ran)
+	_, err := p.Run("status", "-verbose", "extra", "junk")
+	var unexpected *UnexpectedArgsError
+	if !errors.As(err, &unexpected) || unexpected.Command != "status" || len(unexpected.Args) != 2 {
+		t.Fatalf("Run should fail with an UnexpectedArgsError but was %v.", err)
+	}
+	if want := `status: unexpected arguments "extra", "junk"`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if !errors.Is(err, ErrCmdUsage) {
+		t.Error("The error should match ErrCmdUsage.")
+	}
+	if _, ok := ran["status"]; ok {
+		t.Error("status should not run.")
+	}
+	if _, err := p.Run("status", "-verbose"); err != nil {
+		t.Errorf("status without arguments should run but failed with %v.", err)
+	}
+}
+
+func TestStrictArgsExempt(t *testing.T) {
+	ran := make(map[string][]string)
+	p := newStrictPath(ran)
+	for _, args := range [][]string{
+		{"exec", "ls", "-l"},
+		{"copy", "a", "b"},
+		{"read", "a"},
+	} {
+		if _, err := p.Run(args...); err != nil {
+			t.Errorf("%q should run but failed with %v.", args, err)
+		}
+		if len(ran[args[0]]) != len(args)-1 {
+			t.Errorf("%s should run with %q but ran with %q.", args[0], args[1:], ran[args[0]])
+		}
+	}
+	typed := p.Add("resize", "resizes a disk", TypedFunc(func(size int) error { return nil }))
+	if _, err := p.Run("resize", "10"); err != nil {
+		t.Errorf("Typed commands should be exempt but failed with %v.", err)
+	}
+	if typed.takesNoArgs() {
+		t.Error("Typed commands should take arguments.")
+	}
+}
+
+func TestNoArgs(t *testing.T) {
+	p := NewPath()
+	c := p.Add("status", "prints the status", &testCmd{})
+	p.Add("echo", "prints the arguments", &testCmd{})
+	c.NoArgs()
+	_, err := p.Run("status", "now")
+	if want := `status: unexpected argument "now"`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if _, err := p.Run("echo", "now"); err != nil {
+		t.Errorf("Commands should accept arguments by default but failed with %v.", err)
+	}
+}
+
+func TestStrictArgsMounted(t *testing.T) {
+	remote := NewPath()
+	remote.Add("list", "lists the remotes", &testCmd{})
+	p := NewPath()
+	p.SetStrictArgs(true)
+	p.Mount("remote", "manages remotes", remote)
+	_, err := p.Run("remote", "list", "origin")
+	if want := `remote list: unexpected argument "origin"`; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Registers a flag for each field of the struct pointed to by v that
+// has a flag tag, binding the flag to the field:
+//
+//	type options struct {
+//		Port    int           `flag:"port,port to listen on" default:"8080"`
+//		Env     string        `flag:"env,target environment" required:"true"`
+//		Timeout time.Duration `flag:",request timeout" default:"30s"`
+//	}
+//
+// The tag holds the flag name and usage separated by a comma; an empty
+// name defaults to the lower-cased field name. The default tag is
+// parsed like a command line value. Fields of type string, int, int64,
+// uint, uint64, float64, bool, time.Duration, []string, []int and
+// []int64 are supported. Embedded structs are registered recursively.
+func FlagsFromStruct(fs *flag.FlagSet, v interface{}) error {
+	sv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	return structFlags(fs, sv)
+}
+
+// Returns the names of the flags tagged required:"true" in the struct
+// pointed to by v, suitable as the requiredFlags of Add.
+func RequiredFromStruct(v interface{}) []string {
+	sv, err := structValue(v)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	visitStructFlags(sv, func(sf reflect.StructField, _ reflect.Value, name, _ string) error {
+		if required, _ := strconv.ParseBool(sf.Tag.Get("required")); required {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("FlagsFromStruct requires a pointer to a struct")
+	}
+	return rv.Elem(), nil
+}
+
+func structFlags(fs *flag.FlagSet, sv reflect.Value) error {
+	return visitStructFlags(sv, func(sf reflect.StructField, fv reflect.Value, name, usage string) error {
+		if sf.PkgPath != "" {
+			return fmt.Errorf("Flag field %s is not exported", sf.Name)
+		}
+		p := fv.Addr().Interface()
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			// parse the default by setting it on a throwaway flag
+			tmp := flag.NewFlagSet("", flag.ContinueOnError)
+			if err := bindFlag(tmp, p, name, ""); err != nil {
+				return fmt.Errorf("Flag field %s: %w", sf.Name, err)
+			}
+			if err := tmp.Set(name, def); err != nil {
+				return fmt.Errorf("Flag field %s: invalid default %q: %w", sf.Name, def, err)
+			}
+		}
+		if err := bindFlag(fs, p, name, usage); err != nil {
+			return fmt.Errorf("Flag field %s: %w", sf.Name, err)
+		}
+		return nil
+	})
+}
+
+// Calls fn for each tagged field of sv and embedded structs.
+func visitStructFlags(sv reflect.Value, fn func(sf reflect.StructField, fv reflect.Value, name, usage string) error) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		tag, ok := sf.Tag.Lookup("flag")
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				if err := visitStructFlags(sv.Field(i), fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		name, usage, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		if err := fn(sf, sv.Field(i), name, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registers a flag bound to p with its current value as the default.
+func bindFlag(fs *flag.FlagSet, p interface{}, name, usage string) error {
+	switch p := p.(type) {
+	case *string:
+		fs.StringVar(p, name, *p, usage)
+	case *int:
+		fs.IntVar(p, name, *p, usage)
+	case *int64:
+		fs.Int64Var(p, name, *p, usage)
+	case *uint:
+		fs.UintVar(p, name, *p, usage)
+	case *uint64:
+		fs.Uint64Var(p, name, *p, usage)
+	case *float64:
+		fs.Float64Var(p, name, *p, usage)
+	case *bool:
+		fs.BoolVar(p, name, *p, usage)
+	case *time.Duration:
+		fs.DurationVar(p, name, *p, usage)
+	case *[]string:
+		StringSliceVar(fs, p, name, usage)
+	case *[]int:
+		IntSliceVar(fs, p, name, usage)
+	case *[]int64:
+		Int64SliceVar(fs, p, name, usage)
+	case *[]time.Duration:
+		DurationSliceVar(fs, p, name, *p, usage)
+	default:
+		return fmt.Errorf("unsupported type %s", reflect.TypeOf(p).Elem())
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type connOpts struct {
+	Host string `flag:"host,server to connect to" default:"localhost"`
+	Port int    `flag:"port,port to connect to" default:"8080"`
+}
+
+type serveOpts struct {
+	connOpts
+	Env      string        `flag:"env,target environment" required:"true"`
+	Timeout  time.Duration `flag:",request timeout" default:"30s"`
+	Verbose  bool          `flag:"v,verbose output"`
+	Ratio    float64       `flag:"ratio,sampling ratio" default:"0.5"`
+	Workers  uint          `flag:"workers,number of workers" default:"4"`
+	Tags     []string      `flag:"tag,tags to apply" default:"a,b"`
+	Limits   []int         `flag:"limit,rate limits"`
+	Internal string        `flag:"-"`
+	untagged int
+}
+
+func TestFlagsFromStruct(t *testing.T) {
+	var opts serveOpts
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := FlagsFromStruct(fs, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := serveOpts{
+		connOpts: connOpts{Host: "localhost", Port: 8080},
+		Timeout:  30 * time.Second,
+		Ratio:    0.5,
+		Workers:  4,
+		Tags:     []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Defaults should be %+v but were %+v.", want, opts)
+	}
+	if f := fs.Lookup("timeout"); f == nil || f.Usage != "request timeout" || f.DefValue != "30s" {
+		t.Errorf("The timeout flag should be named after the field, got %+v.", f)
+	}
+
+	err := fs.Parse([]string{
+		"-host", "example.com", "-port", "443", "-env", "prod", "-timeout", "1m",
+		"-v", "-ratio", "1", "-workers", "8", "-tag", "c", "-limit", "10,20",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = serveOpts{
+		connOpts: connOpts{Host: "example.com", Port: 443},
+		Env:      "prod",
+		Timeout:  time.Minute,
+		Verbose:  true,
+		Ratio:    1,
+		Workers:  8,
+		Tags:     []string{"c"},
+		Limits:   []int{10, 20},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Parsed options should be %+v but were %+v.", want, opts)
+	}
+	if fs.Lookup("internal") != nil || fs.Lookup("untagged") != nil {
+		t.Error("Untagged fields should not be registered.")
+	}
+}
+
+func TestRequiredFromStruct(t *testing.T) {
+	var opts serveOpts
+	var ran bool
+	p := NewPath()
+	p.Add("serve", "serves the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			if err := FlagsFromStruct(fs, &opts); err != nil {
+				t.Fatal(err)
+			}
+		},
+		run: func(args ...string) error {
+			ran = true
+			return nil
+		},
+	}, RequiredFromStruct(&opts)...)
+	if _, err := p.Run("serve"); err == nil || !strings.Contains(err.Error(), "env") {
+		t.Fatalf("Run should fail without the required env flag but was %v.", err)
+	}
+	if _, err := p.Run("serve", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if !ran || opts.Env != "prod" {
+		t.Errorf("The command should run with env prod but ran=%v env=%q.", ran, opts.Env)
+	}
+}
+
+func TestFlagsFromStructErrors(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		err string
+	}{
+		{serveOpts{}, "pointer to a struct"},
+		{&struct {
+			Labels map[string]string `flag:"label"`
+		}{}, "Flag field Labels: unsupported type map[string]string"},
+		{&struct {
+			Port int `flag:"port" default:"http"`
+		}{}, `Flag field Port: invalid default "http"`},
+		{&struct {
+			port int `flag:"port"`
+		}{}, "Flag field port is not exported"},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		err := FlagsFromStruct(fs, test.v)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%T should fail with %q but was %v.", test.v, test.err, err)
+		}
+	}
+}
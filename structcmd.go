@@ -0,0 +1,152 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Registers the exported methods of the struct pointed to by v as the
+// commands of a new path mounted at name, see Mount. Methods of the
+// types
+//
+//	func(args []string) error
+//	func(ctx context.Context, args []string) error
+//	func(c *CmdCont, args []string) error
+//
+// become commands named after the lower-cased method name, other
+// methods are ignored. The fields of v with a flag tag become the
+// global flags of the mounted path shared by all of its commands, see
+// FlagsFromStruct; required tags are ignored. If v has a method
+//
+//	Describe() map[string]string
+//
+// it returns the descriptions of the commands by name and the one of
+// the mounted path for the empty name.
+//
+// Nothing is registered if name is taken by a command, alias or topic
+// of p or reserved, if two methods have the same lower-cased name, if
+// v has no command methods or if a flag field is invalid.
+func (p *Path) AddStruct(name string, v interface{}) (*CmdCont, error) {
+	sv, err := structValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("AddStruct requires a pointer to a struct")
+	}
+	if err := p.checkReserved(name); err != nil {
+		return nil, err
+	}
+	if c := p.Lookup(name); c != nil {
+		return nil, fmt.Errorf("Command %q is already registered%s", name, c.registeredAtSuffix())
+	}
+	if _, ok := p.aliases[name]; ok {
+		return nil, fmt.Errorf("Command %q collides with the alias of the same name", name)
+	}
+	if _, ok := p.topics[name]; ok {
+		return nil, fmt.Errorf("Command %q collides with the help topic of the same name", name)
+	}
+	cmds, err := structCommands(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if len(cmds) == 0 {
+		return nil, fmt.Errorf("%s has no command methods", sv.Type())
+	}
+	var desc map[string]string
+	if d, ok := v.(interface{ Describe() map[string]string }); ok {
+		desc = d.Describe()
+	}
+	sub := NewPath()
+	if err := structFlags(sub.Flags, sv); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cmds))
+	for n := range cmds {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if _, err := sub.AddE(n, desc[n], cmds[n]); err != nil {
+			return nil, err
+		}
+	}
+	c := p.Mount(name, desc[""], sub)
+	p.recordCaller(c, 0)
+	return c, nil
+}
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	argsType    = reflect.TypeOf([]string(nil))
+	contType    = reflect.TypeOf((*CmdCont)(nil))
+)
+
+// Returns the command methods of rv by their lower-cased name.
+func structCommands(rv reflect.Value) (map[string]Cmd, error) {
+	cmds := make(map[string]Cmd)
+	methods := make(map[string]string)
+	rt := rv.Type()
+	for i := 0; i < rt.NumMethod(); i++ {
+		m := rt.Method(i)
+		cmd := methodCmd(rv.Method(i))
+		if cmd == nil {
+			continue
+		}
+		name := strings.ToLower(m.Name)
+		if other, ok := methods[name]; ok {
+			return nil, fmt.Errorf("Methods %s and %s both define the command %q", other, m.Name, name)
+		}
+		methods[name] = m.Name
+		cmds[name] = cmd
+	}
+	return cmds, nil
+}
+
+// Returns the Cmd calling the method m, nil if m isn't of a command
+// type.
+func methodCmd(m reflect.Value) Cmd {
+	t := m.Type()
+	if t.NumOut() != 1 || t.Out(0) != errorType || t.NumIn() == 0 || t.In(t.NumIn()-1) != argsType {
+		return nil
+	}
+	switch {
+	case t.NumIn() == 1:
+		return CmdFunc(m.Interface().(func([]string) error))
+	case t.NumIn() == 2 && t.In(0) == contextType:
+		return contextFunc(m.Interface().(func(context.Context, []string) error))
+	case t.NumIn() == 2 && t.In(0) == contType:
+		return CmdContFunc(m.Interface().(func(*CmdCont, []string) error))
+	}
+	return nil
+}
+
+// A func implementing ContextCmd.
+type contextFunc func(ctx context.Context, args []string) error
+
+func (s contextFunc) Flags(fs *flag.FlagSet) {
+}
+
+func (s contextFunc) Run(args ...string) error {
+	return s(context.Background(), args)
+}
+
+func (s contextFunc) RunContext(ctx context.Context, args ...string) error {
+	return s(ctx, args)
+}
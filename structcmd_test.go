@@ -0,0 +1,123 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type remoteTool struct {
+	Verbose bool   `flag:"verbose,verbose output"`
+	Remote  string `flag:",remote name" default:"origin"`
+
+	calls []string
+}
+
+func (r *remoteTool) Fetch(args []string) error {
+	r.calls = append(r.calls, "fetch "+r.Remote+" "+strings.Join(args, " "))
+	return nil
+}
+
+func (r *remoteTool) Push(ctx context.Context, args []string) error {
+	c, _ := FromContext(ctx)
+	r.calls = append(r.calls, c.Name+" "+r.Remote)
+	return nil
+}
+
+func (r *remoteTool) Prune(c *CmdCont, args []string) error {
+	r.calls = append(r.calls, c.Name+" "+c.CommandPath())
+	return nil
+}
+
+// not a command
+func (r *remoteTool) Count() int {
+	return len(r.calls)
+}
+
+func (r *remoteTool) Describe() map[string]string {
+	return map[string]string{"": "manages remotes", "fetch": "fetches a remote"}
+}
+
+func TestAddStruct(t *testing.T) {
+	p := NewPath()
+	var tool remoteTool
+	c, err := p.AddStruct("remote", &tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Desc != "manages remotes" {
+		t.Errorf("Description should be %q but was %q.", "manages remotes", c.Desc)
+	}
+	sub := c.sub
+	var names []string
+	for _, n := range []string{"count", "describe", "fetch", "prune", "push"} {
+		if sub.Lookup(n) != nil {
+			names = append(names, n)
+		}
+	}
+	if want := []string{"fetch", "prune", "push"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Commands should be %q but were %q.", want, names)
+	}
+	if d := sub.Lookup("fetch").Desc; d != "fetches a remote" {
+		t.Errorf("Description of fetch should be %q but was %q.", "fetches a remote", d)
+	}
+	for _, args := range [][]string{
+		{"remote", "fetch", "main"},
+		{"remote", "-remote", "upstream", "push"},
+		{"remote", "prune"},
+	} {
+		if _, err := p.Run(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"fetch origin main", "push upstream", "prune remote prune"}
+	if !reflect.DeepEqual(tool.calls, want) {
+		t.Errorf("Calls should be %q but were %q.", want, tool.calls)
+	}
+	if _, err := p.Run("remote", "-verbose", "fetch"); err != nil || !tool.Verbose {
+		t.Errorf("Shared flag -verbose should be set but was %v (%v).", tool.Verbose, err)
+	}
+}
+
+type clashingTool struct{}
+
+func (clashingTool) Sync(args []string) error { return nil }
+func (clashingTool) SYNC(args []string) error { return nil }
+
+func TestAddStructErrors(t *testing.T) {
+	p := NewPath()
+	p.Add("remote", "manages remotes", &testCmd{})
+	for _, test := range []struct {
+		name string
+		v    interface{}
+		err  string
+	}{
+		{"remote", &remoteTool{}, `Command "remote" is already registered`},
+		{"sync", &clashingTool{}, `Methods SYNC and Sync both define the command "sync"`},
+		{"opts", &deployOpts{}, "has no command methods"},
+		{"tool", remoteTool{}, "AddStruct requires a pointer to a struct"},
+	} {
+		_, err := p.AddStruct(test.name, test.v)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("AddStruct(%q) should fail with %q but was %v.", test.name, test.err, err)
+		}
+		if test.name != "remote" && p.Lookup(test.name) != nil {
+			t.Errorf("Command %q should not be registered.", test.name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The maximum number of flag names suggested for an unknown flag.
+const maxSuggestions = 3
+
+// Returned by Run for an unknown flag similar to registered ones. The
+// error of the flag package is available through Unwrap.
+type suggestionError struct {
+	err         error
+	suggestions []string
+}
+
+func (e *suggestionError) Error() string {
+	names := make([]string, len(e.suggestions))
+	for i, name := range e.suggestions {
+		names[i] = flagName(name)
+	}
+	return fmt.Sprintf("%v, did you mean %s?", e.err, strings.Join(names, " or "))
+}
+
+func (e *suggestionError) Unwrap() error {
+	return e.err
+}
+
+// Adds the names of the flags of sets that are similar to the unknown
+// flag of a parse error err. Flags for which hide returns true are not
+// suggested. Other errors are returned unchanged.
+func suggestFlags(err error, sets []*flag.FlagSet, hide func(*flag.Flag) bool) error {
+	const prefix = "flag provided but not defined: -"
+	name := strings.TrimPrefix(err.Error(), prefix)
+	if name == err.Error() {
+		return err
+	}
+	distances := make(map[string]int)
+	for _, fs := range sets {
+		fs.VisitAll(func(f *flag.Flag) {
+			if _, seen := distances[f.Name]; seen || hide != nil && hide(f) {
+				return
+			}
+			if d := editDistance(name, f.Name); similarName(name, f.Name, d) {
+				distances[f.Name] = d
+			}
+		})
+	}
+	if len(distances) == 0 {
+		return err
+	}
+	names := make([]string, 0, len(distances))
+	for name := range distances {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if distances[names[i]] != distances[names[j]] {
+			return distances[names[i]] < distances[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxSuggestions {
+		names = names[:maxSuggestions]
+	}
+	return &suggestionError{err: err, suggestions: names}
+}
+
+// Returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur := min(row[j]+1, row[j-1]+1, prev+cost)
+			prev, row[j] = row[j], cur
+		}
+	}
+	return row[len(t)]
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newSuggestPath() *Path {
+	p := NewPath()
+	p.PersistentFlags().String("namespace", "default", "namespace")
+	c := p.Add("build", "builds the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.SetOutput(io.Discard)
+			fs.Bool("verbose", false, "verbose output")
+			fs.Bool("version", false, "print the version")
+			fs.String("output", "", "output file")
+			fs.String("secret-mode", "", "internal")
+		},
+	})
+	c.FlagAlias("verbose", "v")
+	c.HideFlag("secret-mode")
+	return p
+}
+
+func TestSuggestFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"build", "-verbos"}, "flag provided but not defined: -verbos, did you mean --verbose?"},
+		{[]string{"build", "--verison"}, "flag provided but not defined: -verison, did you mean --version?"},
+		{[]string{"build", "-ver"}, "flag provided but not defined: -ver, did you mean --verbose or --version?"},
+		{[]string{"build", "-outptu=a"}, "flag provided but not defined: -outptu, did you mean --output?"},
+		{[]string{"build", "-namespce", "x"}, "flag provided but not defined: -namespce, did you mean --namespace?"},
+		{[]string{"build", "-secret-mod"}, "flag provided but not defined: -secret-mod"},
+		{[]string{"build", "-color"}, "flag provided but not defined: -color"},
+		{[]string{"build", "-x"}, "flag provided but not defined: -x"},
+	}
+	for _, test := range tests {
+		_, err := newSuggestPath().Run(test.args...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q should fail with %q but was %v.", test.args, test.err, err)
+			continue
+		}
+		if errors.Unwrap(err) != nil && !strings.HasPrefix(test.err, errors.Unwrap(err).Error()) {
+			t.Errorf("%q should wrap the flag package error but was %v.", test.args, errors.Unwrap(err))
+		}
+	}
+}
+
+func TestSuggestGlobalFlags(t *testing.T) {
+	p := newSuggestPath()
+	p.Flags.SetOutput(io.Discard)
+	_, err := p.Run("-namespac", "x", "build")
+	want := "flag provided but not defined: -namespac, did you mean --namespace?"
+	if err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	var s *suggestionError
+	if !errors.As(err, &s) || s.Unwrap().Error() != "flag provided but not defined: -namespac" {
+		t.Errorf("Unwrap should return the flag package error but was %v.", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"verbose", "verbose", 0},
+		{"verbos", "verbose", 1},
+		{"verison", "version", 2},
+		{"kitten", "sitting", 3},
+		{"größe", "grosse", 3},
+	}
+	for _, test := range tests {
+		if d := editDistance(test.a, test.b); d != test.want {
+			t.Errorf("Distance of %q and %q should be %d but was %d.", test.a, test.b, test.want, d)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "errors"
+
+// Exit codes of sysexits.h used by Execute unless SetPlainExitCodes is
+// set. ExitUsage is used for usage errors, the others for errors
+// wrapping the matching sentinel error, such as ErrNoInput.
+const (
+	// The command was used incorrectly, e.g. with unknown flags.
+	ExitUsage = 64
+	// The input data was incorrect.
+	ExitDataErr = 65
+	// An input file did not exist or was not readable.
+	ExitNoInput = 66
+	// The user specified did not exist.
+	ExitNoUser = 67
+	// The host specified did not exist.
+	ExitNoHost = 68
+	// A service is unavailable.
+	ExitUnavailable = 69
+	// An internal software error was detected.
+	ExitSoftware = 70
+	// An operating system error was detected.
+	ExitOSErr = 71
+	// A system file did not exist or had an error.
+	ExitOSFile = 72
+	// An output file could not be created.
+	ExitCantCreate = 73
+	// An error occurred while doing I/O.
+	ExitIOErr = 74
+	// A temporary failure, the user is invited to retry.
+	ExitTempFail = 75
+	// The remote system returned something invalid.
+	ExitProtocol = 76
+	// The user lacks the permission to perform the operation.
+	ExitNoPerm = 77
+	// Something was found in an unconfigured or misconfigured state.
+	ExitConfig = 78
+)
+
+// Commands return errors wrapping these to exit with the matching
+// sysexits.h code, e.g.
+//
+//	return fmt.Errorf("%s: %w", name, command.ErrNoInput)
+//
+// A PermissionError matches ErrNoPerm.
+var (
+	ErrDataErr     = errors.New("data format error")
+	ErrNoInput     = errors.New("cannot open input")
+	ErrNoUser      = errors.New("addressee unknown")
+	ErrNoHost      = errors.New("host name unknown")
+	ErrUnavailable = errors.New("service unavailable")
+	ErrSoftware    = errors.New("internal software error")
+	ErrOSErr       = errors.New("system error")
+	ErrOSFile      = errors.New("critical OS file missing")
+	ErrCantCreate  = errors.New("can't create output file")
+	ErrIOErr       = errors.New("input/output error")
+	ErrTempFail    = errors.New("temporary failure")
+	ErrProtocol    = errors.New("remote protocol error")
+	ErrNoPerm      = errors.New("permission denied")
+	ErrConfig      = errors.New("configuration error")
+)
+
+var sysexits = []exitCode{
+	{ErrDataErr, ExitDataErr},
+	{ErrNoInput, ExitNoInput},
+	{ErrNoUser, ExitNoUser},
+	{ErrNoHost, ExitNoHost},
+	{ErrUnavailable, ExitUnavailable},
+	{ErrSoftware, ExitSoftware},
+	{ErrOSErr, ExitOSErr},
+	{ErrOSFile, ExitOSFile},
+	{ErrCantCreate, ExitCantCreate},
+	{ErrIOErr, ExitIOErr},
+	{ErrTempFail, ExitTempFail},
+	{ErrProtocol, ExitProtocol},
+	{ErrNoPerm, ExitNoPerm},
+	{ErrConfig, ExitConfig},
+}
+
+// Restores the exit codes used before sysexits.h was supported: 2 for
+// usage errors and 1 for all other errors, including those wrapping
+// ErrNoInput and the other sentinel errors. Codes set with SetExitCode
+// and returned by an ExitCoder are unaffected.
+func (p *Path) SetPlainExitCodes(plain bool) {
+	p.plainExitCodes = plain
+}
+
+// Returns the sysexits.h code for err, or 0 if it matches none of the
+// sentinel errors.
+func sysexitCode(err error) int {
+	for _, e := range sysexits {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return 0
+}
+
+func SetPlainExitCodes(plain bool) {
+	globalPath.SetPlainExitCodes(plain)
+}
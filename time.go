@@ -0,0 +1,72 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The layouts accepted by TimeVar if none are given.
+var DefaultTimeLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// Returns the current time, replaced by tests.
+var now = time.Now
+
+// Defines a time flag with specified name, default value, layouts and
+// usage string. The argument p points to a time.Time variable in which
+// to store the value of the flag. Values are parsed with the first
+// matching layout, DefaultTimeLayouts if layouts is empty, and the
+// literal "now" stands for the current time. The help output shows the
+// default formatted with the first layout.
+func TimeVar(fs *flag.FlagSet, p *time.Time, name string, value time.Time, layouts []string, usage string) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	*p = value
+	fs.Var(&timeValue{p: p, layouts: layouts}, name, usage)
+}
+
+type timeValue struct {
+	p       *time.Time
+	layouts []string
+}
+
+func (v *timeValue) Set(s string) error {
+	if s == "now" {
+		*v.p = now()
+		return nil
+	}
+	for _, layout := range v.layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			*v.p = t
+			return nil
+		}
+	}
+	return fmt.Errorf("does not match any of the layouts %s", strings.Join(v.layouts, ", "))
+}
+
+func (v *timeValue) String() string {
+	if v.p == nil || v.p.IsZero() {
+		return ""
+	}
+	return v.p.Format(v.layouts[0])
+}
+
+func (v *timeValue) Get() interface{} {
+	return *v.p
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeVar(t *testing.T) {
+	tests := []struct {
+		layouts []string
+		value   string
+		want    time.Time
+	}{
+		{nil, "2024-06-01T12:00:00Z", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{nil, "2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{[]string{time.Kitchen, "15:04:05"}, "3:04PM", time.Date(0, 1, 1, 15, 4, 0, 0, time.UTC)},
+		{[]string{time.Kitchen, "15:04:05"}, "18:30:00", time.Date(0, 1, 1, 18, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		var at time.Time
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		TimeVar(fs, &at, "at", time.Time{}, test.layouts, "start time")
+		if err := fs.Parse([]string{"-at", test.value}); err != nil {
+			t.Fatal(err)
+		}
+		if !at.Equal(test.want) {
+			t.Errorf("%q should parse to %v but was %v.", test.value, test.want, at)
+		}
+	}
+}
+
+func TestTimeVarNow(t *testing.T) {
+	fixed := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return fixed }
+	var at time.Time
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	TimeVar(fs, &at, "at", time.Time{}, nil, "start time")
+	if err := fs.Parse([]string{"-at", "now"}); err != nil {
+		t.Fatal(err)
+	}
+	if !at.Equal(fixed) {
+		t.Errorf("now should be %v but was %v.", fixed, at)
+	}
+}
+
+func TestTimeVarInvalid(t *testing.T) {
+	var at time.Time
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	TimeVar(fs, &at, "at", time.Time{}, nil, "start time")
+	err := fs.Parse([]string{"-at", "tomorrow"})
+	if err == nil {
+		t.Fatal("tomorrow should not parse.")
+	}
+	for _, want := range []string{"-at", time.RFC3339, "2006-01-02"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("The error should contain %q but was %q.", want, err)
+		}
+	}
+}
+
+func TestTimeVarHelp(t *testing.T) {
+	var at, none time.Time
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	TimeVar(fs, &at, "at", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), []string{"2006-01-02", time.RFC3339}, "start time")
+	TimeVar(fs, &none, "until", time.Time{}, nil, "end time")
+	fs.PrintDefaults()
+	want := "  -at value\n    \tstart time (default 2024-06-01)\n  -until value\n    \tend time\n"
+	if out.String() != want {
+		t.Errorf("Help should be %q but was %q.", want, out.String())
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Defines the global flag --timeout with the given default, such as
+// --timeout 30s. A non-zero timeout cancels the context of the command
+// run by RunContext once it elapsed, see ContextCmd; for commands with
+// a timeout set by SetTimeout the smaller one applies. Zero means no
+// deadline.
+func (p *Path) EnableTimeoutFlag(defaultTimeout time.Duration) {
+	p.timeoutFlag = p.Flags.Duration("timeout", defaultTimeout, "cancel the command after this `duration`, 0 for no deadline")
+}
+
+// Cancels the context of the command once it ran for d, see
+// EnableTimeoutFlag. Retries configured with Retry count toward the
+// timeout. Zero means no deadline.
+func (c *CmdCont) SetTimeout(d time.Duration) {
+	c.timeout = d
+}
+
+// Returns the timeout of cont run by p, the smaller one of the timeout
+// of cont and the flag --timeout of the closest enclosing path defining
+// it, ignoring zero values.
+func (p *Path) commandTimeout(cont *CmdCont) time.Duration {
+	d := cont.timeout
+	for q := p; q != nil; q = q.parent {
+		if q.timeoutFlag == nil {
+			continue
+		}
+		if t := *q.timeoutFlag; t > 0 && (d == 0 || t < d) {
+			d = t
+		}
+		break
+	}
+	return d
+}
+
+// Returned by Run for commands failing after their timeout elapsed,
+// see EnableTimeoutFlag and SetTimeout. The message has the form
+//
+//	deploy: timed out after 30s: context deadline exceeded
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+	// the error returned by the command
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%stimed out after %v: %v", commandPrefix(e.Command), e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Runs run with ctx limited to the timeout of cont, and wraps its error
+// in a TimeoutError if the timeout elapsed.
+func (p *Path) runWithTimeout(ctx context.Context, cont *CmdCont, run func(context.Context) error) error {
+	d := p.commandTimeout(cont)
+	if d <= 0 {
+		return run(ctx)
+	}
+	tctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	err := run(tctx)
+	if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		err = &TimeoutError{Command: cont.CommandPath(), Timeout: d, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Returns a path with the command wait, which waits for its context to
+// be done if args are given, and the deadline of the context it ran
+// with.
+func newTimeoutPath(deadline *time.Duration) (*Path, *CmdCont) {
+	p := NewPath()
+	c := p.Add("wait", "waits for the context", &testContextCmd{
+		runContext: func(ctx context.Context, args ...string) error {
+			*deadline = 0
+			if t, ok := ctx.Deadline(); ok {
+				*deadline = time.Until(t)
+			}
+			if len(args) > 0 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	})
+	return p, c
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		flag           bool
+		args           []string
+		command, limit time.Duration
+	}{
+		{"neither", false, []string{"wait"}, 0, 0},
+		{"flag default", true, []string{"wait"}, 0, time.Hour},
+		{"flag", true, []string{"-timeout", "1m", "wait"}, 0, time.Minute},
+		{"flag zero", true, []string{"-timeout", "0", "wait"}, 0, 0},
+		{"command", false, []string{"wait"}, time.Minute, time.Minute},
+		{"both, flag smaller", true, []string{"-timeout", "1m", "wait"}, time.Hour, time.Minute},
+		{"both, command smaller", true, []string{"-timeout", "1h", "wait"}, time.Minute, time.Minute},
+		{"flag zero, command", true, []string{"-timeout", "0", "wait"}, time.Minute, time.Minute},
+	}
+	for _, test := range tests {
+		var deadline time.Duration
+		p, c := newTimeoutPath(&deadline)
+		if test.flag {
+			p.EnableTimeoutFlag(time.Hour)
+		}
+		c.SetTimeout(test.command)
+		if _, err := p.Run(test.args...); err != nil {
+			t.Fatal(err)
+		}
+		if test.limit == 0 && deadline != 0 || test.limit != 0 && (deadline <= 0 || deadline > test.limit) {
+			t.Errorf("%s: The deadline should be in %v but was in %v.", test.name, test.limit, deadline)
+		}
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	var deadline time.Duration
+	p, _ := newTimeoutPath(&deadline)
+	p.EnableTimeoutFlag(0)
+	_, err := p.Run("-timeout", "10ms", "wait", "forever")
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Command != "wait" || timeout.Timeout != 10*time.Millisecond {
+		t.Fatalf("Run should fail with a TimeoutError but was %v.", err)
+	}
+	if want := "wait: timed out after 10ms: context deadline exceeded"; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err.Error())
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("The error should match context.DeadlineExceeded.")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.RunContext(ctx, "-timeout", "1h", "wait", "forever"); errors.As(err, &timeout) {
+		t.Errorf("The deadline of the caller should not cause a TimeoutError but was %v.", err)
+	}
+}
+
+func TestTimeoutMounted(t *testing.T) {
+	var deadline time.Duration
+	sub, _ := newTimeoutPath(&deadline)
+	p := NewPath()
+	p.EnableTimeoutFlag(0)
+	p.Mount("jobs", "runs jobs", sub)
+	if _, err := p.Run("-timeout", "1m", "jobs", "wait"); err != nil {
+		t.Fatal(err)
+	}
+	if deadline <= 0 || deadline > time.Minute {
+		t.Errorf("The deadline should be in 1m but was in %v.", deadline)
+	}
+}
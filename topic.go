@@ -0,0 +1,192 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// A help topic registered with AddTopic.
+type topic struct {
+	title, content string
+}
+
+// Registers a help topic, a text such as a tutorial that isn't a
+// command. Topics are listed by PrintAvailableCommands under
+// "Additional help topics" and shown by the help command, see
+// AddHelpCommand. Running a topic fails with an UnknownCommandError
+// pointing to help. Topics share the names of commands and aliases:
+// AddTopic fails if name is taken, and AddE fails for the name of a
+// topic.
+//
+// Paragraphs of content are separated by empty lines and wrapped to
+// the width of the terminal. Lines starting with whitespace, such as
+// examples, are kept as they are.
+func (p *Path) AddTopic(name, title, content string) error {
+	if c, ok := p.registry().entries[name]; ok {
+		return fmt.Errorf("Topic %q collides with the command of the same name%s", name, c.registeredAtSuffix())
+	}
+	if _, ok := p.aliases[name]; ok {
+		return fmt.Errorf("Topic %q collides with the alias of the same name", name)
+	}
+	if _, ok := p.topics[name]; ok {
+		return fmt.Errorf("Topic %q is already registered", name)
+	}
+	if p.topics == nil {
+		p.topics = make(map[string]topic)
+	}
+	p.topics[name] = topic{title: title, content: content}
+	return nil
+}
+
+// Writes the title and the content of the named topic to w, wrapped
+// to the width of the terminal w writes to, $COLUMNS or 80 columns.
+func (p *Path) WriteTopic(w io.Writer, name string) error {
+	t, ok := p.topics[name]
+	if !ok {
+		return fmt.Errorf("No such help topic %q", name)
+	}
+	writeTopic(w, t, terminalWidth(w))
+	return nil
+}
+
+func writeTopic(w io.Writer, t topic, width int) {
+	fmt.Fprintf(w, "%s\n\n", t.title)
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			fmt.Fprint(w, wrapText(strings.Join(para, " "), width))
+			para = nil
+		}
+	}
+	for _, line := range strings.Split(strings.TrimRight(t.content, "\n"), "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			fmt.Fprintln(w)
+		case line[0] == ' ' || line[0] == '\t':
+			flush()
+			fmt.Fprintln(w, line)
+		default:
+			para = append(para, strings.TrimSpace(line))
+		}
+	}
+	flush()
+}
+
+// Breaks s into lines of at most width bytes at spaces, each ending
+// in a newline. Words longer than width get a line of their own.
+func wrapText(s string, width int) string {
+	var b strings.Builder
+	n := 0
+	for _, word := range strings.Fields(s) {
+		if n > 0 && n+1+len(word) > width {
+			b.WriteByte('\n')
+			n = 0
+		}
+		if n > 0 {
+			b.WriteByte(' ')
+			n++
+		}
+		b.WriteString(word)
+		n += len(word)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// Writes the topics of p sorted by name, if any.
+func (p *Path) writeTopicList(w io.Writer) {
+	if len(p.topics) == 0 {
+		return
+	}
+	names := make([]string, 0, len(p.topics))
+	for name := range p.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "\nAdditional help topics:")
+	for _, name := range names {
+		fmt.Fprintf(w, "\t%s\t%s\n", name, p.topics[name].title)
+	}
+}
+
+// Registers the command help. Without arguments it lists the commands
+// and topics like PrintAvailableCommands, help COMMAND... prints the
+// help of a command, such as "help remote add", and help TOPIC a topic
+// registered with AddTopic. The output goes through the pager, see
+// SetPager.
+func (p *Path) AddHelpCommand() *CmdCont {
+	return p.Add("help", "shows the help of a command or topic", &helpCmd{p: p})
+}
+
+type helpCmd struct {
+	p *Path
+}
+
+func (c *helpCmd) Flags(fs *flag.FlagSet) {
+}
+
+func (c *helpCmd) Run(args ...string) error {
+	return c.RunContext(context.Background(), args...)
+}
+
+// Prints the help of the command named by args if the authorizer of the
+// path allows the caller to run it and every path on the way, see
+// SetAuthorizer.
+func (c *helpCmd) RunContext(ctx context.Context, args ...string) error {
+	w := c.p.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	q := c.p
+	for i, name := range args {
+		if t, ok := q.topics[name]; ok && i == len(args)-1 {
+			width := terminalWidth(w)
+			q.page(w, func(w io.Writer) { writeTopic(w, t, width) })
+			return nil
+		}
+		cont := q.Lookup(name)
+		if cont == nil {
+			return q.unknownCommand(name)
+		}
+		if err := q.authorize(ctx, cont); err != nil {
+			return err
+		}
+		if i < len(args)-1 {
+			if cont.sub == nil {
+				return fmt.Errorf("Command %q has no sub-command %q", cont.CommandPath(), args[i+1])
+			}
+			q = cont.sub
+			continue
+		}
+		q.page(w, func(w io.Writer) {
+			q.WriteHelp(w, cont)
+			if cont.sub != nil {
+				fmt.Fprintln(w)
+				cont.sub.writeAvailableCommands(w)
+			}
+		})
+		return nil
+	}
+	q.page(w, q.writeAvailableCommands)
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const tutorialTopic = `Commands are run by name, followed by their flags and arguments.
+Global flags come before the name.
+
+For example:
+  app -verbose deploy -env prod
+`
+
+func newTopicPath(t *testing.T) *Path {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{})
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	if err := p.AddTopic("tutorial", "An introduction to app", tutorialTopic); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTopicListing(t *testing.T) {
+	p := newTopicPath(t)
+	p.AddTopic("environment", "Environment variables read by app", "APP_HOME is the data directory.")
+	var b strings.Builder
+	p.writeAvailableCommands(&b)
+	want := "\nAdditional help topics:\n\tenvironment\tEnvironment variables read by app\n\ttutorial\tAn introduction to app\n"
+	if !strings.HasSuffix(b.String(), want) {
+		t.Errorf("The listing should end with %q but was %q.", want, b.String())
+	}
+}
+
+func TestWriteTopic(t *testing.T) {
+	p := newTopicPath(t)
+	t.Setenv("COLUMNS", "30")
+	var b strings.Builder
+	if err := p.WriteTopic(&b, "tutorial"); err != nil {
+		t.Fatal(err)
+	}
+	want := `An introduction to app
+
+Commands are run by name,
+followed by their flags and
+arguments. Global flags come
+before the name.
+
+For example:
+  app -verbose deploy -env prod
+`
+	if b.String() != want {
+		t.Errorf("The topic should be %q but was %q.", want, b.String())
+	}
+	if err := p.WriteTopic(&b, "deploy"); err == nil {
+		t.Error("Commands should not be written as topics.")
+	}
+}
+
+func TestRunTopic(t *testing.T) {
+	p := newTopicPath(t)
+	_, err := p.Run("tutorial")
+	if !errors.Is(err, ErrNoSuchCmd) {
+		t.Fatalf("Running a topic should fail with ErrNoSuchCmd but error was %v.", err)
+	}
+	if want := `"tutorial" is a help topic, not a command; read it with "help tutorial".`; err.Error() != want {
+		t.Errorf("The error should be %q but was %q.", want, err.Error())
+	}
+}
+
+func TestTopicCollisions(t *testing.T) {
+	p := newTopicPath(t)
+	if err := p.LoadAliases(map[string]string{"ship": "deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"deploy", "remote", "ship", "tutorial"} {
+		if err := p.AddTopic(name, "", ""); err == nil {
+			t.Errorf("AddTopic should reject the name %q.", name)
+		}
+	}
+	if _, err := p.AddE("tutorial", "", &testCmd{}); err == nil {
+		t.Error("AddE should reject the name of a topic.")
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	p := newTopicPath(t)
+	p.AddHelpCommand()
+	var out strings.Builder
+	p.stdout = &out
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "Additional help topics:\n\ttutorial\tAn introduction to app\n"},
+		{[]string{"tutorial"}, "An introduction to app\n\nCommands are run by name,"},
+		{[]string{"deploy"}, "Usage: deploy [flags]\n"},
+		{[]string{"remote", "add"}, "Usage: remote add [flags]\n"},
+		{[]string{"remote"}, "Usage: remote [flags]\n\nmanages remotes\n\nAvailable commands:\n\tadd\tadds a remote\n"},
+	} {
+		out.Reset()
+		if _, err := p.Run(append([]string{"help"}, test.args...)...); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), test.want) {
+			t.Errorf("help %q should print %q but printed %q.", test.args, test.want, out.String())
+		}
+	}
+	for _, args := range [][]string{{"destroy"}, {"deploy", "now"}, {"remote", "tutorial"}} {
+		if _, err := p.Run(append([]string{"help"}, args...)...); err == nil {
+			t.Errorf("help %q should fail.", args)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Enables the tracing of the dispatch pipeline of Run, if something
+// surprising happens: the arguments, the matched command, the rewriting
+// of flag names, the final value and source of every flag, and the
+// checks of the flags. The values of secret flags are redacted. Mounted
+// paths trace to the writer of the enclosing path unless set
+// themselves. If w is nil, which is the default, the trace is logged by
+// -v -v, see EnableVerboseFlag, or written to os.Stderr if
+// $COMMAND_TRACE is set to a non-empty value.
+func (p *Path) SetTrace(w io.Writer) {
+	p.trace = w
+}
+
+// Returns the destination of the trace of p, nil if disabled.
+func (p *Path) tracer() io.Writer {
+	for q := p; q != nil; q = q.parent {
+		if q.trace != nil {
+			return q.trace
+		}
+	}
+	if l := p.verboseTracer(); l != nil {
+		return traceWriter{l}
+	}
+	if os.Getenv("COMMAND_TRACE") != "" {
+		return os.Stderr
+	}
+	return nil
+}
+
+// Writes the value and source of every flag of fs to w. Aliases are
+// listed as their target.
+func traceFlags(w io.Writer, prefix string, fs *flag.FlagSet, sources map[string]Source, secret func(*flag.Flag) bool) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*aliasValue); ok {
+			return
+		}
+		value := f.Value.String()
+		if secret != nil && secret(f) {
+			value = redacted
+		}
+		fmt.Fprintf(w, "trace: %sflag -%s = %q (%s)\n", prefix, f.Name, value, sources[f.Name])
+	})
+}
+
+// Redacts the values of secret flags from args for the trace.
+func (c *CmdCont) traceArgs(args []string) []string {
+	if len(c.secret) == 0 {
+		return args
+	}
+	secrets := c.secretValues(c.normalizeArgs(args))
+	redactedArgs := make([]string, len(args))
+	for i, arg := range args {
+		redactedArgs[i] = redactValue(arg, secrets)
+	}
+	return redactedArgs
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	p := NewPath()
+	p.Flags.Bool("verbose", false, "verbose output")
+	remote := NewPath()
+	c := remote.Add("add", "adds a remote", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("name", "", "remote name")
+			fs.String("token", "", "API token")
+			fs.Bool("dry-run", false, "only print the changes")
+		},
+	}, "name")
+	c.MarkSecret("token")
+	p.Mount("remote", "manages remotes", remote)
+	var out strings.Builder
+	p.SetTrace(&out)
+	if _, err := p.Run("-verbose", "remote", "add", "-name", "origin", "-token", "s3cr3t", "-dry_run", "url"); err != nil {
+		t.Fatal(err)
+	}
+	trace := out.String()
+	for _, want := range []string{
+		`trace: path "": global args ["-verbose"]`,
+		`trace: flag -verbose = "true" (cli)`,
+		`trace: matched mounted path "remote"`,
+		`trace: matched command "add"`,
+		`trace: add: args ["-name" "origin" "-token" "****" "-dry_run" "url"]`,
+		`trace: add: normalized -dry_run to -dry-run`,
+		`trace: add: flag -dry-run = "true" (cli)`,
+		`trace: add: flag -name = "origin" (cli)`,
+		`trace: add: flag -token = "****" (cli)`,
+		`trace: add: checking required flags ["name"]`,
+		`trace: add: running with args ["url"]`,
+		`trace: add: done`,
+	} {
+		if !strings.Contains(trace, want+"\n") {
+			t.Errorf("Trace should contain %q but was %q.", want, trace)
+		}
+	}
+	if strings.Contains(trace, "s3cr3t") {
+		t.Errorf("Trace should not contain the secret but was %q.", trace)
+	}
+}
+
+func TestTraceFailure(t *testing.T) {
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{
+		flags: func(fs *flag.FlagSet) {
+			fs.String("env", "", "target environment")
+		},
+	}, "env")
+	var out strings.Builder
+	p.SetTrace(&out)
+	p.Run("deploy")
+	p.Run("destroy")
+	for _, want := range []string{
+		`trace: deploy: flag -env = "" (default)`,
+		`trace: deploy: failed: deploy: required flags not set: --env`,
+		`trace: no such command "destroy"`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("Trace should contain %q but was %q.", want, out.String())
+		}
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	t.Setenv("COMMAND_TRACE", "")
+	if p.tracer() != nil {
+		t.Error("Tracing should be disabled by default.")
+	}
+	t.Setenv("COMMAND_TRACE", "1")
+	if p.tracer() == nil {
+		t.Error("$COMMAND_TRACE should enable tracing.")
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Returned by the func passed to Walk for a mounted path to skip the
+// commands of the path.
+var SkipPath = errors.New("skip this path")
+
+// Calls fn for the commands of p and of the paths mounted to it in
+// lexicographical order, the container of each mounted path before its
+// commands. depth is 0 for the commands of p and increases by one per
+// mounted path. Walk stops at the first error returned by fn other
+// than SkipPath and returns it.
+func (p *Path) Walk(fn func(c *CmdCont, depth int) error) error {
+	return p.walk(fn, 0)
+}
+
+func (p *Path) walk(fn func(c *CmdCont, depth int) error, depth int) error {
+	r := p.registry()
+	for _, name := range r.names {
+		c := r.entries[name]
+		err := fn(c, depth)
+		if err == SkipPath {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if c.sub != nil {
+			if err := c.sub.walk(fn, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Options for WriteTree.
+type TreeOptions struct {
+	// draw the tree with box-drawing characters instead of indenting
+	// the commands of mounted paths
+	Box bool
+	// list the aliases loaded by LoadAliases below the commands of
+	// their path
+	Aliases bool
+	// include hidden and disabled commands, see Hide and EnabledIf,
+	// marked as such
+	Hidden bool
+	// show the number of flags of each command, which registers them,
+	// see FlagSet
+	FlagCounts bool
+	// show where each command was registered, see RegisteredAt
+	Callers bool
+}
+
+// A line of the tree written by WriteTree and the lines below it.
+type treeNode struct {
+	label    string
+	children []*treeNode
+}
+
+// Writes the commands of p and of the paths mounted to it as a tree,
+// one command per line in lexicographical order:
+//
+//	deploy
+//	remote
+//	  add
+//
+// It is meant for debugging large compositions of paths.
+func (p *Path) WriteTree(w io.Writer, opts TreeOptions) error {
+	root := &treeNode{}
+	// the nodes of the mounted paths enclosing the current command
+	stack := []*treeNode{root}
+	paths := map[*treeNode]*Path{root: p}
+	err := p.Walk(func(c *CmdCont, depth int) error {
+		if !c.listed() && !opts.Hidden {
+			return SkipPath
+		}
+		n := &treeNode{label: treeLabel(c, opts)}
+		stack = stack[:depth+1]
+		parent := stack[depth]
+		parent.children = append(parent.children, n)
+		if c.sub != nil {
+			stack = append(stack, n)
+			paths[n] = c.sub
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if opts.Aliases {
+		for n, q := range paths {
+			n.children = append(n.children, aliasNodes(q)...)
+		}
+	}
+	var b strings.Builder
+	for i, n := range root.children {
+		writeTreeNode(&b, n, "", i == len(root.children)-1, opts.Box)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func treeLabel(c *CmdCont, opts TreeOptions) string {
+	label := c.Name
+	if opts.FlagCounts {
+		if n := len(collectFlags(c.FlagSet(), nil)); n == 1 {
+			label += " (1 flag)"
+		} else {
+			label += fmt.Sprintf(" (%d flags)", n)
+		}
+	}
+	if opts.Callers {
+		label += c.registeredAtSuffix()
+	}
+	if c.hiddenCmd {
+		label += " [hidden]"
+	}
+	if enabled, _ := c.Enabled(); !enabled {
+		label += " [disabled]"
+	}
+	return label
+}
+
+// Returns the aliases of p as tree nodes, sorted by name.
+func aliasNodes(p *Path) []*treeNode {
+	names := p.aliasNames()
+	nodes := make([]*treeNode, len(names))
+	for i, name := range names {
+		nodes[i] = &treeNode{label: fmt.Sprintf("%s (alias for %s)", name, strings.Join(p.aliases[name], " "))}
+	}
+	return nodes
+}
+
+// Writes n and its children, prefixed by prefix, the prefix of its
+// parent's line. last reports whether n is the last child of its
+// parent.
+func writeTreeNode(b *strings.Builder, n *treeNode, prefix string, last, box bool) {
+	branch, indent := "", "  "
+	if box {
+		branch, indent = "├── ", "│   "
+		if last {
+			branch, indent = "└── ", "    "
+		}
+	}
+	fmt.Fprintf(b, "%s%s%s\n", prefix, branch, n.label)
+	for i, child := range n.children {
+		writeTreeNode(b, child, prefix+indent, i == len(n.children)-1, box)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newWalkPath() *Path {
+	origin := NewPath()
+	origin.Add("push", "pushes to the origin", &testCmd{})
+	remote := NewPath()
+	remote.Add("add", "adds a remote", &testCmd{})
+	remote.Mount("origin", "manages the origin", origin)
+	p := NewPath()
+	p.Add("status", "prints the status", &testCmd{})
+	p.Mount("remote", "manages remotes", remote)
+	p.Add("debug", "dumps the state", &testCmd{}).Hide()
+	return p
+}
+
+func TestWalk(t *testing.T) {
+	p := newWalkPath()
+	var got []string
+	walk := func(skip string) error {
+		got = nil
+		return p.Walk(func(c *CmdCont, depth int) error {
+			got = append(got, strings.Repeat(".", depth)+c.Name)
+			if c.Name == skip {
+				return SkipPath
+			}
+			return nil
+		})
+	}
+	if err := walk(""); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"debug", "remote", ".add", ".origin", "..push", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk should visit %q but visited %q.", want, got)
+	}
+	walk("origin")
+	if want := []string{"debug", "remote", ".add", ".origin", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SkipPath should skip the commands of origin but Walk visited %q.", got)
+	}
+	stop := errors.New("stop")
+	err := p.Walk(func(c *CmdCont, depth int) error { return stop })
+	if err != stop {
+		t.Errorf("Walk should return the error of fn but returned %v.", err)
+	}
+}
+
+func TestWriteTreeIndented(t *testing.T) {
+	var b strings.Builder
+	if err := newWalkPath().WriteTree(&b, TreeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "remote\n  add\n  origin\n    push\nstatus\n"; b.String() != want {
+		t.Errorf("The tree should be %q but was %q.", want, b.String())
+	}
+}
+
+func TestHide(t *testing.T) {
+	p := newWalkPath()
+	if got := p.Complete("d"); len(got) != 0 {
+		t.Errorf("Hidden commands should not be completed but completions were %q.", got)
+	}
+	if _, err := p.Run("debug"); err != nil {
+		t.Errorf("Hidden commands should still run but error was %v.", err)
+	}
+}
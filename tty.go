@@ -0,0 +1,66 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io"
+	"os"
+)
+
+// Reports whether f is an interactive terminal, such as os.Stdin when
+// the program isn't run with redirected input.
+func IsTerminal(f *os.File) bool {
+	return f != nil && isTerminal(f.Fd())
+}
+
+// Overrides the detection of terminals by p and the paths mounted
+// below it: prompting for missing flags, line editing in
+// ShellInteractive, the pager and Interactive behave as if standard
+// input and output were terminals if set and as if they weren't
+// otherwise, regardless of the actual streams. This is
+// mostly useful for tests, which don't run on a terminal.
+func (p *Path) ForceInteractive(interactive bool) {
+	p.interactive = &interactive
+}
+
+// Reports whether the program can interact with the user, that is if
+// os.Stdin is a terminal, unless overridden with ForceInteractive.
+func (p *Path) Interactive() bool {
+	if forced, ok := p.forcedInteractive(); ok {
+		return forced
+	}
+	return IsTerminal(os.Stdin)
+}
+
+// Returns the value set by ForceInteractive on p or the closest of the
+// enclosing paths.
+func (p *Path) forcedInteractive() (bool, bool) {
+	for q := p; q != nil; q = q.parent {
+		if q.interactive != nil {
+			return *q.interactive, true
+		}
+	}
+	return false, false
+}
+
+// Reports whether w is a terminal, unless overridden with
+// ForceInteractive.
+func (p *Path) isTerminalWriter(w io.Writer) bool {
+	if forced, ok := p.forcedInteractive(); ok {
+		return forced
+	}
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f)
+}
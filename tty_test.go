@@ -0,0 +1,113 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if IsTerminal(f) {
+		t.Error("A regular file should not be a terminal.")
+	}
+	if IsTerminal(nil) {
+		t.Error("A nil file should not be a terminal.")
+	}
+}
+
+func TestForceInteractive(t *testing.T) {
+	p := NewPath()
+	sub := NewPath()
+	p.Mount("sub", "nested commands", sub)
+	p.ForceInteractive(true)
+	if !p.Interactive() || !sub.Interactive() {
+		t.Error("ForceInteractive(true) should be interactive for mounted paths.")
+	}
+	sub.ForceInteractive(false)
+	if !p.Interactive() || sub.Interactive() {
+		t.Error("ForceInteractive on a mounted path should only override it.")
+	}
+}
+
+func TestForceInteractivePrompt(t *testing.T) {
+	var user, password string
+	p, _ := newPromptPath(&user, &password)
+	term := &fakeTerminal{lines: []string{"hunter2"}}
+	p.SetTerminal(term)
+	p.ForceInteractive(true)
+	if _, err := p.Run("login", "--user", "gopher"); err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2" {
+		t.Errorf("password should be %q but was %q.", "hunter2", password)
+	}
+
+	p, _ = newPromptPath(&user, &password)
+	term = &fakeTerminal{tty: true}
+	p.SetTerminal(term)
+	p.ForceInteractive(false)
+	if _, err := p.Run("login", "--user", "gopher"); err == nil {
+		t.Error("Run should fail without prompting.")
+	}
+	if len(term.prompts) != 0 {
+		t.Errorf("ForceInteractive(false) should not prompt but prompted %q.", term.prompts)
+	}
+}
+
+func TestForceInteractivePassword(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.WriteString("hunter2\n")
+	w.Close()
+	var out strings.Builder
+	term := &stdTerminal{in: r, out: &out}
+	v, err := term.ReadLine("password: ", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "hunter2" || out.String() != "password: " {
+		t.Errorf("Password and output should be %q and %q but were %q and %q.", "hunter2", "password: ", v, out.String())
+	}
+}
+
+func TestForceInteractiveShell(t *testing.T) {
+	p := NewPath()
+	var runs int
+	p.Add("status", "prints the status", &testCmd{
+		run: func(args ...string) error {
+			runs++
+			return nil
+		},
+	})
+	p.ForceInteractive(false)
+	term := &testRawTerminal{Reader: strings.NewReader("status\n")}
+	if err := p.ShellInteractive(ShellOptions{Terminal: term}); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 1 || term.raw != 0 {
+		t.Errorf("Shell should read lines without raw mode but ran %d times in raw mode %d times.", runs, term.raw)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Returns a command calling fn with its positional arguments converted
+// to the types of its parameters, for commands taking arguments
+// rather than flags:
+//
+//	p.Add("resize", "resizes a volume", command.TypedFunc(
+//		func(size int, name string, tags ...string) error {
+//			return resize(name, size, tags)
+//		}))
+//
+// The parameters are of type string, int, bool, float64 or
+// time.Duration, optionally preceded by a context.Context, which is
+// the one passed to RunContext, and fn returns an error. A variadic
+// parameter accepts any number of trailing arguments. Run fails with a
+// PositionalArgError if the number of arguments doesn't match or an
+// argument can't be converted. The help of the command shows the
+// arguments derived from the parameters, such as
+//
+//	Usage: resize [flags] <int> <string> [<string>...]
+//
+// TypedFunc panics if fn isn't a function of that form.
+func TypedFunc(fn interface{}) Cmd {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		panic(fmt.Sprintf("TypedFunc requires a function, not %T", fn))
+	}
+	t := fv.Type()
+	if t.NumOut() != 1 || t.Out(0) != errorType {
+		panic(fmt.Sprintf("TypedFunc requires a function returning an error, not %s", t))
+	}
+	c := &typedCmd{fn: fv, variadic: t.IsVariadic()}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if i == 0 && in == contextType {
+			c.context = true
+			continue
+		}
+		if c.variadic && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		if _, ok := typedParsers[in]; !ok {
+			panic(fmt.Sprintf("TypedFunc: unsupported parameter type %s of %s", in, t))
+		}
+		c.params = append(c.params, in)
+	}
+	return c
+}
+
+type typedCmd struct {
+	fn reflect.Value
+	// the types of the positional parameters, the element type for the
+	// variadic one
+	params   []reflect.Type
+	context  bool
+	variadic bool
+	fs       *flag.FlagSet
+}
+
+var typedParsers = map[reflect.Type]func(string) (interface{}, error){
+	reflect.TypeOf(""): func(s string) (interface{}, error) {
+		return s, nil
+	},
+	reflect.TypeOf(0): func(s string) (interface{}, error) {
+		v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+		return int(v), err
+	},
+	reflect.TypeOf(false): func(s string) (interface{}, error) {
+		return strconv.ParseBool(s)
+	},
+	reflect.TypeOf(0.0): func(s string) (interface{}, error) {
+		return strconv.ParseFloat(s, 64)
+	},
+	reflect.TypeOf(time.Duration(0)): func(s string) (interface{}, error) {
+		return time.ParseDuration(s)
+	},
+}
+
+func (c *typedCmd) Flags(fs *flag.FlagSet) {
+	c.fs = fs
+}
+
+func (c *typedCmd) Run(args ...string) error {
+	return c.RunContext(context.Background(), args...)
+}
+
+func (c *typedCmd) RunContext(ctx context.Context, args ...string) error {
+	in, err := c.convert(args)
+	if err != nil {
+		return err
+	}
+	if c.context {
+		in = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, in...)
+	}
+	err, _ = c.fn.Call(in)[0].Interface().(error)
+	return err
+}
+
+// Converts args to the values of the positional parameters.
+func (c *typedCmd) convert(args []string) ([]reflect.Value, error) {
+	fixed := len(c.params)
+	if c.variadic {
+		fixed--
+	}
+	if len(args) < fixed || !c.variadic && len(args) > fixed {
+		return nil, &PositionalArgError{Command: c.name(), Usage: c.argsUsage(), Args: len(args)}
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		typ := c.params[len(c.params)-1]
+		if i < fixed {
+			typ = c.params[i]
+		}
+		v, err := typedParsers[typ](arg)
+		if err != nil {
+			if ne := (*strconv.NumError)(nil); errors.As(err, &ne) {
+				err = ne.Err
+			}
+			return nil, &PositionalArgError{Command: c.name(), Usage: c.argsUsage(), Args: len(args),
+				Position: i + 1, Type: typeArg(typ), Value: arg, Err: err}
+		}
+		in[i] = reflect.ValueOf(v)
+	}
+	return in, nil
+}
+
+func (c *typedCmd) name() string {
+	if c.fs == nil {
+		return ""
+	}
+	return c.fs.Name()
+}
+
+// Returns the positional arguments for the usage line of the help.
+func (c *typedCmd) argsUsage() string {
+	args := make([]string, len(c.params))
+	for i, typ := range c.params {
+		args[i] = "<" + typeArg(typ) + ">"
+	}
+	if c.variadic {
+		args[len(args)-1] = "[" + args[len(args)-1] + "...]"
+	}
+	return strings.Join(args, " ")
+}
+
+func typeArg(typ reflect.Type) string {
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	return typ.String()
+}
+
+// Commands implementing argsUsager describe their positional arguments
+// in the usage line of their help.
+type argsUsager interface {
+	argsUsage() string
+}
+
+// Returned by commands of TypedFunc if the positional arguments don't
+// match the parameters of the function, and by Run if they don't match
+// the arguments declared with RequiredArg and OptionalArg. The message
+// has one of the forms
+//
+//	resize: expected arguments <int> <string> [<string>...], got 1
+//	resize: argument 1: invalid int "10G": invalid syntax
+type PositionalArgError struct {
+	Command string
+	// the arguments expected, such as "<int> <string>"
+	Usage string
+	// the number of arguments given
+	Args int
+	// the 1-based position of the argument that can't be converted to
+	// Type, 0 if the number of arguments is wrong
+	Position int
+	Type     string
+	Value    string
+	Err      error
+}
+
+func (e *PositionalArgError) Error() string {
+	if e.Position == 0 && e.Usage == "" {
+		return fmt.Sprintf("%sexpected no arguments, got %d", commandPrefix(e.Command), e.Args)
+	}
+	if e.Position == 0 {
+		return fmt.Sprintf("%sexpected arguments %s, got %d", commandPrefix(e.Command), e.Usage, e.Args)
+	}
+	return fmt.Sprintf("%sargument %d: invalid %s %q: %v", commandPrefix(e.Command), e.Position, e.Type, e.Value, e.Err)
+}
+
+func (e *PositionalArgError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PositionalArgError) Is(target error) bool {
+	return target == ErrCmdUsage
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedFunc(t *testing.T) {
+	type call struct {
+		size    int
+		name    string
+		force   bool
+		ratio   float64
+		timeout time.Duration
+	}
+	var got call
+	p := NewPath()
+	p.Add("resize", "resizes a volume", TypedFunc(func(size int, name string, force bool, ratio float64, timeout time.Duration) error {
+		got = call{size, name, force, ratio, timeout}
+		return nil
+	}))
+	if _, err := p.Run("resize", "0x10", "data", "true", "1.5", "30s"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (call{16, "data", true, 1.5, 30 * time.Second}); got != want {
+		t.Errorf("Arguments should be %v but were %v.", want, got)
+	}
+}
+
+func TestTypedFuncVariadic(t *testing.T) {
+	var name string
+	var tags []string
+	var ctxCont *CmdCont
+	p := NewPath()
+	p.Add("tag", "tags an image", TypedFunc(func(ctx context.Context, image string, rest ...string) error {
+		ctxCont, _ = FromContext(ctx)
+		name, tags = image, rest
+		return nil
+	}))
+	for _, args := range [][]string{{"app"}, {"app", "v1", "latest"}} {
+		if _, err := p.Run(append([]string{"tag"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+		if name != "app" || strings.Join(tags, " ") != strings.Join(args[1:], " ") {
+			t.Errorf("Arguments should be %q but were %q %q.", args, name, tags)
+		}
+	}
+	if ctxCont == nil || ctxCont.Name != "tag" {
+		t.Errorf("The context should carry the container of tag but was %v.", ctxCont)
+	}
+}
+
+func TestTypedFuncErrors(t *testing.T) {
+	p := NewPath()
+	p.Add("resize", "resizes a volume", TypedFunc(func(size int, name string, tags ...bool) error {
+		return nil
+	}))
+	for _, test := range []struct {
+		args []string
+		err  string
+		pos  int
+	}{
+		{[]string{"10"}, "resize: expected arguments <int> <string> [<bool>...], got 1", 0},
+		{[]string{"10G", "data"}, `resize: argument 1: invalid int "10G": invalid syntax`, 1},
+		{[]string{"10", "data", "true", "maybe"}, `resize: argument 4: invalid bool "maybe": invalid syntax`, 4},
+	} {
+		_, err := p.Run(append([]string{"resize"}, test.args...)...)
+		var pe *PositionalArgError
+		if !errors.As(err, &pe) || pe.Position != test.pos {
+			t.Fatalf("Run(%q) should fail with a PositionalArgError at %d but was %v.", test.args, test.pos, err)
+		}
+		if err.Error() != test.err {
+			t.Errorf("Error should be %q but was %q.", test.err, err)
+		}
+		if !errors.Is(err, ErrCmdUsage) {
+			t.Errorf("Error %v should be a usage error.", err)
+		}
+	}
+	p.Add("stop", "stops the app", TypedFunc(func() error { return nil }))
+	if _, err := p.Run("stop", "now"); err == nil || err.Error() != "stop: expected no arguments, got 1" {
+		t.Errorf("Extra arguments should fail but was %v.", err)
+	}
+}
+
+func TestTypedFuncUsage(t *testing.T) {
+	p := NewPath()
+	c := p.Add("wait", "waits for the app", TypedFunc(func(timeout time.Duration, names ...string) error {
+		return nil
+	}))
+	var out strings.Builder
+	p.WriteHelp(&out, c)
+	if want := "Usage: wait [flags] <duration> [<string>...]\n"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("Help should start with %q but was %q.", want, out.String())
+	}
+}
+
+func TestTypedFuncInvalid(t *testing.T) {
+	for _, fn := range []interface{}{
+		"wait",
+		func(int) {},
+		func(uint8) error { return nil },
+		func(string, context.Context) error { return nil },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TypedFunc should panic for %T.", fn)
+				}
+			}()
+			TypedFunc(fn)
+		}()
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Defines a URL flag with specified name, default value, and usage
+// string. The argument p points to a *url.URL variable in which to
+// store the parsed value of the flag, nil for an empty default. If
+// schemes are given, URLs with other schemes are rejected at parse
+// time. The help output shows the default as given.
+func URLVar(fs *flag.FlagSet, p **url.URL, name, value, usage string, schemes ...string) {
+	v := &urlValue{p: p, schemes: schemes}
+	*p = nil
+	if value != "" {
+		if u, err := url.Parse(value); err == nil {
+			*p = u
+		}
+	}
+	fs.Var(v, name, usage)
+	fs.Lookup(name).DefValue = value
+}
+
+type urlValue struct {
+	p       **url.URL
+	schemes []string
+}
+
+func (v *urlValue) Set(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		// strip the redundant prefix naming the operation and URL
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return err
+	}
+	if len(v.schemes) > 0 && !v.allowed(u.Scheme) {
+		return fmt.Errorf("scheme %q is not allowed, must be one of %s", u.Scheme, strings.Join(v.schemes, ", "))
+	}
+	*v.p = u
+	return nil
+}
+
+func (v *urlValue) allowed(scheme string) bool {
+	for _, s := range v.schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *urlValue) String() string {
+	if v.p == nil || *v.p == nil {
+		return ""
+	}
+	return (*v.p).String()
+}
+
+func (v *urlValue) Get() interface{} {
+	return *v.p
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestURLVar(t *testing.T) {
+	for _, s := range []string{"http://example.com/api", "https://example.com:8443/v1?x=1"} {
+		var u *url.URL
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		URLVar(fs, &u, "endpoint", "", "API endpoint", "http", "https")
+		if err := fs.Parse([]string{"-endpoint", s}); err != nil {
+			t.Fatal(err)
+		}
+		if u == nil || u.String() != s {
+			t.Errorf("endpoint should be %q but was %v.", s, u)
+		}
+	}
+}
+
+func TestURLVarDefault(t *testing.T) {
+	var u, empty *url.URL
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out strings.Builder
+	fs.SetOutput(&out)
+	URLVar(fs, &u, "endpoint", "https://example.com", "API endpoint")
+	URLVar(fs, &empty, "proxy", "", "proxy")
+	if u == nil || u.Host != "example.com" {
+		t.Errorf("The default should be parsed but was %v.", u)
+	}
+	if empty != nil {
+		t.Errorf("An empty default should be nil but was %v.", empty)
+	}
+	fs.PrintDefaults()
+	if want := "(default https://example.com)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Help should contain %q but was %q.", want, out.String())
+	}
+}
+
+func TestURLVarInvalid(t *testing.T) {
+	for _, s := range []string{"ftp://example.com", "http://exa mple.com", "://missing-scheme"} {
+		var u *url.URL
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		URLVar(fs, &u, "endpoint", "", "API endpoint", "http", "https")
+		err := fs.Parse([]string{"-endpoint", s})
+		if err == nil {
+			t.Errorf("%q should be rejected.", s)
+		} else if !strings.Contains(err.Error(), "-endpoint") {
+			t.Errorf("The error should name the flag but was %q.", err)
+		}
+		if u != nil {
+			t.Errorf("%q should not be stored but was %v.", s, u)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The directory searched last by LoadUserConfig, replaced by tests.
+var systemConfigDir = "/etc"
+
+// The file names searched by LoadUserConfig in each directory and
+// their loaders.
+var userConfigFiles = []struct {
+	name string
+	load func(*Path, io.Reader) error
+}{
+	{"config.json", (*Path).LoadConfigJSON},
+	{"config.yaml", (*Path).LoadConfigYAML},
+	{"config.toml", (*Path).LoadConfigTOML},
+}
+
+// Loads the first configuration file of app found in the standard
+// locations: the directory app in $XDG_CONFIG_HOME, in ~/.config and
+// in /etc, each searched for config.json, config.yaml and config.toml
+// in this order. The file is read with the loader of its format, see
+// LoadConfigJSON, and reported by ConfigFile. Finding no file isn't an
+// error.
+func (p *Path) LoadUserConfig(app string) error {
+	var dirs []string
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	dirs = append(dirs, systemConfigDir)
+	for _, dir := range dirs {
+		for _, f := range userConfigFiles {
+			name := filepath.Join(dir, app, f.name)
+			if _, err := os.Stat(name); os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("Reading config: %w", err)
+			}
+			if err := loadConfigFile(name, func(r io.Reader) error { return f.load(p, r) }); err != nil {
+				return err
+			}
+			p.configFile = name
+			return nil
+		}
+	}
+	return nil
+}
+
+// Returns the file loaded by LoadUserConfig, or "" if none was found.
+func (p *Path) ConfigFile() string {
+	return p.configFile
+}
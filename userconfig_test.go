@@ -0,0 +1,94 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeUserConfig(t *testing.T, dir, name, content string) string {
+	name = filepath.Join(dir, "app", name)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoadUserConfig(t *testing.T) {
+	xdg, home, etc := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", home)
+	defer func(dir string) { systemConfigDir = dir }(systemConfigDir)
+	systemConfigDir = etc
+	files := []string{
+		writeUserConfig(t, xdg, "config.toml", "[commands.deploy]\nenv = \"xdg\"\n"),
+		writeUserConfig(t, xdg, "config.json", `{"commands": {"deploy": {"env": "xdg json"}}}`),
+		writeUserConfig(t, filepath.Join(home, ".config"), "config.yaml", "commands:\n  deploy:\n    env: home\n"),
+		writeUserConfig(t, etc, "config.toml", "[commands.deploy]\nenv = \"etc\"\n"),
+	}
+	for i, test := range []struct {
+		file, env string
+	}{
+		{files[1], "xdg json"},
+		{files[0], "xdg"},
+		{files[2], "home"},
+		{files[3], "etc"},
+		{"", ""},
+	} {
+		p := NewPath()
+		c := p.Add("deploy", "deploys the app", &testCmd{
+			flags: func(fs *flag.FlagSet) {
+				fs.String("env", "", "target environment")
+			},
+		})
+		if err := p.LoadUserConfig("app"); err != nil {
+			t.Fatal(err)
+		}
+		if p.ConfigFile() != test.file {
+			t.Errorf("Config file should be %q but was %q.", test.file, p.ConfigFile())
+		}
+		if _, err := p.Run("deploy"); err != nil {
+			t.Fatal(err)
+		}
+		if v := c.Flags.Lookup("env").Value.String(); v != test.env {
+			t.Errorf("Flag -env should be %q but was %q.", test.env, v)
+		}
+		if i < len(files) {
+			os.Remove(test.file)
+		}
+	}
+}
+
+func TestLoadUserConfigInvalid(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	name := writeUserConfig(t, xdg, "config.yaml", "commands: [")
+	p := NewPath()
+	p.Add("deploy", "deploys the app", &testCmd{})
+	err := p.LoadUserConfig("app")
+	if err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+		t.Errorf("Error should name %s but was %v.", name, err)
+	}
+	if p.ConfigFile() != "" {
+		t.Errorf("Config file should not be set on errors but was %q.", p.ConfigFile())
+	}
+}
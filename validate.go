@@ -0,0 +1,227 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type flagValidator struct {
+	name  string
+	fn    func(value string) error
+	ifSet bool
+}
+
+// Registers fn to validate the value of the named flag after parsing,
+// whether it was set or holds its default. Run reports the failures of
+// all validators of the command in one error naming each flag, and
+// doesn't run the command if any fails.
+func (c *CmdCont) ValidateFlag(name string, fn func(value string) error) error {
+	return c.addValidator(name, fn, false)
+}
+
+// Same as ValidateFlag but only validates the flag if it was set, on
+// the command line or by the configuration.
+func (c *CmdCont) ValidateFlagIfSet(name string, fn func(value string) error) error {
+	return c.addValidator(name, fn, true)
+}
+
+func (c *CmdCont) addValidator(name string, fn func(string) error, ifSet bool) error {
+	if c.FlagSet().Lookup(name) == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	c.validators = append(c.validators, flagValidator{name: name, fn: fn, ifSet: ifSet})
+	return nil
+}
+
+// Runs the checks of deferred flag values and the validators in
+// registration order and joins their failures.
+func (c *CmdCont) validateFlags() error {
+	return errors.Join(c.validatorErrors()...)
+}
+
+// Returns the failures of the checks of deferred flag values and the
+// validators in registration order.
+func (c *CmdCont) validatorErrors() []error {
+	var errs []error
+	checked := make(map[string]bool)
+	c.FlagSet().Visit(func(f *flag.Flag) {
+		// aliases are checked as their target
+		f = canonicalFlag(c.FlagSet(), f)
+		if v, ok := f.Value.(deferredValue); ok && !checked[f.Name] {
+			checked[f.Name] = true
+			if err := v.check(); err != nil {
+				errs = append(errs, &ArgError{Command: c.Name, Flag: f.Name, Value: f.Value.String(), Err: err})
+			}
+		}
+	})
+	var set map[string]bool
+	for _, v := range c.validators {
+		if v.ifSet {
+			if set == nil {
+				set = setFlags(c.FlagSet())
+			}
+			if !set[v.name] {
+				continue
+			}
+		}
+		value := c.FlagSet().Lookup(v.name).Value.String()
+		if err := v.fn(value); err != nil {
+			errs = append(errs, &ArgError{Command: c.Name, Flag: v.name, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+// Requires the named flags to be set to values that are not empty
+// after trimming whitespace, so that `--token ""` doesn't satisfy the
+// requirement. The flags are added to RequiredFlags if necessary.
+// Without names, all RequiredFlags must be non-empty.
+func (c *CmdCont) RequireNonEmpty(names ...string) error {
+	if len(names) == 0 {
+		c.allNonEmpty = true
+		c.changed()
+		return nil
+	}
+	for _, name := range names {
+		if c.FlagSet().Lookup(name) == nil {
+			return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+		}
+	}
+	if c.nonEmpty == nil {
+		c.nonEmpty = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.nonEmpty[name] = true
+		if !c.isRequired(name) {
+			c.RequiredFlags = append(c.RequiredFlags, name)
+		}
+	}
+	c.changed()
+	return nil
+}
+
+// Adds the named flag, or the flag an alias refers to, to the
+// RequiredFlags of c, for example to require a flag of a command
+// registered elsewhere. The change is reflected by the help output, the
+// documentation and later runs. Adding a flag that is already required
+// does nothing.
+func (c *CmdCont) AddRequiredFlag(name string) error {
+	f := c.FlagSet().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("No such flag -%s for command %q", name, c.Name)
+	}
+	name = canonicalFlag(c.FlagSet(), f).Name
+	if !c.isRequired(name) {
+		c.RequiredFlags = append(c.RequiredFlags, name)
+		c.changed()
+	}
+	return nil
+}
+
+// Removes the named flag, or the flag an alias refers to, from the
+// RequiredFlags of c and from the flags passed to RequireNonEmpty.
+// Reports whether the flag was required.
+func (c *CmdCont) RemoveRequiredFlag(name string) bool {
+	if f := c.FlagSet().Lookup(name); f != nil {
+		name = canonicalFlag(c.FlagSet(), f).Name
+	}
+	for i, r := range c.RequiredFlags {
+		if r == name {
+			c.RequiredFlags = append(c.RequiredFlags[:i:i], c.RequiredFlags[i+1:]...)
+			delete(c.nonEmpty, name)
+			c.changed()
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CmdCont) isRequired(name string) bool {
+	for _, r := range c.RequiredFlags {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the required flags that must not be empty but are.
+func (c *CmdCont) emptyRequiredFlags() []string {
+	if len(c.nonEmpty) == 0 && !c.allNonEmpty {
+		return nil
+	}
+	var empty []string
+	for _, name := range c.RequiredFlags {
+		if !c.allNonEmpty && !c.nonEmpty[name] {
+			continue
+		}
+		if f := c.FlagSet().Lookup(name); f != nil && strings.TrimSpace(f.Value.String()) == "" {
+			empty = append(empty, name)
+		}
+	}
+	return empty
+}
+
+// Returns a validator accepting integers between min and max inclusive.
+func IntRange(min, max int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("must be an integer between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Returns a validator accepting only the given values.
+func OneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// A validator rejecting values that are empty or only whitespace.
+func NonEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+// A validator accepting paths of existing files.
+func FileExists(value string) error {
+	fi, err := os.Stat(value)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("no such file")
+		}
+		return err
+	}
+	if fi.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFlagPass(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	c.ValidateFlag("port", IntRange(1, 65535))
+	c.ValidateFlag("env", OneOf("dev", "prod"))
+	if _, err := p.Run("deploy", "-port", "8080", "-env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateFlagFailure(t *testing.T) {
+	var opts deployOpts
+	ran := false
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	c.Cmd.(*testCmd).run = func(args ...string) error {
+		ran = true
+		return nil
+	}
+	c.ValidateFlag("port", IntRange(1, 65535))
+	_, err := p.Run("deploy", "-port", "0")
+	if err == nil {
+		t.Fatal("An invalid port should fail.")
+	}
+	if want := `invalid value "0" for flag -port: must be an integer between 1 and 65535`; err.Error() != want {
+		t.Errorf("Error should be %q but was %q.", want, err)
+	}
+	if ran {
+		t.Error("The command should not run.")
+	}
+}
+
+func TestValidateFlagAggregated(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	c.ValidateFlag("port", IntRange(1, 1024))
+	c.ValidateFlag("env", OneOf("staging", "prod"))
+	c.ValidateFlag("target", NonEmpty)
+	_, err := p.Run("deploy", "-port", "8080", "-target", "eu")
+	if err == nil {
+		t.Fatal("Invalid values should fail.")
+	}
+	msg := err.Error()
+	for _, name := range []string{"-port", "-env"} {
+		if !strings.Contains(msg, name) {
+			t.Errorf("Error should name %s but was %q.", name, msg)
+		}
+	}
+	if strings.Contains(msg, "-target") {
+		t.Errorf("Error should not name valid flags but was %q.", msg)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("Error should join 2 failures but joined %d.", n)
+	}
+}
+
+func TestValidateFlagIfSet(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts)
+	c := p.registry().entries["deploy"]
+	if err := c.ValidateFlagIfSet("target", NonEmpty); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("deploy"); err != nil {
+		t.Fatalf("An unset flag should not be validated but was %v.", err)
+	}
+	if _, err := p.Run("deploy", "-target", " "); err == nil {
+		t.Fatal("A set flag should be validated.")
+	}
+	if err := c.ValidateFlag("missing", NonEmpty); err == nil {
+		t.Error("Validating an unknown flag should fail.")
+	}
+}
+
+func TestValidators(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		fn    func(string) error
+		value string
+		ok    bool
+	}{
+		{IntRange(1, 10), "1", true},
+		{IntRange(1, 10), "10", true},
+		{IntRange(1, 10), "11", false},
+		{IntRange(1, 10), "one", false},
+		{OneOf("a", "b"), "b", true},
+		{OneOf("a", "b"), "c", false},
+		{NonEmpty, "x", true},
+		{NonEmpty, " \t", false},
+		{FileExists, file, true},
+		{FileExists, dir, false},
+		{FileExists, filepath.Join(dir, "missing"), false},
+	}
+	for i, test := range tests {
+		if err := test.fn(test.value); (err == nil) != test.ok {
+			t.Errorf("%d: validating %q should succeed=%v but was %v.", i, test.value, test.ok, err)
+		}
+	}
+}
+
+func TestRequireNonEmpty(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"deploy"}, "deploy: required flags not set: --target"},
+		{[]string{"deploy", "-target", ""}, "deploy: required flags set to empty values: --target"},
+		{[]string{"deploy", "-target", " \t"}, "deploy: required flags set to empty values: --target"},
+		{[]string{"deploy", "-target", "eu"}, ""},
+	}
+	for _, test := range tests {
+		var opts deployOpts
+		p := newDeployPath(&opts)
+		if err := p.registry().entries["deploy"].RequireNonEmpty("target"); err != nil {
+			t.Fatal(err)
+		}
+		_, err := p.Run(test.args...)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%q should fail with %q but was %v.", test.args, test.err, err)
+		}
+	}
+}
+
+func TestRequireNonEmptyAll(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts, "target", "env")
+	p.registry().entries["deploy"].RequireNonEmpty()
+	_, err := p.Run("deploy", "-target", "", "-env", "")
+	if want := "deploy: required flags set to empty values: --env, --target"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	if err := p.registry().entries["deploy"].RequireNonEmpty("missing"); err == nil {
+		t.Error("Requiring an unknown flag should fail.")
+	}
+}
+
+func TestAddRequiredFlag(t *testing.T) {
+	var opts deployOpts
+	p := newDeployPath(&opts, "env")
+	c := p.Lookup("deploy")
+	if err := c.FlagAlias("target", "t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddRequiredFlag("t"); err != nil {
+		t.Fatal(err)
+	}
+	c.AddRequiredFlag("target")
+	if want := []string{"env", "target"}; strings.Join(c.RequiredFlags, ",") != strings.Join(want, ",") {
+		t.Errorf("The required flags should be %q but were %q.", want, c.RequiredFlags)
+	}
+	_, err := p.Run("deploy", "-env", "prod")
+	if want := "deploy: required flags not set: --target"; err == nil || err.Error() != want {
+		t.Errorf("Error should be %q but was %v.", want, err)
+	}
+	for _, f := range p.Spec().Commands[0].Flags {
+		if f.Name == "target" && !f.Required {
+			t.Error("The spec should mark the flag as required.")
+		}
+	}
+	if err := c.AddRequiredFlag("missing"); err == nil {
+		t.Error("Requiring an unknown flag should fail.")
+	}
+}
+
+func TestRemoveRequiredFlag(t *testing.T) {
+	var opts deployOpts
+	required := []string{"env", "target"}
+	p := newDeployPath(&opts, required...)
+	c := p.Lookup("deploy")
+	c.RequireNonEmpty("target")
+	if !c.RemoveRequiredFlag("target") {
+		t.Error("Removing a required flag should report it was required.")
+	}
+	if c.RemoveRequiredFlag("target") || c.RemoveRequiredFlag("missing") {
+		t.Error("Removing a flag that isn't required should report false.")
+	}
+	if required[1] != "target" {
+		t.Errorf("The slice passed to Add should be left alone but was changed to %q.", required)
+	}
+	if _, err := p.Run("deploy", "-env", "prod"); err != nil {
+		t.Errorf("The flag shouldn't be required anymore but Run failed with %v.", err)
+	}
+	if _, err := p.Run("deploy", "-target", ""); err == nil || err.Error() != "deploy: required flags not set: --env" {
+		t.Errorf("The other flag should still be required but Run failed with %v.", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// The state of the --verbose flag, see EnableVerboseFlag.
+type verbosity struct {
+	count  int
+	level  slog.LevelVar
+	logger *slog.Logger
+}
+
+// Defines the global counter flag --verbose, with the alias -v, which
+// selects the level of logger after the global flags are parsed: warn
+// by default, info for -v and debug for -v -v, which also writes the
+// trace of the dispatch, see SetTrace, to logger at the debug level.
+// The handler of logger must accept debug records, records below the
+// selected level are dropped before reaching it. If logger is nil,
+// records are written to Output as text. Unless a Logger is set, the
+// informational messages of the package are logged to logger as well.
+// Commands retrieve the logger with LoggerFromContext.
+func (p *Path) EnableVerboseFlag(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(p.Output(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	v := &verbosity{}
+	v.level.Set(slog.LevelWarn)
+	v.logger = slog.New(&levelHandler{Handler: logger.Handler(), level: &v.level})
+	p.verbosity = v
+	CountVar(p.Flags, &v.count, "verbose", "log more details, repeat for debug output")
+	p.Flags.Var(&aliasValue{target: p.Flags.Lookup("verbose")}, "v", p.Flags.Lookup("verbose").Usage)
+	if p.logger == nil {
+		p.logger = slogPrinter{v.logger}
+	}
+}
+
+// Sets the level of the logger of EnableVerboseFlag according to the
+// number of times --verbose was given.
+func (p *Path) applyVerbosity() {
+	v := p.verbosity
+	if v == nil {
+		return
+	}
+	switch {
+	case v.count >= 2:
+		v.level.Set(slog.LevelDebug)
+	case v.count == 1:
+		v.level.Set(slog.LevelInfo)
+	default:
+		v.level.Set(slog.LevelWarn)
+	}
+}
+
+// Returns the logger of EnableVerboseFlag of the path running the
+// command with ctx or of an enclosing path, or slog.Default if there is
+// none.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if c, ok := FromContext(ctx); ok {
+		for p := c.path; p != nil; p = p.parent {
+			if p.verbosity != nil {
+				return p.verbosity.logger
+			}
+		}
+	}
+	return slog.Default()
+}
+
+// Returns the logger the trace is written to by -v -v, or nil.
+func (p *Path) verboseTracer() *slog.Logger {
+	for q := p; q != nil; q = q.parent {
+		if q.verbosity != nil && q.verbosity.count >= 2 {
+			return q.verbosity.logger
+		}
+	}
+	return nil
+}
+
+// Drops the records below level before passing them to the Handler.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.level.Level() && h.Handler.Enabled(ctx, l)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// Logs the messages of the package, see Logger, at the level of their
+// level hint.
+type slogPrinter struct {
+	l *slog.Logger
+}
+
+func (s slogPrinter) Printf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	switch {
+	case strings.HasPrefix(msg, "warning: "):
+		s.l.Warn(msg[len("warning: "):])
+	case strings.HasPrefix(msg, "error: "):
+		s.l.Error(msg[len("error: "):])
+	default:
+		s.l.Info(msg)
+	}
+}
+
+// Writes the lines of the trace as debug records.
+type traceWriter struct {
+	l *slog.Logger
+}
+
+func (w traceWriter) Write(b []byte) (int, error) {
+	msg := strings.TrimSuffix(string(b), "\n")
+	w.l.Debug(strings.TrimPrefix(msg, "trace: "))
+	return len(b), nil
+}
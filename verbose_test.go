@@ -0,0 +1,95 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newVerbosePath(out *strings.Builder, level *slog.Level) *Path {
+	p := NewPath()
+	p.EnableVerboseFlag(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+	p.Add("status", "prints the status", &testContextCmd{
+		runContext: func(ctx context.Context, args ...string) error {
+			l := LoggerFromContext(ctx)
+			for _, lv := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+				if l.Enabled(ctx, lv) {
+					*level = lv
+					break
+				}
+			}
+			l.Info("checking the status")
+			return nil
+		},
+	})
+	return p
+}
+
+func TestVerboseFlag(t *testing.T) {
+	t.Setenv("COMMAND_TRACE", "")
+	tests := []struct {
+		args  []string
+		level slog.Level
+		trace bool
+	}{
+		{[]string{"status"}, slog.LevelWarn, false},
+		{[]string{"-v", "status"}, slog.LevelInfo, false},
+		{[]string{"--verbose", "status"}, slog.LevelInfo, false},
+		{[]string{"-v", "-v", "status"}, slog.LevelDebug, true},
+		{[]string{"-verbose=3", "status"}, slog.LevelDebug, true},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		var level slog.Level
+		p := newVerbosePath(&out, &level)
+		if _, err := p.Run(test.args...); err != nil {
+			t.Fatal(err)
+		}
+		if level != test.level {
+			t.Errorf("The level of %q should be %v but was %v.", test.args, test.level, level)
+		}
+		if logged := strings.Contains(out.String(), "msg=\"checking the status\""); logged != (test.level <= slog.LevelInfo) {
+			t.Errorf("The info record of %q should be logged only for -v but the output was %q.", test.args, out.String())
+		}
+		trace := `level=DEBUG msg="matched command \"status\""`
+		if traced := strings.Contains(out.String(), trace); traced != test.trace {
+			t.Errorf("%q should trace %v, but the output was %q.", test.args, test.trace, out.String())
+		}
+	}
+}
+
+func TestVerboseFlagWarnings(t *testing.T) {
+	var out strings.Builder
+	var level slog.Level
+	p := newVerbosePath(&out, &level)
+	p.warn("", WarnIgnoredConfig, "Ignoring unknown config keys: %s", "colour")
+	if want := "level=WARN msg=\"Ignoring unknown config keys: colour\"\n"; out.String() != want {
+		t.Errorf("The warning should be logged as %q but was %q.", want, out.String())
+	}
+	if l := LoggerFromContext(context.Background()); l != slog.Default() {
+		t.Error("Without a command the logger should be the default one.")
+	}
+}
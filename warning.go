@@ -0,0 +1,83 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import "fmt"
+
+// The codes of the warnings of Run.
+const (
+	// A deprecated flag was set.
+	WarnDeprecatedFlag = "deprecated-flag"
+	// Unknown configuration keys were ignored, see
+	// SetIgnoreUnknownConfig.
+	WarnIgnoredConfig = "ignored-config"
+)
+
+// A problem worth mentioning that doesn't make Run fail.
+type Warning struct {
+	// the command the warning is about, empty for the path itself
+	Command string
+	// one of the Warn constants
+	Code    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// Returns the warnings of Run and loading the configuration since the
+// last call, including those of the mounted paths, and clears them.
+func (p *Path) Warnings() []Warning {
+	root := p.root()
+	warnings := root.warnings
+	root.warnings = nil
+	return warnings
+}
+
+// Print the warnings as they happen, to the Logger if set or else to
+// Output. They are printed by default.
+func (p *Path) SetPrintWarnings(print bool) {
+	p.quietWarnings = !print
+}
+
+func (p *Path) root() *Path {
+	for p.parent != nil {
+		p = p.parent
+	}
+	return p
+}
+
+// Records a warning and prints it unless disabled.
+func (p *Path) warn(command, code, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	root := p.root()
+	root.warnings = append(root.warnings, Warning{Command: command, Code: code, Message: msg})
+	if p.Quiet() {
+		return
+	}
+	for q := p; q != nil; q = q.parent {
+		if q.quietWarnings {
+			return
+		}
+	}
+	for q := p; q != nil; q = q.parent {
+		if q.logger != nil {
+			q.logger.Printf("warning: %s", msg)
+			return
+		}
+	}
+	fmt.Fprintln(p.Output(), msg)
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Drachenfels GmbH. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	for _, print := range []bool{true, false} {
+		var colour, color string
+		sub, c := newColorPath(&colour, &color)
+		c.DeprecateFlag("colour", "use --color instead")
+		p := NewPath()
+		p.Mount("ui", "user interface", sub)
+		var out strings.Builder
+		p.SetOutput(&out)
+		sub.SetOutput(&out)
+		p.SetPrintWarnings(print)
+		p.SetIgnoreUnknownConfig(true)
+		if err := p.LoadConfigJSON(strings.NewReader(`{"commands": {"rm": {}}}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Run("ui", "show", "-colour", "never"); err != nil {
+			t.Fatalf("Warnings should not fail Run but was %v.", err)
+		}
+		want := []Warning{
+			{"", WarnIgnoredConfig, "Ignoring unknown config keys: commands.rm"},
+			{"show", WarnDeprecatedFlag, "Flag --colour is deprecated, use --color instead"},
+		}
+		if warnings := p.Warnings(); !reflect.DeepEqual(warnings, want) {
+			t.Errorf("Warnings should be %v but were %v.", want, warnings)
+		}
+		if warnings := p.Warnings(); len(warnings) > 0 {
+			t.Errorf("Warnings should be cleared but were %v.", warnings)
+		}
+		printed := "Ignoring unknown config keys: commands.rm\nFlag --colour is deprecated, use --color instead\n"
+		if !print {
+			printed = ""
+		}
+		if out.String() != printed {
+			t.Errorf("Output should be %q but was %q.", printed, out.String())
+		}
+	}
+}